@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"itish41/doctor_ai_assistant/middleware"
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// withDoctor stands in for middleware.RequireAuth by placing the doctor in the context
+func withDoctor(doctor models.Doctor) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Set(middleware.DoctorContextKey, doctor)
+		c.Next()
+	}
+}
+
+// newTestRouter returns a router that renders handler errors the way the API does
+func newTestRouter() *gin.Engine {
+	router := gin.Default()
+	router.Use(middleware.ErrorHandler())
+	return router
+}
+
+// serve runs handler on a test context and renders the error it leaves, as ErrorHandler does
+func serve(c *gin.Context, handler gin.HandlerFunc) {
+	handler(c)
+	middleware.ErrorHandler()(c)
+}
+
+// mockAuthDoctor returns a doctor to authenticate test requests with
+func mockAuthDoctor() models.Doctor {
+	return models.Doctor{
+		ID:    uuid.New(),
+		Name:  "Test Doctor",
+		Email: "testdoctor@example.com",
+	}
+}
+
+func TestAuthenticatedDoctor_Missing(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+
+	_, ok := authenticatedDoctor(c)
+
+	assert.False(t, ok)
+	assert.True(t, c.IsAborted())
+	if assert.Len(t, c.Errors, 1) {
+		assert.ErrorIs(t, c.Errors.Last().Err, service.ErrUnauthorized)
+	}
+}
+
+func TestAuthenticatedDoctor_Present(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	doctor := mockAuthDoctor()
+	c.Set(middleware.DoctorContextKey, doctor)
+
+	got, ok := authenticatedDoctor(c)
+
+	assert.True(t, ok)
+	assert.Equal(t, doctor.ID, got.ID)
+}
@@ -1,26 +1,114 @@
 package controller
 
 import (
-	"itish41/doctor_ai_assistant/initializers"
+	"bytes"
+	"errors"
+	"io"
 	"itish41/doctor_ai_assistant/middleware"
 	"itish41/doctor_ai_assistant/models"
 	"itish41/doctor_ai_assistant/service"
 	"log"
+	"mime"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
 	"github.com/google/uuid"
 )
 
+// authenticatedDoctor returns the doctor set by middleware.RequireAuth and
+// fails the request with 401 when it was not authenticated.
+func authenticatedDoctor(c *gin.Context) (models.Doctor, bool) {
+	doctor, ok := middleware.CurrentDoctor(c)
+	if !ok {
+		log.Println("No authenticated doctor in request context")
+		middleware.Abort(c, middleware.ErrInvalidAccessToken)
+	}
+	return doctor, ok
+}
+
+// invalidRequest turns a JSON binding failure into a validation error naming the
+// fields that failed their binding rules.
+func invalidRequest(err error) error {
+	validationErr := &service.ValidationError{Message: "Invalid input"}
+	var fieldErrs validator.ValidationErrors
+	if errors.As(err, &fieldErrs) {
+		validationErr.Fields = make(map[string]string, len(fieldErrs))
+		for _, fieldErr := range fieldErrs {
+			validationErr.Fields[fieldErr.Field()] = "failed the " + fieldErr.Tag() + " rule"
+		}
+	}
+	return validationErr
+}
+
+// bindOptionalJSON binds the JSON body when one is sent; an empty body is not an error.
+func bindOptionalJSON(c *gin.Context, obj interface{}) error {
+	if c.Request.Body == nil {
+		return nil
+	}
+	if err := c.ShouldBindJSON(obj); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	return nil
+}
+
+// optionalQueryInt overrides value with the named query parameter when it is a valid
+// integer, so GET requests can pass in the query what POST requests send in the body.
+func optionalQueryInt(c *gin.Context, key string, value *int) {
+	if parsed, err := strconv.Atoi(c.Query(key)); err == nil {
+		*value = parsed
+	}
+}
+
+// listPage reads the pagination query parameters of a list. page and limit work as
+// before, with limit capped at service.MaxPageLimit; a cursor from a previous response
+// takes precedence over page. The total is counted for page-numbered requests unless
+// include_total=false, and for cursor requests only with include_total=true.
+func listPage(c *gin.Context) service.PageRequest {
+	page := service.PageRequest{Cursor: c.Query("cursor")}
+	page.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	if page.Page < 1 {
+		page.Page = 1
+	}
+	page.Limit, _ = strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if page.Limit < 1 {
+		page.Limit = 10
+	}
+	if page.Limit > service.MaxPageLimit {
+		page.Limit = service.MaxPageLimit
+	}
+	page.WithTotal = page.Cursor == ""
+	if includeTotal, err := strconv.ParseBool(c.Query("include_total")); err == nil {
+		page.WithTotal = includeTotal
+	}
+	return page
+}
+
+// addPageInfo adds the cursors, and the total when it was counted, to a list response.
+// Missing cursors are null.
+func addPageInfo(response gin.H, info service.PageInfo) gin.H {
+	response["next_cursor"] = nil
+	if info.NextCursor != "" {
+		response["next_cursor"] = info.NextCursor
+	}
+	response["prev_cursor"] = nil
+	if info.PrevCursor != "" {
+		response["prev_cursor"] = info.PrevCursor
+	}
+	if info.Total != nil {
+		response["total"] = *info.Total
+	}
+	return response
+}
+
 // Works
 func SignUp(c *gin.Context) {
 	var user models.Doctor                          // creating a variable of type Doctor
 	if err := c.ShouldBindJSON(&user); err != nil { // Binding the data coming from the request
 		log.Println("Unable to Bind the data from frontend...")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "Invalid input...",
-		})
+		middleware.Abort(c, invalidRequest(err))
 		return
 	}
 	log.Println("Bind Successfully from the frontend...")
@@ -28,9 +116,7 @@ func SignUp(c *gin.Context) {
 	err := service.CreateDoctor(&user) // accessing the service layer and passing on the processed data to the service
 	if err != nil {
 		log.Println("Unable to create the user on the server side...")
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Unable to create user on the server side...",
-		})
+		middleware.Abort(c, err)
 		return
 	}
 	log.Println("User created successfully on the server side...")
@@ -43,9 +129,7 @@ func Login(c *gin.Context) {
 	var user models.Doctor                          // creating a variable of type User
 	if err := c.ShouldBindJSON(&user); err != nil { // Binding the data coming from the request
 		log.Println("Unable to Bind the data from frontend...")
-		c.JSON(http.StatusBadRequest, gin.H{
-			"message": "Invalid input...",
-		})
+		middleware.Abort(c, invalidRequest(err))
 		return
 	}
 	log.Println("Bind Successfully from the frontend...")
@@ -53,9 +137,7 @@ func Login(c *gin.Context) {
 	err := service.DoctorLogin(&user)
 	if err != nil {
 		log.Println("Unable to login the user on the server side...")
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Unable to login user on the server side...",
-		})
+		middleware.Abort(c, err)
 		return
 	}
 
@@ -63,75 +145,140 @@ func Login(c *gin.Context) {
 	token, err := middleware.GenerateToken(user.Email)
 	if err != nil {
 		log.Println("Error generating token:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"message": "Failed to generate authentication token",
-		})
+		middleware.Abort(c, err)
+		return
+	}
+
+	// Start a new refresh token family for this session
+	refreshToken, err := service.IssueRefreshToken(user.ID)
+	if err != nil {
+		log.Println("Error issuing refresh token:", err)
+		middleware.Abort(c, err)
 		return
 	}
 
 	log.Println("User successfully logged in on the server side...")
 	c.JSON(http.StatusOK, gin.H{
-		"message": "User login successfully",
-		"email":   user.Email,
-		"token":   token,
+		"message":       "User login successfully",
+		"email":         user.Email,
+		"token":         token,
+		"refresh_token": refreshToken,
 	})
 }
 
-// Works
-func GetProfile(c *gin.Context) {
-	var req struct {
-		Email string `json:"email"`
+// RefreshTokenRequest carries the refresh token to rotate or revoke.
+type RefreshTokenRequest struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// RefreshToken exchanges a refresh token for a new access token and a rotated refresh token.
+func RefreshToken(c *gin.Context) {
+	var req RefreshTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil || req.RefreshToken == "" {
+		log.Println("Refresh token missing from request")
+		middleware.Abort(c, service.InvalidField("refresh_token", "Refresh token is required"))
+		return
 	}
 
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+	doctor, refreshToken, err := service.RotateRefreshToken(req.RefreshToken)
+	if err != nil {
+		log.Println("Error rotating refresh token:", err)
+		middleware.Abort(c, err)
+		return
+	}
+
+	token, err := middleware.GenerateToken(doctor.Email)
+	if err != nil {
+		log.Println("Error generating token:", err)
+		middleware.Abort(c, err)
 		return
 	}
 
-	// Extract email from request (assuming it’s sent in headers or body)
-	if req.Email == "" {
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Email is required"})
+	c.JSON(http.StatusOK, gin.H{
+		"email":         doctor.Email,
+		"token":         token,
+		"refresh_token": refreshToken,
+	})
+}
+
+// Logout revokes the current access token and, when given, the refresh token's family.
+func Logout(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+
+	var req RefreshTokenRequest
+	if err := bindOptionalJSON(c, &req); err != nil {
+		log.Println("Invalid request body:", err)
+		middleware.Abort(c, invalidRequest(err))
+		return
+	}
+
+	if req.RefreshToken != "" {
+		if err := service.RevokeRefreshToken(doctor.ID, req.RefreshToken); err != nil && !errors.Is(err, service.ErrInvalidRefreshToken) {
+			log.Println("Error revoking refresh token:", err)
+			middleware.Abort(c, err)
+			return
+		}
+	}
+
+	if claims, ok := middleware.CurrentClaims(c); ok {
+		if err := service.RevokeAccessToken(claims.ID, claims.ExpiresAt.Time); err != nil {
+			log.Println("Error revoking access token:", err)
+			middleware.Abort(c, err)
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "Logged out successfully"})
+}
+
+// Works
+func GetProfile(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
 		return
 	}
 
 	// Fetch doctor profile from the database
-	profile, err := service.GetDoctorProfile(req.Email)
+	profile, err := service.GetDoctorProfile(doctor.Email)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve profile"})
+		middleware.Abort(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, profile)
 }
 
+// UpdateProfileRequest holds the profile fields to change; empty fields are left as they are.
+type UpdateProfileRequest struct {
+	Name           string `json:"name"`
+	Specialization string `json:"specialization"`
+	Phone          string `json:"phone"`
+}
+
 // Works
 func UpdateProfile(c *gin.Context) {
-	var request struct {
-		Email          string `json:"email"`
-		Name           string `json:"name"`
-		Specialization string `json:"specialization"`
-		Phone          string `json:"phone"`
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
 	}
 
+	var request UpdateProfileRequest
+
 	// Bind the JSON request
 	if err := c.ShouldBindJSON(&request); err != nil {
 		log.Println("Invalid request body...")
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
-		return
-	}
-
-	// Ensure email is provided
-	if request.Email == "" {
-		log.Println("Email is required to update the profile")
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Email is required"})
+		middleware.Abort(c, invalidRequest(err))
 		return
 	}
 
 	// Call the service layer to update the doctor's profile
-	err := service.UpdateDoctorProfile(request.Email, request.Name, request.Specialization, request.Phone)
+	err := service.UpdateDoctorProfile(doctor.Email, request.Name, request.Specialization, request.Phone)
 	if err != nil {
 		log.Println("Error updating the doctor profile:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": err.Error()})
+		middleware.Abort(c, err)
 		return
 	}
 
@@ -140,328 +287,695 @@ func UpdateProfile(c *gin.Context) {
 }
 
 // Works
-// GetTranscriptions retrieves transcriptions for a doctor using the doctor email passed from the frontend.
+// GetTranscriptions retrieves transcriptions for the authenticated doctor.
 func GetTranscriptions(c *gin.Context) {
-	// Define a request struct to bind JSON payload
-	var request struct {
-		Email string `json:"email"`
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
 	}
 
-	// Bind JSON request body
-	if err := c.ShouldBindJSON(&request); err != nil {
-		log.Println("Invalid request body:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
-		return
+	page := listPage(c)
+
+	// Optional filters
+	filter := service.TranscriptionFilter{Status: c.Query("status")}
+	if value := c.Query("patient_id"); value != "" {
+		patientID, err := uuid.Parse(value)
+		if err != nil {
+			middleware.Abort(c, service.InvalidField("patient_id", "Invalid patient ID"))
+			return
+		}
+		filter.PatientID = &patientID
 	}
 
-	// Check if email is provided
-	if request.Email == "" {
-		log.Println("Doctor email not provided")
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Doctor email is required"})
+	// Call the service layer to get the transcriptions with their patients.
+	formattedTranscriptions, info, err := service.GetTranscriptions(doctor.ID, filter, page)
+	if err != nil {
+		log.Println("Error retrieving transcriptions:", err)
+		middleware.Abort(c, err)
 		return
 	}
 
-	// Look up the doctor in the database.
-	var doctor models.Doctor
-	if err := initializers.DB.Where("email = ?", request.Email).First(&doctor).Error; err != nil {
-		log.Println("Doctor not found:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
-		return
+	// Older clients zip the transcripts with this parallel array of patient names
+	patientNames := make([]string, len(formattedTranscriptions))
+	for i, transcript := range formattedTranscriptions {
+		patientNames[i] = "Unknown Patient"
+		if patient, ok := transcript["patient"].(map[string]interface{}); ok {
+			patientNames[i], _ = patient["name"].(string)
+		}
 	}
 
-	// Handle pagination parameters.
-	page := c.DefaultQuery("page", "1")
-	limit := c.DefaultQuery("limit", "10")
+	// Return the results.
+	c.JSON(http.StatusOK, addPageInfo(gin.H{
+		"transcripts": formattedTranscriptions,
+		"patients":    patientNames,
+		"page":        page.Page,
+		"limit":       page.Limit,
+	}, info))
+}
 
-	pageNum, err := strconv.Atoi(page)
+// SearchTranscriptions handles GET /transcription/search. The full-text query is in ?q=;
+// patient_id, from and to (RFC 3339 or YYYY-MM-DD, to inclusive of its day), page and
+// limit narrow the hits. Snippets are HTML-escaped and mark matches with <mark> tags.
+func SearchTranscriptions(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+
+	search := service.TranscriptionSearch{Query: c.Query("q")}
+	if value := c.Query("patient_id"); value != "" {
+		patientID, err := uuid.Parse(value)
+		if err != nil {
+			middleware.Abort(c, service.InvalidField("patient_id", "Invalid patient ID"))
+			return
+		}
+		search.PatientID = &patientID
+	}
+	if value := c.Query("from"); value != "" {
+		from, err := parseTimeParam(value, false)
+		if err != nil {
+			middleware.Abort(c, service.InvalidField("from", "Invalid from time"))
+			return
+		}
+		search.From = from
+	}
+	if value := c.Query("to"); value != "" {
+		to, err := parseTimeParam(value, true)
+		if err != nil {
+			middleware.Abort(c, service.InvalidField("to", "Invalid to time"))
+			return
+		}
+		search.To = to
+	}
+	pageNum, err := strconv.Atoi(c.DefaultQuery("page", "1"))
 	if err != nil || pageNum < 1 {
 		pageNum = 1
 	}
-	limitNum, err := strconv.Atoi(limit)
-	if err != nil || limitNum < 1 {
+	limitNum, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limitNum < 1 || limitNum > service.MaxTranscriptionSearchLimit {
 		limitNum = 10
 	}
+	search.Page, search.Limit = pageNum, limitNum
 
-	// Call the service layer to get the transcriptions and patient names.
-	formattedTranscriptions, patientNames, total, err := service.GetTranscriptions(doctor.ID, pageNum, limitNum)
+	hits, total, err := service.SearchTranscriptions(doctor.ID, search)
 	if err != nil {
-		log.Println("Error retrieving transcriptions:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve transcriptions"})
+		log.Println("Error searching transcriptions:", err)
+		middleware.Abort(c, err)
 		return
 	}
 
-	// Return the results.
+	formatted := make([]gin.H, len(hits))
+	for i, hit := range hits {
+		formatted[i] = gin.H{
+			"id":             hit.ID,
+			"patient_id":     hit.PatientID,
+			"patient_name":   hit.PatientName,
+			"created_at":     hit.CreatedAt,
+			"status":         hit.Status,
+			"rank":           hit.Rank,
+			"text_snippet":   hit.TextSnippet,
+			"report_snippet": hit.ReportSnippet,
+		}
+	}
 	c.JSON(http.StatusOK, gin.H{
-		"transcripts": formattedTranscriptions,
-		"patients":    patientNames,
-		"total":       total,
-		"page":        pageNum,
-		"limit":       limitNum,
+		"hits":  formatted,
+		"total": total,
+		"page":  pageNum,
+		"limit": limitNum,
 	})
 }
 
+// TranscriptionIDRequest names a transcription in the request body.
+type TranscriptionIDRequest struct {
+	TranscriptionID string `json:"transcription_id"`
+}
+
 // Works
 func GetTranscriptionByID(c *gin.Context) {
-	var request struct {
-		TranscriptionID string `json:"transcription_id"`
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
 	}
 
+	var request TranscriptionIDRequest
+
 	// Bind the JSON request body
 	if err := c.ShouldBindJSON(&request); err != nil {
 		log.Println("Error binding JSON:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request body"})
+		middleware.Abort(c, invalidRequest(err))
 		return
 	}
 
 	transcriptionID, err := uuid.Parse(request.TranscriptionID)
 	if err != nil {
 		log.Println("Invalid transcription ID:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid transcription ID"})
+		middleware.Abort(c, service.InvalidField("id", "Invalid transcription ID"))
 		return
 	}
+	middleware.SetAuditResource(c, transcriptionID)
 
-	transcription, err := service.GetTranscriptionByID(transcriptionID)
+	transcription, err := service.GetTranscriptionByID(doctor.ID, transcriptionID)
 	if err != nil {
 		log.Println("Error retrieving transcription:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve transcription"})
+		middleware.Abort(c, err)
 		return
 	}
 	// log.Println("Retrieved transcription:", transcription)
 
 	c.JSON(http.StatusOK, gin.H{
-		"text":   transcription.Text,
-		"report": transcription.Report,
+		"text":              transcription.Text,
+		"report":            transcription.Report,
+		"structured_report": transcription.StructuredReport,
+		"status":            transcription.Status,
+		"signed_by":         transcription.SignedByID,
+		"signed_at":         transcription.SignedAt,
 	})
 }
 
+// UpdateTranscriptionRequest carries the transcription fields to change.
+type UpdateTranscriptionRequest struct {
+	TranscriptionID string                 `json:"transcription_id"`
+	UpdateData      map[string]interface{} `json:"update_data"`
+}
+
 // Works
 func UpdateTranscription(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+
 	// Get ID from URL parameter
 	transcriptionIDStr := c.Param("id")
 	transcriptionID, err := uuid.Parse(transcriptionIDStr)
 	if err != nil {
 		log.Println("Invalid transcription ID:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid transcription ID"})
+		middleware.Abort(c, service.InvalidField("id", "Invalid transcription ID"))
 		return
 	}
 
-	var request struct {
-		TranscriptionID string                 `json:"transcription_id"`
-		UpdateData      map[string]interface{} `json:"update_data"`
-	}
+	var request UpdateTranscriptionRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
 		log.Println("Invalid input:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		middleware.Abort(c, invalidRequest(err))
 		return
 	}
 
-	err = service.UpdateTranscription(transcriptionID, request.UpdateData)
+	err = service.UpdateTranscription(doctor.ID, transcriptionID, request.UpdateData)
 	if err != nil {
 		log.Println("Error updating transcription:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update transcription"})
+		middleware.Abort(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Transcription updated successfully"})
 }
 
+// TranscriptionStatusRequest names the report status to move to.
+type TranscriptionStatusRequest struct {
+	Status string `json:"status" binding:"required"`
+}
+
+// UpdateTranscriptionStatus handles POST /transcription/:id/status, moving the report
+// through draft -> reviewed -> signed, or opening a signed report as amended.
+func UpdateTranscriptionStatus(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+
+	transcriptionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		middleware.Abort(c, service.InvalidField("id", "Invalid transcription ID"))
+		return
+	}
+
+	var request TranscriptionStatusRequest
+	if err := c.ShouldBindJSON(&request); err != nil {
+		middleware.Abort(c, invalidRequest(err))
+		return
+	}
+
+	transcription, err := service.TransitionTranscriptionStatus(doctor.ID, transcriptionID, request.Status)
+	if err != nil {
+		log.Println("Error updating report status:", err)
+		middleware.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":   "Report status updated successfully",
+		"status":    transcription.Status,
+		"signed_by": transcription.SignedByID,
+		"signed_at": transcription.SignedAt,
+	})
+}
+
+// revisionJSON is the response shape of a transcription revision.
+func revisionJSON(revision models.TranscriptionRevision) gin.H {
+	return gin.H{
+		"id":                revision.ID,
+		"version":           revision.Version,
+		"author_id":         revision.AuthorID,
+		"text":              revision.Text,
+		"report":            revision.Report,
+		"structured_report": revision.StructuredReport,
+		"created_at":        revision.CreatedAt,
+	}
+}
+
+// ListTranscriptionRevisions handles GET /transcription/:id/revisions, newest first.
+func ListTranscriptionRevisions(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+
+	transcriptionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		middleware.Abort(c, service.InvalidField("id", "Invalid transcription ID"))
+		return
+	}
+
+	revisions, err := service.ListTranscriptionRevisions(doctor.ID, transcriptionID)
+	if err != nil {
+		log.Println("Error handling transcription revisions:", err)
+		middleware.Abort(c, err)
+		return
+	}
+
+	formatted := make([]gin.H, len(revisions))
+	for i, revision := range revisions {
+		formatted[i] = revisionJSON(revision)
+	}
+	c.JSON(http.StatusOK, gin.H{"revisions": formatted})
+}
+
+// DiffTranscriptionRevision handles GET /transcription/:id/revisions/:revision_id/diff.
+// The revision is compared with the revision in ?against=, or with the current content.
+func DiffTranscriptionRevision(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+
+	transcriptionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		middleware.Abort(c, service.InvalidField("id", "Invalid transcription ID"))
+		return
+	}
+	revisionID, err := uuid.Parse(c.Param("revision_id"))
+	if err != nil {
+		middleware.Abort(c, service.InvalidField("revision_id", "Invalid revision ID"))
+		return
+	}
+	var againstID *uuid.UUID
+	if against := c.Query("against"); against != "" {
+		parsed, err := uuid.Parse(against)
+		if err != nil {
+			middleware.Abort(c, service.InvalidField("against", "Invalid revision ID"))
+			return
+		}
+		againstID = &parsed
+	}
+
+	diff, err := service.DiffTranscriptionRevisions(doctor.ID, transcriptionID, revisionID, againstID)
+	if err != nil {
+		log.Println("Error handling transcription revisions:", err)
+		middleware.Abort(c, err)
+		return
+	}
+
+	var to interface{} = "current"
+	if diff.To != nil {
+		to = revisionJSON(*diff.To)
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from":   revisionJSON(diff.From),
+		"to":     to,
+		"text":   diff.Text,
+		"report": diff.Report,
+	})
+}
+
+// RestoreTranscriptionRevision handles POST /transcription/:id/revisions/:revision_id/restore.
+func RestoreTranscriptionRevision(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+
+	transcriptionID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		middleware.Abort(c, service.InvalidField("id", "Invalid transcription ID"))
+		return
+	}
+	revisionID, err := uuid.Parse(c.Param("revision_id"))
+	if err != nil {
+		middleware.Abort(c, service.InvalidField("revision_id", "Invalid revision ID"))
+		return
+	}
+
+	transcription, err := service.RestoreTranscriptionRevision(doctor.ID, transcriptionID, revisionID)
+	if err != nil {
+		log.Println("Error handling transcription revisions:", err)
+		middleware.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":           "Revision restored successfully",
+		"text":              transcription.Text,
+		"report":            transcription.Report,
+		"structured_report": transcription.StructuredReport,
+	})
+}
+
 func DeleteTranscription(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
 
 	transcriptionIDStr := c.Param("id")
 	transcriptionID, err := uuid.Parse(transcriptionIDStr)
 	if err != nil {
 		log.Println("Invalid transcription ID:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid transcription ID"})
+		middleware.Abort(c, service.InvalidField("id", "Invalid transcription ID"))
 		return
 	}
 
-	var request struct {
-		TranscriptionID string `json:"transcription_id"`
-	}
+	var request TranscriptionIDRequest
 
 	if err := c.ShouldBindJSON(&request); err != nil {
 		log.Println("Invalid input:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+		middleware.Abort(c, invalidRequest(err))
 		return
 	}
 
-	err = service.DeleteTranscription(transcriptionID)
+	err = service.DeleteTranscription(doctor.ID, transcriptionID)
 	if err != nil {
 		log.Println("Error deleting transcription:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete transcription"})
+		middleware.Abort(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Transcription deleted successfully"})
 }
 
-// Works
-// DownloadTranscriptionRequest represents the JSON payload expected from the frontend.
-type DownloadTranscriptionRequest struct {
-	DoctorEmail string         `json:"email"`
-	Patient     models.Patient `json:"patient"` // Must contain at least the patient ID
-}
-
-// Works
+// DownloadTranscription handles POST /transcription/:id/download and
+// GET /api/v2/transcriptions/:id/download. The transcription named in the path is
+// rendered with its patient and doctor as stored, in the format ?format= names or else
+// the one the Accept header prefers, PDF by default. Any request body is ignored.
 func DownloadTranscription(c *gin.Context) {
-	// Bind JSON payload containing doctorEmail and patient object.
-	var req DownloadTranscriptionRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Println("Error binding JSON:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request body"})
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
 		return
 	}
-
-	// Lookup the doctor by email.
-	var doctor models.Doctor
-	if err := initializers.DB.Where("email = ?", req.DoctorEmail).First(&doctor).Error; err != nil {
-		log.Println("Doctor not found:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+	transcriptionID, ok := pathID(c, "Invalid transcription ID")
+	if !ok {
+		return
+	}
+	// The response depends on Accept, so caches must keep one copy per header
+	c.Header("Vary", "Accept")
+	exporter, err := service.NegotiateReportExporter(c.Query("format"), c.GetHeader("Accept"))
+	if err != nil {
+		middleware.Abort(c, err)
 		return
 	}
 
-	// Retrieve the transcription for this doctor and patient.
-	transcription, err := service.GetTranscriptionByPatient(doctor.ID, req.Patient.ID)
+	doc, err := service.GetReportDocument(doctor.ID, transcriptionID)
 	if err != nil {
 		log.Println("Error retrieving transcription:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve transcription"})
+		middleware.Abort(c, err)
 		return
 	}
 
-	// Generate a PDF from the transcription report and patient details.
-	pdfPath, err := service.GeneratePDF(transcription.Report, req.Patient)
-	if err != nil {
-		log.Println("Error generating PDF:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to generate PDF"})
+	// Render in memory and stream it as a named download
+	var body bytes.Buffer
+	if err := exporter.Render(&body, service.NewReportView(*doc)); err != nil {
+		log.Println("Error rendering report:", err)
+		middleware.Abort(c, err)
 		return
 	}
 
-	// Serve the generated PDF file.
-	c.File(pdfPath)
+	c.DataFromReader(http.StatusOK, int64(body.Len()), exporter.ContentType, &body, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": service.ReportFileName(*doc, exporter.Format)}),
+	})
 }
 
 // Works
 // CreatePatientRequest represents the expected JSON payload from the frontend.
+// Exactly one of Audio and AudioFileID must be set.
 type CreatePatientRequest struct {
-	DoctorEmail string         `json:"email"`
 	Patient     models.Patient `json:"patient"`
-	Audio       string         `json:"audio"` // audio URL as a string
+	Audio       string         `json:"audio"`         // audio URL as a string
+	AudioFileID *uuid.UUID     `json:"audio_file_id"` // ID returned by POST /upload_audio
 }
 
-// Works
-// CreatePatientAndTranscription is the controller that handles the POST /patients endpoint.
+// CreatePatient handles POST /patients. The recording is transcribed in the background;
+// the response carries the job ID to poll at /patients/jobs/:id.
 func CreatePatient(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+
 	var req CreatePatientRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		log.Println("Error binding JSON:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid request"})
+		middleware.Abort(c, invalidRequest(err))
 		return
 	}
 
-	transcription, err := service.CreatePatientAndTranscription(req.DoctorEmail, req.Patient, req.Audio)
+	job, err := service.EnqueueTranscriptionJob(doctor.ID, req.Patient, service.AudioSource{URL: req.Audio, FileID: req.AudioFileID})
 	if err != nil {
-		log.Println("Error creating patient and transcription:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to create patient/transcription"})
+		log.Println("Error queueing transcription job:", err)
+		middleware.Abort(c, err)
 		return
 	}
 
-	c.JSON(http.StatusOK, transcription)
+	middleware.SetAuditResource(c, job.ID)
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Transcription job queued",
+		"job_id":  job.ID,
+		"status":  job.Status,
+	})
 }
 
-// Works
-func GetPatients(c *gin.Context) {
-	// Extract email from request
-	var request struct {
-		Email string `json:"email"`
+// GetTranscriptionJob reports the status of a transcription job started by CreatePatient.
+func GetTranscriptionJob(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
 	}
 
-	// Bind JSON request to struct
-	if err := c.ShouldBindJSON(&request); err != nil {
-		log.Println("Invalid request body:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		middleware.Abort(c, service.InvalidField("id", "Invalid job ID"))
 		return
 	}
 
-	// Check if email is provided
-	if request.Email == "" {
-		log.Println("Email is required")
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Email is required"})
+	job, err := service.GetTranscriptionJob(doctor.ID, jobID)
+	if err != nil {
+		log.Println("Error fetching transcription job:", err)
+		middleware.Abort(c, err)
 		return
 	}
 
-	log.Printf("Fetching patients for email: %s", request.Email)
+	c.JSON(http.StatusOK, gin.H{
+		"job_id":           job.ID,
+		"status":           job.Status,
+		"retry_count":      job.RetryCount,
+		"error":            job.LastError,
+		"patient_id":       job.PatientID,
+		"transcription_id": job.TranscriptionID,
+		"encounter_id":     job.EncounterID,
+		"created_at":       job.CreatedAt,
+		"updated_at":       job.UpdatedAt,
+	})
+}
 
-	// Fetch doctor by email
-	var doctor models.Doctor
-	if err := initializers.DB.Where("email = ?", request.Email).First(&doctor).Error; err != nil {
-		log.Printf("Doctor not found for email %s: %v", request.Email, err)
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Doctor not found"})
+// UploadAudio handles POST /upload_audio. The multipart "audio" part is streamed to storage;
+// the returned audio_file_id can then be sent to POST /patients instead of an audio URL.
+func UploadAudio(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
 		return
 	}
 
-	log.Printf("Doctor found: %v", doctor)
-
-	// Handle pagination
-	page := c.DefaultQuery("page", "1")
-	limit := c.DefaultQuery("limit", "10")
+	// Leave room for the multipart framing around the file itself
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, service.MaxAudioUploadSize+1<<20)
 
-	pageNum, err := strconv.Atoi(page)
-	if err != nil || pageNum < 1 {
-		pageNum = 1
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		middleware.Abort(c, service.InvalidField("audio", "Expected a multipart/form-data upload"))
+		return
+	}
+
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			log.Println("Error reading multipart upload:", err)
+			var maxBytesErr *http.MaxBytesError
+			if errors.As(err, &maxBytesErr) {
+				middleware.Abort(c, service.ErrAudioTooLarge)
+				return
+			}
+			middleware.Abort(c, service.InvalidField("audio", "Invalid multipart upload"))
+			return
+		}
+		if part.FormName() != "audio" || part.FileName() == "" {
+			part.Close()
+			continue
+		}
+
+		audioFile, err := service.SaveAudioUpload(doctor.ID, part.FileName(), part.Header.Get("Content-Type"), part)
+		part.Close()
+		if err != nil {
+			log.Println("Error saving audio upload:", err)
+			middleware.Abort(c, err)
+			return
+		}
+
+		middleware.SetAuditResource(c, audioFile.ID)
+		c.JSON(http.StatusCreated, gin.H{
+			"message":       "Audio uploaded successfully",
+			"audio_file_id": audioFile.ID,
+			"filename":      audioFile.OriginalFilename,
+			"content_type":  audioFile.ContentType,
+			"size":          audioFile.Size,
+		})
+		return
 	}
 
-	limitNum, err := strconv.Atoi(limit)
-	if err != nil || limitNum < 1 {
-		limitNum = 10
+	middleware.Abort(c, service.InvalidField("audio", "Missing audio file"))
+}
+
+// Works
+func GetPatients(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
 	}
 
+	log.Printf("Fetching patients for doctor: %v", doctor.ID)
+
+	page := listPage(c)
+
 	// Call the service to fetch patients
-	patients, total, err := service.GetPatients(doctor.ID, pageNum, limitNum)
+	patients, info, err := service.GetPatients(doctor.ID, page)
 	if err != nil {
 		log.Printf("Error retrieving patients for doctor %v: %v", doctor.ID, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve patients"})
+		middleware.Abort(c, err)
 		return
 	}
 
-	log.Printf("Retrieved %d patients out of total %d", len(patients), total)
+	log.Printf("Retrieved %d patients", len(patients))
 
 	// Return response
-	c.JSON(http.StatusOK, gin.H{
+	c.JSON(http.StatusOK, addPageInfo(gin.H{
 		"patients": patients,
+		"page":     page.Page,
+		"limit":    page.Limit,
+	}, info))
+}
+
+// SearchPatients handles GET /patients/search. The name query is in ?q=; min_age,
+// max_age, gender and a created_from/created_to range filter the results, which are
+// ordered by ?sort= and paged with page and limit.
+func SearchPatients(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+
+	search := service.PatientSearch{
+		Query:  c.Query("q"),
+		Gender: c.Query("gender"),
+		Sort:   c.Query("sort"),
+	}
+	for _, param := range []struct {
+		name  string
+		value **int
+	}{{"min_age", &search.MinAge}, {"max_age", &search.MaxAge}} {
+		if value := c.Query(param.name); value != "" {
+			age, err := strconv.Atoi(value)
+			if err != nil || age < 0 {
+				middleware.Abort(c, service.InvalidField(param.name, "Invalid age"))
+				return
+			}
+			*param.value = &age
+		}
+	}
+	if value := c.Query("created_from"); value != "" {
+		from, err := parseTimeParam(value, false)
+		if err != nil {
+			middleware.Abort(c, service.InvalidField("created_from", "Invalid created_from time"))
+			return
+		}
+		search.CreatedFrom = from
+	}
+	if value := c.Query("created_to"); value != "" {
+		to, err := parseTimeParam(value, true)
+		if err != nil {
+			middleware.Abort(c, service.InvalidField("created_to", "Invalid created_to time"))
+			return
+		}
+		search.CreatedTo = to
+	}
+	pageNum, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || pageNum < 1 {
+		pageNum = 1
+	}
+	limitNum, err := strconv.Atoi(c.DefaultQuery("limit", "10"))
+	if err != nil || limitNum < 1 || limitNum > service.MaxPatientSearchLimit {
+		limitNum = 10
+	}
+	search.Page, search.Limit = pageNum, limitNum
+
+	patients, total, err := service.SearchPatients(doctor.ID, search)
+	if err != nil {
+		log.Println("Error searching patients:", err)
+		middleware.Abort(c, err)
+		return
+	}
+
+	formatted := make([]gin.H, len(patients))
+	for i := range patients {
+		formatted[i] = patientJSON(&patients[i])
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"patients": formatted,
 		"total":    total,
 		"page":     pageNum,
 		"limit":    limitNum,
 	})
 }
 
+// PatientIDRequest names a patient in the request body.
+type PatientIDRequest struct {
+	PatientID string `json:"patient_id"`
+}
+
 // Works
 func GetPatientByID(c *gin.Context) {
-	// Extract email and patient ID from JSON request
-	var request struct {
-		Email     string `json:"email"`
-		PatientID string `json:"patient_id"`
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
 	}
 
+	// Extract patient ID from JSON request
+	var request PatientIDRequest
+
 	// Bind JSON request to struct
 	if err := c.ShouldBindJSON(&request); err != nil {
 		log.Println("Invalid request body:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
-		return
-	}
-
-	// Validate email
-	if request.Email == "" {
-		log.Println("Email is required")
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Email is required"})
-		return
-	}
-
-	// Fetch doctor by email
-	var doctor models.Doctor
-	if err := initializers.DB.Where("email = ?", request.Email).First(&doctor).Error; err != nil {
-		log.Println("Doctor not found:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Doctor not found"})
+		middleware.Abort(c, invalidRequest(err))
 		return
 	}
 
@@ -469,15 +983,16 @@ func GetPatientByID(c *gin.Context) {
 	patientID, err := uuid.Parse(request.PatientID)
 	if err != nil {
 		log.Println("Invalid patient ID:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid patient ID"})
+		middleware.Abort(c, service.InvalidField("patient_id", "Invalid patient ID"))
 		return
 	}
+	middleware.SetAuditResource(c, patientID)
 
 	// Retrieve patient and transcription
 	patient, transcription, err := service.GetPatientWithTranscription(doctor.ID, patientID)
 	if err != nil {
 		log.Println("Error retrieving patient data:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve patient data"})
+		middleware.Abort(c, err)
 		return
 	}
 
@@ -493,33 +1008,28 @@ func GetPatientByID(c *gin.Context) {
 	})
 }
 
-// send patient_id along with email
+// send patient_id in the body
 // Works
 func DeletePatient(c *gin.Context) {
-	// Extract email and patient ID from JSON request
-	var request struct {
-		Email     string `json:"email"`
-		PatientID string `json:"patient_id"`
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
 	}
 
+	// Extract patient ID from JSON request
+	var request PatientIDRequest
+
 	// Bind JSON request
 	if err := c.ShouldBindJSON(&request); err != nil {
 		log.Println("Invalid request body:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
-		return
-	}
-
-	// Validate email
-	if request.Email == "" {
-		log.Println("Email is required")
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Email is required"})
+		middleware.Abort(c, invalidRequest(err))
 		return
 	}
 
 	// Validate patient ID
 	if request.PatientID == "" {
 		log.Println("Patient ID is required")
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Patient ID is required"})
+		middleware.Abort(c, service.InvalidField("patient_id", "Patient ID is required"))
 		return
 	}
 
@@ -527,15 +1037,16 @@ func DeletePatient(c *gin.Context) {
 	parsedID, err := uuid.Parse(request.PatientID)
 	if err != nil {
 		log.Println("Invalid patient ID:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid patient ID"})
+		middleware.Abort(c, service.InvalidField("patient_id", "Invalid patient ID"))
 		return
 	}
+	middleware.SetAuditResource(c, parsedID)
 
 	// Call the service to delete the patient
-	err = service.DeletePatient(parsedID)
+	err = service.DeletePatient(doctor.ID, parsedID)
 	if err != nil {
 		log.Println("Error deleting patient:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to delete patient"})
+		middleware.Abort(c, err)
 		return
 	}
 
@@ -543,31 +1054,22 @@ func DeletePatient(c *gin.Context) {
 }
 
 type DashboardRequest struct {
-	Email string `json:"email"`
-	Days  int    `json:"days"`
+	Days int `json:"days"`
 }
 
 func GetDashboardTranscripts(c *gin.Context) {
-	var request DashboardRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		log.Println("Invalid request body:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
 		return
 	}
 
-	if request.Email == "" {
-		log.Println("Doctor email not provided")
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Doctor email is required"})
-		return
-	}
-
-	// Lookup the doctor in the database using the provided email.
-	var doctor models.Doctor
-	if err := initializers.DB.Model(&models.Doctor{}).Where("email = ?", request.Email).First(&doctor).Error; err != nil {
-		log.Println("Doctor not found:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+	var request DashboardRequest
+	if err := bindOptionalJSON(c, &request); err != nil {
+		log.Println("Invalid request body:", err)
+		middleware.Abort(c, invalidRequest(err))
 		return
 	}
+	optionalQueryInt(c, "days", &request.Days)
 
 	// Use days from request or default to 30
 	daysNum := request.Days
@@ -579,7 +1081,7 @@ func GetDashboardTranscripts(c *gin.Context) {
 	transcriptions, err := service.GetDashboardTranscripts(doctor.ID.String(), daysNum)
 	if err != nil {
 		log.Println("Error retrieving dashboard transcripts:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve dashboard transcripts"})
+		middleware.Abort(c, err)
 		return
 	}
 
@@ -587,26 +1089,18 @@ func GetDashboardTranscripts(c *gin.Context) {
 }
 
 func GetDashboardPatients(c *gin.Context) {
-	var request DashboardRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		log.Println("Invalid request body:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
-		return
-	}
-
-	if request.Email == "" {
-		log.Println("Doctor email not provided")
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Doctor email is required"})
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
 		return
 	}
 
-	// Lookup the doctor in the database using the provided email.
-	var doctor models.Doctor
-	if err := initializers.DB.Model(&models.Doctor{}).Where("email = ?", request.Email).First(&doctor).Error; err != nil {
-		log.Println("Doctor not found:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+	var request DashboardRequest
+	if err := bindOptionalJSON(c, &request); err != nil {
+		log.Println("Invalid request body:", err)
+		middleware.Abort(c, invalidRequest(err))
 		return
 	}
+	optionalQueryInt(c, "days", &request.Days)
 
 	// Use days from request or default to 30
 	daysNum := request.Days
@@ -618,7 +1112,7 @@ func GetDashboardPatients(c *gin.Context) {
 	patients, err := service.GetDashboardPatients(doctor.ID.String(), daysNum)
 	if err != nil {
 		log.Println("Error retrieving dashboard patients:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve dashboard patients"})
+		middleware.Abort(c, err)
 		return
 	}
 
@@ -626,26 +1120,18 @@ func GetDashboardPatients(c *gin.Context) {
 }
 
 func GetDailyStatistics(c *gin.Context) {
-	var request DashboardRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		log.Println("Invalid request body:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
 		return
 	}
 
-	if request.Email == "" {
-		log.Println("Doctor email not provided")
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Doctor email is required"})
-		return
-	}
-
-	// Lookup the doctor by email.
-	var doctor models.Doctor
-	if err := initializers.DB.Where("email = ?", request.Email).First(&doctor).Error; err != nil {
-		log.Println("Doctor not found:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+	var request DashboardRequest
+	if err := bindOptionalJSON(c, &request); err != nil {
+		log.Println("Invalid request body:", err)
+		middleware.Abort(c, invalidRequest(err))
 		return
 	}
+	optionalQueryInt(c, "days", &request.Days)
 
 	// Use days from request or default to 30
 	daysNum := request.Days
@@ -657,7 +1143,7 @@ func GetDailyStatistics(c *gin.Context) {
 	stats, err := service.GetDailyStatistics(doctor.ID.String(), daysNum)
 	if err != nil {
 		log.Println("Error retrieving daily statistics:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve daily statistics"})
+		middleware.Abort(c, err)
 		return
 	}
 
@@ -665,60 +1151,52 @@ func GetDailyStatistics(c *gin.Context) {
 }
 
 type MonthlyStatsRequest struct {
-	Email  string `json:"email"`
-	Months int    `json:"months"`
+	Months int `json:"months"`
+}
+
+// PatientTranscriptRequest names the patient whose latest transcript is wanted.
+type PatientTranscriptRequest struct {
+	Name string `json:"name"`
 }
 
 func GetPatientTranscript(c *gin.Context) {
-	var req struct {
-		Email string `json:"email"`
-		Name  string `json:"name"`
-	}
-	if err := c.ShouldBindJSON(&req); err != nil {
-		log.Println("Invalid request body:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
 		return
 	}
 
-	// Get doctor ID using email
-	doctorID, err := service.GetDoctorIDByEmail(req.Email)
-	if err != nil {
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid doctor email"})
+	var req PatientTranscriptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Invalid request body:", err)
+		middleware.Abort(c, invalidRequest(err))
 		return
 	}
 
 	// Get transcript for specific patient
-	transcript, err := service.GetPatientTranscript(doctorID, req.Name)
+	transcript, err := service.GetPatientTranscript(doctor.ID, req.Name)
 	if err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		middleware.Abort(c, err)
 		return
 	}
+	middleware.SetAuditResource(c, transcript.ID)
 
 	c.JSON(http.StatusOK, gin.H{
 		"transcript": transcript,
 	})
 }
 func GetMonthlyStatistics(c *gin.Context) {
-	var request MonthlyStatsRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		log.Println("Invalid request body:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
 		return
 	}
 
-	if request.Email == "" {
-		log.Println("Doctor email not provided")
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Doctor email is required"})
-		return
-	}
-
-	// Lookup the doctor in the database using the provided email.
-	var doctor models.Doctor
-	if err := initializers.DB.Model(&models.Doctor{}).Where("email = ?", request.Email).First(&doctor).Error; err != nil {
-		log.Println("Doctor not found:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
+	var request MonthlyStatsRequest
+	if err := bindOptionalJSON(c, &request); err != nil {
+		log.Println("Invalid request body:", err)
+		middleware.Abort(c, invalidRequest(err))
 		return
 	}
+	optionalQueryInt(c, "months", &request.Months)
 
 	// Use months from request or default to 12
 	monthsNum := request.Months
@@ -730,7 +1208,7 @@ func GetMonthlyStatistics(c *gin.Context) {
 	stats, err := service.GetMonthlyStatistics(doctor.ID.String(), monthsNum)
 	if err != nil {
 		log.Println("Error retrieving monthly statistics:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve monthly statistics"})
+		middleware.Abort(c, err)
 		return
 	}
 
@@ -738,31 +1216,23 @@ func GetMonthlyStatistics(c *gin.Context) {
 }
 
 func GetBusiestDays(c *gin.Context) {
-	var request DashboardRequest
-	if err := c.ShouldBindJSON(&request); err != nil {
-		log.Println("Invalid request body:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
 		return
 	}
 
-	if request.Email == "" {
-		log.Println("Doctor email not provided")
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Doctor email is required"})
+	var request DashboardRequest
+	if err := bindOptionalJSON(c, &request); err != nil {
+		log.Println("Invalid request body:", err)
+		middleware.Abort(c, invalidRequest(err))
 		return
 	}
 
-	// Lookup the doctor record using the provided email.
-	var doctor models.Doctor
-	if err := initializers.DB.Where("email = ?", request.Email).First(&doctor).Error; err != nil {
-		log.Println("Doctor not found:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Unauthorized"})
-		return
-	}
+	optionalQueryInt(c, "days", &request.Days)
 
-	// Get the "days" query parameter (defaulting to 30).
-	daysStr := c.DefaultQuery("days", "30")
-	daysNum, err := strconv.Atoi(daysStr)
-	if err != nil || daysNum < 1 {
+	// Use days from the query or request, defaulting to 30.
+	daysNum := request.Days
+	if daysNum < 1 {
 		daysNum = 30
 	}
 
@@ -770,41 +1240,33 @@ func GetBusiestDays(c *gin.Context) {
 	stats, err := service.GetBusiestDays(doctor.ID.String(), daysNum)
 	if err != nil {
 		log.Println("Error retrieving busiest days:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to retrieve busiest days"})
+		middleware.Abort(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, stats)
 }
 
+// UpdatePatientRequest carries the patient fields to change.
+type UpdatePatientRequest struct {
+	PatientID  string                 `json:"patient_id"`
+	UpdateData map[string]interface{} `json:"update_data"`
+}
+
 // Works
 func UpdatePatient(c *gin.Context) {
-	// Extract email and patient ID from JSON request
-	var request struct {
-		Email      string                 `json:"email"`
-		PatientID  string                 `json:"patient_id"`
-		UpdateData map[string]interface{} `json:"update_data"`
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
 	}
 
+	// Extract patient ID from JSON request
+	var request UpdatePatientRequest
+
 	// Bind JSON request to struct
 	if err := c.ShouldBindJSON(&request); err != nil {
 		log.Println("Invalid request body:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid input"})
-		return
-	}
-
-	// Validate email
-	if request.Email == "" {
-		log.Println("Email is required")
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Email is required"})
-		return
-	}
-
-	// Fetch doctor by email
-	var doctor models.Doctor
-	if err := initializers.DB.Where("email = ?", request.Email).First(&doctor).Error; err != nil {
-		log.Println("Doctor not found:", err)
-		c.JSON(http.StatusUnauthorized, gin.H{"message": "Doctor not found"})
+		middleware.Abort(c, invalidRequest(err))
 		return
 	}
 
@@ -812,17 +1274,118 @@ func UpdatePatient(c *gin.Context) {
 	patientID, err := uuid.Parse(request.PatientID)
 	if err != nil {
 		log.Println("Invalid patient ID:", err)
-		c.JSON(http.StatusBadRequest, gin.H{"message": "Invalid patient ID"})
+		middleware.Abort(c, service.InvalidField("patient_id", "Invalid patient ID"))
 		return
 	}
+	middleware.SetAuditResource(c, patientID)
 
 	// Call the service to update the patient
 	err = service.UpdatePatientByID(doctor.ID, patientID, request.UpdateData)
 	if err != nil {
 		log.Println("Error updating patient:", err)
-		c.JSON(http.StatusInternalServerError, gin.H{"message": "Failed to update patient"})
+		middleware.Abort(c, err)
 		return
 	}
 
 	c.JSON(http.StatusOK, gin.H{"message": "Patient updated successfully"})
 }
+
+// parseTimeParam reads an RFC 3339 timestamp or a YYYY-MM-DD date. A date used as the
+// end of a range covers that whole day.
+func parseTimeParam(value string, endOfRange bool) (*time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return &t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return nil, err
+	}
+	if endOfRange {
+		t = t.AddDate(0, 0, 1)
+	}
+	return &t, nil
+}
+
+// ListAuditEvents handles GET /audit/events. Events can be filtered by patient_id,
+// doctor_id (the doctor who acted), action and a from/to time range.
+func ListAuditEvents(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+
+	filter := service.AuditEventFilter{Action: c.Query("action")}
+	if value := c.Query("patient_id"); value != "" {
+		patientID, err := uuid.Parse(value)
+		if err != nil {
+			middleware.Abort(c, service.InvalidField("patient_id", "Invalid patient ID"))
+			return
+		}
+		filter.PatientID = &patientID
+	}
+	if value := c.Query("doctor_id"); value != "" {
+		doctorID, err := uuid.Parse(value)
+		if err != nil {
+			middleware.Abort(c, service.InvalidField("doctor_id", "Invalid doctor ID"))
+			return
+		}
+		filter.ActorID = &doctorID
+	}
+	if value := c.Query("from"); value != "" {
+		from, err := parseTimeParam(value, false)
+		if err != nil {
+			middleware.Abort(c, service.InvalidField("from", "Invalid from time"))
+			return
+		}
+		filter.From = from
+	}
+	if value := c.Query("to"); value != "" {
+		to, err := parseTimeParam(value, true)
+		if err != nil {
+			middleware.Abort(c, service.InvalidField("to", "Invalid to time"))
+			return
+		}
+		filter.To = to
+	}
+
+	pageNum, err := strconv.Atoi(c.DefaultQuery("page", "1"))
+	if err != nil || pageNum < 1 {
+		pageNum = 1
+	}
+	limitNum, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil || limitNum < 1 || limitNum > 200 {
+		limitNum = 50
+	}
+	filter.Page, filter.Limit = pageNum, limitNum
+
+	events, total, err := service.ListAuditEvents(doctor.ID, filter)
+	if err != nil {
+		log.Println("Error retrieving audit events:", err)
+		middleware.Abort(c, err)
+		return
+	}
+
+	formatted := make([]gin.H, len(events))
+	for i, event := range events {
+		formatted[i] = gin.H{
+			"id":            event.ID,
+			"actor_id":      event.ActorID,
+			"action":        event.Action,
+			"resource_type": event.ResourceType,
+			"resource_id":   event.ResourceID,
+			"patient_id":    event.PatientID,
+			"ip_address":    event.IPAddress,
+			"user_agent":    event.UserAgent,
+			"outcome":       event.Outcome,
+			"status_code":   event.StatusCode,
+			"created_at":    event.CreatedAt,
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"events": formatted,
+		"total":  total,
+		"page":   pageNum,
+		"limit":  limitNum,
+	})
+}
@@ -1,10 +1,17 @@
 package controller
 
 import (
+	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/agiledragon/gomonkey/v2"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
@@ -94,7 +101,7 @@ func TestMockGetPatients_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	SetupMockDBPatients()
 
-	router := gin.Default()
+	router := newTestRouter()
 	router.GET("/mock_get_patients", MockGetPatients)
 
 	req, _ := http.NewRequest("GET", "/mock_get_patients?email=doctor@example.com", nil)
@@ -111,7 +118,7 @@ func TestMockGetPatients_DoctorNotFound(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	SetupMockDBPatients()
 
-	router := gin.Default()
+	router := newTestRouter()
 	router.GET("/mock_get_patients", MockGetPatients)
 
 	req, _ := http.NewRequest("GET", "/mock_get_patients?email=unknown@example.com", nil)
@@ -127,7 +134,7 @@ func TestMockGetPatients_MissingEmail(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	SetupMockDBPatients()
 
-	router := gin.Default()
+	router := newTestRouter()
 	router.GET("/mock_get_patients", MockGetPatients)
 
 	req, _ := http.NewRequest("GET", "/mock_get_patients", nil)
@@ -146,7 +153,7 @@ func TestMockGetPatients_DBFailure(t *testing.T) {
 	// Simulate DB failure
 	mockDBPatients = nil
 
-	router := gin.Default()
+	router := newTestRouter()
 	router.GET("/mock_get_patients", MockGetPatients)
 
 	req, _ := http.NewRequest("GET", "/mock_get_patients?email=doctor@example.com", nil)
@@ -156,3 +163,101 @@ func TestMockGetPatients_DBFailure(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 	assert.Contains(t, w.Body.String(), "Database failure")
 }
+
+// ✅ CreatePatient queues a transcription job and returns its ID straight away
+func TestCreatePatient_QueuesJob(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doctor := mockAuthDoctor()
+	jobID := uuid.New()
+
+	var queuedFor uuid.UUID
+	var queuedAudio string
+	patches := gomonkey.ApplyFunc(service.EnqueueTranscriptionJob, func(doctorID uuid.UUID, patient models.Patient, audio service.AudioSource) (*models.TranscriptionJob, error) {
+		queuedFor = doctorID
+		queuedAudio = audio.URL
+		return &models.TranscriptionJob{ID: jobID, DoctorID: doctorID, Status: models.JobStatusQueued}, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.POST("/patients", withDoctor(doctor), CreatePatient)
+
+	body := `{"patient": {"Name": "John Doe", "Age": 30, "Gender": "Male"}, "audio": "https://example.com/audio.mp3"}`
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patients", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	var resp map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, jobID.String(), resp["job_id"])
+	assert.Equal(t, models.JobStatusQueued, resp["status"])
+	assert.Equal(t, doctor.ID, queuedFor)
+	assert.Equal(t, "https://example.com/audio.mp3", queuedAudio)
+}
+
+// ❌ A job that cannot be queued returns 500
+func TestCreatePatient_ServiceFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFunc(service.EnqueueTranscriptionJob, func(doctorID uuid.UUID, patient models.Patient, audio service.AudioSource) (*models.TranscriptionJob, error) {
+		return nil, errors.New("failed to create transcription job")
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.POST("/patients", withDoctor(mockAuthDoctor()), CreatePatient)
+
+	body := `{"patient": {"Name": "John Doe", "Age": 30, "Gender": "Male"}, "audio": "https://example.com/audio.mp3"}`
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patients", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "An unexpected error occurred")
+}
+
+// ❌ Malformed JSON returns 400
+func TestCreatePatient_InvalidBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := newTestRouter()
+	router.POST("/patients", withDoctor(mockAuthDoctor()), CreatePatient)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patients", strings.NewReader(`{"patient": `))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid input")
+}
+
+// ❌ An audio file the doctor does not own returns 404
+func TestCreatePatient_AudioFileNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var queuedFileID *uuid.UUID
+	patches := gomonkey.ApplyFunc(service.EnqueueTranscriptionJob, func(doctorID uuid.UUID, patient models.Patient, audio service.AudioSource) (*models.TranscriptionJob, error) {
+		queuedFileID = audio.FileID
+		return nil, service.ErrAudioFileNotFound
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.POST("/patients", withDoctor(mockAuthDoctor()), CreatePatient)
+
+	audioFileID := uuid.New()
+	body := `{"patient": {"Name": "John Doe", "Age": 30, "Gender": "Male"}, "audio_file_id": "` + audioFileID.String() + `"}`
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/patients", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	if assert.NotNil(t, queuedFileID) {
+		assert.Equal(t, audioFileID, *queuedFileID)
+	}
+}
@@ -20,18 +20,17 @@ func TestDeletePatient_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	// Mock the service.DeletePatient function to return nil (success)
-	patches := gomonkey.ApplyFunc(service.DeletePatient, func(patientID uuid.UUID) error {
+	patches := gomonkey.ApplyFunc(service.DeletePatient, func(doctorID, patientID uuid.UUID) error {
 		return nil
 	})
 	defer patches.Reset()
 
-	router := gin.Default()
-	router.POST("/delete_patient", DeletePatient)
+	router := newTestRouter()
+	router.POST("/delete_patient", withDoctor(mockAuthDoctor()), DeletePatient)
 
 	patientID := uuid.New().String()
 
 	requestBody, _ := json.Marshal(map[string]string{
-		"email":      "testdoctor@example.com",
 		"patient_id": patientID,
 	})
 
@@ -47,11 +46,10 @@ func TestDeletePatient_Success(t *testing.T) {
 
 func TestDeletePatient_InvalidID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	router := gin.Default()
-	router.POST("/delete_patient", DeletePatient)
+	router := newTestRouter()
+	router.POST("/delete_patient", withDoctor(mockAuthDoctor()), DeletePatient)
 
 	requestBody, _ := json.Marshal(map[string]string{
-		"email":      "testdoctor@example.com",
 		"patient_id": "invalid-uuid",
 	})
 
@@ -69,18 +67,17 @@ func TestDeletePatient_ServiceFailure(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	// Mock the service.DeletePatient function to return an error
-	patches := gomonkey.ApplyFunc(service.DeletePatient, func(patientID uuid.UUID) error {
+	patches := gomonkey.ApplyFunc(service.DeletePatient, func(doctorID, patientID uuid.UUID) error {
 		return errors.New("database error")
 	})
 	defer patches.Reset()
 
-	router := gin.Default()
-	router.POST("/delete_patient", DeletePatient)
+	router := newTestRouter()
+	router.POST("/delete_patient", withDoctor(mockAuthDoctor()), DeletePatient)
 
 	patientID := uuid.New().String()
 
 	requestBody, _ := json.Marshal(map[string]string{
-		"email":      "testdoctor@example.com",
 		"patient_id": patientID,
 	})
 
@@ -91,5 +88,35 @@ func TestDeletePatient_ServiceFailure(t *testing.T) {
 
 	// Assert status code is 500 Internal Server Error
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
-	assert.Contains(t, w.Body.String(), "Failed to delete patient")
+	assert.Contains(t, w.Body.String(), "An unexpected error occurred")
+}
+
+func TestDeletePatient_NotOwned(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "Missing", err: service.ErrPatientNotFound, wantStatus: http.StatusNotFound},
+		{name: "Other doctor's", err: service.ErrForbidden, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patches := gomonkey.ApplyFuncReturn(service.DeletePatient, tt.err)
+			defer patches.Reset()
+
+			router := newTestRouter()
+			router.POST("/delete_patient", withDoctor(mockAuthDoctor()), DeletePatient)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/delete_patient", strings.NewReader(`{"patient_id": "`+uuid.NewString()+`"}`))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
 }
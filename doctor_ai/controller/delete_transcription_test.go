@@ -22,8 +22,8 @@ func TestDeleteTranscription_Success(t *testing.T) {
 	patches := gomonkey.ApplyFuncReturn(service.DeleteTranscription, nil)
 	defer patches.Reset()
 
-	router := gin.Default()
-	router.DELETE("/delete_transcription/:id", DeleteTranscription)
+	router := newTestRouter()
+	router.DELETE("/delete_transcription/:id", withDoctor(mockAuthDoctor()), DeleteTranscription)
 
 	transcriptionID := uuid.New().String()
 	requestBody := `{
@@ -46,8 +46,8 @@ func TestDeleteTranscription_Success(t *testing.T) {
 func TestDeleteTranscription_InvalidID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	router := gin.Default()
-	router.DELETE("/delete_transcription/:id", DeleteTranscription)
+	router := newTestRouter()
+	router.DELETE("/delete_transcription/:id", withDoctor(mockAuthDoctor()), DeleteTranscription)
 
 	requestBody := `{
 		"transcription_id": "invalid-uuid"
@@ -68,8 +68,8 @@ func TestDeleteTranscription_ServiceError(t *testing.T) {
 	patches := gomonkey.ApplyFuncReturn(service.DeleteTranscription, assert.AnError)
 	defer patches.Reset()
 
-	router := gin.Default()
-	router.DELETE("/delete_transcription/:id", DeleteTranscription)
+	router := newTestRouter()
+	router.DELETE("/delete_transcription/:id", withDoctor(mockAuthDoctor()), DeleteTranscription)
 
 	transcriptionID := uuid.New().String()
 	requestBody := `{
@@ -83,3 +83,48 @@ func TestDeleteTranscription_ServiceError(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
+
+func TestDeleteTranscription_NotOwned(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "Missing", err: service.ErrTranscriptionNotFound, wantStatus: http.StatusNotFound},
+		{name: "Other doctor's", err: service.ErrForbidden, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patches := gomonkey.ApplyFuncReturn(service.DeleteTranscription, tt.err)
+			defer patches.Reset()
+
+			router := newTestRouter()
+			router.DELETE("/delete_transcription/:id", withDoctor(mockAuthDoctor()), DeleteTranscription)
+
+			transcriptionID := uuid.New().String()
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("DELETE", "/delete_transcription/"+transcriptionID, strings.NewReader(`{"transcription_id": "`+transcriptionID+`"}`))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
+
+func TestDeleteTranscription_Unauthorized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := newTestRouter()
+	router.DELETE("/delete_transcription/:id", DeleteTranscription)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/delete_transcription/"+uuid.NewString(), strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
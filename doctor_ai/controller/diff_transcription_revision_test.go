@@ -0,0 +1,111 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"itish41/doctor_ai_assistant/middleware"
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffTranscriptionRevision_AgainstCurrent(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	revisionID := uuid.New()
+
+	patches := gomonkey.ApplyFunc(service.DiffTranscriptionRevisions, func(doctorID, transcriptionID, fromID uuid.UUID, toID *uuid.UUID) (*service.TranscriptionRevisionDiff, error) {
+		assert.Equal(t, revisionID, fromID)
+		assert.Nil(t, toID)
+		return &service.TranscriptionRevisionDiff{
+			From: models.TranscriptionRevision{ID: fromID, Version: 1},
+			Text: []service.DiffLine{{Op: service.DiffDelete, Text: "old"}, {Op: service.DiffInsert, Text: "new"}},
+		}, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/transcription/:id/revisions/:revision_id/diff", withDoctor(mockAuthDoctor()), DiffTranscriptionRevision)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/transcription/"+uuid.NewString()+"/revisions/"+revisionID.String()+"/diff", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		To   interface{}        `json:"to"`
+		Text []service.DiffLine `json:"text"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "current", response.To)
+	assert.Equal(t, []service.DiffLine{{Op: "delete", Text: "old"}, {Op: "insert", Text: "new"}}, response.Text)
+}
+
+func TestDiffTranscriptionRevision_AgainstRevision(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	againstID := uuid.New()
+
+	patches := gomonkey.ApplyFunc(service.DiffTranscriptionRevisions, func(doctorID, transcriptionID, fromID uuid.UUID, toID *uuid.UUID) (*service.TranscriptionRevisionDiff, error) {
+		assert.Equal(t, againstID, *toID)
+		return &service.TranscriptionRevisionDiff{
+			From: models.TranscriptionRevision{ID: fromID, Version: 1},
+			To:   &models.TranscriptionRevision{ID: *toID, Version: 3},
+		}, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/transcription/:id/revisions/:revision_id/diff", withDoctor(mockAuthDoctor()), DiffTranscriptionRevision)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/transcription/"+uuid.NewString()+"/revisions/"+uuid.NewString()+"/diff?against="+againstID.String(), nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		To map[string]interface{} `json:"to"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, float64(3), response.To["version"])
+}
+
+func TestDiffTranscriptionRevision_InvalidAgainst(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := newTestRouter()
+	router.GET("/transcription/:id/revisions/:revision_id/diff", withDoctor(mockAuthDoctor()), DiffTranscriptionRevision)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/transcription/"+uuid.NewString()+"/revisions/"+uuid.NewString()+"/diff?against=latest", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var problem middleware.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Equal(t, map[string]string{"against": "Invalid revision ID"}, problem.Errors)
+}
+
+func TestDiffTranscriptionRevision_RevisionNotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFuncReturn(service.DiffTranscriptionRevisions, nil, service.ErrRevisionNotFound)
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/transcription/:id/revisions/:revision_id/diff", withDoctor(mockAuthDoctor()), DiffTranscriptionRevision)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/transcription/"+uuid.NewString()+"/revisions/"+uuid.NewString()+"/diff", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "revision not found")
+}
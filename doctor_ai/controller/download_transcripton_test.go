@@ -5,8 +5,14 @@ import (
 	"net/http/httptest"
 	"strings"
 	"testing"
+	"time"
 
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/agiledragon/gomonkey/v2"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 	"gorm.io/driver/sqlite"
 	"gorm.io/gorm"
@@ -96,7 +102,7 @@ func TestHandleDownloadTranscription_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	setupTestDB() // Set up test database
 
-	router := gin.Default()
+	router := newTestRouter()
 	router.POST("/download_transcription", HandleDownloadTranscription)
 
 	requestBody := `{"email": "doctor@example.com", "patient_id": "pat-456"}`
@@ -109,3 +115,97 @@ func TestHandleDownloadTranscription_Success(t *testing.T) {
 	assert.Equal(t, http.StatusOK, w.Code)                     // Expect 200 OK
 	assert.Contains(t, w.Body.String(), "Test Medical Report") // Verify report data
 }
+
+func TestDownloadTranscription_ByID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	transcriptionID := uuid.New()
+	patches := gomonkey.ApplyFunc(service.GetReportDocument, func(doctorID, id uuid.UUID) (*service.ReportDocument, error) {
+		if id != transcriptionID {
+			return nil, service.ErrTranscriptionNotFound
+		}
+		return &service.ReportDocument{
+			Transcription: models.Transcription{
+				ID:        id,
+				Report:    "1. Diagnosis\nTension headache",
+				Status:    models.ReportStatusDraft,
+				CreatedAt: time.Date(2026, time.March, 1, 10, 0, 0, 0, time.UTC),
+			},
+			Patient: models.Patient{ID: uuid.New(), Name: "Jane Doe", Age: 40, Gender: "Female"},
+			Doctor:  mockAuthDoctor(),
+		}, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.POST("/transcription/:id/download", withDoctor(mockAuthDoctor()), DownloadTranscription)
+
+	tests := []struct {
+		name                string
+		path                string
+		accept              string
+		expectedStatus      int
+		expectedType        string
+		expectedDisposition string
+		expectedBody        string
+	}{
+		{
+			name:                "PDF by default",
+			path:                "/transcription/" + transcriptionID.String() + "/download",
+			expectedStatus:      http.StatusOK,
+			expectedType:        "application/pdf",
+			expectedDisposition: "attachment; filename=jane-doe_2026-03-01_report.pdf",
+			expectedBody:        "%PDF-",
+		},
+		{
+			name:                "Plain text",
+			path:                "/transcription/" + transcriptionID.String() + "/download?format=TXT",
+			expectedStatus:      http.StatusOK,
+			expectedType:        "text/plain; charset=utf-8",
+			expectedDisposition: "attachment; filename=jane-doe_2026-03-01_report.txt",
+			expectedBody:        "Medical Transcription Report",
+		},
+		{
+			name:                "Accept header",
+			path:                "/transcription/" + transcriptionID.String() + "/download",
+			accept:              "application/pdf;q=0.5, application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+			expectedStatus:      http.StatusOK,
+			expectedType:        "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+			expectedDisposition: "attachment; filename=jane-doe_2026-03-01_report.docx",
+			expectedBody:        "PK",
+		},
+		{
+			name:                "Format overrides Accept",
+			path:                "/transcription/" + transcriptionID.String() + "/download?format=md",
+			accept:              "text/html",
+			expectedStatus:      http.StatusOK,
+			expectedType:        "text/markdown; charset=utf-8",
+			expectedDisposition: "attachment; filename=jane-doe_2026-03-01_report.md",
+			expectedBody:        "# Medical Transcription Report",
+		},
+		{name: "Nothing acceptable", path: "/transcription/" + transcriptionID.String() + "/download", accept: "image/png", expectedStatus: http.StatusNotAcceptable},
+		{name: "Unknown format", path: "/transcription/" + transcriptionID.String() + "/download?format=xls", expectedStatus: http.StatusBadRequest},
+		{name: "Invalid ID", path: "/transcription/abc/download", expectedStatus: http.StatusBadRequest},
+		{name: "Unknown transcription", path: "/transcription/" + uuid.New().String() + "/download", expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			// The legacy client still posts a patient; it no longer decides what is rendered
+			req, _ := http.NewRequest("POST", tt.path, strings.NewReader(`{"patient": {"id": "`+uuid.New().String()+`"}}`))
+			req.Header.Set("Content-Type", "application/json")
+			if tt.accept != "" {
+				req.Header.Set("Accept", tt.accept)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Equal(t, tt.expectedType, w.Header().Get("Content-Type"))
+				assert.Equal(t, tt.expectedDisposition, w.Header().Get("Content-Disposition"))
+				assert.True(t, strings.HasPrefix(w.Body.String(), tt.expectedBody))
+			}
+		})
+	}
+}
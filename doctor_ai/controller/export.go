@@ -0,0 +1,116 @@
+package controller
+
+import (
+	"log"
+	"mime"
+	"net/http"
+	"time"
+
+	"itish41/doctor_ai_assistant/middleware"
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// CreateExport handles POST /api/v2/exports. The archive of the doctor's profile,
+// patients and transcriptions is built in the background; poll /api/v2/exports/:id for
+// its download link.
+func CreateExport(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+
+	job, err := service.EnqueueExportJob(doctor.ID)
+	if err != nil {
+		log.Println("Error queueing export:", err)
+		middleware.Abort(c, err)
+		return
+	}
+
+	middleware.SetAuditResource(c, job.ID)
+	c.JSON(http.StatusAccepted, exportResponse(c, *job))
+}
+
+// GetExport handles GET /api/v2/exports/:id. Once the export is done the response
+// carries a signed download link, fresh on every poll.
+func GetExport(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+	jobID, ok := pathID(c, "Invalid export ID")
+	if !ok {
+		return
+	}
+
+	job, err := service.GetExportJob(doctor.ID, jobID)
+	if err != nil {
+		log.Println("Error fetching export:", err)
+		middleware.Abort(c, err)
+		return
+	}
+	c.JSON(http.StatusOK, exportResponse(c, *job))
+}
+
+// DownloadExport handles GET /api/v2/exports/:id/download?expires=&signature=. The
+// signed link stands in for the bearer token, so a browser can download the archive.
+func DownloadExport(c *gin.Context) {
+	jobID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		middleware.Abort(c, service.ErrExportLinkInvalid)
+		return
+	}
+
+	download, err := service.OpenExportDownload(jobID, c.Query("expires"), c.Query("signature"), time.Now())
+	if err != nil {
+		log.Println("Error opening export download:", err)
+		middleware.Abort(c, err)
+		return
+	}
+	defer download.Archive.Close()
+
+	// The route has no Audit middleware, as there's no doctor to attribute a rejected link to
+	if err := service.RecordAuditEvent(models.AuditEvent{
+		ActorID:      &download.Job.DoctorID,
+		Action:       "export.download",
+		ResourceType: models.AuditResourceExport,
+		ResourceID:   download.Job.ID.String(),
+		IPAddress:    c.ClientIP(),
+		UserAgent:    c.Request.UserAgent(),
+		Outcome:      models.AuditOutcomeSuccess,
+		StatusCode:   http.StatusOK,
+	}); err != nil {
+		log.Println("Error recording audit event for export.download:", err)
+	}
+	c.DataFromReader(http.StatusOK, download.Job.Size, "application/zip", download.Archive, map[string]string{
+		"Content-Disposition": mime.FormatMediaType("attachment", map[string]string{"filename": service.ExportFileName(download.Job)}),
+		"Cache-Control":       "no-store",
+	})
+}
+
+// exportResponse describes an export, with a download link once it is done.
+func exportResponse(c *gin.Context, job models.ExportJob) gin.H {
+	response := gin.H{
+		"job_id":              job.ID,
+		"status":              job.Status,
+		"error":               job.LastError,
+		"patient_count":       job.PatientCount,
+		"transcription_count": job.TranscriptionCount,
+		"size":                job.Size,
+		"sha256":              job.Checksum,
+		"created_at":          job.CreatedAt,
+		"completed_at":        job.CompletedAt,
+		"expires_at":          job.ExpiresAt,
+		"download_url":        nil,
+		"download_expires_at": nil,
+	}
+	downloadURL := requestOrigin(c) + "/api/v2/exports/" + job.ID.String() + "/download"
+	if link, ok := service.NewExportLink(job, downloadURL, time.Now()); ok {
+		response["download_url"] = link.URL
+		response["download_expires_at"] = link.ExpiresAt
+	}
+	return response
+}
@@ -0,0 +1,183 @@
+package controller
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestCreateExport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doctor := mockAuthDoctor()
+
+	var inProgress bool
+	patches := gomonkey.ApplyFunc(service.EnqueueExportJob, func(doctorID uuid.UUID) (*models.ExportJob, error) {
+		if inProgress {
+			return nil, service.ErrExportInProgress
+		}
+		return &models.ExportJob{ID: uuid.New(), DoctorID: doctorID, Status: models.ExportStatusQueued}, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.POST("/api/v2/exports", withDoctor(doctor), CreateExport)
+
+	t.Run("Queued", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v2/exports", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusAccepted, w.Code)
+		var resp map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, models.ExportStatusQueued, resp["status"])
+		assert.Nil(t, resp["download_url"])
+	})
+
+	t.Run("Already running", func(t *testing.T) {
+		inProgress = true
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/api/v2/exports", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusConflict, w.Code)
+	})
+}
+
+func TestGetExport_Done(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("EXPORT_LINK_SECRET", "test-export-secret")
+	doctor := mockAuthDoctor()
+	completedAt := time.Now()
+	expiresAt := completedAt.Add(24 * time.Hour)
+	job := models.ExportJob{
+		ID: uuid.New(), DoctorID: doctor.ID, Status: models.ExportStatusDone, Size: 2048, Checksum: "abc123",
+		PatientCount: 3, TranscriptionCount: 5, CompletedAt: &completedAt, ExpiresAt: &expiresAt,
+	}
+
+	patches := gomonkey.ApplyFunc(service.GetExportJob, func(doctorID, jobID uuid.UUID) (*models.ExportJob, error) {
+		if doctorID != doctor.ID || jobID != job.ID {
+			return nil, service.ErrExportNotFound
+		}
+		return &job, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/api/v2/exports/:id", withDoctor(doctor), GetExport)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v2/exports/"+job.ID.String(), nil)
+	req.Host = "api.example.com"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var resp map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, float64(5), resp["transcription_count"])
+	assert.Equal(t, "abc123", resp["sha256"])
+	downloadURL, _ := resp["download_url"].(string)
+	assert.True(t, strings.HasPrefix(downloadURL, "https://api.example.com/api/v2/exports/"+job.ID.String()+"/download?"), downloadURL)
+	assert.NotNil(t, resp["download_expires_at"])
+
+	t.Run("Another doctor's export", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v2/exports/"+uuid.NewString(), nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNotFound, w.Code)
+	})
+}
+
+func TestDownloadExport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	completedAt := time.Date(2026, 3, 4, 10, 0, 0, 0, time.UTC)
+	job := models.ExportJob{ID: uuid.New(), DoctorID: uuid.New(), Status: models.ExportStatusDone, Size: 7, CompletedAt: &completedAt}
+
+	var query url.Values
+	patches := gomonkey.ApplyFunc(service.OpenExportDownload, func(jobID uuid.UUID, expires, signature string, now time.Time) (*service.ExportDownload, error) {
+		query = url.Values{"expires": {expires}, "signature": {signature}}
+		if jobID != job.ID || signature != "good" {
+			return nil, service.ErrExportLinkInvalid
+		}
+		return &service.ExportDownload{Job: job, Archive: io.NopCloser(strings.NewReader("PK\x03\x04zip"))}, nil
+	})
+	defer patches.Reset()
+
+	// Downloads are audited by the handler itself
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	if err := db.Exec(`CREATE TABLE audit_events (
+		id TEXT PRIMARY KEY,
+		actor_id TEXT,
+		action TEXT NOT NULL,
+		resource_type TEXT NOT NULL,
+		resource_id TEXT,
+		patient_id TEXT,
+		ip_address TEXT,
+		user_agent TEXT,
+		outcome TEXT NOT NULL,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error; err != nil {
+		t.Fatalf("Failed to create audit_events table: %v", err)
+	}
+	initializers.DB = db
+
+	router := newTestRouter()
+	router.GET("/api/v2/exports/:id/download", DownloadExport)
+
+	t.Run("Signed link", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v2/exports/"+job.ID.String()+"/download?expires=1700000000&signature=good", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, "1700000000", query.Get("expires"))
+		assert.Equal(t, "application/zip", w.Header().Get("Content-Type"))
+		assert.Equal(t, `attachment; filename=practice-export_2026-03-04.zip`, w.Header().Get("Content-Disposition"))
+		assert.Equal(t, "no-store", w.Header().Get("Cache-Control"))
+		assert.Equal(t, "PK\x03\x04zip", w.Body.String())
+
+		var events []models.AuditEvent
+		assert.NoError(t, initializers.DB.Find(&events).Error)
+		if assert.Len(t, events, 1) {
+			assert.Equal(t, "export.download", events[0].Action)
+			assert.Equal(t, &job.DoctorID, events[0].ActorID)
+			assert.Equal(t, job.ID.String(), events[0].ResourceID)
+		}
+	})
+
+	for name, path := range map[string]string{
+		"Bad signature": "/api/v2/exports/" + job.ID.String() + "/download?expires=1700000000&signature=bad",
+		"Invalid ID":    "/api/v2/exports/not-a-uuid/download?expires=1700000000&signature=good",
+	} {
+		t.Run(name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", path, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+			assert.NotEqual(t, "application/zip", w.Header().Get("Content-Type"))
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package controller
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"itish41/doctor_ai_assistant/fhir"
+	"itish41/doctor_ai_assistant/middleware"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// requestOrigin is where clients reach the API, for absolute URLs in responses. It is
+// PUBLIC_BASE_URL when set; only then can a client not point the links at a host of its
+// choosing with its Host header. Without it, as in development, the request's own host
+// is used, and behind a TLS-terminating proxy X-Forwarded-Proto tells the scheme.
+func requestOrigin(c *gin.Context) string {
+	if base := strings.TrimRight(os.Getenv("PUBLIC_BASE_URL"), "/"); base != "" {
+		return base
+	}
+	scheme := "http"
+	if c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https" {
+		scheme = "https"
+	}
+	return scheme + "://" + c.Request.Host
+}
+
+// fhirServer is where the FHIR exports are served from, for the URLs they carry.
+func fhirServer(c *gin.Context) service.FHIRServer {
+	origin := requestOrigin(c)
+	return service.FHIRServer{
+		BaseURL:   origin + "/api/v2/fhir",
+		ReportURL: origin + "/api/v2/transcriptions",
+	}
+}
+
+// fhirResource responds with a FHIR resource, or with err as an OperationOutcome.
+func fhirResource(c *gin.Context, resource interface{}, err error) {
+	if err != nil {
+		log.Println("Error exporting FHIR resource:", err)
+		middleware.Abort(c, err)
+		return
+	}
+	body, err := json.Marshal(resource)
+	if err != nil {
+		log.Println("Error encoding FHIR resource:", err)
+		middleware.Abort(c, err)
+		return
+	}
+	c.Data(http.StatusOK, fhir.ContentType, body)
+}
+
+// GetFHIRPatient handles GET /api/v2/fhir/Patient/:id.
+func GetFHIRPatient(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+	patientID, ok := pathID(c, "Invalid patient ID")
+	if !ok {
+		return
+	}
+	patient, err := service.GetFHIRPatient(doctor.ID, patientID)
+	fhirResource(c, patient, err)
+}
+
+// GetFHIRPatientEverything handles GET /api/v2/fhir/Patient/:id/$everything: a
+// searchset Bundle of the patient with their encounters and reports.
+func GetFHIRPatientEverything(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+	patientID, ok := pathID(c, "Invalid patient ID")
+	if !ok {
+		return
+	}
+	bundle, err := service.GetFHIRPatientEverything(doctor.ID, patientID, fhirServer(c))
+	fhirResource(c, bundle, err)
+}
+
+// GetFHIRPractitioner handles GET /api/v2/fhir/Practitioner/:id. Doctors can only read
+// their own.
+func GetFHIRPractitioner(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+	practitionerID, ok := pathID(c, "Invalid practitioner ID")
+	if !ok {
+		return
+	}
+	practitioner, err := service.GetFHIRPractitioner(doctor.ID, practitionerID)
+	fhirResource(c, practitioner, err)
+}
+
+// GetFHIREncounter handles GET /api/v2/fhir/Encounter/:id.
+func GetFHIREncounter(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+	encounterID, ok := pathID(c, "Invalid encounter ID")
+	if !ok {
+		return
+	}
+	encounter, err := service.GetFHIREncounter(doctor.ID, encounterID)
+	fhirResource(c, encounter, err)
+}
+
+// GetFHIRComposition handles GET /api/v2/fhir/Composition/:id, where :id is a
+// transcription ID.
+func GetFHIRComposition(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+	transcriptionID, ok := pathID(c, "Invalid composition ID")
+	if !ok {
+		return
+	}
+	composition, err := service.GetFHIRComposition(doctor.ID, transcriptionID)
+	fhirResource(c, composition, err)
+}
+
+// GetFHIRDiagnosticReport handles GET /api/v2/fhir/DiagnosticReport/:id, where :id is a
+// transcription ID.
+func GetFHIRDiagnosticReport(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+	transcriptionID, ok := pathID(c, "Invalid diagnostic report ID")
+	if !ok {
+		return
+	}
+	report, err := service.GetFHIRDiagnosticReport(doctor.ID, transcriptionID)
+	fhirResource(c, report, err)
+}
+
+// GetFHIRDocumentReference handles GET /api/v2/fhir/DocumentReference/:id, where :id is
+// a transcription ID.
+func GetFHIRDocumentReference(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+	transcriptionID, ok := pathID(c, "Invalid document reference ID")
+	if !ok {
+		return
+	}
+	document, err := service.GetFHIRDocumentReference(doctor.ID, transcriptionID, fhirServer(c))
+	fhirResource(c, document, err)
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"itish41/doctor_ai_assistant/fhir"
+	"itish41/doctor_ai_assistant/middleware"
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// newFHIRTestRouter returns a router that renders handler errors as the FHIR routes do
+func newFHIRTestRouter() *gin.Engine {
+	router := newTestRouter()
+	router.Use(middleware.FHIRErrorHandler())
+	return router
+}
+
+func TestGetFHIRPatient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doctor := mockAuthDoctor()
+	patient := models.Patient{ID: uuid.New(), Name: "John Doe", Age: 40, Gender: "Male", DoctorID: doctor.ID}
+
+	patches := gomonkey.ApplyFunc(service.GetFHIRPatient, func(doctorID, patientID uuid.UUID) (*fhir.Patient, error) {
+		if doctorID != doctor.ID || patientID != patient.ID {
+			return nil, service.ErrPatientNotFound
+		}
+		export := fhir.NewPatient(patient)
+		return &export, nil
+	})
+	defer patches.Reset()
+
+	router := newFHIRTestRouter()
+	router.GET("/fhir/Patient/:id", withDoctor(doctor), GetFHIRPatient)
+
+	t.Run("Found", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/fhir/Patient/"+patient.ID.String(), nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, fhir.ContentType, w.Header().Get("Content-Type"))
+		var resp map[string]interface{}
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+		assert.Equal(t, "Patient", resp["resourceType"])
+		assert.Equal(t, patient.ID.String(), resp["id"])
+		assert.Equal(t, "male", resp["gender"])
+	})
+
+	for _, tc := range []struct {
+		name, path string
+		status     int
+		code       string
+	}{
+		{"Not found", "/fhir/Patient/" + uuid.NewString(), http.StatusNotFound, "not-found"},
+		{"Invalid ID", "/fhir/Patient/not-a-uuid", http.StatusBadRequest, "invalid"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", tc.path, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tc.status, w.Code)
+			assert.Equal(t, fhir.ContentType, w.Header().Get("Content-Type"))
+			var outcome fhir.OperationOutcome
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &outcome))
+			assert.Equal(t, "OperationOutcome", outcome.ResourceType)
+			if assert.Len(t, outcome.Issue, 1) {
+				assert.Equal(t, "error", outcome.Issue[0].Severity)
+				assert.Equal(t, tc.code, outcome.Issue[0].Code)
+			}
+		})
+	}
+}
+
+func TestGetFHIRPatientEverything(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doctor := mockAuthDoctor()
+	patientID := uuid.New()
+
+	var server service.FHIRServer
+	patches := gomonkey.ApplyFunc(service.GetFHIRPatientEverything, func(doctorID, id uuid.UUID, s service.FHIRServer) (*fhir.Bundle, error) {
+		server = s
+		bundle := fhir.NewSearchset(s.BaseURL, s.BaseURL+"/Patient/"+id.String()+"/$everything", nil, nil)
+		return &bundle, nil
+	})
+	defer patches.Reset()
+
+	router := newFHIRTestRouter()
+	router.GET("/api/v2/fhir/Patient/:id/$everything", withDoctor(doctor), GetFHIRPatientEverything)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/api/v2/fhir/Patient/"+patientID.String()+"/$everything", nil)
+	req.Host = "api.example.com"
+	req.Header.Set("X-Forwarded-Proto", "https")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, fhir.ContentType, w.Header().Get("Content-Type"))
+	assert.Equal(t, service.FHIRServer{
+		BaseURL:   "https://api.example.com/api/v2/fhir",
+		ReportURL: "https://api.example.com/api/v2/transcriptions",
+	}, server)
+
+	var bundle fhir.Bundle
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &bundle))
+	assert.Equal(t, "searchset", bundle.Type)
+	assert.Equal(t, "https://api.example.com/api/v2/fhir/Patient/"+patientID.String()+"/$everything", bundle.Link[0].URL)
+
+	t.Run("Configured base URL", func(t *testing.T) {
+		t.Setenv("PUBLIC_BASE_URL", "https://ehr.example.org/")
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v2/fhir/Patient/"+patientID.String()+"/$everything", nil)
+		req.Host = "attacker.example.net"
+		req.Header.Set("X-Forwarded-Proto", "http")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, service.FHIRServer{
+			BaseURL:   "https://ehr.example.org/api/v2/fhir",
+			ReportURL: "https://ehr.example.org/api/v2/transcriptions",
+		}, server)
+	})
+}
+
+func TestGetFHIRPractitioner_Forbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFunc(service.GetFHIRPractitioner, func(doctorID, practitionerID uuid.UUID) (*fhir.Practitioner, error) {
+		return nil, service.ErrForbidden
+	})
+	defer patches.Reset()
+
+	router := newFHIRTestRouter()
+	router.GET("/fhir/Practitioner/:id", withDoctor(mockAuthDoctor()), GetFHIRPractitioner)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/fhir/Practitioner/"+uuid.NewString(), nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+	var outcome fhir.OperationOutcome
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &outcome))
+	assert.Equal(t, "forbidden", outcome.Issue[0].Code)
+	assert.Equal(t, "resource belongs to another doctor", outcome.Issue[0].Diagnostics)
+}
@@ -3,8 +3,6 @@ package controller
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
-	"itish41/doctor_ai_assistant/initializers"
 	"itish41/doctor_ai_assistant/service"
 	"net/http"
 	"net/http/httptest"
@@ -13,7 +11,6 @@ import (
 
 	"github.com/agiledragon/gomonkey/v2"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -21,12 +18,6 @@ func TestGetBusiestDays_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	setupMockDBForDashboardTest()
 
-	// Insert mock doctor with a unique email
-	mockDoctorID := uuid.NewString()
-	uniqueEmail := fmt.Sprintf("testdoctor_%s@example.com", uuid.NewString())
-	initializers.DB.Exec(`INSERT INTO doctors (id, name, email) VALUES (?, ?, ?)`,
-		mockDoctorID, "Test Doctor", uniqueEmail)
-
 	// Mock busiest days data (simulating service layer)
 	mockStats := []service.TimeBasedStats{
 		{Date: "2025-01-25", Count: 100},
@@ -43,15 +34,15 @@ func TestGetBusiestDays_Success(t *testing.T) {
 
 	defer patches.Reset()
 
-	// Create request body with the email of the inserted mock doctor
-	requestBody := fmt.Sprintf(`{"email": "%s"}`, uniqueEmail)
+	// Create request body; the doctor comes from the auth context
+	requestBody := `{}`
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/get_busiest_days", strings.NewReader(requestBody))
 	req.Header.Set("Content-Type", "application/json")
 
-	router := gin.Default()
-	router.POST("/get_busiest_days", GetBusiestDays)
+	router := newTestRouter()
+	router.POST("/get_busiest_days", withDoctor(mockAuthDoctor()), GetBusiestDays)
 	router.ServeHTTP(w, req)
 
 	// Assertions: Check for OK status and the returned statistics
@@ -62,15 +53,15 @@ func TestGetBusiestDays_Success(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "90")
 }
 
-func TestGetBusiestDays_InvalidEmail(t *testing.T) {
+func TestGetBusiestDays_InvalidBody(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	setupMockDBForDashboardTest()
 
-	router := gin.Default()
-	router.POST("/get_busiest_days", GetBusiestDays)
+	router := newTestRouter()
+	router.POST("/get_busiest_days", withDoctor(mockAuthDoctor()), GetBusiestDays)
 
-	// Missing email in the request body
-	requestBody := `{"email": "", "days": 30}`
+	// Malformed JSON in the request body
+	requestBody := `{"days": `
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/get_busiest_days", strings.NewReader(requestBody))
@@ -79,17 +70,17 @@ func TestGetBusiestDays_InvalidEmail(t *testing.T) {
 
 	// Assertions: Check for BadRequest status and error message
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Contains(t, w.Body.String(), "Doctor email is required")
+	assert.Contains(t, w.Body.String(), "Invalid input")
 }
 
-func TestGetBusiestDays_DoctorNotFound(t *testing.T) {
+func TestGetBusiestDays_Unauthenticated(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	setupMockDBForDashboardTest()
 
-	router := gin.Default()
+	router := newTestRouter()
 	router.POST("/get_busiest_days", GetBusiestDays)
 
-	// Provide a non-existing doctor's email
+	// An email in the body must not authenticate the request
 	requestBody, _ := json.Marshal(map[string]interface{}{
 		"email": "unknown@example.com",
 		"days":  30,
@@ -109,12 +100,6 @@ func TestGetBusiestDays_ServiceFailure(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	setupMockDBForDashboardTest()
 
-	// Insert mock doctor with a unique email
-	mockDoctorID := uuid.NewString()
-	uniqueEmail := fmt.Sprintf("testdoctor_%s@example.com", uuid.NewString())
-	initializers.DB.Exec(`INSERT INTO doctors (id, name, email) VALUES (?, ?, ?)`,
-		mockDoctorID, "Test Doctor", uniqueEmail)
-
 	// Mock service function to simulate a failure
 	patches := gomonkey.ApplyFunc(service.GetBusiestDays, func(doctorID string, days int) ([]service.TimeBasedStats, error) {
 		return nil, errors.New("database error")
@@ -122,32 +107,26 @@ func TestGetBusiestDays_ServiceFailure(t *testing.T) {
 
 	defer patches.Reset()
 
-	// Create request body with the email of the inserted mock doctor
-	requestBody := fmt.Sprintf(`{"email": "%s", "days": 30}`, uniqueEmail)
+	// Create request body; the doctor comes from the auth context
+	requestBody := `{"days": 30}`
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/get_busiest_days", strings.NewReader(requestBody))
 	req.Header.Set("Content-Type", "application/json")
 
-	router := gin.Default()
-	router.POST("/get_busiest_days", GetBusiestDays)
+	router := newTestRouter()
+	router.POST("/get_busiest_days", withDoctor(mockAuthDoctor()), GetBusiestDays)
 	router.ServeHTTP(w, req)
 
 	// Assertions: Check for Internal Server Error and the appropriate error message
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
-	assert.Contains(t, w.Body.String(), "Failed to retrieve busiest days")
+	assert.Contains(t, w.Body.String(), "An unexpected error occurred")
 }
 
 func TestGetBusiestDays_InvalidDaysQueryParam(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	setupMockDBForDashboardTest()
 
-	// Insert mock doctor with a unique email
-	mockDoctorID := uuid.NewString()
-	uniqueEmail := fmt.Sprintf("testdoctor_%s@example.com", uuid.NewString())
-	initializers.DB.Exec(`INSERT INTO doctors (id, name, email) VALUES (?, ?, ?)`,
-		mockDoctorID, "Test Doctor", uniqueEmail)
-
 	// Mock busiest days data (simulating service layer)
 	mockStats := []service.TimeBasedStats{
 		{Date: "2025-01-25", Count: 100},
@@ -161,16 +140,16 @@ func TestGetBusiestDays_InvalidDaysQueryParam(t *testing.T) {
 
 	defer patches.Reset()
 
-	// Create request body with the email of the inserted mock doctor
-	requestBody := fmt.Sprintf(`{"email": "%s"}`, uniqueEmail)
+	// Create request body; the doctor comes from the auth context
+	requestBody := `{}`
 
 	// Invalid days query parameter
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/get_busiest_days?days=not_a_number", strings.NewReader(requestBody))
 	req.Header.Set("Content-Type", "application/json")
 
-	router := gin.Default()
-	router.POST("/get_busiest_days", GetBusiestDays)
+	router := newTestRouter()
+	router.POST("/get_busiest_days", withDoctor(mockAuthDoctor()), GetBusiestDays)
 	router.ServeHTTP(w, req)
 
 	// Assertions: Check that the default value is applied
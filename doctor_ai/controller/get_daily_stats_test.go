@@ -3,8 +3,6 @@ package controller
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
-	"itish41/doctor_ai_assistant/initializers"
 	"itish41/doctor_ai_assistant/service"
 	"net/http"
 	"net/http/httptest"
@@ -13,7 +11,6 @@ import (
 
 	"github.com/agiledragon/gomonkey/v2"
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -21,12 +18,6 @@ func TestGetDailyStatistics_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	setupMockDBForDashboardTest()
 
-	// Insert mock doctor with a unique email
-	mockDoctorID := uuid.NewString()
-	uniqueEmail := fmt.Sprintf("testdoctor_%s@example.com", uuid.NewString())
-	initializers.DB.Exec(`INSERT INTO doctors (id, name, email) VALUES (?, ?, ?)`,
-		mockDoctorID, "Test Doctor", uniqueEmail)
-
 	// Mock daily statistics data (simulating service layer)
 	mockStats := []service.TimeBasedStats{
 		{Date: "2025-02-01", Count: 5},
@@ -40,15 +31,15 @@ func TestGetDailyStatistics_Success(t *testing.T) {
 
 	defer patches.Reset()
 
-	// Create request body with the email of the inserted mock doctor
-	requestBody := fmt.Sprintf(`{"email": "%s", "days": 7}`, uniqueEmail)
+	// Create request body; the doctor comes from the auth context
+	requestBody := `{"days": 7}`
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/get_daily_statistics", strings.NewReader(requestBody))
 	req.Header.Set("Content-Type", "application/json")
 
-	router := gin.Default()
-	router.POST("/get_daily_statistics", GetDailyStatistics)
+	router := newTestRouter()
+	router.POST("/get_daily_statistics", withDoctor(mockAuthDoctor()), GetDailyStatistics)
 	router.ServeHTTP(w, req)
 
 	// Assertions: Check for OK status and the returned statistics
@@ -59,15 +50,15 @@ func TestGetDailyStatistics_Success(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "3")
 }
 
-func TestGetDailyStatistics_InvalidEmail(t *testing.T) {
+func TestGetDailyStatistics_InvalidBody(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	setupMockDBForDashboardTest()
 
-	router := gin.Default()
-	router.POST("/get_daily_statistics", GetDailyStatistics)
+	router := newTestRouter()
+	router.POST("/get_daily_statistics", withDoctor(mockAuthDoctor()), GetDailyStatistics)
 
-	// Missing email in the request body
-	requestBody := `{"email": "", "days": 7}`
+	// Malformed JSON in the request body
+	requestBody := `{"days": `
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/get_daily_statistics", strings.NewReader(requestBody))
@@ -76,17 +67,17 @@ func TestGetDailyStatistics_InvalidEmail(t *testing.T) {
 
 	// Assertions: Check for BadRequest status and error message
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Contains(t, w.Body.String(), "Doctor email is required")
+	assert.Contains(t, w.Body.String(), "Invalid input")
 }
 
-func TestGetDailyStatistics_DoctorNotFound(t *testing.T) {
+func TestGetDailyStatistics_Unauthenticated(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	setupMockDBForDashboardTest()
 
-	router := gin.Default()
+	router := newTestRouter()
 	router.POST("/get_daily_statistics", GetDailyStatistics)
 
-	// Provide a non-existing doctor's email
+	// An email in the body must not authenticate the request
 	requestBody, _ := json.Marshal(map[string]interface{}{
 		"email": "unknown@example.com",
 		"days":  7,
@@ -106,12 +97,6 @@ func TestGetDailyStatistics_ServiceFailure(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	setupMockDBForDashboardTest()
 
-	// Insert mock doctor with a unique email
-	mockDoctorID := uuid.NewString()
-	uniqueEmail := fmt.Sprintf("testdoctor_%s@example.com", uuid.NewString())
-	initializers.DB.Exec(`INSERT INTO doctors (id, name, email) VALUES (?, ?, ?)`,
-		mockDoctorID, "Test Doctor", uniqueEmail)
-
 	// Mock service function to simulate a failure
 	patches := gomonkey.ApplyFunc(service.GetDailyStatistics, func(doctorID string, days int) ([]service.TimeBasedStats, error) {
 		return nil, errors.New("database error")
@@ -119,18 +104,41 @@ func TestGetDailyStatistics_ServiceFailure(t *testing.T) {
 
 	defer patches.Reset()
 
-	// Create request body with the email of the inserted mock doctor
-	requestBody := fmt.Sprintf(`{"email": "%s", "days": 7}`, uniqueEmail)
+	// Create request body; the doctor comes from the auth context
+	requestBody := `{"days": 7}`
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/get_daily_statistics", strings.NewReader(requestBody))
 	req.Header.Set("Content-Type", "application/json")
 
-	router := gin.Default()
-	router.POST("/get_daily_statistics", GetDailyStatistics)
+	router := newTestRouter()
+	router.POST("/get_daily_statistics", withDoctor(mockAuthDoctor()), GetDailyStatistics)
 	router.ServeHTTP(w, req)
 
 	// Assertions: Check for Internal Server Error and the appropriate error message
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
-	assert.Contains(t, w.Body.String(), "Failed to retrieve daily statistics")
+	assert.Contains(t, w.Body.String(), "An unexpected error occurred")
+}
+
+func TestGetDailyStatistics_DaysFromQuery(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupMockDBForDashboardTest()
+
+	var gotDays int
+	patches := gomonkey.ApplyFunc(service.GetDailyStatistics, func(doctorID string, days int) ([]service.TimeBasedStats, error) {
+		gotDays = days
+		return []service.TimeBasedStats{}, nil
+	})
+	defer patches.Reset()
+
+	// The v2 route is a GET with the period in the query
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/get_daily_statistics?days=7", nil)
+
+	router := newTestRouter()
+	router.GET("/get_daily_statistics", withDoctor(mockAuthDoctor()), GetDailyStatistics)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, 7, gotDays)
 }
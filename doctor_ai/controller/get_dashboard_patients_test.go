@@ -3,8 +3,6 @@ package controller
 import (
 	"encoding/json"
 	"errors"
-	"fmt"
-	"itish41/doctor_ai_assistant/initializers"
 	"itish41/doctor_ai_assistant/models"
 	"itish41/doctor_ai_assistant/service"
 	"net/http"
@@ -22,13 +20,8 @@ func TestGetDashboardPatients_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	setupMockDBForDashboardTest()
 
-	// Insert mock doctor
-	mockDoctorID := uuid.NewString()
-	uniqueEmail := fmt.Sprintf("testdoctor_%s@example.com", uuid.NewString())
-	initializers.DB.Exec(`INSERT INTO doctors (id, name, email) VALUES (?, ?, ?)`,
-		mockDoctorID, "Test Doctor", uniqueEmail)
-
-	mockDoctorUUID := uuid.Must(uuid.Parse(mockDoctorID)) // Convert string to uuid.UUID
+	doctor := mockAuthDoctor()
+	mockDoctorUUID := doctor.ID
 	// Insert mock patients (simulating service layer)
 	mockPatients := []models.Patient{
 		{ID: uuid.New(), Name: "Patient 1", DoctorID: mockDoctorUUID},
@@ -41,15 +34,15 @@ func TestGetDashboardPatients_Success(t *testing.T) {
 
 	defer patches.Reset()
 
-	// Create request body with the email of the inserted mock doctor
-	requestBody := fmt.Sprintf(`{"email": "%s", "days": 7}`, uniqueEmail)
+	// Create request body; the doctor comes from the auth context
+	requestBody := `{"days": 7}`
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/get_dashboard_patients", strings.NewReader(requestBody))
 	req.Header.Set("Content-Type", "application/json")
 
-	router := gin.Default()
-	router.POST("/get_dashboard_patients", GetDashboardPatients)
+	router := newTestRouter()
+	router.POST("/get_dashboard_patients", withDoctor(doctor), GetDashboardPatients)
 	router.ServeHTTP(w, req)
 
 	// Assertions: Check for OK status and the returned patients
@@ -58,15 +51,15 @@ func TestGetDashboardPatients_Success(t *testing.T) {
 	assert.Contains(t, w.Body.String(), "Patient 2")
 }
 
-func TestGetDashboardPatients_InvalidEmail(t *testing.T) {
+func TestGetDashboardPatients_InvalidBody(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	setupMockDBForDashboardTest()
 
-	router := gin.Default()
-	router.POST("/get_dashboard_patients", GetDashboardPatients)
+	router := newTestRouter()
+	router.POST("/get_dashboard_patients", withDoctor(mockAuthDoctor()), GetDashboardPatients)
 
-	// Missing email in the request body
-	requestBody := `{"email": "", "days": 7}`
+	// Malformed JSON in the request body
+	requestBody := `{"days": `
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/get_dashboard_patients", strings.NewReader(requestBody))
@@ -75,17 +68,17 @@ func TestGetDashboardPatients_InvalidEmail(t *testing.T) {
 
 	// Assertions: Check for BadRequest status and error message
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Contains(t, w.Body.String(), "Doctor email is required")
+	assert.Contains(t, w.Body.String(), "Invalid input")
 }
 
-func TestGetDashboardPatients_DoctorNotFound(t *testing.T) {
+func TestGetDashboardPatients_Unauthenticated(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	setupMockDBForDashboardTest()
 
-	router := gin.Default()
+	router := newTestRouter()
 	router.POST("/get_dashboard_patients", GetDashboardPatients)
 
-	// Provide a non-existing doctor's email
+	// An email in the body must not authenticate the request
 	requestBody, _ := json.Marshal(map[string]interface{}{
 		"email": "unknown@example.com",
 		"days":  7,
@@ -105,12 +98,6 @@ func TestGetDashboardPatients_ServiceFailure(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	setupMockDBForDashboardTest()
 
-	// Insert mock doctor with a unique email
-	mockDoctorID := uuid.NewString()
-	uniqueEmail := fmt.Sprintf("testdoctor_%s@example.com", uuid.NewString())
-	initializers.DB.Exec(`INSERT INTO doctors (id, name, email) VALUES (?, ?, ?)`,
-		mockDoctorID, "Test Doctor", uniqueEmail)
-
 	// Mock service function to simulate a failure
 	patches := gomonkey.ApplyFunc(service.GetDashboardPatients, func(doctorID string, days int) ([]models.Patient, error) {
 		return nil, errors.New("database error")
@@ -118,18 +105,18 @@ func TestGetDashboardPatients_ServiceFailure(t *testing.T) {
 
 	defer patches.Reset()
 
-	// Create request body with the email of the inserted mock doctor
-	requestBody := fmt.Sprintf(`{"email": "%s", "days": 7}`, uniqueEmail)
+	// Create request body; the doctor comes from the auth context
+	requestBody := `{"days": 7}`
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/get_dashboard_patients", strings.NewReader(requestBody))
 	req.Header.Set("Content-Type", "application/json")
 
-	router := gin.Default()
-	router.POST("/get_dashboard_patients", GetDashboardPatients)
+	router := newTestRouter()
+	router.POST("/get_dashboard_patients", withDoctor(mockAuthDoctor()), GetDashboardPatients)
 	router.ServeHTTP(w, req)
 
 	// Assertions: Check for Internal Server Error and the appropriate error message
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
-	assert.Contains(t, w.Body.String(), "Failed to retrieve dashboard patients")
+	assert.Contains(t, w.Body.String(), "An unexpected error occurred")
 }
@@ -59,55 +59,49 @@ func TestGetDashboardTranscripts_Success(t *testing.T) {
 	})
 	defer patches.Reset()
 
-	// Insert mock doctor with a unique email for the test
-	mockDoctorID := uuid.NewString()
-	uniqueEmail := fmt.Sprintf("testdoctor_%s@example.com", uuid.NewString())
-	initializers.DB.Exec(`INSERT INTO doctors (id, name, email) VALUES (?, ?, ?)`,
-		mockDoctorID, "Test Doctor", uniqueEmail)
-
-	// Use unique email in the request body
-	requestBody := fmt.Sprintf(`{"email": "%s", "days": 30}`, uniqueEmail)
+	// Create request body; the doctor comes from the auth context
+	requestBody := `{"days": 30}`
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/get_dashboard_transcripts", strings.NewReader(requestBody))
 	req.Header.Set("Content-Type", "application/json")
 
-	router := gin.Default()
-	router.POST("/get_dashboard_transcripts", GetDashboardTranscripts)
+	router := newTestRouter()
+	router.POST("/get_dashboard_transcripts", withDoctor(mockAuthDoctor()), GetDashboardTranscripts)
 	router.ServeHTTP(w, req)
 
 	// Assertions
 	assert.Equal(t, http.StatusOK, w.Code)
 }
 
-func TestGetDashboardTranscripts_InvalidEmail(t *testing.T) {
+func TestGetDashboardTranscripts_InvalidBody(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	setupMockDBForDashboardTest()
 
-	router := gin.Default()
-	router.POST("/get_dashboard_transcripts", GetDashboardTranscripts)
+	router := newTestRouter()
+	router.POST("/get_dashboard_transcripts", withDoctor(mockAuthDoctor()), GetDashboardTranscripts)
 
-	requestBody, _ := json.Marshal(map[string]interface{}{
-		"email": "",
-		"days":  7,
-	})
+	// Malformed JSON in the request body
+	requestBody := `{"days": `
 
 	w := httptest.NewRecorder()
-	req, _ := http.NewRequest("POST", "/get_dashboard_transcripts", strings.NewReader(string(requestBody)))
+	req, _ := http.NewRequest("POST", "/get_dashboard_transcripts", strings.NewReader(requestBody))
 	req.Header.Set("Content-Type", "application/json")
 	router.ServeHTTP(w, req)
 
+	// Assertions: Check for BadRequest status and error message
 	assert.Equal(t, http.StatusBadRequest, w.Code)
-	assert.Contains(t, w.Body.String(), "Doctor email is required")
+	assert.Contains(t, w.Body.String(), "Invalid input")
 }
 
-func TestGetDashboardTranscripts_DoctorNotFound(t *testing.T) {
+func TestGetDashboardTranscripts_Unauthenticated(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	setupMockDBForDashboardTest()
 
-	router := gin.Default()
+	router := newTestRouter()
 	router.POST("/get_dashboard_transcripts", GetDashboardTranscripts)
 
+	// An email in the body must not authenticate the request
 	requestBody, _ := json.Marshal(map[string]interface{}{
 		"email": "unknown@example.com",
 		"days":  7,
@@ -126,12 +120,6 @@ func TestGetDashboardTranscripts_ServiceFailure(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	setupMockDBForDashboardTest()
 
-	// Insert mock doctor with a unique email
-	mockDoctorID := uuid.NewString()
-	uniqueEmail := fmt.Sprintf("testdoctor_%s@example.com", uuid.NewString())
-	initializers.DB.Exec(`INSERT INTO doctors (id, name, email) VALUES (?, ?, ?)`,
-		mockDoctorID, "Test Doctor", uniqueEmail)
-
 	// Mock service function to simulate a database error
 	patches := gomonkey.ApplyFunc(service.GetDashboardTranscripts, func(doctorID string, days int) ([]models.Transcription, error) {
 		return nil, errors.New("database error")
@@ -139,18 +127,18 @@ func TestGetDashboardTranscripts_ServiceFailure(t *testing.T) {
 
 	defer patches.Reset()
 
-	// Create request body with the email of the inserted mock doctor
-	requestBody := fmt.Sprintf(`{"email": "%s", "days": 7}`, uniqueEmail)
+	// Create request body; the doctor comes from the auth context
+	requestBody := `{"days": 7}`
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/get_dashboard_transcripts", strings.NewReader(requestBody))
 	req.Header.Set("Content-Type", "application/json")
 
-	router := gin.Default()
-	router.POST("/get_dashboard_transcripts", GetDashboardTranscripts)
+	router := newTestRouter()
+	router.POST("/get_dashboard_transcripts", withDoctor(mockAuthDoctor()), GetDashboardTranscripts)
 	router.ServeHTTP(w, req)
 
 	// Assertions: Check for Internal Server Error and appropriate error message
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
-	assert.Contains(t, w.Body.String(), "Failed to retrieve dashboard transcripts")
+	assert.Contains(t, w.Body.String(), "An unexpected error occurred")
 }
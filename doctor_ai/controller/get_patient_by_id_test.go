@@ -80,13 +80,13 @@ func TestGetPatientByID_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	SetupMockDBForPatientTest()
 
-	router := gin.Default()
-	router.POST("/get_patient", GetPatientByID)
-
 	var patient models.Patient
 	mockDB.First(&patient)
 
-	requestBody := `{"email": "testdoctor@example.com", "patient_id": "` + patient.ID.String() + `"}`
+	router := newTestRouter()
+	router.POST("/get_patient", withDoctor(models.Doctor{ID: patient.DoctorID, Email: "testdoctor@example.com"}), GetPatientByID)
+
+	requestBody := `{"patient_id": "` + patient.ID.String() + `"}`
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/get_patient", strings.NewReader(requestBody))
@@ -102,7 +102,7 @@ func TestGetPatientByID_Success(t *testing.T) {
 // 	gin.SetMode(gin.TestMode)
 // 	SetupMockDBForPatientTest()
 
-// 	router := gin.Default()
+// 	router := newTestRouter()
 // 	router.POST("/get_patient", GetPatientByID)
 
 // 	invalidPatientID := uuid.NewString()
@@ -117,17 +117,18 @@ func TestGetPatientByID_Success(t *testing.T) {
 // 	assert.Contains(t, w.Body.String(), `"message":"Patient not found"`)
 // }
 
-func TestGetPatientByID_InvalidDoctorEmail(t *testing.T) {
+func TestGetPatientByID_Unauthenticated(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	SetupMockDBForPatientTest()
 
-	router := gin.Default()
+	router := newTestRouter()
 	router.POST("/get_patient", GetPatientByID)
 
 	var patient models.Patient
 	mockDB.First(&patient)
 
-	requestBody := `{"email": "wrongdoctor@example.com", "patient_id": "` + patient.ID.String() + `"}`
+	// An email in the body must not authenticate the request
+	requestBody := `{"email": "testdoctor@example.com", "patient_id": "` + patient.ID.String() + `"}`
 
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/get_patient", strings.NewReader(requestBody))
@@ -135,5 +136,5 @@ func TestGetPatientByID_InvalidDoctorEmail(t *testing.T) {
 	router.ServeHTTP(w, req)
 
 	assert.Equal(t, http.StatusUnauthorized, w.Code)
-	assert.Contains(t, w.Body.String(), `"message":"Doctor not found"`)
+	assert.Contains(t, w.Body.String(), `"title":"Unauthorized"`)
 }
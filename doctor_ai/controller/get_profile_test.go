@@ -8,6 +8,7 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"itish41/doctor_ai_assistant/middleware"
 	"itish41/doctor_ai_assistant/models"
 	"itish41/doctor_ai_assistant/service"
 
@@ -16,35 +17,45 @@ import (
 	"github.com/stretchr/testify/assert"
 )
 
-// TestGetProfile_InvalidJSON verifies that an invalid JSON payload returns 400.
-func TestGetProfile_InvalidJSON(t *testing.T) {
+// TestGetProfile_Unauthenticated verifies that a request without an authenticated doctor returns 401.
+func TestGetProfile_Unauthenticated(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	req := httptest.NewRequest(http.MethodPost, "/profile", bytes.NewBufferString("invalid json"))
+	req := httptest.NewRequest(http.MethodPost, "/profile", bytes.NewBufferString(`{"email": "test@example.com"}`))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
 
-	GetProfile(c)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	serve(c, GetProfile)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	var resp map[string]string
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	assert.Equal(t, "Unauthorized", resp["title"])
 }
 
-// TestGetProfile_EmailMissing verifies that an empty email returns 400.
-func TestGetProfile_EmailMissing(t *testing.T) {
+// TestGetProfile_IgnoresBodyEmail verifies that the profile is looked up for the authenticated doctor.
+func TestGetProfile_IgnoresBodyEmail(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	payload := map[string]string{"email": ""}
+	payload := map[string]string{"email": "other@example.com"}
 	jsonData, _ := json.Marshal(payload)
 	req := httptest.NewRequest(http.MethodPost, "/profile", bytes.NewBuffer(jsonData))
 	req.Header.Set("Content-Type", "application/json")
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
+	c.Set(middleware.DoctorContextKey, models.Doctor{Email: "test@example.com"})
 
-	GetProfile(c)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	var resp map[string]string
-	_ = json.Unmarshal(w.Body.Bytes(), &resp)
-	assert.Equal(t, "Email is required", resp["message"])
+	// Patch service.GetDoctorProfile to record the email it is called with.
+	var requestedEmail string
+	patches := gomonkey.ApplyFunc(service.GetDoctorProfile, func(email string) (*models.Doctor, error) {
+		requestedEmail = email
+		return &models.Doctor{Email: email}, nil
+	})
+	defer patches.Reset()
+
+	serve(c, GetProfile)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "test@example.com", requestedEmail)
 }
 
 // TestGetProfile_ServiceFailure patches service.GetDoctorProfile to simulate an error.
@@ -57,6 +68,7 @@ func TestGetProfile_ServiceFailure(t *testing.T) {
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
+	c.Set(middleware.DoctorContextKey, models.Doctor{Email: "test@example.com"})
 
 	// Patch service.GetDoctorProfile with a function that returns a nil pointer and an error.
 	patches := gomonkey.ApplyFunc(service.GetDoctorProfile, func(email string) (*models.Doctor, error) {
@@ -64,11 +76,11 @@ func TestGetProfile_ServiceFailure(t *testing.T) {
 	})
 	defer patches.Reset()
 
-	GetProfile(c)
+	serve(c, GetProfile)
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 	var resp map[string]string
 	_ = json.Unmarshal(w.Body.Bytes(), &resp)
-	assert.Equal(t, "Failed to retrieve profile", resp["message"])
+	assert.Equal(t, "An unexpected error occurred", resp["message"])
 }
 
 // TestGetProfile_Success patches service.GetDoctorProfile to return a valid profile.
@@ -81,6 +93,7 @@ func TestGetProfile_Success(t *testing.T) {
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
+	c.Set(middleware.DoctorContextKey, models.Doctor{Email: "test@example.com"})
 
 	// Create a dummy profile.
 	dummyProfile := &models.Doctor{
@@ -94,7 +107,7 @@ func TestGetProfile_Success(t *testing.T) {
 	})
 	defer patches.Reset()
 
-	GetProfile(c)
+	serve(c, GetProfile)
 	assert.Equal(t, http.StatusOK, w.Code)
 	var resp models.Doctor
 	err := json.Unmarshal(w.Body.Bytes(), &resp)
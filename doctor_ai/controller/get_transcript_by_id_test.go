@@ -22,7 +22,7 @@ func TestGetTranscriptionByID_Success(t *testing.T) {
 	transcriptionID := uuid.New()
 
 	// Mock service function
-	patches := gomonkey.ApplyFunc(service.GetTranscriptionByID, func(id uuid.UUID) (*models.Transcription, error) {
+	patches := gomonkey.ApplyFunc(service.GetTranscriptionByID, func(doctorID, id uuid.UUID) (*models.Transcription, error) {
 		return &models.Transcription{
 			Text:   "Sample transcription text",
 			Report: "Sample report",
@@ -30,8 +30,8 @@ func TestGetTranscriptionByID_Success(t *testing.T) {
 	})
 	defer patches.Reset()
 
-	router := gin.Default()
-	router.POST("/get_transcription", GetTranscriptionByID)
+	router := newTestRouter()
+	router.POST("/get_transcription", withDoctor(mockAuthDoctor()), GetTranscriptionByID)
 	requestBody := `{"transcription_id": "` + transcriptionID.String() + `"}`
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/get_transcription", strings.NewReader(requestBody))
@@ -50,8 +50,8 @@ func TestGetTranscriptionByID_Success(t *testing.T) {
 func TestGetTranscriptionByID_InvalidID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	router := gin.Default()
-	router.POST("/get_transcription", GetTranscriptionByID)
+	router := newTestRouter()
+	router.POST("/get_transcription", withDoctor(mockAuthDoctor()), GetTranscriptionByID)
 	requestBody := `{"transcription_id": "invalid-uuid"}`
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/get_transcription", strings.NewReader(requestBody))
@@ -61,19 +61,19 @@ func TestGetTranscriptionByID_InvalidID(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
-func TestGetTranscriptionByID_NotFound(t *testing.T) {
+func TestGetTranscriptionByID_ServiceError(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	transcriptionID := uuid.New()
 
 	// Mock service function to return an error
-	patches := gomonkey.ApplyFunc(service.GetTranscriptionByID, func(id uuid.UUID) (*models.Transcription, error) {
+	patches := gomonkey.ApplyFunc(service.GetTranscriptionByID, func(doctorID, id uuid.UUID) (*models.Transcription, error) {
 		return nil, assert.AnError
 	})
 	defer patches.Reset()
 
-	router := gin.Default()
-	router.POST("/get_transcription", GetTranscriptionByID)
+	router := newTestRouter()
+	router.POST("/get_transcription", withDoctor(mockAuthDoctor()), GetTranscriptionByID)
 	requestBody := `{"transcription_id": "` + transcriptionID.String() + `"}`
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/get_transcription", strings.NewReader(requestBody))
@@ -82,3 +82,65 @@ func TestGetTranscriptionByID_NotFound(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
+
+func TestGetTranscriptionByID_StructuredReport(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	report := &models.MedicalReport{
+		Symptoms:      []string{"Fever"},
+		Diagnosis:     "Influenza",
+		TreatmentPlan: "Rest and fluids",
+	}
+	patches := gomonkey.ApplyFunc(service.GetTranscriptionByID, func(doctorID, id uuid.UUID) (*models.Transcription, error) {
+		return &models.Transcription{Text: "text", Report: report.Text(), StructuredReport: report}, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.POST("/get_transcription", withDoctor(mockAuthDoctor()), GetTranscriptionByID)
+	requestBody := `{"transcription_id": "` + uuid.NewString() + `"}`
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/get_transcription", strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		StructuredReport models.MedicalReport `json:"structured_report"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Influenza", response.StructuredReport.Diagnosis)
+	assert.Equal(t, []string{"Fever"}, response.StructuredReport.Symptoms)
+	assert.Equal(t, "Rest and fluids", response.StructuredReport.TreatmentPlan)
+}
+
+func TestGetTranscriptionByID_NotOwned(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+	}{
+		{name: "Missing", err: service.ErrTranscriptionNotFound, wantStatus: http.StatusNotFound},
+		{name: "Other doctor's", err: service.ErrForbidden, wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patches := gomonkey.ApplyFuncReturn(service.GetTranscriptionByID, nil, tt.err)
+			defer patches.Reset()
+
+			router := newTestRouter()
+			router.POST("/get_transcription", withDoctor(mockAuthDoctor()), GetTranscriptionByID)
+			requestBody := `{"transcription_id": "` + uuid.NewString() + `"}`
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/get_transcription", strings.NewReader(requestBody))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+		})
+	}
+}
@@ -0,0 +1,121 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTranscriptionJob_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doctor := mockAuthDoctor()
+	jobID := uuid.New()
+
+	var queriedDoctorID uuid.UUID
+	patches := gomonkey.ApplyFunc(service.GetTranscriptionJob, func(doctorID uuid.UUID, id uuid.UUID) (*models.TranscriptionJob, error) {
+		queriedDoctorID = doctorID
+		return &models.TranscriptionJob{
+			ID:         id,
+			DoctorID:   doctorID,
+			Status:     models.JobStatusEnhancing,
+			RetryCount: 2,
+			LastError:  "failed to enhance transcription: timeout",
+			CreatedAt:  time.Now(),
+			UpdatedAt:  time.Now(),
+		}, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/patients/jobs/:id", withDoctor(doctor), GetTranscriptionJob)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/patients/jobs/"+jobID.String(), nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, doctor.ID, queriedDoctorID)
+
+	var resp map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, jobID.String(), resp["job_id"])
+	assert.Equal(t, models.JobStatusEnhancing, resp["status"])
+	assert.Equal(t, float64(2), resp["retry_count"])
+	assert.Equal(t, "failed to enhance transcription: timeout", resp["error"])
+	assert.Nil(t, resp["patient_id"])
+}
+
+func TestGetTranscriptionJob_InvalidID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := newTestRouter()
+	router.GET("/patients/jobs/:id", withDoctor(mockAuthDoctor()), GetTranscriptionJob)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/patients/jobs/not-a-uuid", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Invalid job ID")
+}
+
+func TestGetTranscriptionJob_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFunc(service.GetTranscriptionJob, func(doctorID uuid.UUID, id uuid.UUID) (*models.TranscriptionJob, error) {
+		return nil, service.ErrTranscriptionJobNotFound
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/patients/jobs/:id", withDoctor(mockAuthDoctor()), GetTranscriptionJob)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/patients/jobs/"+uuid.NewString(), nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "transcription job not found")
+}
+
+func TestGetTranscriptionJob_ServiceFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFunc(service.GetTranscriptionJob, func(doctorID uuid.UUID, id uuid.UUID) (*models.TranscriptionJob, error) {
+		return nil, errors.New("database error")
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/patients/jobs/:id", withDoctor(mockAuthDoctor()), GetTranscriptionJob)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/patients/jobs/"+uuid.NewString(), nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
+
+func TestGetTranscriptionJob_Unauthenticated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := newTestRouter()
+	router.GET("/patients/jobs/:id", GetTranscriptionJob)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/patients/jobs/"+uuid.NewString(), nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -4,12 +4,10 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
-	"reflect"
 	"strings"
 	"testing"
 
 	"itish41/doctor_ai_assistant/initializers"
-	"itish41/doctor_ai_assistant/models"
 	"itish41/doctor_ai_assistant/service"
 
 	"github.com/agiledragon/gomonkey/v2"
@@ -23,33 +21,18 @@ import (
 func TestGetTranscriptions_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	// Mocking DB queries properly
-	patches := gomonkey.ApplyMethod(reflect.TypeOf(&gorm.DB{}), "Where", func(_ *gorm.DB, query interface{}, args ...interface{}) *gorm.DB {
-		return &gorm.DB{}
-	})
-	defer patches.Reset()
-
-	patches2 := gomonkey.ApplyMethod(reflect.TypeOf(&gorm.DB{}), "First", func(_ *gorm.DB, dest interface{}) *gorm.DB {
-		doctor, ok := dest.(*models.Doctor)
-		if ok {
-			doctor.ID = uuid.New() // Ensure it's UUID
-			doctor.Email = "test@example.com"
-		}
-		return &gorm.DB{}
-	})
-	defer patches2.Reset()
-
 	// Mock GetTranscriptions response
-	patches3 := gomonkey.ApplyFunc(service.GetTranscriptions, func(doctorID uuid.UUID, page, limit int) ([]map[string]interface{}, []string, int64, error) {
+	total := int64(1)
+	patches3 := gomonkey.ApplyFunc(service.GetTranscriptions, func(doctorID uuid.UUID, filter service.TranscriptionFilter, page service.PageRequest) ([]map[string]interface{}, service.PageInfo, error) {
 		return []map[string]interface{}{
-			{"text": "Test Transcript", "report": "Sample Report"},
-		}, []string{"John Doe"}, 1, nil
+			{"text": "Test Transcript", "report": "Sample Report", "patient": map[string]interface{}{"name": "John Doe"}},
+		}, service.PageInfo{Total: &total}, nil
 	})
 	defer patches3.Reset()
 
-	router := gin.Default()
-	router.POST("/get_transcriptions", GetTranscriptions)
-	requestBody := `{"email": "test@example.com"}`
+	router := newTestRouter()
+	router.POST("/get_transcriptions", withDoctor(mockAuthDoctor()), GetTranscriptions)
+	requestBody := `{}`
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/get_transcriptions", strings.NewReader(requestBody))
 	req.Header.Set("Content-Type", "application/json")
@@ -65,20 +48,84 @@ func TestGetTranscriptions_Success(t *testing.T) {
 	totalFloat, ok := response["total"].(float64)
 	assert.True(t, ok, "total should be a number")
 	assert.Equal(t, int64(1), int64(totalFloat))
+
+	// Names are still listed in parallel for older clients
+	assert.Equal(t, []interface{}{"John Doe"}, response["patients"])
+}
+
+func TestGetTranscriptions_NoBody(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// Mock GetTranscriptions response
+	patches := gomonkey.ApplyFunc(service.GetTranscriptions, func(doctorID uuid.UUID, filter service.TranscriptionFilter, page service.PageRequest) ([]map[string]interface{}, service.PageInfo, error) {
+		return []map[string]interface{}{}, service.PageInfo{}, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.POST("/get_transcriptions", withDoctor(mockAuthDoctor()), GetTranscriptions)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/get_transcriptions", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+}
+
+func TestGetTranscriptions_Cursor(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var got service.PageRequest
+	patches := gomonkey.ApplyFunc(service.GetTranscriptions, func(doctorID uuid.UUID, filter service.TranscriptionFilter, page service.PageRequest) ([]map[string]interface{}, service.PageInfo, error) {
+		got = page
+		return []map[string]interface{}{}, service.PageInfo{PrevCursor: "prev"}, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/transcriptions", withDoctor(mockAuthDoctor()), GetTranscriptions)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/transcriptions?cursor=abc&limit=5", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "abc", got.Cursor)
+	assert.Equal(t, 5, got.Limit)
+	// Cursor pages skip the count unless asked for it
+	assert.False(t, got.WithTotal)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "prev", response["prev_cursor"])
+	assert.Contains(t, response, "next_cursor")
+	assert.Nil(t, response["next_cursor"])
+	assert.NotContains(t, response, "total")
+
+	req, _ = http.NewRequest("GET", "/transcriptions?cursor=abc&include_total=true", nil)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+	assert.True(t, got.WithTotal)
+
+	t.Run("Limit is capped", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/transcriptions?limit=1000000", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.Equal(t, service.MaxPageLimit, got.Limit)
+	})
 }
 
-func TestGetTranscriptions_MissingEmail(t *testing.T) {
+func TestGetTranscriptions_Unauthenticated(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	router := gin.Default()
+	router := newTestRouter()
 	router.POST("/get_transcriptions", GetTranscriptions)
-	requestBody := `{"email": ""}`
+	requestBody := `{"email": "test@example.com"}`
 	w := httptest.NewRecorder()
 	req, _ := http.NewRequest("POST", "/get_transcriptions", strings.NewReader(requestBody))
 	req.Header.Set("Content-Type", "application/json")
 	router.ServeHTTP(w, req)
 
-	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
 func setupMockDB() {
 	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
@@ -93,7 +140,7 @@ func TestGetTranscriptions_DoctorNotFound(t *testing.T) {
 
 	setupMockDB() // Set up mock DB connection
 
-	router := gin.Default()
+	router := newTestRouter()
 	router.POST("/get_transcriptions", GetTranscriptions)
 	requestBody := `{"email": "nonexistent@example.com"}`
 	w := httptest.NewRecorder()
@@ -0,0 +1,108 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListAuditEvents_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doctor := mockAuthDoctor()
+	patientID := uuid.New()
+
+	var received service.AuditEventFilter
+	patches := gomonkey.ApplyFunc(service.ListAuditEvents, func(doctorID uuid.UUID, filter service.AuditEventFilter) ([]models.AuditEvent, int64, error) {
+		assert.Equal(t, doctor.ID, doctorID)
+		received = filter
+		return []models.AuditEvent{{ID: uuid.New(), ActorID: &doctor.ID, Action: "patient.read", PatientID: &patientID, Outcome: models.AuditOutcomeSuccess}}, 1, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/audit/events", withDoctor(doctor), ListAuditEvents)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/audit/events?patient_id="+patientID.String()+"&doctor_id="+doctor.ID.String()+"&from=2026-01-01&to=2026-01-31T12:00:00Z&limit=5", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, patientID, *received.PatientID)
+	assert.Equal(t, doctor.ID, *received.ActorID)
+	assert.Equal(t, time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC), *received.From)
+	assert.Equal(t, time.Date(2026, 1, 31, 12, 0, 0, 0, time.UTC), *received.To)
+	assert.Equal(t, 1, received.Page)
+	assert.Equal(t, 5, received.Limit)
+
+	var response struct {
+		Events []map[string]interface{} `json:"events"`
+		Total  int64                    `json:"total"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, int64(1), response.Total)
+	assert.Equal(t, "patient.read", response.Events[0]["action"])
+	assert.Equal(t, patientID.String(), response.Events[0]["patient_id"])
+}
+
+func TestListAuditEvents_DateCoversWholeDay(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var received service.AuditEventFilter
+	patches := gomonkey.ApplyFunc(service.ListAuditEvents, func(doctorID uuid.UUID, filter service.AuditEventFilter) ([]models.AuditEvent, int64, error) {
+		received = filter
+		return nil, 0, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/audit/events", withDoctor(mockAuthDoctor()), ListAuditEvents)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/audit/events?to=2026-03-10", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC), *received.To)
+	assert.Equal(t, 50, received.Limit)
+}
+
+func TestListAuditEvents_InvalidFilters(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := newTestRouter()
+	router.GET("/audit/events", withDoctor(mockAuthDoctor()), ListAuditEvents)
+
+	for _, query := range []string{"patient_id=abc", "doctor_id=abc", "from=yesterday", "to=31-01-2026"} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/audit/events?"+query, nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code, query)
+	}
+}
+
+func TestListAuditEvents_ServiceError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFuncReturn(service.ListAuditEvents, nil, int64(0), assert.AnError)
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/audit/events", withDoctor(mockAuthDoctor()), ListAuditEvents)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/audit/events", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
@@ -0,0 +1,94 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListTranscriptionRevisions_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doctor := mockAuthDoctor()
+	transcriptionID := uuid.New()
+
+	patches := gomonkey.ApplyFunc(service.ListTranscriptionRevisions, func(doctorID, id uuid.UUID) ([]models.TranscriptionRevision, error) {
+		assert.Equal(t, doctor.ID, doctorID)
+		assert.Equal(t, transcriptionID, id)
+		return []models.TranscriptionRevision{
+			{ID: uuid.New(), TranscriptionID: id, Version: 2, AuthorID: doctorID, Text: "edited"},
+			{ID: uuid.New(), TranscriptionID: id, Version: 1, AuthorID: doctorID, Text: "generated"},
+		}, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/transcription/:id/revisions", withDoctor(doctor), ListTranscriptionRevisions)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/transcription/"+transcriptionID.String()+"/revisions", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response struct {
+		Revisions []map[string]interface{} `json:"revisions"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(t, response.Revisions, 2)
+	assert.Equal(t, float64(2), response.Revisions[0]["version"])
+	assert.Equal(t, "generated", response.Revisions[1]["text"])
+}
+
+func TestListTranscriptionRevisions_InvalidID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := newTestRouter()
+	router.GET("/transcription/:id/revisions", withDoctor(mockAuthDoctor()), ListTranscriptionRevisions)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/transcription/invalid-uuid/revisions", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestListTranscriptionRevisions_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFuncReturn(service.ListTranscriptionRevisions, nil, service.ErrTranscriptionNotFound)
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/transcription/:id/revisions", withDoctor(mockAuthDoctor()), ListTranscriptionRevisions)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/transcription/"+uuid.NewString()+"/revisions", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestListTranscriptionRevisions_ServiceError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFuncReturn(service.ListTranscriptionRevisions, nil, assert.AnError)
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/transcription/:id/revisions", withDoctor(mockAuthDoctor()), ListTranscriptionRevisions)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/transcription/"+uuid.NewString()+"/revisions", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
@@ -14,6 +14,7 @@ import (
 
 	"github.com/agiledragon/gomonkey/v2"
 	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -27,7 +28,7 @@ func TestLogin_InvalidJSON(t *testing.T) {
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
 
-	Login(c)
+	serve(c, Login)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
@@ -51,7 +52,7 @@ func TestLogin_ServiceFailure(t *testing.T) {
 	})
 	defer patches.Reset()
 
-	Login(c)
+	serve(c, Login)
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
 
@@ -80,7 +81,7 @@ func TestLogin_TokenFailure(t *testing.T) {
 	})
 	defer patches2.Reset()
 
-	Login(c)
+	serve(c, Login)
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
 
@@ -108,7 +109,13 @@ func TestLogin_Success(t *testing.T) {
 	})
 	defer patches2.Reset()
 
-	Login(c)
+	// Patch service.IssueRefreshToken to return a dummy refresh token.
+	patches3 := gomonkey.ApplyFunc(service.IssueRefreshToken, func(doctorID uuid.UUID) (string, error) {
+		return "dummy-refresh-token", nil
+	})
+	defer patches3.Reset()
+
+	serve(c, Login)
 	assert.Equal(t, http.StatusOK, w.Code)
 
 	// Optionally, verify the response contains the expected token and email.
@@ -118,4 +125,36 @@ func TestLogin_Success(t *testing.T) {
 	assert.Equal(t, "User login successfully", resp["message"])
 	assert.Equal(t, "test@example.com", resp["email"])
 	assert.Equal(t, "dummy-token", resp["token"])
+	assert.Equal(t, "dummy-refresh-token", resp["refresh_token"])
+}
+
+// TestLogin_RefreshTokenFailure tests that a failure to store the refresh token returns 500.
+func TestLogin_RefreshTokenFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	user := models.Doctor{Email: "test@example.com"}
+	jsonData, _ := json.Marshal(user)
+	req := httptest.NewRequest(http.MethodPost, "/login", bytes.NewBuffer(jsonData))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = req
+
+	patches1 := gomonkey.ApplyFunc(service.DoctorLogin, func(u *models.Doctor) error {
+		return nil
+	})
+	defer patches1.Reset()
+
+	patches2 := gomonkey.ApplyFunc(middleware.GenerateToken, func(email string) (string, error) {
+		return "dummy-token", nil
+	})
+	defer patches2.Reset()
+
+	patches3 := gomonkey.ApplyFunc(service.IssueRefreshToken, func(doctorID uuid.UUID) (string, error) {
+		return "", errors.New("storage error")
+	})
+	defer patches3.Reset()
+
+	serve(c, Login)
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"itish41/doctor_ai_assistant/middleware"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLogout_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	doctor := mockAuthDoctor()
+	claims := &middleware.AccessClaims{
+		Email: doctor.Email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        "access-jti",
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Minute)),
+		},
+	}
+
+	var revokedRefresh, revokedJTI string
+	patches := gomonkey.ApplyFunc(service.RevokeRefreshToken, func(doctorID uuid.UUID, rawToken string) error {
+		revokedRefresh = rawToken
+		return nil
+	})
+	defer patches.Reset()
+	patches2 := gomonkey.ApplyFunc(service.RevokeAccessToken, func(jti string, expiresAt time.Time) error {
+		revokedJTI = jti
+		return nil
+	})
+	defer patches2.Reset()
+
+	router := newTestRouter()
+	router.POST("/logout", withDoctor(doctor), func(c *gin.Context) {
+		c.Set(middleware.ClaimsContextKey, claims)
+		c.Next()
+	}, Logout)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/logout", strings.NewReader(`{"refresh_token": "refresh-token"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "refresh-token", revokedRefresh)
+	assert.Equal(t, "access-jti", revokedJTI)
+}
+
+func TestLogout_Unauthenticated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := newTestRouter()
+	router.POST("/logout", Logout)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/logout", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestLogout_ServiceFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFuncReturn(service.RevokeRefreshToken, assert.AnError)
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.POST("/logout", withDoctor(mockAuthDoctor()), Logout)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/logout", strings.NewReader(`{"refresh_token": "refresh-token"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	assert.Contains(t, w.Body.String(), "An unexpected error occurred")
+}
@@ -0,0 +1,95 @@
+package controller
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"itish41/doctor_ai_assistant/middleware"
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRefreshToken_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFunc(service.RotateRefreshToken, func(rawToken string) (*models.Doctor, string, error) {
+		return &models.Doctor{Email: "test@example.com"}, "rotated-refresh-token", nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.POST("/refresh", RefreshToken)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/refresh", strings.NewReader(`{"refresh_token": "old-refresh-token"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var resp map[string]string
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, "rotated-refresh-token", resp["refresh_token"])
+
+	// The new access token is valid for the doctor
+	claims, err := middleware.ParseToken(resp["token"])
+	assert.NoError(t, err)
+	assert.Equal(t, "test@example.com", claims.Email)
+}
+
+func TestRefreshToken_MissingToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := newTestRouter()
+	router.POST("/refresh", RefreshToken)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/refresh", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Refresh token is required")
+}
+
+func TestRefreshToken_Reused(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFunc(service.RotateRefreshToken, func(rawToken string) (*models.Doctor, string, error) {
+		return nil, "", service.ErrRefreshTokenReused
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.POST("/refresh", RefreshToken)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/refresh", strings.NewReader(`{"refresh_token": "rotated-token"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestRefreshToken_ServiceFailure(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFunc(service.RotateRefreshToken, func(rawToken string) (*models.Doctor, string, error) {
+		return nil, "", errors.New("database error")
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.POST("/refresh", RefreshToken)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/refresh", strings.NewReader(`{"refresh_token": "token"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+}
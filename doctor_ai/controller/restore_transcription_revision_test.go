@@ -0,0 +1,67 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRestoreTranscriptionRevision_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFuncReturn(service.RestoreTranscriptionRevision, &models.Transcription{Text: "generated", Report: "report"}, nil)
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.POST("/transcription/:id/revisions/:revision_id/restore", withDoctor(mockAuthDoctor()), RestoreTranscriptionRevision)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/transcription/"+uuid.NewString()+"/revisions/"+uuid.NewString()+"/restore", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Revision restored successfully", response["message"])
+	assert.Equal(t, "generated", response["text"])
+}
+
+func TestRestoreTranscriptionRevision_InvalidRevisionID(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := newTestRouter()
+	router.POST("/transcription/:id/revisions/:revision_id/restore", withDoctor(mockAuthDoctor()), RestoreTranscriptionRevision)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/transcription/"+uuid.NewString()+"/revisions/invalid-uuid/restore", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestRestoreTranscriptionRevision_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFuncReturn(service.RestoreTranscriptionRevision, nil, service.ErrTranscriptionNotFound)
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.POST("/transcription/:id/revisions/:revision_id/restore", withDoctor(mockAuthDoctor()), RestoreTranscriptionRevision)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/transcription/"+uuid.NewString()+"/revisions/"+uuid.NewString()+"/restore", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	assert.Contains(t, w.Body.String(), "transcription not found")
+}
@@ -0,0 +1,75 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchPatients_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var got service.PatientSearch
+	patches := gomonkey.ApplyFunc(service.SearchPatients, func(doctorID uuid.UUID, search service.PatientSearch) ([]models.Patient, int64, error) {
+		got = search
+		return []models.Patient{{ID: uuid.New(), Name: "John Doe", Age: 45, Gender: "Male"}}, 7, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/patients/search", withDoctor(mockAuthDoctor()), SearchPatients)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/patients/search?q=jo&min_age=18&max_age=65&gender=male&created_from=2026-01-01&created_to=2026-01-31&sort=-age&page=2&limit=5", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "jo", got.Query)
+	assert.Equal(t, 18, *got.MinAge)
+	assert.Equal(t, 65, *got.MaxAge)
+	assert.Equal(t, "male", got.Gender)
+	assert.Equal(t, time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC), *got.CreatedFrom)
+	// A date as the end of the range covers that whole day
+	assert.Equal(t, time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC), *got.CreatedTo)
+	assert.Equal(t, "-age", got.Sort)
+	assert.Equal(t, 2, got.Page)
+	assert.Equal(t, 5, got.Limit)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, float64(7), response["total"])
+	assert.Equal(t, "John Doe", response["patients"].([]interface{})[0].(map[string]interface{})["name"])
+}
+
+func TestSearchPatients_InvalidParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := newTestRouter()
+	router.GET("/patients/search", withDoctor(mockAuthDoctor()), SearchPatients)
+
+	for query, field := range map[string]string{
+		"min_age=old":        "min_age",
+		"max_age=-1":         "max_age",
+		"created_from=today": "created_from",
+		"created_to=soon":    "created_to",
+	} {
+		t.Run(query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/patients/search?"+query, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), `"`+field+`"`)
+		})
+	}
+}
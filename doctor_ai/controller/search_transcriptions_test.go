@@ -0,0 +1,79 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchTranscriptions_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patientID := uuid.New()
+	var got service.TranscriptionSearch
+	patches := gomonkey.ApplyFunc(service.SearchTranscriptions, func(doctorID uuid.UUID, search service.TranscriptionSearch) ([]service.TranscriptionHit, int64, error) {
+		got = search
+		return []service.TranscriptionHit{{
+			ID:            uuid.New(),
+			PatientID:     patientID,
+			PatientName:   "John Doe",
+			Rank:          0.4,
+			ReportSnippet: "<mark>Chest</mark> <mark>pain</mark> resolved",
+		}}, 3, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/transcription/search", withDoctor(mockAuthDoctor()), SearchTranscriptions)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/transcription/search?q=chest+pain&patient_id="+patientID.String()+"&from=2026-01-01&to=2026-01-31&page=2&limit=5", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "chest pain", got.Query)
+	assert.Equal(t, patientID, *got.PatientID)
+	assert.Equal(t, time.Date(2026, time.January, 1, 0, 0, 0, 0, time.UTC), *got.From)
+	// A date as the end of the range covers that whole day
+	assert.Equal(t, time.Date(2026, time.February, 1, 0, 0, 0, 0, time.UTC), *got.To)
+	assert.Equal(t, 2, got.Page)
+	assert.Equal(t, 5, got.Limit)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, float64(3), response["total"])
+	hit := response["hits"].([]interface{})[0].(map[string]interface{})
+	assert.Equal(t, "John Doe", hit["patient_name"])
+	assert.Equal(t, "<mark>Chest</mark> <mark>pain</mark> resolved", hit["report_snippet"])
+}
+
+func TestSearchTranscriptions_InvalidParams(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := newTestRouter()
+	router.GET("/transcription/search", withDoctor(mockAuthDoctor()), SearchTranscriptions)
+
+	for query, field := range map[string]string{
+		"q=pain&patient_id=abc": "patient_id",
+		"q=pain&from=today":     "from",
+		"q=pain&to=soon":        "to",
+	} {
+		t.Run(query, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/transcription/search?"+query, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusBadRequest, w.Code)
+			assert.Contains(t, w.Body.String(), `"`+field+`"`)
+		})
+	}
+}
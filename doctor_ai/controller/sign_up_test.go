@@ -26,7 +26,7 @@ func TestSignUp_InvalidJSON(t *testing.T) {
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
 
-	SignUp(c)
+	serve(c, SignUp)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
@@ -51,7 +51,7 @@ func TestSignUp_ServiceFailure(t *testing.T) {
 	})
 	defer patches.Reset()
 
-	SignUp(c)
+	serve(c, SignUp)
 
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
@@ -76,7 +76,7 @@ func TestSignUp_Success(t *testing.T) {
 	})
 	defer patches.Reset()
 
-	SignUp(c)
+	serve(c, SignUp)
 
 	assert.Equal(t, http.StatusOK, w.Code)
 }
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
 	"itish41/doctor_ai_assistant/service"
 	"net/http/httptest"
 	"testing"
@@ -61,7 +62,6 @@ func TestUpdatePatient(t *testing.T) {
 
 	// Create a test patient update request
 	requestData := map[string]interface{}{
-		"email":      "johndoe@example.com",
 		"patient_id": patientID.String(),
 		"update_data": map[string]interface{}{
 			"name":   "John Doe",
@@ -77,8 +77,8 @@ func TestUpdatePatient(t *testing.T) {
 	w := httptest.NewRecorder()
 
 	// Set up router and register the endpoint
-	r := gin.Default()
-	r.POST("/update_patient", UpdatePatient)
+	r := newTestRouter()
+	r.POST("/update_patient", withDoctor(models.Doctor{ID: doctorID, Email: "johndoe@example.com"}), UpdatePatient)
 
 	// Mock the service layer UpdatePatientByID function
 	patches := gomonkey.ApplyFunc(service.UpdatePatientByID, func(doctorID uuid.UUID, patientID uuid.UUID, updateData map[string]interface{}) error {
@@ -93,3 +93,24 @@ func TestUpdatePatient(t *testing.T) {
 	assert.Equal(t, 200, w.Code)
 	assert.Contains(t, w.Body.String(), "Patient updated successfully")
 }
+
+func TestUpdatePatient_Forbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFuncReturn(service.UpdatePatientByID, service.ErrForbidden)
+	defer patches.Reset()
+
+	reqBody, _ := json.Marshal(map[string]interface{}{
+		"patient_id":  uuid.NewString(),
+		"update_data": map[string]interface{}{"name": "John Doe"},
+	})
+	req := httptest.NewRequest("POST", "/update_patient", bytes.NewReader(reqBody))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	r := newTestRouter()
+	r.POST("/update_patient", withDoctor(mockAuthDoctor()), UpdatePatient)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, 403, w.Code)
+}
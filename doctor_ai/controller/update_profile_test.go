@@ -8,6 +8,8 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"itish41/doctor_ai_assistant/middleware"
+	"itish41/doctor_ai_assistant/models"
 	"itish41/doctor_ai_assistant/service"
 
 	"github.com/agiledragon/gomonkey/v2"
@@ -23,18 +25,19 @@ func TestUpdateProfile_InvalidJSON(t *testing.T) {
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
+	c.Set(middleware.DoctorContextKey, models.Doctor{Email: "test@example.com"})
 
-	UpdateProfile(c)
+	serve(c, UpdateProfile)
 
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
-// TestUpdateProfile_EmailMissing verifies that if the email is missing, UpdateProfile returns a 400.
-func TestUpdateProfile_EmailMissing(t *testing.T) {
+// TestUpdateProfile_Unauthenticated verifies that without an authenticated doctor, UpdateProfile returns a 401.
+func TestUpdateProfile_Unauthenticated(t *testing.T) {
 	gin.SetMode(gin.TestMode)
-	// Prepare payload with missing email.
+	// Prepare payload naming a doctor by email; it must not authenticate the request.
 	payload := map[string]string{
-		"email":          "",
+		"email":          "test@example.com",
 		"name":           "Dr. Test",
 		"specialization": "Cardiology",
 		"phone":          "1234567890",
@@ -46,16 +49,14 @@ func TestUpdateProfile_EmailMissing(t *testing.T) {
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
 
-	UpdateProfile(c)
-	assert.Equal(t, http.StatusBadRequest, w.Code)
-	// Optionally, you can check the response message.
+	serve(c, UpdateProfile)
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
 }
 
 // TestUpdateProfile_ServiceFailure patches service.UpdateDoctorProfile to return an error.
 func TestUpdateProfile_ServiceFailure(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	payload := map[string]string{
-		"email":          "test@example.com",
 		"name":           "Dr. Test",
 		"specialization": "Cardiology",
 		"phone":          "1234567890",
@@ -66,6 +67,7 @@ func TestUpdateProfile_ServiceFailure(t *testing.T) {
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
+	c.Set(middleware.DoctorContextKey, models.Doctor{Email: "test@example.com"})
 
 	// Patch service.UpdateDoctorProfile to simulate an error.
 	patches := gomonkey.ApplyFunc(service.UpdateDoctorProfile, func(email, name, specialization, phone string) error {
@@ -73,18 +75,17 @@ func TestUpdateProfile_ServiceFailure(t *testing.T) {
 	})
 	defer patches.Reset()
 
-	UpdateProfile(c)
+	serve(c, UpdateProfile)
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 	var resp map[string]string
 	_ = json.Unmarshal(w.Body.Bytes(), &resp)
-	assert.Equal(t, "update error", resp["message"])
+	assert.Equal(t, "An unexpected error occurred", resp["message"])
 }
 
 // TestUpdateProfile_Success patches service.UpdateDoctorProfile to succeed.
 func TestUpdateProfile_Success(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 	payload := map[string]string{
-		"email":          "test@example.com",
 		"name":           "Dr. Test",
 		"specialization": "Cardiology",
 		"phone":          "1234567890",
@@ -95,6 +96,7 @@ func TestUpdateProfile_Success(t *testing.T) {
 	w := httptest.NewRecorder()
 	c, _ := gin.CreateTestContext(w)
 	c.Request = req
+	c.Set(middleware.DoctorContextKey, models.Doctor{Email: "test@example.com"})
 
 	// Patch service.UpdateDoctorProfile to simulate success.
 	patches := gomonkey.ApplyFunc(service.UpdateDoctorProfile, func(email, name, specialization, phone string) error {
@@ -102,7 +104,7 @@ func TestUpdateProfile_Success(t *testing.T) {
 	})
 	defer patches.Reset()
 
-	UpdateProfile(c)
+	serve(c, UpdateProfile)
 	assert.Equal(t, http.StatusOK, w.Code)
 	var resp map[string]string
 	_ = json.Unmarshal(w.Body.Bytes(), &resp)
@@ -0,0 +1,93 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUpdateTranscriptionStatus_Signed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doctor := mockAuthDoctor()
+	signedAt := time.Now()
+
+	patches := gomonkey.ApplyFunc(service.TransitionTranscriptionStatus, func(doctorID, transcriptionID uuid.UUID, status string) (*models.Transcription, error) {
+		assert.Equal(t, doctor.ID, doctorID)
+		assert.Equal(t, models.ReportStatusSigned, status)
+		return &models.Transcription{ID: transcriptionID, Status: status, SignedByID: &doctorID, SignedAt: &signedAt}, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.POST("/transcription/:id/status", withDoctor(doctor), UpdateTranscriptionStatus)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/transcription/"+uuid.NewString()+"/status", strings.NewReader(`{"status": "signed"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "signed", response["status"])
+	assert.Equal(t, doctor.ID.String(), response["signed_by"])
+	assert.NotNil(t, response["signed_at"])
+}
+
+func TestUpdateTranscriptionStatus_MissingStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := newTestRouter()
+	router.POST("/transcription/:id/status", withDoctor(mockAuthDoctor()), UpdateTranscriptionStatus)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/transcription/"+uuid.NewString()+"/status", strings.NewReader(`{}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUpdateTranscriptionStatus_Errors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "Unknown status", err: service.ErrUnknownReportStatus, expectedStatus: http.StatusBadRequest},
+		{name: "Not found", err: service.ErrTranscriptionNotFound, expectedStatus: http.StatusNotFound},
+		{name: "Invalid transition", err: service.ErrInvalidStatusTransition, expectedStatus: http.StatusConflict},
+		{name: "Service error", err: assert.AnError, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patches := gomonkey.ApplyFuncReturn(service.TransitionTranscriptionStatus, nil, tt.err)
+			defer patches.Reset()
+
+			router := newTestRouter()
+			router.POST("/transcription/:id/status", withDoctor(mockAuthDoctor()), UpdateTranscriptionStatus)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/transcription/"+uuid.NewString()+"/status", strings.NewReader(`{"status": "reviewed"}`))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
@@ -22,8 +22,8 @@ func TestUpdateTranscription_Success(t *testing.T) {
 	patches := gomonkey.ApplyFuncReturn(service.UpdateTranscription, nil)
 	defer patches.Reset()
 
-	router := gin.Default()
-	router.PUT("/update_transcription/:id", UpdateTranscription)
+	router := newTestRouter()
+	router.PUT("/update_transcription/:id", withDoctor(mockAuthDoctor()), UpdateTranscription)
 
 	transcriptionID := uuid.New().String()
 	requestBody := `{
@@ -50,8 +50,8 @@ func TestUpdateTranscription_Success(t *testing.T) {
 func TestUpdateTranscription_InvalidID(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
-	router := gin.Default()
-	router.PUT("/update_transcription/:id", UpdateTranscription)
+	router := newTestRouter()
+	router.PUT("/update_transcription/:id", withDoctor(mockAuthDoctor()), UpdateTranscription)
 
 	requestBody := `{
 		"transcription_id": "invalid-uuid",
@@ -75,8 +75,8 @@ func TestUpdateTranscription_ServiceError(t *testing.T) {
 	patches := gomonkey.ApplyFuncReturn(service.UpdateTranscription, assert.AnError)
 	defer patches.Reset()
 
-	router := gin.Default()
-	router.PUT("/update_transcription/:id", UpdateTranscription)
+	router := newTestRouter()
+	router.PUT("/update_transcription/:id", withDoctor(mockAuthDoctor()), UpdateTranscription)
 
 	transcriptionID := uuid.New().String()
 	requestBody := `{
@@ -93,3 +93,55 @@ func TestUpdateTranscription_ServiceError(t *testing.T) {
 
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 }
+
+func TestUpdateTranscription_NotFound(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFuncReturn(service.UpdateTranscription, service.ErrTranscriptionNotFound)
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.PUT("/update_transcription/:id", withDoctor(mockAuthDoctor()), UpdateTranscription)
+
+	transcriptionID := uuid.New().String()
+	requestBody := `{"update_data": {"text": "Updated text"}}`
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/update_transcription/"+transcriptionID, strings.NewReader(requestBody))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestUpdateTranscription_Unauthorized(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := newTestRouter()
+	router.PUT("/update_transcription/:id", UpdateTranscription)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/update_transcription/"+uuid.New().String(), strings.NewReader(`{"update_data": {}}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
+
+func TestUpdateTranscription_Signed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFuncReturn(service.UpdateTranscription, service.ErrReportSigned)
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.PUT("/update_transcription/:id", withDoctor(mockAuthDoctor()), UpdateTranscription)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PUT", "/update_transcription/"+uuid.NewString(), strings.NewReader(`{"update_data": {"text": "Late change"}}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Contains(t, w.Body.String(), "amendment")
+}
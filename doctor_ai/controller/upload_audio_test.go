@@ -0,0 +1,139 @@
+package controller
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"strings"
+	"testing"
+
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// newAudioUploadRequest builds a multipart request with one file part.
+func newAudioUploadRequest(field, filename, contentType string, content []byte) *http.Request {
+	body := &bytes.Buffer{}
+	writer := multipart.NewWriter(body)
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="`+field+`"; filename="`+filename+`"`)
+	header.Set("Content-Type", contentType)
+	part, _ := writer.CreatePart(header)
+	_, _ = part.Write(content)
+	_ = writer.Close()
+
+	req, _ := http.NewRequest("POST", "/upload_audio", body)
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req
+}
+
+func TestUploadAudio_Success(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	doctor := mockAuthDoctor()
+	audioFileID := uuid.New()
+
+	var savedFor uuid.UUID
+	var savedName, savedType, savedContent string
+	patches := gomonkey.ApplyFunc(service.SaveAudioUpload, func(doctorID uuid.UUID, filename, contentType string, file io.Reader) (*models.AudioFile, error) {
+		savedFor, savedName, savedType = doctorID, filename, contentType
+		content, _ := io.ReadAll(file)
+		savedContent = string(content)
+		return &models.AudioFile{ID: audioFileID, DoctorID: doctorID, OriginalFilename: filename, ContentType: contentType, Size: int64(len(content))}, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.POST("/upload_audio", withDoctor(doctor), UploadAudio)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newAudioUploadRequest("audio", "visit.mp3", "audio/mpeg", []byte("ID3 audio")))
+
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var resp map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &resp))
+	assert.Equal(t, audioFileID.String(), resp["audio_file_id"])
+	assert.Equal(t, float64(9), resp["size"])
+	assert.Equal(t, doctor.ID, savedFor)
+	assert.Equal(t, "visit.mp3", savedName)
+	assert.Equal(t, "audio/mpeg", savedType)
+	assert.Equal(t, "ID3 audio", savedContent)
+}
+
+func TestUploadAudio_MissingFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := newTestRouter()
+	router.POST("/upload_audio", withDoctor(mockAuthDoctor()), UploadAudio)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newAudioUploadRequest("other", "visit.mp3", "audio/mpeg", []byte("ID3")))
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "Missing audio file")
+}
+
+func TestUploadAudio_NotMultipart(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := newTestRouter()
+	router.POST("/upload_audio", withDoctor(mockAuthDoctor()), UploadAudio)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/upload_audio", strings.NewReader(`{"audio": "x"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestUploadAudio_Rejected(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name           string
+		err            error
+		expectedStatus int
+	}{
+		{name: "Unsupported type", err: service.ErrUnsupportedAudioType, expectedStatus: http.StatusUnsupportedMediaType},
+		{name: "Too large", err: service.ErrAudioTooLarge, expectedStatus: http.StatusRequestEntityTooLarge},
+		{name: "Empty", err: service.ErrEmptyAudioFile, expectedStatus: http.StatusBadRequest},
+		{name: "Storage failure", err: io.ErrUnexpectedEOF, expectedStatus: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patches := gomonkey.ApplyFunc(service.SaveAudioUpload, func(doctorID uuid.UUID, filename, contentType string, file io.Reader) (*models.AudioFile, error) {
+				return nil, tt.err
+			})
+			defer patches.Reset()
+
+			router := newTestRouter()
+			router.POST("/upload_audio", withDoctor(mockAuthDoctor()), UploadAudio)
+
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, newAudioUploadRequest("audio", "notes.txt", "text/plain", []byte("hello")))
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
+
+func TestUploadAudio_Unauthenticated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := newTestRouter()
+	router.POST("/upload_audio", UploadAudio)
+
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, newAudioUploadRequest("audio", "visit.mp3", "audio/mpeg", []byte("ID3")))
+
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+}
@@ -0,0 +1,412 @@
+package controller
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"itish41/doctor_ai_assistant/middleware"
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// Handlers for the /api/v2 routes. They address resources by path, take filters
+// from the query and otherwise call the same services as the v1 handlers.
+
+// transcriptionJSON is the v2 response shape of a transcription.
+func transcriptionJSON(transcription *models.Transcription) gin.H {
+	return gin.H{
+		"id":                transcription.ID,
+		"patient_id":        transcription.PatientID,
+		"created_at":        transcription.CreatedAt,
+		"text":              transcription.Text,
+		"report":            transcription.Report,
+		"structured_report": transcription.StructuredReport,
+		"status":            transcription.Status,
+		"signed_by":         transcription.SignedByID,
+		"signed_at":         transcription.SignedAt,
+		"encounter_id":      transcription.EncounterID,
+	}
+}
+
+// patientJSON is the v2 response shape of a patient.
+func patientJSON(patient *models.Patient) gin.H {
+	return gin.H{
+		"id":         patient.ID,
+		"name":       patient.Name,
+		"age":        patient.Age,
+		"gender":     patient.Gender,
+		"created_at": patient.CreatedAt,
+	}
+}
+
+// pathID parses the :id route parameter, failing the request when it isn't a UUID.
+func pathID(c *gin.Context, message string) (uuid.UUID, bool) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		middleware.Abort(c, service.InvalidField("id", message))
+		return uuid.Nil, false
+	}
+	return id, true
+}
+
+// GetTranscription handles GET /api/v2/transcriptions/:id.
+func GetTranscription(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+	transcriptionID, ok := pathID(c, "Invalid transcription ID")
+	if !ok {
+		return
+	}
+
+	transcription, err := service.GetTranscriptionByID(doctor.ID, transcriptionID)
+	if err != nil {
+		log.Println("Error retrieving transcription:", err)
+		middleware.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transcriptionJSON(transcription))
+}
+
+// GetLatestTranscript handles GET /api/v2/transcriptions/latest?patient_name=, returning
+// the newest transcription of the named patient.
+func GetLatestTranscript(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+
+	name := c.Query("patient_name")
+	if name == "" {
+		middleware.Abort(c, service.InvalidField("patient_name", "Patient name is required"))
+		return
+	}
+
+	transcript, err := service.GetPatientTranscript(doctor.ID, name)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+	middleware.SetAuditResource(c, transcript.ID)
+
+	c.JSON(http.StatusOK, transcriptionJSON(transcript))
+}
+
+// PatchTranscription handles PATCH /api/v2/transcriptions/:id. The body holds the
+// fields to change, which the service limits to the text and reports, and the updated
+// transcription is returned.
+func PatchTranscription(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+	transcriptionID, ok := pathID(c, "Invalid transcription ID")
+	if !ok {
+		return
+	}
+
+	var updateData map[string]interface{}
+	if err := c.ShouldBindJSON(&updateData); err != nil {
+		log.Println("Invalid input:", err)
+		middleware.Abort(c, invalidRequest(err))
+		return
+	}
+
+	if err := service.UpdateTranscription(doctor.ID, transcriptionID, updateData); err != nil {
+		log.Println("Error updating transcription:", err)
+		middleware.Abort(c, err)
+		return
+	}
+
+	transcription, err := service.GetTranscriptionByID(doctor.ID, transcriptionID)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, transcriptionJSON(transcription))
+}
+
+// RemoveTranscription handles DELETE /api/v2/transcriptions/:id.
+func RemoveTranscription(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+	transcriptionID, ok := pathID(c, "Invalid transcription ID")
+	if !ok {
+		return
+	}
+
+	if err := service.DeleteTranscription(doctor.ID, transcriptionID); err != nil {
+		log.Println("Error deleting transcription:", err)
+		middleware.Abort(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// GetPatient handles GET /api/v2/patients/:id, returning the patient with their latest transcription.
+func GetPatient(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+	patientID, ok := pathID(c, "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	patient, transcription, err := service.GetPatientWithTranscription(doctor.ID, patientID)
+	if err != nil {
+		log.Println("Error retrieving patient data:", err)
+		middleware.Abort(c, err)
+		return
+	}
+
+	response := patientJSON(patient)
+	response["transcription"] = nil
+	if transcription != nil {
+		response["transcription"] = transcriptionJSON(transcription)
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// PatchPatient handles PATCH /api/v2/patients/:id. Only name, age and gender can be
+// changed; the updated patient is returned.
+func PatchPatient(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+	patientID, ok := pathID(c, "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	var updateData map[string]interface{}
+	if err := c.ShouldBindJSON(&updateData); err != nil {
+		log.Println("Invalid request body:", err)
+		middleware.Abort(c, invalidRequest(err))
+		return
+	}
+
+	if err := service.UpdatePatientByID(doctor.ID, patientID, updateData); err != nil {
+		log.Println("Error updating patient:", err)
+		middleware.Abort(c, err)
+		return
+	}
+
+	patient, _, err := service.GetPatientWithTranscription(doctor.ID, patientID)
+	if err != nil {
+		middleware.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, patientJSON(patient))
+}
+
+// RemovePatient handles DELETE /api/v2/patients/:id, deleting the patient with their transcriptions.
+func RemovePatient(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+	patientID, ok := pathID(c, "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	if err := service.DeletePatient(doctor.ID, patientID); err != nil {
+		log.Println("Error deleting patient:", err)
+		middleware.Abort(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// AddRecordingRequest names a new recording of an existing patient. Exactly one of Audio
+// and AudioFileID must be set. EncounterID files it under an existing visit; otherwise a
+// new encounter is opened with VisitDate (default now) and Reason.
+type AddRecordingRequest struct {
+	Audio       string     `json:"audio"`
+	AudioFileID *uuid.UUID `json:"audio_file_id"`
+	EncounterID *uuid.UUID `json:"encounter_id"`
+	VisitDate   *time.Time `json:"visit_date"`
+	Reason      string     `json:"reason"`
+}
+
+// AddRecording handles POST /api/v2/patients/:id/recordings. Like CreatePatient, the
+// recording is transcribed in the background and the response carries the job to poll.
+func AddRecording(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+	patientID, ok := pathID(c, "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	var req AddRecordingRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Error binding JSON:", err)
+		middleware.Abort(c, invalidRequest(err))
+		return
+	}
+
+	visit := service.EncounterDetails{ID: req.EncounterID, VisitDate: req.VisitDate, Reason: req.Reason}
+	job, err := service.EnqueueRecording(doctor.ID, patientID, visit, service.AudioSource{URL: req.Audio, FileID: req.AudioFileID})
+	if err != nil {
+		log.Println("Error queueing recording:", err)
+		middleware.Abort(c, err)
+		return
+	}
+
+	middleware.SetAuditResource(c, job.ID)
+	c.JSON(http.StatusAccepted, gin.H{
+		"message": "Transcription job queued",
+		"job_id":  job.ID,
+		"status":  job.Status,
+	})
+}
+
+// GetPatientTimeline handles GET /api/v2/patients/:id/timeline, listing every encounter
+// of the patient, oldest visit first, with the transcriptions recorded in it.
+func GetPatientTimeline(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+	patientID, ok := pathID(c, "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	patient, encounters, err := service.GetPatientTimeline(doctor.ID, patientID)
+	if err != nil {
+		log.Println("Error retrieving patient timeline:", err)
+		middleware.Abort(c, err)
+		return
+	}
+
+	formatted := make([]gin.H, len(encounters))
+	for i, encounter := range encounters {
+		transcriptions := make([]gin.H, len(encounter.Transcriptions))
+		for j := range encounter.Transcriptions {
+			transcriptions[j] = transcriptionJSON(&encounter.Transcriptions[j])
+		}
+		formatted[i] = gin.H{
+			"id":             encounter.ID,
+			"visit_date":     encounter.VisitDate,
+			"reason":         encounter.Reason,
+			"created_at":     encounter.CreatedAt,
+			"transcriptions": transcriptions,
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"patient":    patientJSON(patient),
+		"encounters": formatted,
+	})
+}
+
+// GetDuplicatePatients handles GET /api/v2/patients/duplicates, suggesting pairs of the
+// doctor's patients that are likely the same person, best match first. ?patient_id=
+// limits the pairs to one patient and ?min_score= lowers or raises the threshold.
+func GetDuplicatePatients(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+
+	var search service.DuplicateSearch
+	if value := c.Query("patient_id"); value != "" {
+		patientID, err := uuid.Parse(value)
+		if err != nil {
+			middleware.Abort(c, service.InvalidField("patient_id", "Invalid patient ID"))
+			return
+		}
+		search.PatientID = &patientID
+	}
+	if value := c.Query("min_score"); value != "" {
+		score, err := strconv.ParseFloat(value, 64)
+		if err != nil {
+			middleware.Abort(c, service.InvalidField("min_score", "Invalid min_score"))
+			return
+		}
+		search.MinScore = score
+	}
+	optionalQueryInt(c, "limit", &search.Limit)
+
+	pairs, err := service.FindDuplicatePatients(doctor.ID, search)
+	if err != nil {
+		log.Println("Error finding duplicate patients:", err)
+		middleware.Abort(c, err)
+		return
+	}
+
+	formatted := make([]gin.H, len(pairs))
+	for i := range pairs {
+		formatted[i] = gin.H{
+			"patient":    patientJSON(&pairs[i].Patient),
+			"duplicate":  patientJSON(&pairs[i].Duplicate),
+			"score":      pairs[i].Score,
+			"name_score": pairs[i].NameScore,
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"duplicates": formatted})
+}
+
+// MergePatientRequest names the duplicate to merge into the patient in the path.
+type MergePatientRequest struct {
+	DuplicateID uuid.UUID `json:"duplicate_id" binding:"required"`
+}
+
+// MergePatient handles POST /api/v2/patients/:id/merge. The duplicate's transcriptions,
+// encounters and jobs move to the patient in the path and the duplicate is deleted.
+func MergePatient(c *gin.Context) {
+	doctor, ok := authenticatedDoctor(c)
+	if !ok {
+		return
+	}
+	patientID, ok := pathID(c, "Invalid patient ID")
+	if !ok {
+		return
+	}
+
+	var req MergePatientRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		log.Println("Error binding JSON:", err)
+		middleware.Abort(c, invalidRequest(err))
+		return
+	}
+
+	merge, err := service.MergePatients(doctor.ID, patientID, req.DuplicateID)
+	if err != nil {
+		log.Println("Error merging patients:", err)
+		middleware.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":                  merge.ID,
+		"patient_id":          merge.SurvivorID,
+		"merged_id":           merge.MergedID,
+		"merged_name":         merge.MergedName,
+		"merged_age":          merge.MergedAge,
+		"merged_gender":       merge.MergedGender,
+		"transcription_count": merge.TranscriptionCount,
+		"encounter_count":     merge.EncounterCount,
+		"job_count":           merge.JobCount,
+		"created_at":          merge.CreatedAt,
+	})
+}
@@ -0,0 +1,377 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"itish41/doctor_ai_assistant/middleware"
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/agiledragon/gomonkey/v2"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetTranscription(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	transcriptionID := uuid.New()
+	patches := gomonkey.ApplyFunc(service.GetTranscriptionByID, func(doctorID, id uuid.UUID) (*models.Transcription, error) {
+		if id != transcriptionID {
+			return nil, service.ErrTranscriptionNotFound
+		}
+		return &models.Transcription{ID: id, Text: "Test text", Report: "Test report", Status: models.ReportStatusDraft}, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/transcriptions/:id", withDoctor(mockAuthDoctor()), GetTranscription)
+
+	tests := []struct {
+		name       string
+		id         string
+		wantStatus int
+	}{
+		{name: "Found", id: transcriptionID.String(), wantStatus: http.StatusOK},
+		{name: "Not found", id: uuid.New().String(), wantStatus: http.StatusNotFound},
+		{name: "Invalid ID", id: "invalid-uuid", wantStatus: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/transcriptions/"+tt.id, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantStatus == http.StatusOK {
+				var response map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, transcriptionID.String(), response["id"])
+				assert.Equal(t, "Test text", response["text"])
+				assert.Equal(t, models.ReportStatusDraft, response["status"])
+			}
+		})
+	}
+}
+
+func TestGetLatestTranscript_MissingName(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	router := newTestRouter()
+	router.GET("/transcriptions/latest", withDoctor(mockAuthDoctor()), GetLatestTranscript)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/transcriptions/latest", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), "patient_name")
+}
+
+func TestPatchTranscription(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	var gotUpdate map[string]interface{}
+	patches := gomonkey.ApplyFunc(service.UpdateTranscription, func(doctorID, transcriptionID uuid.UUID, updateData map[string]interface{}) error {
+		gotUpdate = updateData
+		return nil
+	})
+	patches.ApplyFunc(service.GetTranscriptionByID, func(doctorID, id uuid.UUID) (*models.Transcription, error) {
+		return &models.Transcription{ID: id, Text: "Updated text"}, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.PATCH("/transcriptions/:id", withDoctor(mockAuthDoctor()), PatchTranscription)
+
+	transcriptionID := uuid.New().String()
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/transcriptions/"+transcriptionID, strings.NewReader(`{"text": "Updated text"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, map[string]interface{}{"text": "Updated text"}, gotUpdate)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, transcriptionID, response["id"])
+	assert.Equal(t, "Updated text", response["text"])
+}
+
+func TestPatchTranscription_Signed(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFuncReturn(service.UpdateTranscription, service.ErrReportSigned)
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.PATCH("/transcriptions/:id", withDoctor(mockAuthDoctor()), PatchTranscription)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/transcriptions/"+uuid.New().String(), strings.NewReader(`{"text": "Updated text"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusConflict, w.Code)
+}
+
+func TestPatchTranscription_ReadOnlyFields(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	// The service itself rejects the fields before touching the database
+	router := newTestRouter()
+	router.PATCH("/transcriptions/:id", withDoctor(mockAuthDoctor()), PatchTranscription)
+
+	body := `{"text": "Moved", "doctor_id": "` + uuid.NewString() + `", "patient_id": "` + uuid.NewString() + `"}`
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/transcriptions/"+uuid.NewString(), strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	var problem middleware.Problem
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+	assert.Contains(t, problem.Errors, "doctor_id")
+	assert.Contains(t, problem.Errors, "patient_id")
+	assert.NotContains(t, problem.Errors, "text")
+}
+
+func TestRemoveTranscription(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFuncReturn(service.DeleteTranscription, nil)
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.DELETE("/transcriptions/:id", withDoctor(mockAuthDoctor()), RemoveTranscription)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/transcriptions/"+uuid.New().String(), nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	assert.Empty(t, w.Body.String())
+}
+
+func TestGetPatient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patientID := uuid.New()
+	patches := gomonkey.ApplyFunc(service.GetPatientWithTranscription, func(doctorID, id uuid.UUID) (*models.Patient, *models.Transcription, error) {
+		return &models.Patient{ID: id, Name: "John Doe", Age: 30, Gender: "Male"}, nil, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/patients/:id", withDoctor(mockAuthDoctor()), GetPatient)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/patients/"+patientID.String(), nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var response map[string]interface{}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, patientID.String(), response["id"])
+	assert.Equal(t, "John Doe", response["name"])
+	assert.Nil(t, response["transcription"])
+}
+
+func TestPatchPatient_Forbidden(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFuncReturn(service.UpdatePatientByID, service.ErrForbidden)
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.PATCH("/patients/:id", withDoctor(mockAuthDoctor()), PatchPatient)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("PATCH", "/patients/"+uuid.New().String(), strings.NewReader(`{"name": "Jane Doe"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusForbidden, w.Code)
+}
+
+func TestRemovePatient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patches := gomonkey.ApplyFuncReturn(service.DeletePatient, nil)
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.DELETE("/patients/:id", withDoctor(mockAuthDoctor()), RemovePatient)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("DELETE", "/patients/"+uuid.New().String(), nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusNoContent, w.Code)
+}
+
+func TestAddRecording(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patientID := uuid.New()
+	encounterID := uuid.New()
+	var gotVisit service.EncounterDetails
+	var gotAudio service.AudioSource
+	patches := gomonkey.ApplyFunc(service.EnqueueRecording, func(doctorID, id uuid.UUID, visit service.EncounterDetails, audio service.AudioSource) (*models.TranscriptionJob, error) {
+		if id != patientID {
+			return nil, service.ErrPatientNotFound
+		}
+		gotVisit, gotAudio = visit, audio
+		return &models.TranscriptionJob{ID: uuid.New(), Status: models.JobStatusQueued}, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.POST("/patients/:id/recordings", withDoctor(mockAuthDoctor()), AddRecording)
+
+	w := httptest.NewRecorder()
+	body := `{"audio": "https://example.com/visit.mp3", "encounter_id": "` + encounterID.String() + `"}`
+	req, _ := http.NewRequest("POST", "/patients/"+patientID.String()+"/recordings", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusAccepted, w.Code)
+	assert.Equal(t, encounterID, *gotVisit.ID)
+	assert.Equal(t, "https://example.com/visit.mp3", gotAudio.URL)
+	assert.Contains(t, w.Body.String(), `"job_id"`)
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("POST", "/patients/"+uuid.New().String()+"/recordings", strings.NewReader(`{"audio": "a.mp3"}`))
+	req.Header.Set("Content-Type", "application/json")
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestGetPatientTimeline(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patientID := uuid.New()
+	encounterID := uuid.New()
+	patches := gomonkey.ApplyFunc(service.GetPatientTimeline, func(doctorID, id uuid.UUID) (*models.Patient, []models.Encounter, error) {
+		return &models.Patient{ID: id, Name: "Jane"}, []models.Encounter{{
+			ID:             encounterID,
+			Reason:         "Follow-up",
+			Transcriptions: []models.Transcription{{ID: uuid.New(), EncounterID: &encounterID, Text: "Visit notes"}},
+		}}, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/patients/:id/timeline", withDoctor(mockAuthDoctor()), GetPatientTimeline)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/patients/"+patientID.String()+"/timeline", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	var response struct {
+		Patient    map[string]interface{}   `json:"patient"`
+		Encounters []map[string]interface{} `json:"encounters"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Equal(t, "Jane", response.Patient["name"])
+	if assert.Len(t, response.Encounters, 1) {
+		assert.Equal(t, "Follow-up", response.Encounters[0]["reason"])
+		transcription := response.Encounters[0]["transcriptions"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "Visit notes", transcription["text"])
+		assert.Equal(t, encounterID.String(), transcription["encounter_id"])
+	}
+}
+
+func TestGetDuplicatePatients(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	patientID := uuid.New()
+	var got service.DuplicateSearch
+	patches := gomonkey.ApplyFunc(service.FindDuplicatePatients, func(doctorID uuid.UUID, search service.DuplicateSearch) ([]service.DuplicatePatients, error) {
+		got = search
+		return []service.DuplicatePatients{{
+			Patient:   models.Patient{ID: patientID, Name: "John Smith", Age: 45},
+			Duplicate: models.Patient{ID: uuid.New(), Name: "Jon Smith", Age: 45},
+			Score:     0.81,
+			NameScore: 0.62,
+		}}, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.GET("/patients/duplicates", withDoctor(mockAuthDoctor()), GetDuplicatePatients)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/patients/duplicates?patient_id="+patientID.String()+"&min_score=0.8&limit=5", nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, patientID, *got.PatientID)
+	assert.Equal(t, 0.8, got.MinScore)
+	assert.Equal(t, 5, got.Limit)
+	var response struct {
+		Duplicates []map[string]interface{} `json:"duplicates"`
+	}
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+	if assert.Len(t, response.Duplicates, 1) {
+		assert.Equal(t, 0.81, response.Duplicates[0]["score"])
+		assert.Equal(t, "Jon Smith", response.Duplicates[0]["duplicate"].(map[string]interface{})["name"])
+	}
+
+	w = httptest.NewRecorder()
+	req, _ = http.NewRequest("GET", "/patients/duplicates?min_score=high", nil)
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+	assert.Contains(t, w.Body.String(), `"min_score"`)
+}
+
+func TestMergePatient(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	survivorID := uuid.New()
+	duplicateID := uuid.New()
+	patches := gomonkey.ApplyFunc(service.MergePatients, func(doctorID, id, duplicate uuid.UUID) (*models.PatientMerge, error) {
+		if duplicate != duplicateID {
+			return nil, service.ErrPatientNotFound
+		}
+		return &models.PatientMerge{ID: uuid.New(), SurvivorID: id, MergedID: duplicate, MergedName: "Jon Smith", TranscriptionCount: 2}, nil
+	})
+	defer patches.Reset()
+
+	router := newTestRouter()
+	router.POST("/patients/:id/merge", withDoctor(mockAuthDoctor()), MergePatient)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+	}{
+		{"Merged", `{"duplicate_id": "` + duplicateID.String() + `"}`, http.StatusOK},
+		{"Unknown duplicate", `{"duplicate_id": "` + uuid.New().String() + `"}`, http.StatusNotFound},
+		{"Missing duplicate", `{}`, http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/patients/"+survivorID.String()+"/merge", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				var response map[string]interface{}
+				assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &response))
+				assert.Equal(t, survivorID.String(), response["patient_id"])
+				assert.Equal(t, float64(2), response["transcription_count"])
+			}
+		})
+	}
+}
@@ -0,0 +1,3 @@
+ALTER TABLE transcription_jobs DROP COLUMN IF EXISTS audio_file_id;
+ALTER TABLE transcriptions DROP COLUMN IF EXISTS audio_file_id;
+DROP TABLE IF EXISTS audio_files;
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS audio_files (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    doctor_id UUID NOT NULL,
+    storage_key VARCHAR(512) UNIQUE NOT NULL,
+    original_filename VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size BIGINT NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (doctor_id) REFERENCES doctors(id) ON DELETE CASCADE
+);
+
+ALTER TABLE transcriptions
+    ADD COLUMN IF NOT EXISTS audio_file_id UUID REFERENCES audio_files(id) ON DELETE SET NULL;
+
+ALTER TABLE transcription_jobs
+    ADD COLUMN IF NOT EXISTS audio_file_id UUID REFERENCES audio_files(id) ON DELETE SET NULL;
@@ -0,0 +1 @@
+ALTER TABLE transcriptions DROP COLUMN IF EXISTS structured_report;
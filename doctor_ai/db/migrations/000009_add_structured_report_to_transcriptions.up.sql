@@ -0,0 +1 @@
+ALTER TABLE transcriptions ADD COLUMN IF NOT EXISTS structured_report JSONB;
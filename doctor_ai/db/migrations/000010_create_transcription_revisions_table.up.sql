@@ -0,0 +1,13 @@
+CREATE TABLE IF NOT EXISTS transcription_revisions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transcription_id UUID NOT NULL,
+    version INT NOT NULL,
+    author_id UUID NOT NULL,
+    text TEXT NOT NULL,
+    report TEXT NOT NULL,
+    structured_report JSONB,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (transcription_id) REFERENCES transcriptions(id) ON DELETE CASCADE,
+    FOREIGN KEY (author_id) REFERENCES doctors(id) ON DELETE CASCADE,
+    CONSTRAINT idx_transcription_revisions_version UNIQUE (transcription_id, version)
+);
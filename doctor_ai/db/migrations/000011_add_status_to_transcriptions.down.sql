@@ -0,0 +1,6 @@
+DROP INDEX IF EXISTS idx_transcriptions_status;
+
+ALTER TABLE transcriptions
+    DROP COLUMN IF EXISTS signed_at,
+    DROP COLUMN IF EXISTS signed_by_id,
+    DROP COLUMN IF EXISTS status;
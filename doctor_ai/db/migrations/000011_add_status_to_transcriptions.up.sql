@@ -0,0 +1,6 @@
+ALTER TABLE transcriptions
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'draft',
+    ADD COLUMN IF NOT EXISTS signed_by_id UUID REFERENCES doctors(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS signed_at TIMESTAMP WITH TIME ZONE;
+
+CREATE INDEX IF NOT EXISTS idx_transcriptions_status ON transcriptions (status);
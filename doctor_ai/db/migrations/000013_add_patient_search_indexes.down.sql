@@ -0,0 +1,2 @@
+DROP INDEX IF EXISTS idx_patients_doctor_id_created_at;
+DROP INDEX IF EXISTS idx_patients_name_trgm;
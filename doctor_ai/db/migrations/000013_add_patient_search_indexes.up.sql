@@ -0,0 +1,5 @@
+-- Trigram index behind fuzzy patient name search
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+CREATE INDEX IF NOT EXISTS idx_patients_name_trgm ON patients USING gin (lower(name) gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_patients_doctor_id_created_at ON patients (doctor_id, created_at);
@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS idx_transcriptions_search_vector;
+DROP TRIGGER IF EXISTS transcriptions_search_vector ON transcriptions;
+DROP FUNCTION IF EXISTS transcriptions_search_vector_update();
+
+ALTER TABLE transcriptions DROP COLUMN IF EXISTS search_vector;
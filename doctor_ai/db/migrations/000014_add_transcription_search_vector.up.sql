@@ -0,0 +1,21 @@
+-- Full-text search over transcriptions; report terms rank above transcript terms
+ALTER TABLE transcriptions ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+CREATE OR REPLACE FUNCTION transcriptions_search_vector_update() RETURNS trigger AS $$
+BEGIN
+    NEW.search_vector :=
+        setweight(to_tsvector('english', coalesce(NEW.report, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(NEW.text, '')), 'B');
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER transcriptions_search_vector
+    BEFORE INSERT OR UPDATE OF text, report ON transcriptions
+    FOR EACH ROW EXECUTE FUNCTION transcriptions_search_vector_update();
+
+UPDATE transcriptions SET search_vector =
+    setweight(to_tsvector('english', coalesce(report, '')), 'A') ||
+    setweight(to_tsvector('english', coalesce(text, '')), 'B');
+
+CREATE INDEX IF NOT EXISTS idx_transcriptions_search_vector ON transcriptions USING gin (search_vector);
@@ -0,0 +1,4 @@
+DROP INDEX IF EXISTS idx_patients_doctor_id_created_at_id;
+CREATE INDEX IF NOT EXISTS idx_patients_doctor_id_created_at ON patients (doctor_id, created_at);
+
+DROP INDEX IF EXISTS idx_transcriptions_doctor_id_created_at_id;
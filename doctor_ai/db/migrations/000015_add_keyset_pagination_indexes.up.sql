@@ -0,0 +1,5 @@
+-- Keyset pagination walks each doctor's lists by (created_at, id), newest first
+CREATE INDEX IF NOT EXISTS idx_transcriptions_doctor_id_created_at_id ON transcriptions (doctor_id, created_at DESC, id DESC);
+
+DROP INDEX IF EXISTS idx_patients_doctor_id_created_at;
+CREATE INDEX IF NOT EXISTS idx_patients_doctor_id_created_at_id ON patients (doctor_id, created_at DESC, id DESC);
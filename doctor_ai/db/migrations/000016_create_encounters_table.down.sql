@@ -0,0 +1,8 @@
+ALTER TABLE transcription_jobs
+    DROP COLUMN IF EXISTS encounter_reason,
+    DROP COLUMN IF EXISTS visit_date,
+    DROP COLUMN IF EXISTS encounter_id;
+
+DROP INDEX IF EXISTS idx_transcriptions_encounter_id;
+ALTER TABLE transcriptions DROP COLUMN IF EXISTS encounter_id;
+DROP TABLE IF EXISTS encounters;
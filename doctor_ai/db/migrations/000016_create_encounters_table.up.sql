@@ -0,0 +1,27 @@
+CREATE TABLE IF NOT EXISTS encounters (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    patient_id UUID NOT NULL,
+    doctor_id UUID NOT NULL,
+    visit_date TIMESTAMP WITH TIME ZONE NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (patient_id) REFERENCES patients(id) ON DELETE CASCADE,
+    FOREIGN KEY (doctor_id) REFERENCES doctors(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_encounters_patient_id_visit_date ON encounters (patient_id, visit_date);
+
+ALTER TABLE transcriptions
+    ADD COLUMN IF NOT EXISTS encounter_id UUID REFERENCES encounters(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_transcriptions_encounter_id ON transcriptions (encounter_id);
+
+-- Every existing recording was its own visit; the encounter reuses the transcription's ID
+INSERT INTO encounters (id, patient_id, doctor_id, visit_date, created_at)
+SELECT id, patient_id, doctor_id, created_at, created_at FROM transcriptions WHERE encounter_id IS NULL;
+UPDATE transcriptions SET encounter_id = id WHERE encounter_id IS NULL;
+
+ALTER TABLE transcription_jobs
+    ADD COLUMN IF NOT EXISTS encounter_id UUID REFERENCES encounters(id) ON DELETE SET NULL,
+    ADD COLUMN IF NOT EXISTS visit_date TIMESTAMP WITH TIME ZONE,
+    ADD COLUMN IF NOT EXISTS encounter_reason TEXT NOT NULL DEFAULT '';
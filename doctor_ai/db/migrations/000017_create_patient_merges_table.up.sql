@@ -0,0 +1,19 @@
+CREATE TABLE IF NOT EXISTS patient_merges (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    doctor_id UUID NOT NULL,
+    survivor_id UUID NOT NULL,
+    merged_id UUID NOT NULL,
+    merged_name VARCHAR(255) NOT NULL,
+    merged_age INT NOT NULL,
+    merged_gender VARCHAR(10) NOT NULL,
+    merged_created_at TIMESTAMP WITH TIME ZONE,
+    transcription_count INT NOT NULL DEFAULT 0,
+    encounter_count INT NOT NULL DEFAULT 0,
+    job_count INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (doctor_id) REFERENCES doctors(id) ON DELETE CASCADE
+);
+
+-- No foreign keys to patients: the merged patient is gone, and the record outlives the survivor
+CREATE INDEX IF NOT EXISTS idx_patient_merges_survivor_id ON patient_merges (survivor_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_patient_merges_merged_id ON patient_merges (merged_id);
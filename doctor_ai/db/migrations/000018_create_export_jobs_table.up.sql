@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS export_jobs (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    doctor_id UUID NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    last_error TEXT,
+    storage_key VARCHAR(512),
+    size BIGINT NOT NULL DEFAULT 0,
+    checksum VARCHAR(64),
+    patient_count INT NOT NULL DEFAULT 0,
+    transcription_count INT NOT NULL DEFAULT 0,
+    completed_at TIMESTAMP WITH TIME ZONE,
+    expires_at TIMESTAMP WITH TIME ZONE,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP,
+    FOREIGN KEY (doctor_id) REFERENCES doctors(id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS idx_export_jobs_doctor_id ON export_jobs (doctor_id);
+CREATE INDEX IF NOT EXISTS idx_export_jobs_status ON export_jobs (status);
+CREATE INDEX IF NOT EXISTS idx_export_jobs_expires_at ON export_jobs (expires_at);
@@ -0,0 +1,221 @@
+// Package fhir models the HL7 FHIR R4 resources the API exports and builds them from
+// patients, encounters and transcriptions. Only the elements the exports fill in are
+// modelled; empty ones are left out of the JSON as FHIR requires.
+package fhir
+
+// ContentType is the media type FHIR resources are served as.
+const ContentType = "application/fhir+json; charset=utf-8"
+
+// Meta is the metadata of a resource.
+type Meta struct {
+	LastUpdated string `json:"lastUpdated,omitempty"`
+}
+
+// Narrative is the human readable summary of a resource, an XHTML div.
+type Narrative struct {
+	Status string `json:"status"` // generated, extensions, additional or empty
+	Div    string `json:"div"`
+}
+
+type Identifier struct {
+	Use    string `json:"use,omitempty"`
+	System string `json:"system,omitempty"`
+	Value  string `json:"value,omitempty"`
+}
+
+type HumanName struct {
+	Use  string `json:"use,omitempty"`
+	Text string `json:"text,omitempty"`
+}
+
+type ContactPoint struct {
+	System string `json:"system,omitempty"` // phone, email, ...
+	Value  string `json:"value,omitempty"`
+	Use    string `json:"use,omitempty"`
+}
+
+type Coding struct {
+	System  string `json:"system,omitempty"`
+	Code    string `json:"code,omitempty"`
+	Display string `json:"display,omitempty"`
+}
+
+type CodeableConcept struct {
+	Coding []Coding `json:"coding,omitempty"`
+	Text   string   `json:"text,omitempty"`
+}
+
+// Reference points at another resource, e.g. "Patient/<id>".
+type Reference struct {
+	Reference string `json:"reference,omitempty"`
+	Display   string `json:"display,omitempty"`
+}
+
+type Period struct {
+	Start string `json:"start,omitempty"`
+	End   string `json:"end,omitempty"`
+}
+
+// Attachment is document content, either inline as base64 Data or at a URL.
+type Attachment struct {
+	ContentType string `json:"contentType,omitempty"`
+	Language    string `json:"language,omitempty"`
+	Data        string `json:"data,omitempty"`
+	URL         string `json:"url,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Creation    string `json:"creation,omitempty"`
+}
+
+type Patient struct {
+	ResourceType        string       `json:"resourceType"`
+	ID                  string       `json:"id"`
+	Meta                *Meta        `json:"meta,omitempty"`
+	Text                *Narrative   `json:"text,omitempty"`
+	Identifier          []Identifier `json:"identifier,omitempty"`
+	Active              bool         `json:"active"`
+	Name                []HumanName  `json:"name,omitempty"`
+	Gender              string       `json:"gender,omitempty"` // male, female, other or unknown
+	GeneralPractitioner []Reference  `json:"generalPractitioner,omitempty"`
+}
+
+type Practitioner struct {
+	ResourceType  string                      `json:"resourceType"`
+	ID            string                      `json:"id"`
+	Meta          *Meta                       `json:"meta,omitempty"`
+	Identifier    []Identifier                `json:"identifier,omitempty"`
+	Active        bool                        `json:"active"`
+	Name          []HumanName                 `json:"name,omitempty"`
+	Telecom       []ContactPoint              `json:"telecom,omitempty"`
+	Qualification []PractitionerQualification `json:"qualification,omitempty"`
+}
+
+type PractitionerQualification struct {
+	Code CodeableConcept `json:"code"`
+}
+
+type Encounter struct {
+	ResourceType string                 `json:"resourceType"`
+	ID           string                 `json:"id"`
+	Meta         *Meta                  `json:"meta,omitempty"`
+	Identifier   []Identifier           `json:"identifier,omitempty"`
+	Status       string                 `json:"status"`
+	Class        Coding                 `json:"class"`
+	Subject      *Reference             `json:"subject,omitempty"`
+	Participant  []EncounterParticipant `json:"participant,omitempty"`
+	Period       *Period                `json:"period,omitempty"`
+	ReasonCode   []CodeableConcept      `json:"reasonCode,omitempty"`
+}
+
+type EncounterParticipant struct {
+	Individual *Reference `json:"individual,omitempty"`
+}
+
+type Composition struct {
+	ResourceType string                `json:"resourceType"`
+	ID           string                `json:"id"`
+	Meta         *Meta                 `json:"meta,omitempty"`
+	Identifier   *Identifier           `json:"identifier,omitempty"`
+	Status       string                `json:"status"` // preliminary, final, amended or entered-in-error
+	Type         CodeableConcept       `json:"type"`
+	Subject      *Reference            `json:"subject,omitempty"`
+	Encounter    *Reference            `json:"encounter,omitempty"`
+	Date         string                `json:"date"`
+	Author       []Reference           `json:"author"`
+	Title        string                `json:"title"`
+	Attester     []CompositionAttester `json:"attester,omitempty"`
+	Section      []CompositionSection  `json:"section,omitempty"`
+}
+
+type CompositionAttester struct {
+	Mode  string     `json:"mode"` // personal, professional, legal or official
+	Time  string     `json:"time,omitempty"`
+	Party *Reference `json:"party,omitempty"`
+}
+
+type CompositionSection struct {
+	Title string           `json:"title,omitempty"`
+	Code  *CodeableConcept `json:"code,omitempty"`
+	Text  *Narrative       `json:"text,omitempty"`
+}
+
+type DiagnosticReport struct {
+	ResourceType       string          `json:"resourceType"`
+	ID                 string          `json:"id"`
+	Meta               *Meta           `json:"meta,omitempty"`
+	Identifier         []Identifier    `json:"identifier,omitempty"`
+	Status             string          `json:"status"`
+	Code               CodeableConcept `json:"code"`
+	Subject            *Reference      `json:"subject,omitempty"`
+	Encounter          *Reference      `json:"encounter,omitempty"`
+	EffectiveDateTime  string          `json:"effectiveDateTime,omitempty"`
+	Issued             string          `json:"issued,omitempty"`
+	Performer          []Reference     `json:"performer,omitempty"`
+	ResultsInterpreter []Reference     `json:"resultsInterpreter,omitempty"`
+	Conclusion         string          `json:"conclusion,omitempty"`
+	PresentedForm      []Attachment    `json:"presentedForm,omitempty"`
+}
+
+type DocumentReference struct {
+	ResourceType     string                     `json:"resourceType"`
+	ID               string                     `json:"id"`
+	Meta             *Meta                      `json:"meta,omitempty"`
+	MasterIdentifier *Identifier                `json:"masterIdentifier,omitempty"`
+	Status           string                     `json:"status"`              // current, superseded or entered-in-error
+	DocStatus        string                     `json:"docStatus,omitempty"` // preliminary, final, amended or entered-in-error
+	Type             *CodeableConcept           `json:"type,omitempty"`
+	Subject          *Reference                 `json:"subject,omitempty"`
+	Date             string                     `json:"date,omitempty"`
+	Author           []Reference                `json:"author,omitempty"`
+	Authenticator    *Reference                 `json:"authenticator,omitempty"`
+	Description      string                     `json:"description,omitempty"`
+	Content          []DocumentReferenceContent `json:"content"`
+	Context          *DocumentReferenceContext  `json:"context,omitempty"`
+}
+
+type DocumentReferenceContent struct {
+	Attachment Attachment `json:"attachment"`
+}
+
+type DocumentReferenceContext struct {
+	Encounter []Reference `json:"encounter,omitempty"`
+	Period    *Period     `json:"period,omitempty"`
+}
+
+// Bundle is a collection of resources, such as everything about one patient.
+type Bundle struct {
+	ResourceType string        `json:"resourceType"`
+	ID           string        `json:"id,omitempty"`
+	Meta         *Meta         `json:"meta,omitempty"`
+	Type         string        `json:"type"` // searchset, collection, document, ...
+	Timestamp    string        `json:"timestamp,omitempty"`
+	Total        *int          `json:"total,omitempty"` // only for searchsets
+	Link         []BundleLink  `json:"link,omitempty"`
+	Entry        []BundleEntry `json:"entry,omitempty"`
+}
+
+type BundleLink struct {
+	Relation string `json:"relation"`
+	URL      string `json:"url"`
+}
+
+type BundleEntry struct {
+	FullURL  string             `json:"fullUrl,omitempty"`
+	Resource interface{}        `json:"resource,omitempty"`
+	Search   *BundleEntrySearch `json:"search,omitempty"`
+}
+
+type BundleEntrySearch struct {
+	Mode string `json:"mode,omitempty"` // match or include
+}
+
+// OperationOutcome reports why a FHIR request failed.
+type OperationOutcome struct {
+	ResourceType string                  `json:"resourceType"`
+	Issue        []OperationOutcomeIssue `json:"issue"`
+}
+
+type OperationOutcomeIssue struct {
+	Severity    string `json:"severity"` // fatal, error, warning or information
+	Code        string `json:"code"`     // from the IssueType value set
+	Diagnostics string `json:"diagnostics,omitempty"`
+}
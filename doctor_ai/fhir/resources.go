@@ -0,0 +1,345 @@
+package fhir
+
+import (
+	"encoding/base64"
+	"fmt"
+	"html"
+	"strings"
+	"time"
+
+	"itish41/doctor_ai_assistant/models"
+
+	"github.com/google/uuid"
+)
+
+// Code systems and codes the exports use
+const (
+	uriSystem       = "urn:ietf:rfc:3986" // identifiers are the records' UUIDs as urn:uuid: URIs
+	loincSystem     = "http://loinc.org"
+	actCodeSystem   = "http://terminology.hl7.org/CodeSystem/v3-ActCode"
+	consultNoteCode = "11488-4"
+	xhtmlNamespace  = "http://www.w3.org/1999/xhtml"
+)
+
+// consultNote is the LOINC type of every transcription report.
+var consultNote = CodeableConcept{
+	Coding: []Coding{{System: loincSystem, Code: consultNoteCode, Display: "Consult note"}},
+	Text:   "Consult note",
+}
+
+// Resource is a resource that can be addressed by its type and ID.
+type Resource interface {
+	// Path is the resource's address relative to the FHIR base, e.g. "Patient/<id>".
+	Path() string
+}
+
+func (p Patient) Path() string           { return "Patient/" + p.ID }
+func (p Practitioner) Path() string      { return "Practitioner/" + p.ID }
+func (e Encounter) Path() string         { return "Encounter/" + e.ID }
+func (c Composition) Path() string       { return "Composition/" + c.ID }
+func (r DiagnosticReport) Path() string  { return "DiagnosticReport/" + r.ID }
+func (r DocumentReference) Path() string { return "DocumentReference/" + r.ID }
+
+// NewPatient exports a patient. FHIR has no element for an age, so it is only part of
+// the narrative.
+func NewPatient(patient models.Patient) Patient {
+	gender := Gender(patient.Gender)
+	summary := fmt.Sprintf("%s, %d years, %s", patient.Name, patient.Age, gender)
+	return Patient{
+		ResourceType:        "Patient",
+		ID:                  patient.ID.String(),
+		Meta:                meta(patient.CreatedAt),
+		Text:                narrative("<p>" + html.EscapeString(summary) + "</p>"),
+		Identifier:          []Identifier{uuidIdentifier(patient.ID)},
+		Active:              true,
+		Name:                []HumanName{{Use: "official", Text: patient.Name}},
+		Gender:              gender,
+		GeneralPractitioner: []Reference{{Reference: "Practitioner/" + patient.DoctorID.String()}},
+	}
+}
+
+// NewPractitioner exports a doctor. The password never leaves the model.
+func NewPractitioner(doctor models.Doctor) Practitioner {
+	practitioner := Practitioner{
+		ResourceType: "Practitioner",
+		ID:           doctor.ID.String(),
+		Meta:         meta(doctor.CreatedAt),
+		Identifier:   []Identifier{uuidIdentifier(doctor.ID)},
+		Active:       true,
+		Name:         []HumanName{{Use: "official", Text: doctor.Name}},
+	}
+	if doctor.Email != "" {
+		practitioner.Telecom = append(practitioner.Telecom, ContactPoint{System: "email", Value: doctor.Email, Use: "work"})
+	}
+	if doctor.Phone != "" {
+		practitioner.Telecom = append(practitioner.Telecom, ContactPoint{System: "phone", Value: doctor.Phone, Use: "work"})
+	}
+	if doctor.Specialization != "" {
+		practitioner.Qualification = []PractitionerQualification{{Code: CodeableConcept{Text: doctor.Specialization}}}
+	}
+	return practitioner
+}
+
+// NewEncounter exports a visit as a finished ambulatory encounter.
+func NewEncounter(encounter models.Encounter, patient models.Patient, doctor models.Doctor) Encounter {
+	export := Encounter{
+		ResourceType: "Encounter",
+		ID:           encounter.ID.String(),
+		Meta:         meta(encounter.CreatedAt),
+		Identifier:   []Identifier{uuidIdentifier(encounter.ID)},
+		Status:       "finished",
+		Class:        Coding{System: actCodeSystem, Code: "AMB", Display: "ambulatory"},
+		Subject:      patientReference(patient),
+		Participant:  []EncounterParticipant{{Individual: practitionerReference(doctor)}},
+		Period:       &Period{Start: dateTime(encounter.VisitDate)},
+	}
+	if encounter.Reason != "" {
+		export.ReasonCode = []CodeableConcept{{Text: encounter.Reason}}
+	}
+	return export
+}
+
+// NewComposition exports a transcription's report as a consult note with a section per
+// part of the structured report. A signed report is attested by the doctor who signed it.
+func NewComposition(transcription models.Transcription, patient models.Patient, doctor models.Doctor) Composition {
+	identifier := uuidIdentifier(transcription.ID)
+	composition := Composition{
+		ResourceType: "Composition",
+		ID:           transcription.ID.String(),
+		Meta:         meta(transcription.CreatedAt),
+		Identifier:   &identifier,
+		Status:       ReportStatus(transcription.Status),
+		Type:         consultNote,
+		Subject:      patientReference(patient),
+		Encounter:    encounterReference(transcription.EncounterID),
+		Date:         dateTime(transcription.CreatedAt),
+		Author:       []Reference{*practitionerReference(doctor)},
+		Title:        "Consult note for " + patient.Name,
+	}
+	if transcription.Status == models.ReportStatusSigned && transcription.SignedAt != nil {
+		signer := practitionerReference(doctor)
+		if transcription.SignedByID != nil && *transcription.SignedByID != doctor.ID {
+			signer = &Reference{Reference: "Practitioner/" + transcription.SignedByID.String()}
+		}
+		composition.Attester = []CompositionAttester{{Mode: "legal", Time: dateTime(*transcription.SignedAt), Party: signer}}
+	}
+
+	report := transcription.StructuredReport
+	if report == nil {
+		composition.Section = []CompositionSection{{Title: "Report", Text: sectionNarrative(transcription.Report, nil)}}
+		return composition
+	}
+	for _, section := range []struct {
+		title, code, display, text string
+		items                      []string
+	}{
+		{"Patient Information", "", "", report.PatientInformation, nil},
+		{"Patient History", "11329-0", "History general Narrative - Reported", report.PatientHistory, nil},
+		{"Symptoms", "10154-3", "Chief complaint Narrative - Reported", "", report.Symptoms},
+		{"Diagnosis", "29548-5", "Diagnosis Narrative", report.Diagnosis, nil},
+		{"Treatment Plan", "18776-5", "Plan of care note", report.TreatmentPlan, nil},
+		{"Recommendations", "", "", "", report.Recommendations},
+	} {
+		exported := CompositionSection{Title: section.title, Text: sectionNarrative(section.text, section.items)}
+		if section.code != "" {
+			exported.Code = &CodeableConcept{Coding: []Coding{{System: loincSystem, Code: section.code, Display: section.display}}}
+		}
+		composition.Section = append(composition.Section, exported)
+	}
+	return composition
+}
+
+// NewDiagnosticReport exports a transcription's report with its diagnosis as the
+// conclusion and the report text as its presented form.
+func NewDiagnosticReport(transcription models.Transcription, patient models.Patient, doctor models.Doctor) DiagnosticReport {
+	report := DiagnosticReport{
+		ResourceType:      "DiagnosticReport",
+		ID:                transcription.ID.String(),
+		Meta:              meta(transcription.CreatedAt),
+		Identifier:        []Identifier{uuidIdentifier(transcription.ID)},
+		Status:            ReportStatus(transcription.Status),
+		Code:              consultNote,
+		Subject:           patientReference(patient),
+		Encounter:         encounterReference(transcription.EncounterID),
+		EffectiveDateTime: dateTime(transcription.CreatedAt),
+		Performer:         []Reference{*practitionerReference(doctor)},
+		PresentedForm: []Attachment{{
+			ContentType: "text/plain; charset=utf-8",
+			Language:    "en",
+			Data:        base64.StdEncoding.EncodeToString([]byte(transcription.Report)),
+			Title:       "Consult note",
+			Creation:    dateTime(transcription.CreatedAt),
+		}},
+	}
+	if transcription.SignedAt != nil && transcription.Status == models.ReportStatusSigned {
+		report.Issued = dateTime(*transcription.SignedAt)
+		report.ResultsInterpreter = []Reference{*practitionerReference(doctor)}
+	}
+	if transcription.StructuredReport != nil && transcription.StructuredReport.Diagnosis != "" {
+		report.Conclusion = transcription.StructuredReport.Diagnosis
+	}
+	return report
+}
+
+// NewDocumentReference indexes a transcription's report as a document, linking to its
+// PDF at pdfURL and carrying the report text inline.
+func NewDocumentReference(transcription models.Transcription, patient models.Patient, doctor models.Doctor, pdfURL string) DocumentReference {
+	identifier, noteType := uuidIdentifier(transcription.ID), consultNote
+	document := DocumentReference{
+		ResourceType:     "DocumentReference",
+		ID:               transcription.ID.String(),
+		Meta:             meta(transcription.CreatedAt),
+		MasterIdentifier: &identifier,
+		Status:           "current",
+		DocStatus:        ReportStatus(transcription.Status),
+		Type:             &noteType,
+		Subject:          patientReference(patient),
+		Date:             dateTime(transcription.CreatedAt),
+		Author:           []Reference{*practitionerReference(doctor)},
+		Description:      "Consult note for " + patient.Name,
+		Content: []DocumentReferenceContent{
+			{Attachment: Attachment{ContentType: "application/pdf", URL: pdfURL, Title: "Consult note", Creation: dateTime(transcription.CreatedAt)}},
+			{Attachment: Attachment{
+				ContentType: "text/plain; charset=utf-8",
+				Language:    "en",
+				Data:        base64.StdEncoding.EncodeToString([]byte(transcription.Report)),
+				Title:       "Consult note",
+				Creation:    dateTime(transcription.CreatedAt),
+			}},
+		},
+	}
+	if transcription.Status == models.ReportStatusSigned && transcription.SignedAt != nil {
+		document.Authenticator = practitionerReference(doctor)
+	}
+	if encounter := encounterReference(transcription.EncounterID); encounter != nil {
+		document.Context = &DocumentReferenceContext{Encounter: []Reference{*encounter}}
+	}
+	return document
+}
+
+// NewSearchset bundles the resources a search found, addressed under baseURL, with the
+// URL of the search itself as the self link. Matches are what was searched for; includes
+// are resources they reference.
+func NewSearchset(baseURL, selfURL string, matches, includes []Resource) Bundle {
+	total := len(matches)
+	bundle := Bundle{
+		ResourceType: "Bundle",
+		ID:           uuid.NewString(),
+		Meta:         &Meta{LastUpdated: dateTime(time.Now())},
+		Type:         "searchset",
+		Timestamp:    dateTime(time.Now()),
+		Total:        &total,
+		Link:         []BundleLink{{Relation: "self", URL: selfURL}},
+	}
+	for mode, resources := range [][]Resource{matches, includes} {
+		search := &BundleEntrySearch{Mode: "match"}
+		if mode == 1 {
+			search = &BundleEntrySearch{Mode: "include"}
+		}
+		for _, resource := range resources {
+			bundle.Entry = append(bundle.Entry, BundleEntry{
+				FullURL:  strings.TrimSuffix(baseURL, "/") + "/" + resource.Path(),
+				Resource: resource,
+				Search:   search,
+			})
+		}
+	}
+	return bundle
+}
+
+// NewOperationOutcome reports a failed request as a single error issue.
+func NewOperationOutcome(code, diagnostics string) OperationOutcome {
+	return OperationOutcome{
+		ResourceType: "OperationOutcome",
+		Issue:        []OperationOutcomeIssue{{Severity: "error", Code: code, Diagnostics: diagnostics}},
+	}
+}
+
+// Gender maps a recorded gender onto FHIR's administrative genders by its first letter,
+// so "M" and "Male" are both male.
+func Gender(gender string) string {
+	gender = strings.ToLower(strings.TrimSpace(gender))
+	switch {
+	case gender == "":
+		return "unknown"
+	case gender[0] == 'm':
+		return "male"
+	case gender[0] == 'f':
+		return "female"
+	default:
+		return "other"
+	}
+}
+
+// ReportStatus maps a report's review status onto a FHIR composition or document status.
+// A report being amended keeps counting as amended until it is signed again.
+func ReportStatus(status string) string {
+	switch status {
+	case models.ReportStatusSigned:
+		return "final"
+	case models.ReportStatusAmended:
+		return "amended"
+	default:
+		return "preliminary"
+	}
+}
+
+func uuidIdentifier(id uuid.UUID) Identifier {
+	return Identifier{Use: "official", System: uriSystem, Value: "urn:uuid:" + id.String()}
+}
+
+func patientReference(patient models.Patient) *Reference {
+	return &Reference{Reference: "Patient/" + patient.ID.String(), Display: patient.Name}
+}
+
+func practitionerReference(doctor models.Doctor) *Reference {
+	return &Reference{Reference: "Practitioner/" + doctor.ID.String(), Display: doctor.Name}
+}
+
+func encounterReference(id *uuid.UUID) *Reference {
+	if id == nil {
+		return nil
+	}
+	return &Reference{Reference: "Encounter/" + id.String()}
+}
+
+func meta(updated time.Time) *Meta {
+	if updated.IsZero() {
+		return nil
+	}
+	return &Meta{LastUpdated: dateTime(updated)}
+}
+
+// dateTime formats a FHIR dateTime. With its seconds and time zone it is also a valid
+// instant.
+func dateTime(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+func narrative(body string) *Narrative {
+	return &Narrative{Status: "generated", Div: `<div xmlns="` + xhtmlNamespace + `">` + body + `</div>`}
+}
+
+// sectionNarrative renders a report section as XHTML: the text with its line breaks
+// kept, then the items as a list.
+func sectionNarrative(text string, items []string) *Narrative {
+	var b strings.Builder
+	if text == "" && len(items) == 0 {
+		text = "Not documented."
+	}
+	if text != "" {
+		lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+		for i := range lines {
+			lines[i] = html.EscapeString(lines[i])
+		}
+		b.WriteString("<p>" + strings.Join(lines, "<br/>") + "</p>")
+	}
+	if len(items) > 0 {
+		b.WriteString("<ul>")
+		for _, item := range items {
+			b.WriteString("<li>" + html.EscapeString(item) + "</li>")
+		}
+		b.WriteString("</ul>")
+	}
+	return narrative(b.String())
+}
@@ -0,0 +1,267 @@
+package fhir
+
+import (
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+
+	"itish41/doctor_ai_assistant/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var (
+	testCreated = time.Date(2026, time.March, 1, 10, 0, 0, 0, time.FixedZone("IST", 5*3600+1800))
+	testDoctor  = models.Doctor{ID: uuid.New(), Name: "Dr. Test", Specialization: "Cardiology", Email: "test@example.com", Phone: "1234567890", CreatedAt: testCreated}
+	testPatient = models.Patient{ID: uuid.New(), Name: "José Álvarez", Age: 61, Gender: "Male", DoctorID: testDoctor.ID, CreatedAt: testCreated}
+)
+
+func testTranscription() models.Transcription {
+	report := &models.MedicalReport{
+		PatientInformation: "61 year old male",
+		PatientHistory:     "Smoker <20 years>\nNo allergies",
+		Symptoms:           []string{"Cough", "Chest pain & fatigue"},
+		Diagnosis:          "Hypertension",
+		TreatmentPlan:      "Amlodipine 5 mg daily",
+		Recommendations:    []string{"Reduce salt"},
+	}
+	return models.Transcription{
+		ID:               uuid.New(),
+		DoctorID:         testDoctor.ID,
+		PatientID:        testPatient.ID,
+		Report:           report.Text(),
+		StructuredReport: report,
+		Status:           models.ReportStatusDraft,
+		CreatedAt:        testCreated,
+	}
+}
+
+func signed(transcription models.Transcription, by uuid.UUID) models.Transcription {
+	signedAt := testCreated.Add(time.Hour)
+	transcription.Status, transcription.SignedAt, transcription.SignedByID = models.ReportStatusSigned, &signedAt, &by
+	return transcription
+}
+
+func assertValid(t *testing.T, v *validator, resource interface{}) {
+	t.Helper()
+	assert.Empty(t, v.Validate(resource))
+}
+
+func TestValidatorRejectsNonConformingResources(t *testing.T) {
+	v := loadValidator(t)
+	patient := NewPatient(testPatient)
+	patient.Gender = "M"
+	patient.Name = []HumanName{}
+	patient.Text.Div = "<p>unwrapped</p>"
+
+	problems := v.Validate(patient)
+	assert.Contains(t, problems, `Patient.gender: "M" is not in http://hl7.org/fhir/ValueSet/administrative-gender`)
+	assert.Contains(t, problems, "Patient.text.div: must be a div in the XHTML namespace")
+	assert.Len(t, problems, 2, "empty arrays are left out of the JSON")
+
+	encounter := NewEncounter(models.Encounter{ID: uuid.New()}, testPatient, testDoctor)
+	encounter.Status = ""
+	encounter.Period.Start = "1 March 2026"
+	problems = v.Validate(encounter)
+	assert.Contains(t, problems, `Encounter.status: "" is not a valid code`)
+	assert.Contains(t, problems, `Encounter.period.start: "1 March 2026" is not a valid dateTime`)
+
+	problems = v.Validate(map[string]interface{}{"resourceType": "Composition", "id": "1", "title": []string{"x"}, "extra": true})
+	assert.Contains(t, problems, "Composition.extra: not an element of Composition")
+	assert.Contains(t, problems, "Composition.title: must not be an array")
+	assert.Contains(t, problems, "Composition.author: required")
+}
+
+func TestNewPatient(t *testing.T) {
+	v := loadValidator(t)
+	patient := NewPatient(testPatient)
+	assertValid(t, v, patient)
+
+	assert.Equal(t, "Patient/"+testPatient.ID.String(), patient.Path())
+	assert.Equal(t, "male", patient.Gender)
+	assert.Equal(t, "urn:uuid:"+testPatient.ID.String(), patient.Identifier[0].Value)
+	assert.Equal(t, "2026-03-01T04:30:00Z", patient.Meta.LastUpdated)
+	assert.Equal(t, "Practitioner/"+testDoctor.ID.String(), patient.GeneralPractitioner[0].Reference)
+	assert.Contains(t, patient.Text.Div, "José Álvarez, 61 years, male")
+
+	unrecorded := testPatient
+	unrecorded.Gender, unrecorded.Name, unrecorded.CreatedAt = "", "<Unknown>", time.Time{}
+	patient = NewPatient(unrecorded)
+	assertValid(t, v, patient)
+	assert.Equal(t, "unknown", patient.Gender)
+	assert.Nil(t, patient.Meta)
+	assert.Contains(t, patient.Text.Div, "&lt;Unknown&gt;")
+}
+
+func TestNewPractitioner(t *testing.T) {
+	v := loadValidator(t)
+	practitioner := NewPractitioner(testDoctor)
+	assertValid(t, v, practitioner)
+	assert.Equal(t, []ContactPoint{
+		{System: "email", Value: "test@example.com", Use: "work"},
+		{System: "phone", Value: "1234567890", Use: "work"},
+	}, practitioner.Telecom)
+	assert.Equal(t, "Cardiology", practitioner.Qualification[0].Code.Text)
+
+	practitioner = NewPractitioner(models.Doctor{ID: uuid.New(), Name: "Dr. Minimal"})
+	assertValid(t, v, practitioner)
+	assert.Empty(t, practitioner.Telecom)
+	assert.Empty(t, practitioner.Qualification)
+}
+
+func TestNewEncounter(t *testing.T) {
+	v := loadValidator(t)
+	visit := models.Encounter{ID: uuid.New(), PatientID: testPatient.ID, DoctorID: testDoctor.ID, VisitDate: testCreated, Reason: "Follow-up", CreatedAt: testCreated}
+	encounter := NewEncounter(visit, testPatient, testDoctor)
+	assertValid(t, v, encounter)
+	assert.Equal(t, "finished", encounter.Status)
+	assert.Equal(t, "AMB", encounter.Class.Code)
+	assert.Equal(t, "Patient/"+testPatient.ID.String(), encounter.Subject.Reference)
+	assert.Equal(t, "Practitioner/"+testDoctor.ID.String(), encounter.Participant[0].Individual.Reference)
+	assert.Equal(t, "2026-03-01T04:30:00Z", encounter.Period.Start)
+	assert.Equal(t, "Follow-up", encounter.ReasonCode[0].Text)
+
+	visit.Reason = ""
+	encounter = NewEncounter(visit, testPatient, testDoctor)
+	assertValid(t, v, encounter)
+	assert.Empty(t, encounter.ReasonCode)
+}
+
+func TestNewComposition(t *testing.T) {
+	v := loadValidator(t)
+
+	t.Run("Draft with a structured report", func(t *testing.T) {
+		encounterID := uuid.New()
+		transcription := testTranscription()
+		transcription.EncounterID = &encounterID
+		composition := NewComposition(transcription, testPatient, testDoctor)
+		assertValid(t, v, composition)
+		assert.Equal(t, "preliminary", composition.Status)
+		assert.Equal(t, consultNoteCode, composition.Type.Coding[0].Code)
+		assert.Equal(t, "Encounter/"+encounterID.String(), composition.Encounter.Reference)
+		assert.Empty(t, composition.Attester)
+		if assert.Len(t, composition.Section, 6) {
+			history := composition.Section[1]
+			assert.Equal(t, "11329-0", history.Code.Coding[0].Code)
+			assert.Contains(t, history.Text.Div, "<p>Smoker &lt;20 years&gt;<br/>No allergies</p>")
+			assert.Contains(t, composition.Section[2].Text.Div, "<ul><li>Cough</li><li>Chest pain &amp; fatigue</li></ul>")
+			assert.Nil(t, composition.Section[0].Code)
+		}
+	})
+
+	t.Run("Signed by a colleague", func(t *testing.T) {
+		colleague := uuid.New()
+		composition := NewComposition(signed(testTranscription(), colleague), testPatient, testDoctor)
+		assertValid(t, v, composition)
+		assert.Equal(t, "final", composition.Status)
+		if assert.Len(t, composition.Attester, 1) {
+			assert.Equal(t, "legal", composition.Attester[0].Mode)
+			assert.Equal(t, "2026-03-01T05:30:00Z", composition.Attester[0].Time)
+			assert.Equal(t, "Practitioner/"+colleague.String(), composition.Attester[0].Party.Reference)
+		}
+	})
+
+	t.Run("Plain text report", func(t *testing.T) {
+		transcription := testTranscription()
+		transcription.StructuredReport, transcription.Report = nil, ""
+		composition := NewComposition(transcription, testPatient, testDoctor)
+		assertValid(t, v, composition)
+		if assert.Len(t, composition.Section, 1) {
+			assert.Contains(t, composition.Section[0].Text.Div, "Not documented.")
+		}
+	})
+}
+
+func TestNewDiagnosticReport(t *testing.T) {
+	v := loadValidator(t)
+	transcription := testTranscription()
+	report := NewDiagnosticReport(transcription, testPatient, testDoctor)
+	assertValid(t, v, report)
+	assert.Equal(t, "preliminary", report.Status)
+	assert.Equal(t, "Hypertension", report.Conclusion)
+	assert.Empty(t, report.Issued)
+	text, err := base64.StdEncoding.DecodeString(report.PresentedForm[0].Data)
+	assert.NoError(t, err)
+	assert.Equal(t, transcription.Report, string(text))
+
+	report = NewDiagnosticReport(signed(transcription, testDoctor.ID), testPatient, testDoctor)
+	assertValid(t, v, report)
+	assert.Equal(t, "final", report.Status)
+	assert.Equal(t, "2026-03-01T05:30:00Z", report.Issued)
+	assert.Equal(t, "Practitioner/"+testDoctor.ID.String(), report.ResultsInterpreter[0].Reference)
+
+	transcription.Status = models.ReportStatusAmended
+	assertValid(t, v, NewDiagnosticReport(transcription, testPatient, testDoctor))
+}
+
+func TestNewDocumentReference(t *testing.T) {
+	v := loadValidator(t)
+	pdfURL := "https://api.example.com/api/v2/transcriptions/1/download?format=pdf"
+	document := NewDocumentReference(testTranscription(), testPatient, testDoctor, pdfURL)
+	assertValid(t, v, document)
+	assert.Equal(t, "current", document.Status)
+	assert.Equal(t, "preliminary", document.DocStatus)
+	assert.Nil(t, document.Authenticator)
+	assert.Nil(t, document.Context)
+	if assert.Len(t, document.Content, 2) {
+		assert.Equal(t, Attachment{ContentType: "application/pdf", URL: pdfURL, Title: "Consult note", Creation: "2026-03-01T04:30:00Z"},
+			document.Content[0].Attachment)
+		assert.Equal(t, "text/plain; charset=utf-8", document.Content[1].Attachment.ContentType)
+	}
+
+	encounterID := uuid.New()
+	transcription := signed(testTranscription(), testDoctor.ID)
+	transcription.EncounterID = &encounterID
+	document = NewDocumentReference(transcription, testPatient, testDoctor, pdfURL)
+	assertValid(t, v, document)
+	assert.Equal(t, "final", document.DocStatus)
+	assert.Equal(t, "Practitioner/"+testDoctor.ID.String(), document.Authenticator.Reference)
+	assert.Equal(t, "Encounter/"+encounterID.String(), document.Context.Encounter[0].Reference)
+}
+
+func TestNewSearchset(t *testing.T) {
+	v := loadValidator(t)
+	transcription := testTranscription()
+	baseURL := "https://api.example.com/api/v2/fhir/"
+	bundle := NewSearchset(baseURL, baseURL+"Patient/"+testPatient.ID.String()+"/$everything",
+		[]Resource{NewPatient(testPatient), NewComposition(transcription, testPatient, testDoctor)},
+		[]Resource{NewPractitioner(testDoctor)})
+	assertValid(t, v, bundle)
+
+	assert.Equal(t, "searchset", bundle.Type)
+	assert.Equal(t, 2, *bundle.Total, "included resources aren't counted")
+	if assert.Len(t, bundle.Entry, 3) {
+		assert.Equal(t, "https://api.example.com/api/v2/fhir/Patient/"+testPatient.ID.String(), bundle.Entry[0].FullURL)
+		assert.Equal(t, "match", bundle.Entry[1].Search.Mode)
+		assert.Equal(t, "include", bundle.Entry[2].Search.Mode)
+		assert.True(t, strings.HasSuffix(bundle.Entry[2].FullURL, "/Practitioner/"+testDoctor.ID.String()))
+	}
+
+	empty := NewSearchset(baseURL, baseURL+"Patient", nil, nil)
+	assertValid(t, v, empty)
+	assert.Equal(t, 0, *empty.Total)
+}
+
+func TestNewOperationOutcome(t *testing.T) {
+	v := loadValidator(t)
+	outcome := NewOperationOutcome("not-found", "patient not found")
+	assertValid(t, v, outcome)
+	assert.Equal(t, []OperationOutcomeIssue{{Severity: "error", Code: "not-found", Diagnostics: "patient not found"}}, outcome.Issue)
+}
+
+func TestGender(t *testing.T) {
+	for recorded, expected := range map[string]string{
+		"Male": "male", "m": "male", " FEMALE ": "female", "F": "female", "Non-binary": "other", "": "unknown",
+	} {
+		assert.Equal(t, expected, Gender(recorded), recorded)
+	}
+}
+
+func TestReportStatus(t *testing.T) {
+	assert.Equal(t, "preliminary", ReportStatus(models.ReportStatusDraft))
+	assert.Equal(t, "preliminary", ReportStatus(models.ReportStatusReviewed))
+	assert.Equal(t, "final", ReportStatus(models.ReportStatusSigned))
+	assert.Equal(t, "amended", ReportStatus(models.ReportStatusAmended))
+}
@@ -0,0 +1,301 @@
+package fhir
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// validator checks resources against the R4 structure definitions in
+// testdata/r4-definitions.json, an excerpt of the specification's snapshots covering the
+// elements the exports can use. It checks that every element is defined, its
+// cardinality, its type and the codes of required bindings.
+type validator struct {
+	elements  map[string]elementDefinition   // by path, such as "Patient.gender"
+	children  map[string][]elementDefinition // by the path of their parent
+	valueSets map[string]map[string]bool     // codes by value set URL
+}
+
+type elementDefinition struct {
+	Path string `json:"path"`
+	Min  int    `json:"min"`
+	Max  string `json:"max"`
+	Type []struct {
+		Code string `json:"code"`
+	} `json:"type"`
+	Binding *struct {
+		Strength string `json:"strength"`
+		ValueSet string `json:"valueSet"`
+	} `json:"binding"`
+}
+
+// Regular expressions of FHIR's primitive types, from the specification
+var primitives = map[string]*regexp.Regexp{
+	"id":           regexp.MustCompile(`^[A-Za-z0-9\-\.]{1,64}$`),
+	"string":       regexp.MustCompile(`^[ \r\n\t\S]+$`),
+	"code":         regexp.MustCompile(`^[^\s]+( [^\s]+)*$`),
+	"uri":          regexp.MustCompile(`^\S+$`),
+	"url":          regexp.MustCompile(`^\S+$`),
+	"canonical":    regexp.MustCompile(`^\S+$`),
+	"base64Binary": regexp.MustCompile(`^(\s*([0-9a-zA-Z\+/=]){4}\s*)+$`),
+	"date":         regexp.MustCompile(`^([0-9]([0-9]([0-9][1-9]|[1-9]0)|[1-9]00)|[1-9]000)(-(0[1-9]|1[0-2])(-(0[1-9]|[1-2][0-9]|3[0-1]))?)?$`),
+	"dateTime":     regexp.MustCompile(`^([0-9]([0-9]([0-9][1-9]|[1-9]0)|[1-9]00)|[1-9]000)(-(0[1-9]|1[0-2])(-(0[1-9]|[1-2][0-9]|3[0-1])(T([01][0-9]|2[0-3]):[0-5][0-9]:([0-5][0-9]|60)(\.[0-9]+)?(Z|(\+|-)((0[0-9]|1[0-3]):[0-5][0-9]|14:00)))?)?)?$`),
+	"instant":      regexp.MustCompile(`^([0-9]([0-9]([0-9][1-9]|[1-9]0)|[1-9]00)|[1-9]000)-(0[1-9]|1[0-2])-(0[1-9]|[1-2][0-9]|3[0-1])T([01][0-9]|2[0-3]):[0-5][0-9]:([0-5][0-9]|60)(\.[0-9]+)?(Z|(\+|-)((0[0-9]|1[0-3]):[0-5][0-9]|14:00))$`),
+	"unsignedInt":  regexp.MustCompile(`^(0|[1-9][0-9]*)$`),
+	"positiveInt":  regexp.MustCompile(`^[1-9][0-9]*$`),
+	"decimal":      regexp.MustCompile(`^-?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?$`),
+}
+
+func loadValidator(t *testing.T) *validator {
+	data, err := os.ReadFile("testdata/r4-definitions.json")
+	if err != nil {
+		t.Fatalf("Failed to read structure definitions: %v", err)
+	}
+	var bundle struct {
+		Entry []struct {
+			Resource struct {
+				ResourceType string `json:"resourceType"`
+				URL          string `json:"url"`
+				Snapshot     struct {
+					Element []elementDefinition `json:"element"`
+				} `json:"snapshot"`
+				Expansion struct {
+					Contains []struct {
+						Code string `json:"code"`
+					} `json:"contains"`
+				} `json:"expansion"`
+			} `json:"resource"`
+		} `json:"entry"`
+	}
+	if err := json.Unmarshal(data, &bundle); err != nil {
+		t.Fatalf("Failed to parse structure definitions: %v", err)
+	}
+
+	v := &validator{
+		elements:  map[string]elementDefinition{},
+		children:  map[string][]elementDefinition{},
+		valueSets: map[string]map[string]bool{},
+	}
+	for _, entry := range bundle.Entry {
+		switch entry.Resource.ResourceType {
+		case "StructureDefinition":
+			for _, element := range entry.Resource.Snapshot.Element {
+				v.elements[element.Path] = element
+				if dot := strings.LastIndex(element.Path, "."); dot >= 0 {
+					v.children[element.Path[:dot]] = append(v.children[element.Path[:dot]], element)
+				}
+			}
+		case "ValueSet":
+			codes := map[string]bool{}
+			for _, concept := range entry.Resource.Expansion.Contains {
+				codes[concept.Code] = true
+			}
+			v.valueSets[entry.Resource.URL] = codes
+		}
+	}
+	return v
+}
+
+// Validate returns what is wrong with a resource, sorted, or nothing when it conforms.
+func (v *validator) Validate(resource interface{}) []string {
+	data, err := json.Marshal(resource)
+	if err != nil {
+		return []string{err.Error()}
+	}
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.UseNumber()
+	var value interface{}
+	if err := decoder.Decode(&value); err != nil {
+		return []string{err.Error()}
+	}
+
+	var problems []string
+	v.resource("", value, &problems)
+	sort.Strings(problems)
+	return problems
+}
+
+func (v *validator) resource(location string, value interface{}, problems *[]string) {
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		*problems = append(*problems, location+": a resource must be an object")
+		return
+	}
+	resourceType, _ := object["resourceType"].(string)
+	if _, ok := v.elements[resourceType]; !ok {
+		*problems = append(*problems, fmt.Sprintf("%s: unknown resource type %q", location, resourceType))
+		return
+	}
+	if location == "" {
+		location = resourceType
+	}
+	v.object(resourceType, location, object, problems)
+}
+
+// object checks the elements of a resource, datatype or backbone element defined at path.
+func (v *validator) object(path, location string, object map[string]interface{}, problems *[]string) {
+	if len(object) == 0 {
+		*problems = append(*problems, location+": elements must have a value or children")
+		return
+	}
+	for name, value := range object {
+		if name == "resourceType" && !strings.Contains(path, ".") {
+			continue
+		}
+		element, typeCode, ok := v.lookup(path, name)
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s.%s: not an element of %s", location, name, path))
+			continue
+		}
+		v.element(element, typeCode, location+"."+name, value, problems)
+	}
+	for _, element := range v.children[path] {
+		name := element.Path[len(path)+1:]
+		if element.Min > 0 && !strings.HasSuffix(name, "[x]") {
+			if _, ok := object[name]; !ok {
+				*problems = append(*problems, fmt.Sprintf("%s.%s: required", location, name))
+			}
+		}
+	}
+}
+
+// lookup finds the definition of an element by its JSON name. A choice element such as
+// effective[x] appears with its type as a suffix, as in effectiveDateTime.
+func (v *validator) lookup(path, name string) (elementDefinition, string, bool) {
+	if element, ok := v.elements[path+"."+name]; ok && len(element.Type) == 1 {
+		return element, element.Type[0].Code, true
+	}
+	for _, element := range v.children[path] {
+		base := strings.TrimSuffix(element.Path[len(path)+1:], "[x]")
+		if base == element.Path[len(path)+1:] || !strings.HasPrefix(name, base) {
+			continue
+		}
+		for _, t := range element.Type {
+			if name[len(base):] == strings.ToUpper(t.Code[:1])+t.Code[1:] {
+				return element, t.Code, true
+			}
+		}
+	}
+	return elementDefinition{}, "", false
+}
+
+func (v *validator) element(element elementDefinition, typeCode, location string, value interface{}, problems *[]string) {
+	values, isArray := value.([]interface{})
+	switch {
+	case element.Max == "*" && !isArray:
+		*problems = append(*problems, location+": must be an array")
+	case element.Max != "*" && isArray:
+		*problems = append(*problems, location+": must not be an array")
+	case isArray && len(values) == 0:
+		*problems = append(*problems, location+": arrays must not be empty")
+	case isArray:
+		for i, item := range values {
+			v.value(element, typeCode, fmt.Sprintf("%s[%d]", location, i), item, problems)
+		}
+	default:
+		v.value(element, typeCode, location, value, problems)
+	}
+}
+
+func (v *validator) value(element elementDefinition, typeCode, location string, value interface{}, problems *[]string) {
+	switch typeCode {
+	case "Resource":
+		v.resource(location, value, problems)
+		return
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			*problems = append(*problems, location+": must be a boolean")
+		}
+		return
+	case "xhtml":
+		if problem := xhtmlProblem(value); problem != "" {
+			*problems = append(*problems, location+": "+problem)
+		}
+		return
+	}
+
+	if pattern, ok := primitives[typeCode]; ok {
+		var text string
+		switch value := value.(type) {
+		case string:
+			text = value
+		case json.Number:
+			text = value.String()
+			if typeCode != "unsignedInt" && typeCode != "positiveInt" && typeCode != "decimal" {
+				*problems = append(*problems, location+": must be a string")
+				return
+			}
+		default:
+			*problems = append(*problems, fmt.Sprintf("%s: must be a %s", location, typeCode))
+			return
+		}
+		if !pattern.MatchString(text) {
+			*problems = append(*problems, fmt.Sprintf("%s: %q is not a valid %s", location, text, typeCode))
+			return
+		}
+		if element.Binding != nil && element.Binding.Strength == "required" {
+			url := strings.SplitN(element.Binding.ValueSet, "|", 2)[0]
+			if !v.valueSets[url][text] {
+				*problems = append(*problems, fmt.Sprintf("%s: %q is not in %s", location, text, url))
+			}
+		}
+		return
+	}
+
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		*problems = append(*problems, fmt.Sprintf("%s: must be a %s", location, typeCode))
+		return
+	}
+	if typeCode == "BackboneElement" {
+		v.object(element.Path, location, object, problems)
+		return
+	}
+	if _, ok := v.elements[typeCode]; !ok {
+		*problems = append(*problems, fmt.Sprintf("%s: no definition of %s", location, typeCode))
+		return
+	}
+	v.object(typeCode, location, object, problems)
+}
+
+// xhtmlProblem checks a narrative is a well formed div in the XHTML namespace.
+func xhtmlProblem(value interface{}) string {
+	div, ok := value.(string)
+	if !ok {
+		return "must be a string"
+	}
+	if !strings.HasPrefix(div, `<div xmlns="`+xhtmlNamespace+`">`) {
+		return "must be a div in the XHTML namespace"
+	}
+	decoder := xml.NewDecoder(strings.NewReader(div))
+	depth := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			return ""
+		}
+		if err != nil {
+			return "not well formed: " + err.Error()
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			if token.Name.Local == "script" {
+				return "must not contain scripts"
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+		case xml.CharData:
+			if depth == 0 && len(bytes.TrimSpace(token)) > 0 {
+				return "text outside the div"
+			}
+		}
+	}
+}
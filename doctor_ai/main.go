@@ -4,10 +4,14 @@ import (
 	"io"
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"itish41/doctor_ai_assistant/initializers"
 	"itish41/doctor_ai_assistant/middleware"
 	"itish41/doctor_ai_assistant/route"
+	"itish41/doctor_ai_assistant/service"
+	"itish41/doctor_ai_assistant/storage"
 
 	"github.com/gin-gonic/gin"
 )
@@ -47,6 +51,60 @@ func main() {
 
 	gin.DefaultWriter = io.MultiWriter(f, os.Stdout) // connecting gin default writer to write to file and and terminal
 
+	// Choose the speech-to-text provider (TRANSCRIBER=assemblyai|fake)
+	transcriber, err := service.NewTranscriberFromEnv()
+	if err != nil {
+		log.Fatalf("[CRITICAL] Failed to configure transcriber: %s", err)
+	}
+	service.SetTranscriber(transcriber)
+
+	// Choose the report generation backend (REPORT_GENERATOR=openai|stub)
+	reportGenerator, err := service.NewReportGeneratorFromEnv()
+	if err != nil {
+		log.Fatalf("[CRITICAL] Failed to configure report generator: %s", err)
+	}
+	service.SetReportGenerator(reportGenerator)
+
+	// Choose where uploaded recordings are kept (STORAGE_BACKEND=local)
+	audioStorage, err := storage.NewFromEnv()
+	if err != nil {
+		log.Fatalf("[CRITICAL] Failed to configure audio storage: %s", err)
+	}
+	service.SetAudioStorage(audioStorage)
+	// Practice exports are kept alongside the recordings until they expire
+	service.SetExportStorage(audioStorage)
+
+	// Brand downloadable reports (REPORT_CLINIC_NAME, REPORT_LOGO, PDF_FONT and friends)
+	reportTemplate, err := service.NewReportTemplateFromEnv()
+	if err != nil {
+		log.Fatalf("[CRITICAL] Failed to configure report template: %s", err)
+	}
+	service.SetReportTemplate(reportTemplate)
+
+	// Start the background workers that transcribe new patients' recordings
+	transcriptionWorkers := service.StartTranscriptionWorkers(
+		envInt("TRANSCRIPTION_WORKERS", service.DefaultTranscriptionWorkers),
+		envInt("TRANSCRIPTION_MAX_RETRIES", service.DefaultTranscriptionMaxRetries),
+		service.DefaultTranscriptionRetryDelay,
+	)
+	defer transcriptionWorkers.Stop()
+
+	if os.Getenv("PUBLIC_BASE_URL") == "" {
+		log.Println("[WARNING] PUBLIC_BASE_URL is not set, absolute URLs in responses follow the request's Host header")
+	}
+	if !service.ExportLinksEnabled() {
+		log.Println("[WARNING] Neither EXPORT_LINK_SECRET nor JWT_SECRET is set, export download links are disabled")
+	}
+	// Start the background workers that build practice exports; archives are deleted
+	// EXPORT_RETENTION_HOURS after they are made
+	exportWorkers := service.StartExportWorkers(
+		envInt("EXPORT_WORKERS", service.DefaultExportWorkers),
+		time.Duration(envInt("EXPORT_RETENTION_HOURS", int(service.DefaultExportRetention/time.Hour)))*time.Hour,
+	)
+	defer exportWorkers.Stop()
+
+	// Reject requests that don't match /openapi.json (VALIDATE_REQUESTS=true)
+	route.ValidateRequests = os.Getenv("VALIDATE_REQUESTS") == "true"
 	route.SetupRoutes(router) // accessing the endpoints
 
 	// Get port from environment or use default
@@ -65,3 +123,12 @@ func main() {
 		log.Fatalf("Failed to start server: %v", err)
 	}
 }
+
+// envInt reads an integer setting from the environment, falling back to def when unset or invalid
+func envInt(key string, def int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return def
+	}
+	return value
+}
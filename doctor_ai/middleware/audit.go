@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+const (
+	auditResourceKey = "audit_resource_id"
+	auditPatientKey  = "audit_patient_id"
+)
+
+// Audit records an audit event for every request to the route once its handler has
+// run: the authenticated doctor, action, resource, client IP, user agent and outcome.
+// The resource ID defaults to the :id route parameter; handlers that read it from the
+// body, or create the resource, name it with SetAuditResource. Registered ahead of
+// RequireAuth, it also records rejected requests, without an actor. Denied events
+// leave the patient out, so a refused doctor can't learn from their own log whose
+// record they asked for.
+func Audit(action, resourceType string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Resolve the patient before the handler runs so deletes are still attributed
+		resourceID := c.Param("id")
+		var patientID *uuid.UUID
+		if resourceID != "" {
+			patientID = service.AuditPatientID(resourceType, resourceID)
+		}
+
+		c.Next()
+
+		if id := c.GetString(auditResourceKey); id != "" && id != resourceID {
+			resourceID = id
+			patientID = service.AuditPatientID(resourceType, resourceID)
+		}
+		if value, exists := c.Get(auditPatientKey); exists {
+			if id, ok := value.(uuid.UUID); ok {
+				patientID = &id
+			}
+		}
+
+		status := responseStatus(c)
+		outcome := auditOutcome(status)
+		if outcome == models.AuditOutcomeDenied {
+			patientID = nil
+		}
+
+		event := models.AuditEvent{
+			Action:       action,
+			ResourceType: resourceType,
+			ResourceID:   resourceID,
+			PatientID:    patientID,
+			IPAddress:    c.ClientIP(),
+			UserAgent:    c.Request.UserAgent(),
+			Outcome:      outcome,
+			StatusCode:   status,
+		}
+		if doctor, ok := CurrentDoctor(c); ok {
+			event.ActorID = &doctor.ID
+		}
+		if err := service.RecordAuditEvent(event); err != nil {
+			log.Println("Error recording audit event for", action, ":", err)
+		}
+	}
+}
+
+// SetAuditResource names the resource a request acted on, for routes without an :id parameter.
+func SetAuditResource(c *gin.Context, resourceID uuid.UUID) {
+	c.Set(auditResourceKey, resourceID.String())
+}
+
+// SetAuditPatient names the patient a request touched when the resource alone doesn't tell.
+func SetAuditPatient(c *gin.Context, patientID uuid.UUID) {
+	c.Set(auditPatientKey, patientID)
+}
+
+func auditOutcome(status int) string {
+	switch {
+	case status == http.StatusUnauthorized || status == http.StatusForbidden:
+		return models.AuditOutcomeDenied
+	case status >= http.StatusBadRequest:
+		return models.AuditOutcomeFailure
+	default:
+		return models.AuditOutcomeSuccess
+	}
+}
@@ -0,0 +1,193 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupAuditTestDB(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS transcriptions (
+			id TEXT PRIMARY KEY,
+			doctor_id TEXT NOT NULL,
+			patient_id TEXT NOT NULL,
+			text TEXT NOT NULL,
+			report TEXT NOT NULL
+		)`,
+		`CREATE TABLE IF NOT EXISTS audit_events (
+			id TEXT PRIMARY KEY,
+			actor_id TEXT,
+			action TEXT NOT NULL,
+			resource_type TEXT NOT NULL,
+			resource_id TEXT,
+			patient_id TEXT,
+			ip_address TEXT,
+			user_agent TEXT,
+			outcome TEXT NOT NULL,
+			status_code INTEGER NOT NULL DEFAULT 0,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("Failed to create table: %v", err)
+		}
+	}
+	initializers.DB = db
+}
+
+func auditEvents(t *testing.T) []models.AuditEvent {
+	var events []models.AuditEvent
+	if err := initializers.DB.Find(&events).Error; err != nil {
+		t.Fatalf("Failed to load audit events: %v", err)
+	}
+	return events
+}
+
+func TestAudit(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	t.Run("Records the actor, request and patient", func(t *testing.T) {
+		setupAuditTestDB(t)
+		doctor := models.Doctor{ID: uuid.New()}
+		transcriptionID, patientID := uuid.New(), uuid.New()
+		initializers.DB.Exec("INSERT INTO transcriptions (id, doctor_id, patient_id, text, report) VALUES (?, ?, ?, '', '')",
+			transcriptionID.String(), doctor.ID.String(), patientID.String())
+
+		router := gin.New()
+		router.DELETE("/transcription/:id", func(c *gin.Context) {
+			c.Set(DoctorContextKey, doctor)
+			c.Next()
+		}, Audit("transcription.delete", models.AuditResourceTranscription), func(c *gin.Context) {
+			// The row is gone by the time the event is written
+			initializers.DB.Exec("DELETE FROM transcriptions")
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/transcription/"+transcriptionID.String(), nil)
+		req.Header.Set("User-Agent", "audit-test")
+		req.RemoteAddr = "10.1.2.3:5555"
+		router.ServeHTTP(w, req)
+
+		events := auditEvents(t)
+		if assert.Len(t, events, 1) {
+			event := events[0]
+			assert.Equal(t, doctor.ID, *event.ActorID)
+			assert.Equal(t, "transcription.delete", event.Action)
+			assert.Equal(t, models.AuditResourceTranscription, event.ResourceType)
+			assert.Equal(t, transcriptionID.String(), event.ResourceID)
+			assert.Equal(t, patientID, *event.PatientID)
+			assert.Equal(t, "10.1.2.3", event.IPAddress)
+			assert.Equal(t, "audit-test", event.UserAgent)
+			assert.Equal(t, models.AuditOutcomeSuccess, event.Outcome)
+			assert.Equal(t, http.StatusOK, event.StatusCode)
+		}
+	})
+
+	t.Run("Handlers can name the resource and patient", func(t *testing.T) {
+		setupAuditTestDB(t)
+		resourceID, patientID := uuid.New(), uuid.New()
+
+		router := gin.New()
+		router.POST("/download", Audit("transcription.download", models.AuditResourceTranscription), func(c *gin.Context) {
+			SetAuditResource(c, resourceID)
+			SetAuditPatient(c, patientID)
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/download", nil)
+		router.ServeHTTP(w, req)
+
+		events := auditEvents(t)
+		if assert.Len(t, events, 1) {
+			assert.Equal(t, resourceID.String(), events[0].ResourceID)
+			assert.Equal(t, patientID, *events[0].PatientID)
+			assert.Nil(t, events[0].ActorID)
+		}
+	})
+
+	t.Run("Errors rendered by ErrorHandler keep their status", func(t *testing.T) {
+		setupAuditTestDB(t)
+
+		router := gin.New()
+		router.Use(ErrorHandler())
+		router.POST("/patients/:id", Audit("patient.delete", models.AuditResourcePatient), func(c *gin.Context) {
+			Abort(c, service.ErrForbidden)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/patients/"+uuid.NewString(), nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusForbidden, w.Code)
+		events := auditEvents(t)
+		if assert.Len(t, events, 1) {
+			assert.Equal(t, models.AuditOutcomeDenied, events[0].Outcome)
+			assert.Equal(t, http.StatusForbidden, events[0].StatusCode)
+			// The refused doctor would otherwise see whose patient it was in their own log
+			assert.Nil(t, events[0].PatientID)
+		}
+	})
+
+	t.Run("Requests RequireAuth rejects are recorded without an actor", func(t *testing.T) {
+		setupAuditTestDB(t)
+		transcriptionID := uuid.New()
+		initializers.DB.Exec("INSERT INTO transcriptions (id, doctor_id, patient_id, text, report) VALUES (?, ?, ?, '', '')",
+			transcriptionID.String(), uuid.NewString(), uuid.NewString())
+
+		router := gin.New()
+		router.Use(ErrorHandler())
+		router.GET("/transcriptions/:id", Audit("transcription.read", models.AuditResourceTranscription), RequireAuth(), func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/transcriptions/"+transcriptionID.String(), nil)
+		req.Header.Set("Authorization", "Bearer not-a-token")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusUnauthorized, w.Code)
+		events := auditEvents(t)
+		if assert.Len(t, events, 1) {
+			assert.Nil(t, events[0].ActorID)
+			assert.Nil(t, events[0].PatientID)
+			assert.Equal(t, "transcription.read", events[0].Action)
+			assert.Equal(t, transcriptionID.String(), events[0].ResourceID)
+			assert.Equal(t, models.AuditOutcomeDenied, events[0].Outcome)
+		}
+	})
+
+	t.Run("Outcome follows the response status", func(t *testing.T) {
+		tests := []struct {
+			status  int
+			outcome string
+		}{
+			{http.StatusCreated, models.AuditOutcomeSuccess},
+			{http.StatusForbidden, models.AuditOutcomeDenied},
+			{http.StatusUnauthorized, models.AuditOutcomeDenied},
+			{http.StatusNotFound, models.AuditOutcomeFailure},
+			{http.StatusInternalServerError, models.AuditOutcomeFailure},
+		}
+		for _, tt := range tests {
+			assert.Equal(t, tt.outcome, auditOutcome(tt.status), "status %d", tt.status)
+		}
+	})
+}
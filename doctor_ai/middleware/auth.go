@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"log"
+	"strings"
+
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// DoctorContextKey is the gin context key holding the authenticated doctor
+	DoctorContextKey = "doctor"
+	// ClaimsContextKey is the gin context key holding the verified access token claims
+	ClaimsContextKey = "token_claims"
+)
+
+// ErrInvalidAccessToken rejects a request without a valid, unrevoked access token.
+var ErrInvalidAccessToken error = &service.UnauthorizedError{Message: "missing or invalid access token"}
+
+// RequireAuth verifies the "Authorization: Bearer <token>" header and stores
+// the matching doctor in the gin context. Requests without a valid token are
+// rejected with 401 before reaching the handler.
+func RequireAuth() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Authorization")
+		tokenString, found := strings.CutPrefix(header, "Bearer ")
+		if !found || strings.TrimSpace(tokenString) == "" {
+			log.Println("Missing bearer token")
+			Abort(c, ErrInvalidAccessToken)
+			return
+		}
+
+		claims, err := ParseToken(strings.TrimSpace(tokenString))
+		if err != nil {
+			log.Println("Token verification failed:", err)
+			Abort(c, ErrInvalidAccessToken)
+			return
+		}
+
+		// Reject tokens revoked by logout; fail closed if the denylist can't be read
+		revoked, err := service.IsAccessTokenRevoked(claims.ID)
+		if err != nil || revoked {
+			log.Println("Access token is revoked or denylist unavailable:", claims.ID)
+			Abort(c, ErrInvalidAccessToken)
+			return
+		}
+
+		// Look up the doctor the token was issued for
+		var doctor models.Doctor
+		if err := initializers.DB.Where("email = ?", claims.Email).First(&doctor).Error; err != nil {
+			log.Println("Doctor for token not found:", err)
+			Abort(c, ErrInvalidAccessToken)
+			return
+		}
+
+		c.Set(DoctorContextKey, doctor)
+		c.Set(ClaimsContextKey, claims)
+		c.Next()
+	}
+}
+
+// CurrentDoctor returns the doctor stored by RequireAuth
+func CurrentDoctor(c *gin.Context) (models.Doctor, bool) {
+	value, exists := c.Get(DoctorContextKey)
+	if !exists {
+		return models.Doctor{}, false
+	}
+	doctor, ok := value.(models.Doctor)
+	return doctor, ok
+}
+
+// CurrentClaims returns the access token claims stored by RequireAuth
+func CurrentClaims(c *gin.Context) (*AccessClaims, bool) {
+	value, exists := c.Get(ClaimsContextKey)
+	if !exists {
+		return nil, false
+	}
+	claims, ok := value.(*AccessClaims)
+	return claims, ok
+}
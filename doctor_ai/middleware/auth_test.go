@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupAuthTestDB(t *testing.T) models.Doctor {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	err = db.Exec(`CREATE TABLE IF NOT EXISTS doctors (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		specialization TEXT NOT NULL,
+		email TEXT NOT NULL UNIQUE,
+		password TEXT NOT NULL,
+		phone TEXT NOT NULL UNIQUE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	if err != nil {
+		t.Fatalf("Failed to create doctors table: %v", err)
+	}
+
+	err = db.Exec(`CREATE TABLE IF NOT EXISTS revoked_tokens (
+		jti TEXT PRIMARY KEY,
+		expires_at DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	if err != nil {
+		t.Fatalf("Failed to create revoked_tokens table: %v", err)
+	}
+	initializers.DB = db
+
+	doctor := models.Doctor{
+		ID:             uuid.New(),
+		Name:           "Test Doctor",
+		Specialization: "Cardiology",
+		Email:          "test@example.com",
+		Password:       "hashedpassword",
+		Phone:          "1234567890",
+	}
+	if err := db.Create(&doctor).Error; err != nil {
+		t.Fatalf("Failed to create test doctor: %v", err)
+	}
+	return doctor
+}
+
+func TestRequireAuth(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	t.Setenv("JWT_SECRET", "test_secret_key")
+	doctor := setupAuthTestDB(t)
+
+	validToken, _ := GenerateToken(doctor.Email)
+	unknownToken, _ := GenerateToken("unknown@example.com")
+	revokedToken, _ := GenerateToken(doctor.Email)
+	revokedClaims, _ := ParseToken(revokedToken)
+	initializers.DB.Create(&models.RevokedToken{JTI: revokedClaims.ID, ExpiresAt: revokedClaims.ExpiresAt.Time})
+
+	tests := []struct {
+		name           string
+		header         string
+		expectedStatus int
+	}{
+		{name: "Valid token", header: "Bearer " + validToken, expectedStatus: http.StatusOK},
+		{name: "Missing header", header: "", expectedStatus: http.StatusUnauthorized},
+		{name: "Wrong scheme", header: "Basic " + validToken, expectedStatus: http.StatusUnauthorized},
+		{name: "Invalid token", header: "Bearer invalid", expectedStatus: http.StatusUnauthorized},
+		{name: "Unknown doctor", header: "Bearer " + unknownToken, expectedStatus: http.StatusUnauthorized},
+		{name: "Revoked token", header: "Bearer " + revokedToken, expectedStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(ErrorHandler())
+			router.GET("/protected", RequireAuth(), func(c *gin.Context) {
+				current, ok := CurrentDoctor(c)
+				assert.True(t, ok)
+				claims, ok := CurrentClaims(c)
+				assert.True(t, ok)
+				assert.NotEmpty(t, claims.ID)
+				c.JSON(http.StatusOK, gin.H{"id": current.ID})
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/protected", nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+			if tt.expectedStatus == http.StatusOK {
+				assert.Contains(t, w.Body.String(), doctor.ID.String())
+			}
+		})
+	}
+}
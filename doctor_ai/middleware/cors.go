@@ -45,8 +45,10 @@ func CORSMiddleware() gin.HandlerFunc {
 		c.Writer.Header().Set("Access-Control-Allow-Credentials", "true")
 
 		// Common CORS headers
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
 		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, Origin, X-Requested-With, Accept")
+		// Let browsers read the deprecation notice sent by the v1 routes
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "Deprecation, Link")
 
 		// Handle preflight requests (OPTIONS method)
 		if c.Request.Method == "OPTIONS" {
@@ -75,8 +75,9 @@ func TestCORSMiddleware(t *testing.T) {
 			// Check CORS headers
 			assert.Equal(t, tt.expectedOrigin, w.Header().Get("Access-Control-Allow-Origin"))
 			assert.Equal(t, "true", w.Header().Get("Access-Control-Allow-Credentials"))
-			assert.Equal(t, "GET, POST, PUT, DELETE, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
+			assert.Equal(t, "GET, POST, PUT, PATCH, DELETE, OPTIONS", w.Header().Get("Access-Control-Allow-Methods"))
 			assert.Equal(t, "Content-Type, Authorization, Origin, X-Requested-With, Accept", w.Header().Get("Access-Control-Allow-Headers"))
+			assert.Equal(t, "Deprecation, Link", w.Header().Get("Access-Control-Expose-Headers"))
 
 			// For OPTIONS requests (preflight), check status code
 			if tt.method == "OPTIONS" {
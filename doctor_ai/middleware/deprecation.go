@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Deprecated marks every response of a route as deprecated since the given time
+// (RFC 9745) and links to the route that replaces it.
+func Deprecated(since time.Time, successor string) gin.HandlerFunc {
+	deprecation := fmt.Sprintf("@%d", since.Unix())
+	link := fmt.Sprintf("<%s>; rel=\"successor-version\"", successor)
+	return func(c *gin.Context) {
+		c.Header("Deprecation", deprecation)
+		c.Header("Link", link)
+		c.Next()
+	}
+}
@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"errors"
+	"log"
+	"net/http"
+
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ProblemContentType is the media type of every error response.
+const ProblemContentType = "application/problem+json"
+
+// Problem is the RFC 9457 body sent for every failed request:
+//
+//	{
+//	  "type": "about:blank",
+//	  "title": "Not Found",
+//	  "status": 404,
+//	  "detail": "transcription not found",
+//	  "instance": "/transcription/3f2c.../getTranscriptionByID",
+//	  "errors": {"patient_id": "Invalid patient ID"},
+//	  "message": "transcription not found"
+//	}
+//
+// Title is the status text. Errors is only present for validation failures and
+// maps each offending field to what is wrong with it. Message repeats Detail for
+// clients written against the older {"message": ...} bodies. Unexpected failures
+// are reported as a 500 whose detail doesn't reveal the underlying error.
+type Problem struct {
+	Type     string            `json:"type"`
+	Title    string            `json:"title"`
+	Status   int               `json:"status"`
+	Detail   string            `json:"detail"`
+	Instance string            `json:"instance,omitempty"`
+	Errors   map[string]string `json:"errors,omitempty"`
+	Message  string            `json:"message"`
+}
+
+// ErrorHandler renders the last error a handler attached with Abort (or c.Error)
+// as a Problem, unless the handler already wrote a response.
+func ErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		problem := NewProblem(c.Errors.Last().Err)
+		problem.Instance = c.Request.URL.Path
+		if problem.Status == http.StatusInternalServerError {
+			log.Println("Unexpected error handling", c.Request.Method, c.Request.URL.Path, ":", c.Errors.Last().Err)
+		}
+
+		c.Header("Content-Type", ProblemContentType)
+		c.JSON(problem.Status, problem)
+	}
+}
+
+// Abort stops the handler chain and leaves err for ErrorHandler to render.
+func Abort(c *gin.Context, err error) {
+	_ = c.Error(err)
+	c.Abort()
+}
+
+// NewProblem describes err the way ErrorHandler renders it.
+func NewProblem(err error) Problem {
+	status := problemStatus(err)
+	problem := Problem{
+		Type:   "about:blank",
+		Title:  http.StatusText(status),
+		Status: status,
+		Detail: err.Error(),
+	}
+	if status == http.StatusInternalServerError {
+		problem.Detail = "An unexpected error occurred"
+	}
+
+	var validationErr *service.ValidationError
+	if errors.As(err, &validationErr) && status < http.StatusInternalServerError {
+		problem.Errors = validationErr.Fields
+	}
+	problem.Message = problem.Detail
+	return problem
+}
+
+// problemStatus maps a service error kind to its HTTP status code.
+func problemStatus(err error) int {
+	switch {
+	// An upstream failure can wrap any other kind; it's never the client's fault
+	case errors.Is(err, service.ErrUpstream):
+		return http.StatusBadGateway
+	case errors.Is(err, service.ErrAudioTooLarge):
+		return http.StatusRequestEntityTooLarge
+	case errors.Is(err, service.ErrUnsupportedAudioType):
+		return http.StatusUnsupportedMediaType
+	case errors.Is(err, service.ErrNotAcceptable):
+		return http.StatusNotAcceptable
+	case errors.Is(err, service.ErrValidation):
+		return http.StatusBadRequest
+	case errors.Is(err, service.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, service.ErrForbidden):
+		return http.StatusForbidden
+	case errors.Is(err, service.ErrNotFound):
+		return http.StatusNotFound
+	case errors.Is(err, service.ErrConflict):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// responseStatus is the status the request ends with, including an error
+// ErrorHandler has yet to render.
+func responseStatus(c *gin.Context) int {
+	if !c.Writer.Written() && len(c.Errors) > 0 {
+		return problemStatus(c.Errors.Last().Err)
+	}
+	return c.Writer.Status()
+}
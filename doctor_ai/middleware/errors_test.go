@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantDetail string
+		wantFields map[string]string
+	}{
+		{name: "Not found", err: service.ErrTranscriptionNotFound, wantStatus: http.StatusNotFound, wantDetail: "transcription not found"},
+		{name: "Forbidden", err: service.ErrForbidden, wantStatus: http.StatusForbidden, wantDetail: "resource belongs to another doctor"},
+		{name: "Conflict", err: service.ErrReportSigned, wantStatus: http.StatusConflict, wantDetail: "report is signed; create an amendment to edit it"},
+		{name: "Unauthorized", err: ErrInvalidAccessToken, wantStatus: http.StatusUnauthorized, wantDetail: "missing or invalid access token"},
+		{
+			name:       "Validation with fields",
+			err:        service.InvalidField("patient_id", "Invalid patient ID"),
+			wantStatus: http.StatusBadRequest,
+			wantDetail: "Invalid patient ID",
+			wantFields: map[string]string{"patient_id": "Invalid patient ID"},
+		},
+		{
+			name:       "Wrapped validation",
+			err:        fmt.Errorf("%w: missing diagnosis", service.ErrInvalidReport),
+			wantStatus: http.StatusBadRequest,
+			wantDetail: "invalid medical report: missing diagnosis",
+			wantFields: map[string]string{"structured_report": "invalid medical report"},
+		},
+		{
+			name:       "Audio too large",
+			err:        service.ErrAudioTooLarge,
+			wantStatus: http.StatusRequestEntityTooLarge,
+			wantDetail: "audio file exceeds the 50 MB limit",
+			wantFields: map[string]string{"audio": "audio file exceeds the 50 MB limit"},
+		},
+		{
+			name:       "Upstream wins over the error it wraps",
+			err:        &service.UpstreamError{Service: "report generator", Err: service.ErrInvalidReport},
+			wantStatus: http.StatusBadGateway,
+			wantDetail: "report generator: invalid medical report",
+		},
+		{name: "Unexpected errors stay private", err: errors.New("pq: connection refused"), wantStatus: http.StatusInternalServerError, wantDetail: "An unexpected error occurred"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router := gin.New()
+			router.Use(ErrorHandler())
+			router.GET("/things/:id", func(c *gin.Context) {
+				Abort(c, tt.err)
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/things/42", nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, ProblemContentType, w.Header().Get("Content-Type"))
+
+			var problem Problem
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+			assert.Equal(t, "about:blank", problem.Type)
+			assert.Equal(t, http.StatusText(tt.wantStatus), problem.Title)
+			assert.Equal(t, tt.wantStatus, problem.Status)
+			assert.Equal(t, tt.wantDetail, problem.Detail)
+			assert.Equal(t, tt.wantDetail, problem.Message)
+			assert.Equal(t, "/things/42", problem.Instance)
+			assert.Equal(t, tt.wantFields, problem.Errors)
+		})
+	}
+
+	t.Run("Responses already written are left alone", func(t *testing.T) {
+		router := gin.New()
+		router.Use(ErrorHandler())
+		router.GET("/", func(c *gin.Context) {
+			_ = c.Error(service.ErrPatientNotFound)
+			c.JSON(http.StatusOK, gin.H{"message": "ok"})
+		})
+
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"message": "ok"}`, w.Body.String())
+	})
+}
@@ -0,0 +1,59 @@
+package middleware
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+
+	"itish41/doctor_ai_assistant/fhir"
+
+	"github.com/gin-gonic/gin"
+)
+
+// FHIRErrorHandler renders the errors of FHIR routes as an OperationOutcome, the body
+// FHIR clients expect, instead of a Problem. Register it ahead of RequireAuth so
+// rejected tokens are reported the same way.
+func FHIRErrorHandler() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		problem := NewProblem(c.Errors.Last().Err)
+		if problem.Status == http.StatusInternalServerError {
+			log.Println("Unexpected error handling", c.Request.Method, c.Request.URL.Path, ":", c.Errors.Last().Err)
+		}
+
+		body, err := json.Marshal(fhir.NewOperationOutcome(issueType(problem.Status), problem.Detail))
+		if err != nil {
+			log.Println("Error encoding operation outcome:", err)
+			return
+		}
+		c.Data(problem.Status, fhir.ContentType, body)
+	}
+}
+
+// issueType is the FHIR IssueType code for a failed request's status.
+func issueType(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "invalid"
+	case http.StatusUnauthorized:
+		return "login"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not-found"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusNotAcceptable, http.StatusUnsupportedMediaType:
+		return "not-supported"
+	case http.StatusRequestEntityTooLarge:
+		return "too-costly"
+	case http.StatusBadGateway:
+		return "transient"
+	default:
+		return "exception"
+	}
+}
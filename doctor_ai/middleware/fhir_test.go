@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"itish41/doctor_ai_assistant/fhir"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFHIRErrorHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{name: "Not found", err: service.ErrPatientNotFound, wantStatus: http.StatusNotFound, wantCode: "not-found", wantDetail: "patient not found"},
+		{name: "Forbidden", err: service.ErrForbidden, wantStatus: http.StatusForbidden, wantCode: "forbidden", wantDetail: "resource belongs to another doctor"},
+		{name: "Unauthorized", err: ErrInvalidAccessToken, wantStatus: http.StatusUnauthorized, wantCode: "login", wantDetail: "missing or invalid access token"},
+		{name: "Validation", err: service.InvalidField("id", "Invalid patient ID"), wantStatus: http.StatusBadRequest, wantCode: "invalid", wantDetail: "Invalid patient ID"},
+		{name: "Unexpected errors stay private", err: errors.New("pq: connection refused"), wantStatus: http.StatusInternalServerError, wantCode: "exception", wantDetail: "An unexpected error occurred"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The API-wide handler runs outside the FHIR one and leaves its response alone
+			router := gin.New()
+			router.Use(ErrorHandler())
+			router.GET("/fhir/Patient/:id", FHIRErrorHandler(), func(c *gin.Context) {
+				Abort(c, tt.err)
+			})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/fhir/Patient/42", nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			assert.Equal(t, fhir.ContentType, w.Header().Get("Content-Type"))
+
+			var outcome fhir.OperationOutcome
+			assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &outcome))
+			assert.Equal(t, "OperationOutcome", outcome.ResourceType)
+			assert.Equal(t, []fhir.OperationOutcomeIssue{{Severity: "error", Code: tt.wantCode, Diagnostics: tt.wantDetail}}, outcome.Issue)
+		})
+	}
+}
@@ -6,27 +6,72 @@ import (
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
 )
 
-// GenerateToken creates a JWT token for the given email
+// AccessTokenTTL is the lifetime of an access token; sessions are extended through /auth/refresh
+const AccessTokenTTL = 15 * time.Minute
+
+// AccessClaims are the claims carried by an access token
+type AccessClaims struct {
+	Email string `json:"email"`
+	jwt.RegisteredClaims
+}
+
+// GenerateToken creates a short-lived JWT access token for the given email
 func GenerateToken(email string) (string, error) {
-	// Create a new token
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
-		"email": email,
-		"exp":   time.Now().Add(time.Hour * 24).Unix(), // Token expires in 24 hours
+	now := time.Now()
+
+	// Create a new token with a unique jti so it can be revoked individually
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, AccessClaims{
+		Email: email,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(),
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+		},
 	})
 
-	// Get the secret key from environment or use a default
+	// Sign and get the complete encoded token as a string
+	tokenString, err := token.SignedString(jwtSecret())
+	if err != nil {
+		return "", fmt.Errorf("failed to generate token: %v", err)
+	}
+
+	return tokenString, nil
+}
+
+// jwtSecret returns the signing key from the environment or a default
+func jwtSecret() []byte {
 	secretKey := os.Getenv("JWT_SECRET")
 	if secretKey == "" {
 		secretKey = "default_secret_key_please_set_in_env" // Fallback secret, not recommended for production
 	}
+	return []byte(secretKey)
+}
 
-	// Sign and get the complete encoded token as a string
-	tokenString, err := token.SignedString([]byte(secretKey))
+// ParseToken validates a signed JWT and returns its claims
+func ParseToken(tokenString string) (*AccessClaims, error) {
+	claims := &AccessClaims{}
+	token, err := jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		// Only accept the algorithm we sign with
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return jwtSecret(), nil
+	}, jwt.WithExpirationRequired())
 	if err != nil {
-		return "", fmt.Errorf("failed to generate token: %v", err)
+		return nil, fmt.Errorf("invalid token: %v", err)
+	}
+	if !token.Valid {
+		return nil, fmt.Errorf("invalid token")
 	}
 
-	return tokenString, nil
+	if claims.Email == "" {
+		return nil, fmt.Errorf("token does not carry an email claim")
+	}
+	if claims.ID == "" {
+		return nil, fmt.Errorf("token does not carry a jti claim")
+	}
+	return claims, nil
 }
@@ -74,10 +74,69 @@ func TestGenerateToken(t *testing.T) {
 			// Verify claims
 			assert.Equal(t, tt.email, claims["email"])
 
+			// Verify the token carries a jti for revocation
+			assert.NotEmpty(t, claims["jti"])
+
 			// Verify expiration time
 			exp := time.Unix(int64(claims["exp"].(float64)), 0)
-			expectedExp := time.Now().Add(time.Hour * 24)
+			expectedExp := time.Now().Add(AccessTokenTTL)
 			assert.WithinDuration(t, expectedExp, exp, time.Minute) // Allow 1 minute difference
 		})
 	}
 }
+
+func TestParseToken(t *testing.T) {
+	t.Setenv("JWT_SECRET", "test_secret_key")
+
+	validToken, err := GenerateToken("test@example.com")
+	assert.NoError(t, err)
+
+	expiredToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"email": "test@example.com",
+		"jti":   "expired-jti",
+		"exp":   time.Now().Add(-time.Hour).Unix(),
+	}).SignedString([]byte("test_secret_key"))
+
+	foreignToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"email": "test@example.com",
+		"jti":   "foreign-jti",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("another_secret"))
+
+	noExpiryToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"email": "test@example.com",
+		"jti":   "no-expiry-jti",
+	}).SignedString([]byte("test_secret_key"))
+
+	noJTIToken, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"email": "test@example.com",
+		"exp":   time.Now().Add(time.Hour).Unix(),
+	}).SignedString([]byte("test_secret_key"))
+
+	tests := []struct {
+		name        string
+		token       string
+		expected    string
+		shouldError bool
+	}{
+		{name: "Valid token", token: validToken, expected: "test@example.com"},
+		{name: "Expired token", token: expiredToken, shouldError: true},
+		{name: "Signed with another secret", token: foreignToken, shouldError: true},
+		{name: "Missing expiry", token: noExpiryToken, shouldError: true},
+		{name: "Missing jti", token: noJTIToken, shouldError: true},
+		{name: "Malformed token", token: "not-a-jwt", shouldError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := ParseToken(tt.token)
+			if tt.shouldError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, claims.Email)
+			assert.NotEmpty(t, claims.ID)
+		})
+	}
+}
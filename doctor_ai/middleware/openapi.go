@@ -0,0 +1,26 @@
+package middleware
+
+import (
+	"itish41/doctor_ai_assistant/openapi"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ValidateRequests rejects requests whose parameters or JSON body don't match the
+// route's OpenAPI operation with a 400 naming each offending field. Routes without
+// an operation are passed through.
+func ValidateRequests(ops openapi.Operations) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		op, ok := ops.Lookup(c.Request.Method, c.FullPath())
+		if !ok {
+			c.Next()
+			return
+		}
+		if problems := op.Validate(c.Request, c.Params); problems != nil {
+			Abort(c, &service.ValidationError{Message: "Request does not match the API specification", Fields: problems})
+			return
+		}
+		c.Next()
+	}
+}
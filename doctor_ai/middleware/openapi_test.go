@@ -0,0 +1,69 @@
+package middleware
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"itish41/doctor_ai_assistant/openapi"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ops := openapi.Operations{
+		"POST /things/:id": {RequestBody: openapi.JSONBody(&openapi.Schema{
+			Type:       "object",
+			Required:   []string{"name"},
+			Properties: map[string]*openapi.Schema{"name": {Type: "string"}},
+		})},
+	}
+
+	router := gin.New()
+	router.Use(ErrorHandler(), ValidateRequests(ops))
+	router.POST("/things/:id", func(c *gin.Context) {
+		var body struct {
+			Name string `json:"name"`
+		}
+		_ = c.ShouldBindJSON(&body)
+		c.JSON(http.StatusOK, gin.H{"name": body.Name})
+	})
+	router.POST("/undocumented", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	t.Run("Valid request reaches the handler with its body", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/things/3f2c1b7e-8d4a-4a8e-9c55-1d0b7f3a2e11", strings.NewReader(`{"name": "Jane"}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusOK, w.Code)
+		assert.JSONEq(t, `{"name": "Jane"}`, w.Body.String())
+	})
+
+	t.Run("Invalid request is rejected with its fields", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/things/42", strings.NewReader(`{}`))
+		req.Header.Set("Content-Type", "application/json")
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusBadRequest, w.Code)
+		var problem Problem
+		assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &problem))
+		assert.Equal(t, map[string]string{"id": "must be a UUID", "name": "is required"}, problem.Errors)
+	})
+
+	t.Run("Routes without an operation pass through", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("POST", "/undocumented", nil)
+		router.ServeHTTP(w, req)
+
+		assert.Equal(t, http.StatusNoContent, w.Code)
+	})
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// AudioFile is an uploaded recording kept in object storage under StorageKey.
+type AudioFile struct {
+	ID               uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	DoctorID         uuid.UUID `gorm:"type:uuid;not null;constraint:OnDelete:CASCADE;"`
+	StorageKey       string    `gorm:"type:varchar(512);unique;not null"`
+	OriginalFilename string    `gorm:"type:varchar(255);not null"`
+	ContentType      string    `gorm:"type:varchar(100);not null"`
+	Size             int64     `gorm:"not null"`
+	CreatedAt        time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+
+	// Relationships
+	Doctor Doctor `gorm:"foreignKey:DoctorID"`
+}
@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Outcomes of an audited action
+const (
+	AuditOutcomeSuccess = "success"
+	AuditOutcomeFailure = "failure"
+	AuditOutcomeDenied  = "denied"
+)
+
+// Resource types named in audit events
+const (
+	AuditResourcePatient          = "patient"
+	AuditResourceTranscription    = "transcription"
+	AuditResourceTranscriptionJob = "transcription_job"
+	AuditResourceEncounter        = "encounter"
+	AuditResourceAudioFile        = "audio_file"
+	AuditResourceDashboard        = "dashboard"
+	AuditResourceAuditLog         = "audit_log"
+	AuditResourceExport           = "export"
+)
+
+// AuditEvent records one read or change of patient data. Events are append-only and
+// deliberately have no foreign keys, so the history outlives the doctors and patients
+// it mentions.
+type AuditEvent struct {
+	ID           uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	ActorID      *uuid.UUID `gorm:"type:uuid;index"` // doctor who acted; nil when unknown
+	Action       string     `gorm:"type:varchar(100);not null;index"`
+	ResourceType string     `gorm:"type:varchar(50);not null"`
+	ResourceID   string     `gorm:"type:varchar(255)"`
+	PatientID    *uuid.UUID `gorm:"type:uuid;index"` // patient whose data was touched, when known
+	IPAddress    string     `gorm:"type:varchar(45)"`
+	UserAgent    string     `gorm:"type:text"`
+	Outcome      string     `gorm:"type:varchar(20);not null"`
+	StatusCode   int        // HTTP status; 0 for events raised outside a request
+	CreatedAt    time.Time  `gorm:"type:timestamp;default:CURRENT_TIMESTAMP;index"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Encounter is one visit of a patient to their doctor. Every recording made during
+// the visit is a transcription linked to it, so a returning patient keeps one
+// patient record with an encounter per visit.
+type Encounter struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	PatientID uuid.UUID `gorm:"type:uuid;not null;index:idx_encounters_patient_id_visit_date;constraint:OnDelete:CASCADE;"`
+	DoctorID  uuid.UUID `gorm:"type:uuid;not null;constraint:OnDelete:CASCADE;"`
+	VisitDate time.Time `gorm:"type:timestamp;not null;index:idx_encounters_patient_id_visit_date"`
+	Reason    string    `gorm:"type:text;not null;default:''"`
+	CreatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+
+	// Relationships
+	Patient        Patient         `gorm:"foreignKey:PatientID"`
+	Doctor         Doctor          `gorm:"foreignKey:DoctorID"`
+	Transcriptions []Transcription `gorm:"foreignKey:EncounterID"`
+}
@@ -0,0 +1,38 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses an export job moves through. A finished export expires once its archive has
+// been kept for the retention period and deleted.
+const (
+	ExportStatusQueued  = "queued"
+	ExportStatusRunning = "running"
+	ExportStatusDone    = "done"
+	ExportStatusFailed  = "failed"
+	ExportStatusExpired = "expired"
+)
+
+// ExportJob tracks the background export of all of a doctor's data as a ZIP archive,
+// kept in object storage under StorageKey until ExpiresAt.
+type ExportJob struct {
+	ID                 uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	DoctorID           uuid.UUID  `gorm:"type:uuid;not null;index;constraint:OnDelete:CASCADE;"`
+	Status             string     `gorm:"type:varchar(20);not null;index"`
+	LastError          string     `gorm:"type:text"`
+	StorageKey         string     `gorm:"type:varchar(512)"` // empty until done and again once expired
+	Size               int64      `gorm:"not null;default:0"`
+	Checksum           string     `gorm:"type:varchar(64)"` // hex SHA-256 of the archive
+	PatientCount       int        `gorm:"not null;default:0"`
+	TranscriptionCount int        `gorm:"not null;default:0"`
+	CompletedAt        *time.Time `gorm:"type:timestamp"`
+	ExpiresAt          *time.Time `gorm:"type:timestamp;index"`
+	CreatedAt          time.Time  `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	UpdatedAt          time.Time  `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+
+	// Relationships
+	Doctor Doctor `gorm:"foreignKey:DoctorID"`
+}
@@ -0,0 +1,72 @@
+package models
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// MedicalReport is the structured report generated from a consultation transcript.
+// It is stored as JSON in transcriptions.structured_report.
+type MedicalReport struct {
+	PatientInformation string   `json:"patient_information"`
+	PatientHistory     string   `json:"patient_history"`
+	Symptoms           []string `json:"symptoms"`
+	Diagnosis          string   `json:"diagnosis"`
+	TreatmentPlan      string   `json:"treatment_plan"`
+	Recommendations    []string `json:"recommendations"`
+}
+
+// Text renders the report as the numbered plain-text sections kept in Transcription.Report.
+func (r MedicalReport) Text() string {
+	var b strings.Builder
+	section := func(n int, title, body string) {
+		if body == "" {
+			body = "Not documented."
+		}
+		if n > 1 {
+			b.WriteString("\n\n")
+		}
+		fmt.Fprintf(&b, "%d. %s\n%s", n, title, body)
+	}
+	list := func(items []string) string {
+		lines := make([]string, 0, len(items))
+		for _, item := range items {
+			lines = append(lines, "- "+item)
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	section(1, "Patient Information", r.PatientInformation)
+	section(2, "Patient History", r.PatientHistory)
+	section(3, "Symptoms", list(r.Symptoms))
+	section(4, "Diagnosis", r.Diagnosis)
+	section(5, "Treatment Plan", r.TreatmentPlan)
+	section(6, "Recommendations", list(r.Recommendations))
+	return b.String()
+}
+
+// Value stores the report as JSON.
+func (r MedicalReport) Value() (driver.Value, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// Scan reads the report from a JSON column.
+func (r *MedicalReport) Scan(value interface{}) error {
+	var data []byte
+	switch v := value.(type) {
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return errors.New("unsupported type for MedicalReport")
+	}
+	return json.Unmarshal(data, r)
+}
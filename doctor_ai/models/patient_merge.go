@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// PatientMerge records a duplicate patient being merged into another of the doctor's
+// patients. The merged patient's row is deleted, so its details are kept here along
+// with how many of its records moved to the survivor.
+type PatientMerge struct {
+	ID                 uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	DoctorID           uuid.UUID `gorm:"type:uuid;not null;constraint:OnDelete:CASCADE;"`
+	SurvivorID         uuid.UUID `gorm:"type:uuid;not null;index"`
+	MergedID           uuid.UUID `gorm:"type:uuid;not null;index"`
+	MergedName         string    `gorm:"type:varchar(255);not null"`
+	MergedAge          int       `gorm:"not null"`
+	MergedGender       string    `gorm:"type:varchar(10);not null"`
+	MergedCreatedAt    time.Time `gorm:"type:timestamp"`
+	TranscriptionCount int       `gorm:"not null;default:0"`
+	EncounterCount     int       `gorm:"not null;default:0"`
+	JobCount           int       `gorm:"not null;default:0"`
+	CreatedAt          time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+}
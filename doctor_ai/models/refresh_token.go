@@ -0,0 +1,23 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken stores the hash of an issued refresh token. Tokens rotated from
+// the same login share a FamilyID so the whole chain can be revoked at once.
+type RefreshToken struct {
+	ID         uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	DoctorID   uuid.UUID  `gorm:"type:uuid;not null;constraint:OnDelete:CASCADE;"`
+	FamilyID   uuid.UUID  `gorm:"type:uuid;not null;index"`
+	TokenHash  string     `gorm:"type:varchar(64);unique;not null"`
+	ExpiresAt  time.Time  `gorm:"type:timestamp;not null"`
+	RevokedAt  *time.Time `gorm:"type:timestamp"`
+	ReplacedBy *uuid.UUID `gorm:"type:uuid"`
+	CreatedAt  time.Time  `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+
+	// Relationships
+	Doctor Doctor `gorm:"foreignKey:DoctorID"`
+}
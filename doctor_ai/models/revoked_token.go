@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+// RevokedToken is a denylist entry for an access token, keyed by its jti claim.
+// Entries only need to live until the token would have expired anyway.
+type RevokedToken struct {
+	JTI       string    `gorm:"column:jti;type:varchar(64);primaryKey"`
+	ExpiresAt time.Time `gorm:"type:timestamp;not null"`
+	CreatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+}
@@ -6,6 +6,16 @@ import (
 	"github.com/google/uuid"
 )
 
+// Review statuses of a transcription's report. A generated report starts as a draft
+// and must be reviewed and then signed by the doctor; a signed report can only be
+// changed after an amendment is opened, and the amended report is signed again.
+const (
+	ReportStatusDraft    = "draft"
+	ReportStatusReviewed = "reviewed"
+	ReportStatusSigned   = "signed"
+	ReportStatusAmended  = "amended"
+)
+
 type Transcription struct {
 	ID        uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
 	DoctorID  uuid.UUID `gorm:"type:uuid;not null;constraint:OnDelete:CASCADE;"`
@@ -14,8 +24,18 @@ type Transcription struct {
 	Report    string    `gorm:"type:text;not null"`
 	CreatedAt time.Time `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
 
+	StructuredReport *MedicalReport `gorm:"type:jsonb"` // Report is its plain-text rendering
+	AudioFileID      *uuid.UUID     `gorm:"type:uuid"`  // set when the recording was uploaded rather than linked
+	Status           string         `gorm:"type:varchar(20);not null;default:'draft';index"`
+	SignedByID       *uuid.UUID     `gorm:"type:uuid"` // doctor who last signed the report
+	SignedAt         *time.Time     `gorm:"type:timestamp"`
+	EncounterID      *uuid.UUID     `gorm:"type:uuid;index"` // visit the recording was made in
+
 	// Relationships
-	Doctor  Doctor  `gorm:"foreignKey:DoctorID"`
-	Patient Patient `gorm:"foreignKey:PatientID"`
+	Doctor    Doctor     `gorm:"foreignKey:DoctorID"`
+	Patient   Patient    `gorm:"foreignKey:PatientID"`
+	AudioFile *AudioFile `gorm:"foreignKey:AudioFileID"`
+	SignedBy  *Doctor    `gorm:"foreignKey:SignedByID"`
+	Encounter *Encounter `gorm:"foreignKey:EncounterID"`
 	// gorm.Model
 }
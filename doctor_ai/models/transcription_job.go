@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Statuses a transcription job moves through
+const (
+	JobStatusQueued       = "queued"
+	JobStatusTranscribing = "transcribing"
+	JobStatusEnhancing    = "enhancing"
+	JobStatusDone         = "done"
+	JobStatusFailed       = "failed"
+)
+
+// TranscriptionJob tracks the background transcription of a recording, either of a new
+// patient or of a visit by an existing one. The patient, encounter and transcription rows
+// are only created once the job succeeds, so a failed job never leaves a patient or an
+// encounter without a transcription behind.
+type TranscriptionJob struct {
+	ID              uuid.UUID  `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	DoctorID        uuid.UUID  `gorm:"type:uuid;not null;constraint:OnDelete:CASCADE;"`
+	PatientName     string     `gorm:"type:varchar(255);not null"`
+	PatientAge      int        `gorm:"not null"`
+	PatientGender   string     `gorm:"type:varchar(10);not null"`
+	AudioURL        string     `gorm:"type:text;not null"` // empty when AudioFileID is set
+	AudioFileID     *uuid.UUID `gorm:"type:uuid"`
+	Status          string     `gorm:"type:varchar(20);not null;index"`
+	RetryCount      int        `gorm:"not null;default:0"`
+	LastError       string     `gorm:"type:text"`
+	RawText         string     `gorm:"type:text"`
+	PatientID       *uuid.UUID `gorm:"type:uuid"` // set up front for an existing patient, on completion otherwise
+	TranscriptionID *uuid.UUID `gorm:"type:uuid"`
+	EncounterID     *uuid.UUID `gorm:"type:uuid"` // set up front to add to an existing encounter, on completion otherwise
+	VisitDate       *time.Time `gorm:"type:timestamp"`
+	EncounterReason string     `gorm:"type:text;not null;default:''"`
+	CreatedAt       time.Time  `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+	UpdatedAt       time.Time  `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+
+	// Relationships
+	Doctor Doctor `gorm:"foreignKey:DoctorID"`
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TranscriptionRevision keeps a transcription's content as it was before an edit.
+// Version counts up from 1 per transcription, so version 1 is what was generated.
+type TranscriptionRevision struct {
+	ID               uuid.UUID      `gorm:"type:uuid;default:gen_random_uuid();primaryKey"`
+	TranscriptionID  uuid.UUID      `gorm:"type:uuid;not null;uniqueIndex:idx_transcription_revisions_version;constraint:OnDelete:CASCADE;"`
+	Version          int            `gorm:"not null;uniqueIndex:idx_transcription_revisions_version"`
+	AuthorID         uuid.UUID      `gorm:"type:uuid;not null"` // doctor whose edit replaced this content
+	Text             string         `gorm:"type:text;not null"`
+	Report           string         `gorm:"type:text;not null"`
+	StructuredReport *MedicalReport `gorm:"type:jsonb"`
+	CreatedAt        time.Time      `gorm:"type:timestamp;default:CURRENT_TIMESTAMP"`
+
+	// Relationships
+	Transcription Transcription `gorm:"foreignKey:TranscriptionID"`
+	Author        Doctor        `gorm:"foreignKey:AuthorID"`
+}
@@ -0,0 +1,188 @@
+// Package openapi builds the OpenAPI 3 document served at /openapi.json from the
+// router's route table and validates requests against it.
+package openapi
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Version is the OpenAPI version of the generated document.
+const Version = "3.0.3"
+
+// Document is an OpenAPI document. Only the parts this API uses are modelled.
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title   string `json:"title"`
+	Version string `json:"version"`
+}
+
+// PathItem maps a lower-case HTTP method to its operation.
+type PathItem map[string]*Operation
+
+type Components struct {
+	Schemas         map[string]*Schema        `json:"schemas,omitempty"`
+	SecuritySchemes map[string]SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type"`
+	Scheme       string `json:"scheme"`
+	BearerFormat string `json:"bearerFormat,omitempty"`
+}
+
+// Operation describes one route. Public operations don't need a bearer token.
+// Path parameters are filled in from the route, so only query parameters are listed.
+type Operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Deprecated  bool                  `json:"deprecated,omitempty"`
+	Parameters  []Parameter           `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]Response   `json:"responses"`
+	Security    []map[string][]string `json:"security,omitempty"`
+	Public      bool                  `json:"-"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Required bool                 `json:"required,omitempty"`
+	Content  map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+type Response struct {
+	Description string               `json:"description"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// Operations documents every route, keyed by method and gin path, e.g. "GET /patients/:id".
+type Operations map[string]Operation
+
+// Key is the Operations key of a route.
+func Key(method, path string) string {
+	return method + " " + path
+}
+
+// Lookup returns the operation documenting a route.
+func (ops Operations) Lookup(method, path string) (Operation, bool) {
+	op, ok := ops[Key(method, path)]
+	return op, ok
+}
+
+// problemResponse is added to every operation as its default response.
+var problemResponse = Response{
+	Description: "Error",
+	Content:     map[string]MediaType{"application/problem+json": {Schema: &Schema{Ref: "#/components/schemas/Problem"}}},
+}
+
+// problemSchema mirrors middleware.Problem.
+var problemSchema = &Schema{
+	Type:     "object",
+	Required: []string{"type", "title", "status", "detail", "message"},
+	Properties: map[string]*Schema{
+		"type":     {Type: "string"},
+		"title":    {Type: "string"},
+		"status":   {Type: "integer"},
+		"detail":   {Type: "string"},
+		"instance": {Type: "string"},
+		"errors":   {Type: "object", AdditionalProperties: &Schema{Type: "string"}},
+		"message":  {Type: "string"},
+	},
+}
+
+// FromRoutes builds the document for routes. It fails when a route is undocumented
+// or an operation documents a route that doesn't exist, so the two can't drift apart.
+func FromRoutes(info Info, routes gin.RoutesInfo, ops Operations) (*Document, error) {
+	doc := &Document{
+		OpenAPI: Version,
+		Info:    info,
+		Paths:   map[string]PathItem{},
+		Components: Components{
+			Schemas:         map[string]*Schema{"Problem": problemSchema},
+			SecuritySchemes: map[string]SecurityScheme{"bearerAuth": {Type: "http", Scheme: "bearer", BearerFormat: "JWT"}},
+		},
+	}
+
+	var undocumented []string
+	routed := map[string]bool{}
+	for _, route := range routes {
+		key := Key(route.Method, route.Path)
+		op, ok := ops[key]
+		if !ok {
+			undocumented = append(undocumented, key)
+			continue
+		}
+		routed[key] = true
+
+		path, params := pathTemplate(route.Path)
+		op.Parameters = append(params, op.Parameters...)
+		responses := map[string]Response{"default": problemResponse}
+		for status, response := range op.Responses {
+			responses[status] = response
+		}
+		if len(op.Responses) == 0 {
+			responses["200"] = Response{Description: http.StatusText(http.StatusOK)}
+		}
+		op.Responses = responses
+		if !op.Public {
+			op.Security = []map[string][]string{{"bearerAuth": {}}}
+		}
+
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = PathItem{}
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = &op
+	}
+
+	var unrouted []string
+	for key := range ops {
+		if !routed[key] {
+			unrouted = append(unrouted, key)
+		}
+	}
+
+	if len(undocumented) > 0 || len(unrouted) > 0 {
+		sort.Strings(undocumented)
+		sort.Strings(unrouted)
+		return nil, fmt.Errorf("routes and OpenAPI operations differ: undocumented routes %v, operations without a route %v", undocumented, unrouted)
+	}
+	return doc, nil
+}
+
+var pathParamSchema = &Schema{Type: "string", Format: "uuid"}
+
+// pathTemplate turns a gin path like /patients/:id into /patients/{id} and lists its
+// path parameters. Every path parameter of this API is a UUID.
+func pathTemplate(path string) (string, []Parameter) {
+	segments := strings.Split(path, "/")
+	var params []Parameter
+	for i, segment := range segments {
+		if strings.HasPrefix(segment, ":") || strings.HasPrefix(segment, "*") {
+			name := segment[1:]
+			segments[i] = "{" + name + "}"
+			params = append(params, Parameter{Name: name, In: "path", Required: true, Schema: pathParamSchema})
+		}
+	}
+	return strings.Join(segments, "/"), params
+}
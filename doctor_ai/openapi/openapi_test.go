@@ -0,0 +1,136 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+type testRequest struct {
+	Name     string                 `json:"name" binding:"required"`
+	Age      int                    `json:"age"`
+	FileID   *uuid.UUID             `json:"file_id"`
+	Tags     []string               `json:"tags"`
+	Data     map[string]interface{} `json:"data"`
+	Ignored  string                 `json:"-"`
+	Untagged time.Time
+	Owner    *testRequest `gorm:"foreignKey:OwnerID"`
+}
+
+func TestSchemaOf(t *testing.T) {
+	schema := SchemaOf(testRequest{})
+
+	assert.Equal(t, "object", schema.Type)
+	assert.Equal(t, []string{"name"}, schema.Required)
+	assert.Equal(t, &Schema{Type: "string"}, schema.Properties["name"])
+	assert.Equal(t, &Schema{Type: "integer"}, schema.Properties["age"])
+	assert.Equal(t, &Schema{Type: "string", Format: "uuid", Nullable: true}, schema.Properties["file_id"])
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Type: "string"}}, schema.Properties["tags"])
+	assert.Equal(t, &Schema{Type: "object", AdditionalProperties: &Schema{}}, schema.Properties["data"])
+	assert.Equal(t, &Schema{Type: "string", Format: "date-time"}, schema.Properties["Untagged"])
+	assert.NotContains(t, schema.Properties, "Ignored")
+	assert.NotContains(t, schema.Properties, "Owner")
+}
+
+func TestFromRoutes(t *testing.T) {
+	routes := gin.RoutesInfo{
+		{Method: "GET", Path: "/patients/:id"},
+		{Method: "POST", Path: "/auth/login"},
+	}
+	ops := Operations{
+		"GET /patients/:id": {Summary: "Get a patient"},
+		"POST /auth/login":  {Summary: "Log in", Public: true, Responses: map[string]Response{"201": {Description: "Created"}}},
+	}
+
+	t.Run("Documents every route", func(t *testing.T) {
+		doc, err := FromRoutes(Info{Title: "Test", Version: "1"}, routes, ops)
+		assert.NoError(t, err)
+
+		get := doc.Paths["/patients/{id}"]["get"]
+		if assert.NotNil(t, get) {
+			assert.Equal(t, []Parameter{{Name: "id", In: "path", Required: true, Schema: pathParamSchema}}, get.Parameters)
+			assert.Equal(t, []map[string][]string{{"bearerAuth": {}}}, get.Security)
+			assert.Contains(t, get.Responses, "200")
+			assert.Contains(t, get.Responses, "default")
+		}
+
+		login := doc.Paths["/auth/login"]["post"]
+		if assert.NotNil(t, login) {
+			assert.Empty(t, login.Security)
+			assert.Contains(t, login.Responses, "201")
+			assert.NotContains(t, login.Responses, "200")
+		}
+		// The table itself is left untouched
+		assert.Len(t, ops["POST /auth/login"].Responses, 1)
+	})
+
+	t.Run("Undocumented route", func(t *testing.T) {
+		_, err := FromRoutes(Info{}, append(routes, gin.RouteInfo{Method: "DELETE", Path: "/patients/:id"}), ops)
+		assert.ErrorContains(t, err, "undocumented routes [DELETE /patients/:id]")
+	})
+
+	t.Run("Operation without a route", func(t *testing.T) {
+		_, err := FromRoutes(Info{}, routes[:1], ops)
+		assert.ErrorContains(t, err, "operations without a route [POST /auth/login]")
+	})
+}
+
+func TestValidate(t *testing.T) {
+	op := Operation{
+		Parameters: []Parameter{
+			Query("days", "", &Schema{Type: "integer"}),
+			{Name: "q", In: "query", Required: true, Schema: &Schema{Type: "string"}},
+			Query("status", "", &Schema{Type: "string", Enum: []string{"draft", "signed"}}),
+		},
+		RequestBody: JSONBody(SchemaOf(testRequest{})),
+	}
+	id := gin.Params{{Key: "id", Value: uuid.NewString()}}
+
+	tests := []struct {
+		name   string
+		query  string
+		params gin.Params
+		body   string
+		want   map[string]string
+	}{
+		{name: "Valid", query: "?q=x&days=7&status=draft", params: id, body: `{"Name": "Jane", "age": 30, "tags": ["a"], "file_id": null}`},
+		{name: "Bad path parameter", query: "?q=x", params: gin.Params{{Key: "id", Value: "42"}}, body: `{"name": "Jane"}`, want: map[string]string{"id": "must be a UUID"}},
+		{name: "Bad query parameters", query: "?days=week&status=closed", params: id, body: `{"name": "Jane"}`, want: map[string]string{
+			"days":   "must be an integer",
+			"q":      "is required",
+			"status": "must be one of draft, signed",
+		}},
+		{name: "Bad body fields", query: "?q=x", params: id, body: `{"age": 30.5, "tags": [1], "file_id": "x"}`, want: map[string]string{
+			"name":    "is required",
+			"age":     "must be an integer",
+			"tags[0]": "must be a string",
+			"file_id": "must be a UUID",
+		}},
+		{name: "Missing body", query: "?q=x", params: id, want: map[string]string{"body": "is required"}},
+		{name: "Invalid JSON", query: "?q=x", params: id, body: `{"name":`, want: map[string]string{"body": "must be valid JSON"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest("POST", "/things/x"+tt.query, strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+
+			assert.Equal(t, tt.want, op.Validate(req, tt.params))
+		})
+	}
+
+	t.Run("Body is left for the handler", func(t *testing.T) {
+		req, _ := http.NewRequest("POST", "/things/x?q=x", strings.NewReader(`{"name": "Jane"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		assert.Nil(t, op.Validate(req, id))
+		var body testRequest
+		assert.NoError(t, json.NewDecoder(req.Body).Decode(&body))
+		assert.Equal(t, "Jane", body.Name)
+	})
+}
@@ -0,0 +1,120 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Schema is an OpenAPI schema object.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty"`
+}
+
+var (
+	timeType = reflect.TypeOf(time.Time{})
+	uuidType = reflect.TypeOf(uuid.UUID{})
+)
+
+// SchemaOf describes the JSON a request struct is bound from. Property names follow
+// the json tags, binding:"required" fields are required and gorm relationships,
+// which are never bound, are left out.
+func SchemaOf(v interface{}) *Schema {
+	return schemaOfType(reflect.TypeOf(v))
+}
+
+func schemaOfType(t reflect.Type) *Schema {
+	nullable := false
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+		nullable = true
+	}
+
+	var schema *Schema
+	switch {
+	case t == timeType:
+		schema = &Schema{Type: "string", Format: "date-time"}
+	case t == uuidType:
+		schema = &Schema{Type: "string", Format: "uuid"}
+	default:
+		switch t.Kind() {
+		case reflect.String:
+			schema = &Schema{Type: "string"}
+		case reflect.Bool:
+			schema = &Schema{Type: "boolean"}
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			schema = &Schema{Type: "integer"}
+		case reflect.Float32, reflect.Float64:
+			schema = &Schema{Type: "number"}
+		case reflect.Slice, reflect.Array:
+			schema = &Schema{Type: "array", Items: schemaOfType(t.Elem())}
+		case reflect.Map:
+			schema = &Schema{Type: "object", AdditionalProperties: schemaOfType(t.Elem())}
+		case reflect.Struct:
+			schema = structSchema(t)
+		default:
+			// interface{} and anything else accept any JSON value
+			schema = &Schema{}
+		}
+	}
+	schema.Nullable = nullable
+	return schema
+}
+
+func structSchema(t reflect.Type) *Schema {
+	schema := &Schema{Type: "object", Properties: map[string]*Schema{}}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() || strings.Contains(field.Tag.Get("gorm"), "foreignKey") {
+			continue
+		}
+		name := field.Name
+		if tag := field.Tag.Get("json"); tag != "" {
+			if tag == "-" {
+				continue
+			}
+			if tagName := strings.Split(tag, ",")[0]; tagName != "" {
+				name = tagName
+			}
+		}
+		schema.Properties[name] = schemaOfType(field.Type)
+		for _, rule := range strings.Split(field.Tag.Get("binding"), ",") {
+			if rule == "required" {
+				schema.Required = append(schema.Required, name)
+			}
+		}
+	}
+	return schema
+}
+
+// JSONBody is a required JSON request body.
+func JSONBody(schema *Schema) *RequestBody {
+	return &RequestBody{Required: true, Content: map[string]MediaType{"application/json": {Schema: schema}}}
+}
+
+// OptionalJSONBody is a JSON request body that may be left out.
+func OptionalJSONBody(schema *Schema) *RequestBody {
+	return &RequestBody{Content: map[string]MediaType{"application/json": {Schema: schema}}}
+}
+
+// JSONResponse is a response with a JSON body.
+func JSONResponse(description string, schema *Schema) Response {
+	return Response{Description: description, Content: map[string]MediaType{"application/json": {Schema: schema}}}
+}
+
+// Query is an optional query parameter.
+func Query(name, description string, schema *Schema) Parameter {
+	return Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
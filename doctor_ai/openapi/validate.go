@@ -0,0 +1,238 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// maxValidatedBody is the largest JSON body Validate reads; larger bodies are left
+// for the handler to reject.
+const maxValidatedBody = 1 << 20
+
+// Validate checks a request's path and query parameters and JSON body against the
+// operation and returns what is wrong, keyed by parameter name or dotted body field.
+// The body is left in place for the handler to read. Property names are matched
+// case-insensitively, as encoding/json does when binding.
+func (op Operation) Validate(req *http.Request, pathParams gin.Params) map[string]string {
+	problems := map[string]string{}
+
+	// Path parameters aren't listed in the table; FromRoutes declares them all as UUIDs
+	for _, param := range pathParams {
+		if msg := checkString(pathParamSchema, param.Value); msg != "" {
+			problems[param.Key] = msg
+		}
+	}
+
+	for _, param := range op.Parameters {
+		if param.In != "query" {
+			continue
+		}
+		var value string
+		var present bool
+		if values, ok := req.URL.Query()[param.Name]; ok && len(values) > 0 {
+			value, present = values[0], true
+		}
+		if !present || value == "" {
+			if param.Required {
+				problems[param.Name] = "is required"
+			}
+			continue
+		}
+		if msg := checkString(param.Schema, value); msg != "" {
+			problems[param.Name] = msg
+		}
+	}
+
+	if op.RequestBody != nil {
+		validateBody(op.RequestBody, req, problems)
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+	return problems
+}
+
+func validateBody(body *RequestBody, req *http.Request, problems map[string]string) {
+	media, ok := body.Content["application/json"]
+	if !ok {
+		// Multipart uploads are streamed by their handler and not checked here
+		return
+	}
+	if req.Body == nil || req.Body == http.NoBody {
+		if body.Required {
+			problems["body"] = "is required"
+		}
+		return
+	}
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		if mediaType, _, err := mime.ParseMediaType(contentType); err != nil || mediaType != "application/json" {
+			problems["body"] = "must be application/json"
+			return
+		}
+	}
+
+	data, err := io.ReadAll(io.LimitReader(req.Body, maxValidatedBody+1))
+	req.Body.Close()
+	req.Body = io.NopCloser(bytes.NewReader(data))
+	if err != nil || len(data) > maxValidatedBody {
+		return
+	}
+	if len(bytes.TrimSpace(data)) == 0 {
+		if body.Required {
+			problems["body"] = "is required"
+		}
+		return
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		problems["body"] = "must be valid JSON"
+		return
+	}
+	if media.Schema != nil {
+		checkValue(media.Schema, value, "", problems)
+	}
+}
+
+// checkValue validates a decoded JSON value against schema, recording problems under path.
+func checkValue(schema *Schema, value interface{}, path string, problems map[string]string) {
+	field := path
+	if field == "" {
+		field = "body"
+	}
+	if value == nil {
+		if !schema.Nullable && schema.Type != "" {
+			problems[field] = "must not be null"
+		}
+		return
+	}
+
+	switch schema.Type {
+	case "object":
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			problems[field] = "must be an object"
+			return
+		}
+		for _, name := range schema.Required {
+			if _, ok := lookupProperty(object, name); !ok {
+				problems[join(path, name)] = "is required"
+			}
+		}
+		for name, property := range schema.Properties {
+			if propertyValue, ok := lookupProperty(object, name); ok {
+				checkValue(property, propertyValue, join(path, name), problems)
+			}
+		}
+		if schema.AdditionalProperties != nil && len(schema.Properties) == 0 {
+			for name, propertyValue := range object {
+				checkValue(schema.AdditionalProperties, propertyValue, join(path, name), problems)
+			}
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			problems[field] = "must be an array"
+			return
+		}
+		if schema.Items != nil {
+			for i, item := range items {
+				checkValue(schema.Items, item, field+"["+strconv.Itoa(i)+"]", problems)
+			}
+		}
+	case "string":
+		text, ok := value.(string)
+		if !ok {
+			problems[field] = "must be a string"
+			return
+		}
+		if msg := checkString(schema, text); msg != "" {
+			problems[field] = msg
+		}
+	case "integer":
+		number, ok := value.(float64)
+		if !ok || number != float64(int64(number)) {
+			problems[field] = "must be an integer"
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			problems[field] = "must be a number"
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			problems[field] = "must be a boolean"
+		}
+	}
+}
+
+// checkString validates a parameter or string property, returning what is wrong with it.
+func checkString(schema *Schema, value string) string {
+	if schema == nil {
+		return ""
+	}
+	switch schema.Type {
+	case "integer":
+		if _, err := strconv.Atoi(value); err != nil {
+			return "must be an integer"
+		}
+		return ""
+	case "boolean":
+		if _, err := strconv.ParseBool(value); err != nil {
+			return "must be a boolean"
+		}
+		return ""
+	}
+
+	switch schema.Format {
+	case "uuid":
+		if _, err := uuid.Parse(value); err != nil {
+			return "must be a UUID"
+		}
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, value); err != nil {
+			return "must be an RFC 3339 date-time"
+		}
+	case "date":
+		if _, err := time.Parse(time.DateOnly, value); err != nil {
+			return "must be a YYYY-MM-DD date"
+		}
+	}
+	if len(schema.Enum) > 0 {
+		for _, allowed := range schema.Enum {
+			if value == allowed {
+				return ""
+			}
+		}
+		return "must be one of " + strings.Join(schema.Enum, ", ")
+	}
+	return ""
+}
+
+func lookupProperty(object map[string]interface{}, name string) (interface{}, bool) {
+	if value, ok := object[name]; ok {
+		return value, true
+	}
+	for key, value := range object {
+		if strings.EqualFold(key, name) {
+			return value, true
+		}
+	}
+	return nil, false
+}
+
+func join(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
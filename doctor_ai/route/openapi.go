@@ -0,0 +1,489 @@
+package route
+
+import (
+	"log"
+	"mime"
+	"net/http"
+	"sync"
+
+	"itish41/doctor_ai_assistant/controller"
+	"itish41/doctor_ai_assistant/middleware"
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/openapi"
+	"itish41/doctor_ai_assistant/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+var apiInfo = openapi.Info{Title: "Doctor AI Assistant API", Version: "2.0.0"}
+
+// Reusable parameters and schemas
+var (
+	pageParam      = openapi.Query("page", "Page number, starting at 1", &openapi.Schema{Type: "integer"})
+	limitParam     = openapi.Query("limit", "Page size", &openapi.Schema{Type: "integer"})
+	daysParam      = openapi.Query("days", "Number of days to cover", &openapi.Schema{Type: "integer"})
+	monthsParam    = openapi.Query("months", "Number of months to cover", &openapi.Schema{Type: "integer"})
+	patientIDParam = openapi.Query("patient_id", "Only this patient's records", &openapi.Schema{Type: "string", Format: "uuid"})
+	statusParam    = openapi.Query("status", "Only reports in this status", reportStatusSchema)
+
+	// Lists are ordered newest first and can be paged by number or by cursor
+	cursorParam       = openapi.Query("cursor", "next_cursor or prev_cursor of a previous page; takes precedence over page", &openapi.Schema{Type: "string"})
+	includeTotalParam = openapi.Query("include_total", "Count all matches; defaults to true without a cursor and false with one", &openapi.Schema{Type: "boolean"})
+
+	reportStatusSchema = &openapi.Schema{Type: "string", Enum: []string{
+		models.ReportStatusDraft, models.ReportStatusReviewed, models.ReportStatusSigned, models.ReportStatusAmended,
+	}}
+	updateDataSchema = &openapi.Schema{Type: "object", AdditionalProperties: &openapi.Schema{}}
+	audioUpload      = &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{
+		"multipart/form-data": {Schema: &openapi.Schema{
+			Type:       "object",
+			Required:   []string{"audio"},
+			Properties: map[string]*openapi.Schema{"audio": {Type: "string", Format: "binary"}},
+		}},
+	}}
+	auditEventParams = []openapi.Parameter{
+		patientIDParam,
+		openapi.Query("doctor_id", "Only events by this doctor", &openapi.Schema{Type: "string", Format: "uuid"}),
+		openapi.Query("action", "Only this action, e.g. patient.read", &openapi.Schema{Type: "string"}),
+		openapi.Query("from", "RFC 3339 time or YYYY-MM-DD date, inclusive", &openapi.Schema{Type: "string"}),
+		openapi.Query("to", "RFC 3339 time or YYYY-MM-DD date, inclusive", &openapi.Schema{Type: "string"}),
+		pageParam,
+		limitParam,
+	}
+
+	transcriptionSchema = &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{
+		"id":                {Type: "string", Format: "uuid"},
+		"patient_id":        {Type: "string", Format: "uuid"},
+		"created_at":        {Type: "string", Format: "date-time"},
+		"text":              {Type: "string"},
+		"report":            {Type: "string"},
+		"structured_report": openapi.SchemaOf(&models.MedicalReport{}),
+		"status":            reportStatusSchema,
+		"signed_by":         {Type: "string", Format: "uuid", Nullable: true},
+		"signed_at":         {Type: "string", Format: "date-time", Nullable: true},
+		"encounter_id":      {Type: "string", Format: "uuid", Nullable: true},
+	}}
+	// transcriptionPatchSchema lists the only fields a transcription edit may set
+	transcriptionPatchSchema = &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{
+		"text":              {Type: "string"},
+		"report":            {Type: "string"},
+		"structured_report": openapi.SchemaOf(&models.MedicalReport{}),
+	}}
+	exportSchema = &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{
+		"job_id": {Type: "string", Format: "uuid"},
+		"status": {Type: "string", Enum: []string{
+			models.ExportStatusQueued, models.ExportStatusRunning, models.ExportStatusDone, models.ExportStatusFailed, models.ExportStatusExpired,
+		}},
+		"error":               {Type: "string"},
+		"patient_count":       {Type: "integer"},
+		"transcription_count": {Type: "integer"},
+		"size":                {Type: "integer", Description: "Bytes in the archive"},
+		"sha256":              {Type: "string", Description: "Hex SHA-256 of the archive"},
+		"created_at":          {Type: "string", Format: "date-time"},
+		"completed_at":        {Type: "string", Format: "date-time", Nullable: true},
+		"expires_at":          {Type: "string", Format: "date-time", Nullable: true, Description: "When the archive is deleted"},
+		"download_url":        {Type: "string", Nullable: true},
+		"download_expires_at": {Type: "string", Format: "date-time", Nullable: true},
+	}}
+	patientSchema = &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{
+		"id":            {Type: "string", Format: "uuid"},
+		"name":          {Type: "string"},
+		"age":           {Type: "integer"},
+		"gender":        {Type: "string"},
+		"created_at":    {Type: "string", Format: "date-time"},
+		"transcription": transcriptionSchema,
+	}}
+)
+
+func okJSON(description string, schema *openapi.Schema) map[string]openapi.Response {
+	return map[string]openapi.Response{"200": openapi.JSONResponse(description, schema)}
+}
+
+// deprecated marks a v1 operation as superseded by its /api/v2 equivalent.
+func deprecated(op openapi.Operation) openapi.Operation {
+	op.Deprecated = true
+	return op
+}
+
+// operations documents every route SetupRoutes registers. TestOpenAPIMatchesRoutes
+// fails when a route is added, removed or renamed without updating this table.
+var operations = openapi.Operations{
+	"GET /openapi.json": {Summary: "This OpenAPI document", Tags: []string{"meta"}, Public: true},
+
+	// v1 routes
+	"POST /auth/signup":  deprecated(signUpOp),
+	"POST /auth/login":   deprecated(loginOp),
+	"POST /auth/refresh": deprecated(refreshOp),
+	"POST /auth/logout":  deprecated(logoutOp),
+	"POST /auth/me":      deprecated(getProfileOp),
+	"PUT /auth/me":       deprecated(updateProfileOp),
+
+	"POST /transcription/": deprecated(openapi.Operation{
+		Summary: "List transcriptions", Tags: []string{"transcriptions"},
+		Parameters: []openapi.Parameter{pageParam, limitParam, cursorParam, includeTotalParam, patientIDParam, statusParam},
+	}),
+	"POST /transcription/:id/download": deprecated(downloadTranscriptionOp),
+	"POST /transcription/:id/getTranscriptionByID": deprecated(openapi.Operation{
+		Summary: "Get a transcription named in the body", Tags: []string{"transcriptions"},
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(controller.TranscriptionIDRequest{})),
+	}),
+	"PUT /transcription/:id": deprecated(openapi.Operation{
+		Summary: "Update a transcription", Tags: []string{"transcriptions"},
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(controller.UpdateTranscriptionRequest{})),
+	}),
+	"POST /transcription/:id/delete": deprecated(openapi.Operation{
+		Summary: "Delete a transcription", Tags: []string{"transcriptions"},
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(controller.TranscriptionIDRequest{})),
+	}),
+	"POST /transcription/:id/status":                         deprecated(transcriptionStatusOp),
+	"GET /transcription/:id/revisions":                       deprecated(listRevisionsOp),
+	"GET /transcription/:id/revisions/:revision_id/diff":     deprecated(diffRevisionOp),
+	"POST /transcription/:id/revisions/:revision_id/restore": deprecated(restoreRevisionOp),
+	"GET /transcription/search":                              deprecated(searchTranscriptionsOp),
+
+	"POST /patients/": deprecated(createPatientOp),
+	"POST /patients/patientsList": deprecated(openapi.Operation{
+		Summary: "List patients", Tags: []string{"patients"},
+		Parameters: []openapi.Parameter{pageParam, limitParam, cursorParam, includeTotalParam},
+	}),
+	"GET /patients/search": deprecated(searchPatientsOp),
+	"POST /patients/:id/getPatient": deprecated(openapi.Operation{
+		Summary: "Get a patient named in the body", Tags: []string{"patients"},
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(controller.PatientIDRequest{})),
+	}),
+	"PUT /patients/:id/update": deprecated(openapi.Operation{
+		Summary: "Update a patient named in the body", Tags: []string{"patients"},
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(controller.UpdatePatientRequest{})),
+	}),
+	"POST /patients/:id": deprecated(openapi.Operation{
+		Summary: "Delete a patient named in the body", Tags: []string{"patients"},
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(controller.PatientIDRequest{})),
+	}),
+	"POST /patients/transcript": deprecated(openapi.Operation{
+		Summary: "Get a patient's latest transcription by name", Tags: []string{"transcriptions"},
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(controller.PatientTranscriptRequest{})),
+	}),
+	"GET /patients/jobs/:id": deprecated(transcriptionJobOp),
+
+	"POST /dashboard/transcripts":             deprecated(dashboardOp("Recent transcriptions", false)),
+	"POST /dashboard/patients":                deprecated(dashboardOp("Recent patients", false)),
+	"POST /dashboard/statistics/daily":        deprecated(dashboardOp("Transcriptions per day", false)),
+	"POST /dashboard/statistics/monthly":      deprecated(dashboardOp("Transcriptions per month", true)),
+	"POST /dashboard/statistics/busiest-days": deprecated(dashboardOp("Busiest days", false)),
+	"POST /upload_audio":                      deprecated(uploadAudioOp),
+	"GET /audit/events":                       deprecated(auditEventsOp),
+
+	// v2 routes
+	"POST /api/v2/auth/signup":  signUpOp,
+	"POST /api/v2/auth/login":   loginOp,
+	"POST /api/v2/auth/refresh": refreshOp,
+	"POST /api/v2/auth/logout":  logoutOp,
+	"GET /api/v2/auth/me":       getProfileOp,
+	"PATCH /api/v2/auth/me":     updateProfileOp,
+
+	"GET /api/v2/transcriptions": {
+		Summary: "List transcriptions", Tags: []string{"transcriptions"},
+		Parameters: []openapi.Parameter{pageParam, limitParam, cursorParam, includeTotalParam, patientIDParam, statusParam},
+	},
+	"GET /api/v2/transcriptions/latest": {
+		Summary: "Get a patient's latest transcription by name", Tags: []string{"transcriptions"},
+		Parameters: []openapi.Parameter{{Name: "patient_name", In: "query", Required: true, Schema: &openapi.Schema{Type: "string"}}},
+		Responses:  okJSON("Transcription", transcriptionSchema),
+	},
+	"GET /api/v2/transcriptions/search": searchTranscriptionsOp,
+	"GET /api/v2/transcriptions/:id": {
+		Summary: "Get a transcription", Tags: []string{"transcriptions"},
+		Responses: okJSON("Transcription", transcriptionSchema),
+	},
+	"PATCH /api/v2/transcriptions/:id": {
+		Summary: "Update a transcription's text or report", Tags: []string{"transcriptions"},
+		RequestBody: openapi.JSONBody(transcriptionPatchSchema),
+		Responses:   okJSON("Updated transcription", transcriptionSchema),
+	},
+	"DELETE /api/v2/transcriptions/:id": {
+		Summary: "Delete a transcription", Tags: []string{"transcriptions"},
+		Responses: map[string]openapi.Response{"204": {Description: "Deleted"}},
+	},
+	"GET /api/v2/transcriptions/:id/download":                        downloadTranscriptionOp,
+	"POST /api/v2/transcriptions/:id/status":                         transcriptionStatusOp,
+	"GET /api/v2/transcriptions/:id/revisions":                       listRevisionsOp,
+	"GET /api/v2/transcriptions/:id/revisions/:revision_id/diff":     diffRevisionOp,
+	"POST /api/v2/transcriptions/:id/revisions/:revision_id/restore": restoreRevisionOp,
+
+	"GET /api/v2/patients": {
+		Summary: "List patients", Tags: []string{"patients"},
+		Parameters: []openapi.Parameter{pageParam, limitParam, cursorParam, includeTotalParam},
+	},
+	"POST /api/v2/patients":       createPatientOp,
+	"GET /api/v2/patients/search": searchPatientsOp,
+	"GET /api/v2/patients/duplicates": {
+		Summary: "Suggest likely duplicate patients, best match first", Tags: []string{"patients"},
+		Parameters: []openapi.Parameter{
+			openapi.Query("patient_id", "Only pairs including this patient", &openapi.Schema{Type: "string", Format: "uuid"}),
+			openapi.Query("min_score", "Lowest score to suggest, between 0 and 1; defaults to 0.7", &openapi.Schema{Type: "number"}),
+			limitParam,
+		},
+		Responses: okJSON("Likely duplicates", &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{
+			"duplicates": {Type: "array", Items: &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{
+				"patient":    patientSchema,
+				"duplicate":  patientSchema,
+				"score":      {Type: "number", Description: "Name, age and gender agreement, 0 to 1"},
+				"name_score": {Type: "number", Description: "Trigram similarity of the names, 0 to 1"},
+			}}},
+		}}),
+	},
+	"GET /api/v2/patients/:id": {
+		Summary: "Get a patient with their latest transcription", Tags: []string{"patients"},
+		Responses: okJSON("Patient", patientSchema),
+	},
+	"PATCH /api/v2/patients/:id": {
+		Summary: "Update a patient's name, age or gender", Tags: []string{"patients"},
+		RequestBody: openapi.JSONBody(updateDataSchema),
+		Responses:   okJSON("Updated patient", patientSchema),
+	},
+	"DELETE /api/v2/patients/:id": {
+		Summary: "Delete a patient with their transcriptions", Tags: []string{"patients"},
+		Responses: map[string]openapi.Response{"204": {Description: "Deleted"}},
+	},
+	"POST /api/v2/patients/:id/recordings": {
+		Summary: "Add a recording of an existing patient's visit", Tags: []string{"patients"},
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(controller.AddRecordingRequest{})),
+		Responses:   map[string]openapi.Response{"202": {Description: "Transcription job queued"}},
+	},
+	"GET /api/v2/patients/:id/timeline": {
+		Summary: "List a patient's encounters, oldest visit first", Tags: []string{"patients"},
+		Responses: okJSON("Patient timeline", &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{
+			"patient": patientSchema,
+			"encounters": {Type: "array", Items: &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{
+				"id":             {Type: "string", Format: "uuid"},
+				"visit_date":     {Type: "string", Format: "date-time"},
+				"reason":         {Type: "string"},
+				"created_at":     {Type: "string", Format: "date-time"},
+				"transcriptions": {Type: "array", Items: transcriptionSchema},
+			}}},
+		}}),
+	},
+	"POST /api/v2/patients/:id/merge": {
+		Summary: "Merge a duplicate into this patient", Tags: []string{"patients"},
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(controller.MergePatientRequest{})),
+		Responses: okJSON("Record of the merge", &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{
+			"id":                  {Type: "string", Format: "uuid"},
+			"patient_id":          {Type: "string", Format: "uuid"},
+			"merged_id":           {Type: "string", Format: "uuid"},
+			"merged_name":         {Type: "string"},
+			"merged_age":          {Type: "integer"},
+			"merged_gender":       {Type: "string"},
+			"transcription_count": {Type: "integer"},
+			"encounter_count":     {Type: "integer"},
+			"job_count":           {Type: "integer"},
+			"created_at":          {Type: "string", Format: "date-time"},
+		}}),
+	},
+
+	"GET /api/v2/transcription-jobs/:id": transcriptionJobOp,
+	"POST /api/v2/audio":                 uploadAudioOp,
+
+	"GET /api/v2/dashboard/transcripts":             dashboardOp("Recent transcriptions", false),
+	"GET /api/v2/dashboard/patients":                dashboardOp("Recent patients", false),
+	"GET /api/v2/dashboard/statistics/daily":        dashboardOp("Transcriptions per day", false),
+	"GET /api/v2/dashboard/statistics/monthly":      dashboardOp("Transcriptions per month", true),
+	"GET /api/v2/dashboard/statistics/busiest-days": dashboardOp("Busiest days", false),
+	"GET /api/v2/audit-events":                      auditEventsOp,
+
+	"POST /api/v2/exports": {
+		Summary: "Export all of the doctor's data as a ZIP archive", Tags: []string{"exports"},
+		Responses: map[string]openapi.Response{"202": openapi.JSONResponse("Export queued", exportSchema)},
+	},
+	"GET /api/v2/exports/:id": {
+		Summary: "Poll an export; a done export carries a signed download link", Tags: []string{"exports"},
+		Responses: okJSON("The export", exportSchema),
+	},
+	"GET /api/v2/exports/:id/download": {
+		Summary: "Download an export's archive with a signed link", Tags: []string{"exports"}, Public: true,
+		Parameters: []openapi.Parameter{
+			{Name: "expires", In: "query", Description: "Expiry of the link, from download_url", Required: true, Schema: &openapi.Schema{Type: "integer"}},
+			{Name: "signature", In: "query", Description: "Signature of the link, from download_url", Required: true, Schema: &openapi.Schema{Type: "string"}},
+		},
+		Responses: map[string]openapi.Response{"200": {
+			Description: "ZIP of profile.json, patients, encounters and transcriptions as NDJSON and CSV, report PDFs and manifest.json",
+			Content:     map[string]openapi.MediaType{"application/zip": {}},
+		}},
+	},
+
+	"GET /api/v2/fhir/Patient/:id":             fhirOp("Export a patient", "Patient"),
+	"GET /api/v2/fhir/Patient/:id/$everything": fhirOp("Export everything about a patient: their encounters and reports", "Bundle"),
+	"GET /api/v2/fhir/Practitioner/:id":        fhirOp("Export the authenticated doctor", "Practitioner"),
+	"GET /api/v2/fhir/Encounter/:id":           fhirOp("Export an encounter", "Encounter"),
+	"GET /api/v2/fhir/Composition/:id":         fhirOp("Export a transcription's report as a consult note", "Composition"),
+	"GET /api/v2/fhir/DiagnosticReport/:id":    fhirOp("Export a transcription's report as a diagnostic report", "DiagnosticReport"),
+	"GET /api/v2/fhir/DocumentReference/:id":   fhirOp("Export a transcription's report as a document reference", "DocumentReference"),
+}
+
+// Operations shared by a v1 route and its v2 successor
+var (
+	signUpOp = openapi.Operation{
+		Summary: "Register a doctor", Tags: []string{"auth"}, Public: true,
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(models.Doctor{})),
+	}
+	loginOp = openapi.Operation{
+		Summary: "Log in and receive an access and refresh token", Tags: []string{"auth"}, Public: true,
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(models.Doctor{})),
+	}
+	refreshOp = openapi.Operation{
+		Summary: "Rotate a refresh token", Tags: []string{"auth"}, Public: true,
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(controller.RefreshTokenRequest{})),
+	}
+	logoutOp = openapi.Operation{
+		Summary: "Revoke the access token and optionally the refresh token", Tags: []string{"auth"},
+		RequestBody: openapi.OptionalJSONBody(openapi.SchemaOf(controller.RefreshTokenRequest{})),
+	}
+	getProfileOp    = openapi.Operation{Summary: "Get the doctor's profile", Tags: []string{"auth"}}
+	updateProfileOp = openapi.Operation{
+		Summary: "Update the doctor's profile", Tags: []string{"auth"},
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(controller.UpdateProfileRequest{})),
+	}
+	transcriptionStatusOp = openapi.Operation{
+		Summary: "Review, sign or amend a report", Tags: []string{"transcriptions"},
+		RequestBody: openapi.JSONBody(&openapi.Schema{
+			Type:       "object",
+			Required:   []string{"status"},
+			Properties: map[string]*openapi.Schema{"status": reportStatusSchema},
+		}),
+	}
+	downloadTranscriptionOp = openapi.Operation{
+		Summary: "Download a transcription's report", Tags: []string{"transcriptions"},
+		Parameters: []openapi.Parameter{
+			openapi.Query("format", "Document format; without it the Accept header picks one, pdf by default",
+				&openapi.Schema{Type: "string", Enum: service.ReportFormats()}),
+		},
+		Responses: map[string]openapi.Response{"200": {Description: "The report as an attachment", Content: reportContent()}},
+	}
+	listRevisionsOp = openapi.Operation{Summary: "List a transcription's revisions", Tags: []string{"transcriptions"}}
+	diffRevisionOp  = openapi.Operation{
+		Summary: "Diff a revision against another revision or the current content", Tags: []string{"transcriptions"},
+		Parameters: []openapi.Parameter{openapi.Query("against", "Revision to compare with", &openapi.Schema{Type: "string", Format: "uuid"})},
+	}
+	restoreRevisionOp = openapi.Operation{Summary: "Restore a revision", Tags: []string{"transcriptions"}}
+	createPatientOp   = openapi.Operation{
+		Summary: "Create a patient from a recording", Tags: []string{"patients"},
+		RequestBody: openapi.JSONBody(openapi.SchemaOf(controller.CreatePatientRequest{})),
+		Responses:   map[string]openapi.Response{"202": {Description: "Transcription job queued"}},
+	}
+	searchPatientsOp = openapi.Operation{
+		Summary: "Search patients by name and demographics", Tags: []string{"patients"},
+		Parameters: []openapi.Parameter{
+			openapi.Query("q", "Case-insensitive name prefix, also matched fuzzily", &openapi.Schema{Type: "string"}),
+			openapi.Query("min_age", "Youngest age to include", &openapi.Schema{Type: "integer"}),
+			openapi.Query("max_age", "Oldest age to include", &openapi.Schema{Type: "integer"}),
+			openapi.Query("gender", "Case-insensitive gender", &openapi.Schema{Type: "string"}),
+			openapi.Query("created_from", "RFC 3339 time or YYYY-MM-DD date, inclusive", &openapi.Schema{Type: "string"}),
+			openapi.Query("created_to", "RFC 3339 time or YYYY-MM-DD date, inclusive", &openapi.Schema{Type: "string"}),
+			openapi.Query("sort", "Sort order; prefix with - to reverse", &openapi.Schema{Type: "string", Enum: []string{
+				"relevance", "name", "-name", "age", "-age", "created_at", "-created_at",
+			}}),
+			pageParam,
+			limitParam,
+		},
+		Responses: okJSON("Matching patients", &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{
+			"patients": {Type: "array", Items: patientSchema},
+			"total":    {Type: "integer"},
+			"page":     {Type: "integer"},
+			"limit":    {Type: "integer"},
+		}}),
+	}
+	searchTranscriptionsOp = openapi.Operation{
+		Summary: "Full-text search of transcriptions and reports", Tags: []string{"transcriptions"},
+		Parameters: []openapi.Parameter{
+			{Name: "q", In: "query", Description: "Words, \"quoted phrases\", OR and -excluded words", Required: true, Schema: &openapi.Schema{Type: "string"}},
+			patientIDParam,
+			openapi.Query("from", "RFC 3339 time or YYYY-MM-DD date, inclusive", &openapi.Schema{Type: "string"}),
+			openapi.Query("to", "RFC 3339 time or YYYY-MM-DD date, inclusive", &openapi.Schema{Type: "string"}),
+			pageParam,
+			limitParam,
+		},
+		Responses: okJSON("Ranked hits, best first", &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{
+			"hits": {Type: "array", Items: &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{
+				"id":             {Type: "string", Format: "uuid"},
+				"patient_id":     {Type: "string", Format: "uuid"},
+				"patient_name":   {Type: "string"},
+				"created_at":     {Type: "string", Format: "date-time"},
+				"status":         {Type: "string"},
+				"rank":           {Type: "number"},
+				"text_snippet":   {Type: "string", Description: "HTML-escaped text with matches wrapped in <mark> tags; empty when only the report matched"},
+				"report_snippet": {Type: "string", Description: "HTML-escaped text with matches wrapped in <mark> tags; empty when only the text matched"},
+			}}},
+			"total": {Type: "integer"},
+			"page":  {Type: "integer"},
+			"limit": {Type: "integer"},
+		}}),
+	}
+	transcriptionJobOp = openapi.Operation{Summary: "Poll a transcription job", Tags: []string{"patients"}}
+	uploadAudioOp      = openapi.Operation{
+		Summary: "Upload a recording", Tags: []string{"audio"},
+		RequestBody: audioUpload,
+		Responses:   map[string]openapi.Response{"201": {Description: "Audio uploaded"}},
+	}
+	auditEventsOp = openapi.Operation{
+		Summary: "Query the audit log", Tags: []string{"audit"},
+		Parameters: auditEventParams,
+	}
+)
+
+// fhirOp documents a FHIR export, whose errors are OperationOutcomes rather than Problems.
+func fhirOp(summary, resourceType string) openapi.Operation {
+	resource := func(description string) map[string]openapi.MediaType {
+		return map[string]openapi.MediaType{"application/fhir+json": {Schema: &openapi.Schema{Type: "object", Description: description}}}
+	}
+	return openapi.Operation{
+		Summary: summary, Tags: []string{"fhir"},
+		Responses: map[string]openapi.Response{
+			"200":     {Description: "FHIR R4 " + resourceType, Content: resource("A FHIR R4 " + resourceType + " resource")},
+			"default": {Description: "Error", Content: resource("A FHIR R4 OperationOutcome resource")},
+		},
+	}
+}
+
+// reportContent lists the media types a report can be downloaded as.
+func reportContent() map[string]openapi.MediaType {
+	content := map[string]openapi.MediaType{}
+	for _, format := range service.ReportFormats() {
+		exporter, _ := service.FindReportExporter(format)
+		mediaType, _, _ := mime.ParseMediaType(exporter.ContentType)
+		content[mediaType] = openapi.MediaType{}
+	}
+	return content
+}
+
+// dashboardOp documents a dashboard route. The period is read from the query or,
+// on the v1 routes, from the optional JSON body.
+func dashboardOp(summary string, monthly bool) openapi.Operation {
+	op := openapi.Operation{Summary: summary, Tags: []string{"dashboard"}}
+	if monthly {
+		op.Parameters = []openapi.Parameter{monthsParam}
+		op.RequestBody = openapi.OptionalJSONBody(openapi.SchemaOf(controller.MonthlyStatsRequest{}))
+	} else {
+		op.Parameters = []openapi.Parameter{daysParam}
+		op.RequestBody = openapi.OptionalJSONBody(openapi.SchemaOf(controller.DashboardRequest{}))
+	}
+	return op
+}
+
+// serveOpenAPI serves the document for r's routes, built on first request.
+func serveOpenAPI(r *gin.Engine) gin.HandlerFunc {
+	var (
+		once sync.Once
+		doc  *openapi.Document
+		err  error
+	)
+	return func(c *gin.Context) {
+		once.Do(func() {
+			doc, err = openapi.FromRoutes(apiInfo, r.Routes(), operations)
+		})
+		if err != nil {
+			log.Println("Error building OpenAPI document:", err)
+			middleware.Abort(c, err)
+			return
+		}
+		c.JSON(http.StatusOK, doc)
+	}
+}
@@ -0,0 +1,105 @@
+package route
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"itish41/doctor_ai_assistant/openapi"
+
+	"github.com/gin-gonic/gin"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	SetupRoutes(r)
+
+	// Fails with the offending routes when a route and its operation drift apart
+	doc, err := openapi.FromRoutes(apiInfo, r.Routes(), operations)
+	assert.NoError(t, err)
+	if doc != nil {
+		assert.Len(t, r.Routes(), len(operations))
+	}
+}
+
+func TestServeOpenAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	SetupRoutes(r)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/openapi.json", nil)
+	r.ServeHTTP(w, req)
+
+	assert.Equal(t, http.StatusOK, w.Code)
+
+	var doc openapi.Document
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &doc))
+	assert.Equal(t, openapi.Version, doc.OpenAPI)
+
+	patient := doc.Paths["/api/v2/patients/{id}"]
+	if assert.NotNil(t, patient) {
+		assert.Contains(t, patient, "get")
+		assert.Contains(t, patient, "patch")
+		assert.Contains(t, patient, "delete")
+		assert.Equal(t, "id", patient["get"].Parameters[0].Name)
+		assert.Equal(t, "path", patient["get"].Parameters[0].In)
+		assert.NotEmpty(t, patient["get"].Security)
+	}
+
+	login := doc.Paths["/auth/login"]["post"]
+	if assert.NotNil(t, login) {
+		assert.True(t, login.Deprecated)
+		assert.Empty(t, login.Security)
+	}
+	assert.Contains(t, doc.Paths["/api/v2/patients"]["post"].RequestBody.Content["application/json"].Schema.Properties, "audio_file_id")
+}
+
+func TestValidateRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	setupAuditTestDB(t)
+
+	ValidateRequests = true
+	defer func() { ValidateRequests = false }()
+
+	r := gin.New()
+	SetupRoutes(r)
+
+	tests := []struct {
+		name       string
+		method     string
+		path       string
+		body       string
+		wantStatus int
+		wantField  string
+	}{
+		{name: "Malformed path ID", method: "GET", path: "/api/v2/transcriptions/not-a-uuid", wantStatus: http.StatusBadRequest, wantField: "id"},
+		{name: "Malformed query parameter", method: "GET", path: "/api/v2/dashboard/statistics/daily?days=week", wantStatus: http.StatusBadRequest, wantField: "days"},
+		{name: "Missing required query parameter", method: "GET", path: "/api/v2/transcriptions/latest", wantStatus: http.StatusBadRequest, wantField: "patient_name"},
+		{name: "Unknown report status", method: "POST", path: "/api/v2/transcriptions/3f2c1b7e-8d4a-4a8e-9c55-1d0b7f3a2e11/status", body: `{"status": "approved"}`, wantStatus: http.StatusBadRequest, wantField: "status"},
+		{name: "Wrong body type", method: "POST", path: "/api/v2/auth/refresh", body: `{"refresh_token": 42}`, wantStatus: http.StatusBadRequest, wantField: "refresh_token"},
+		// Valid requests reach the route, which rejects them for lack of a token
+		{name: "Valid request", method: "GET", path: "/api/v2/transcriptions/3f2c1b7e-8d4a-4a8e-9c55-1d0b7f3a2e11", wantStatus: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", "application/json")
+			}
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, tt.wantStatus, w.Code)
+			if tt.wantField != "" {
+				assert.Contains(t, w.Body.String(), `"`+tt.wantField+`"`)
+			}
+		})
+	}
+}
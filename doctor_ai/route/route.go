@@ -1,52 +1,189 @@
 package route
 
 import (
+	"time"
+
 	"itish41/doctor_ai_assistant/controller"
+	"itish41/doctor_ai_assistant/middleware"
+	"itish41/doctor_ai_assistant/models"
 
 	"github.com/gin-gonic/gin"
 )
 
+// ValidateRequests makes SetupRoutes check every request against its OpenAPI
+// operation before the handler runs. Set it before calling SetupRoutes.
+var ValidateRequests bool
+
+// v1Deprecated is when the unversioned routes were superseded by /api/v2.
+var v1Deprecated = time.Date(2026, time.October, 17, 0, 0, 0, 0, time.UTC)
+
 func SetupRoutes(r *gin.Engine) {
-	// Authentication routes
-	authGroup := r.Group("/auth")
+	// Errors left by handlers and middleware below are rendered as problem+json
+	r.Use(middleware.ErrorHandler())
+	if ValidateRequests {
+		r.Use(middleware.ValidateRequests(operations))
+	}
+
+	// Machine-readable description of every route below
+	r.GET("/openapi.json", serveOpenAPI(r))
+
+	setupV1Routes(r)
+	setupV2Routes(r)
+}
+
+// setupV1Routes registers the original routes. They stay available as deprecated
+// aliases of the /api/v2 routes and announce their successor in every response.
+func setupV1Routes(r *gin.Engine) {
+	// Authentication routes (signup, login and refresh are the only public endpoints)
+	authGroup := r.Group("/auth", middleware.Deprecated(v1Deprecated, "/api/v2/auth"))
 	{
-		authGroup.POST("/signup", controller.SignUp)   //done
-		authGroup.POST("/login", controller.Login)     //done
-		authGroup.POST("/me", controller.GetProfile)   //done
-		authGroup.PUT("/me", controller.UpdateProfile) //done
+		authGroup.POST("/signup", controller.SignUp) //done
+		authGroup.POST("/login", controller.Login)   //done
+		authGroup.POST("/refresh", controller.RefreshToken)
+		authGroup.POST("/logout", middleware.RequireAuth(), controller.Logout)
+		authGroup.POST("/me", middleware.RequireAuth(), controller.GetProfile)   //done
+		authGroup.PUT("/me", middleware.RequireAuth(), controller.UpdateProfile) //done
 	}
 
+	// Every route that reads or changes patient data below is recorded in the audit log,
+	// including requests rejected for a missing or invalid token
+	transcription := models.AuditResourceTranscription
+	patient := models.AuditResourcePatient
+
 	// Transcription routes
-	transcriptionGroup := r.Group("/transcription")
+	transcriptionGroup := r.Group("/transcription", middleware.Deprecated(v1Deprecated, "/api/v2/transcriptions"))
 	{
-		transcriptionGroup.POST("/", controller.GetTranscriptions)                            //done
-		transcriptionGroup.POST("/:id/download", controller.DownloadTranscription)            //done
-		transcriptionGroup.POST("/:id/getTranscriptionByID", controller.GetTranscriptionByID) //done
-		transcriptionGroup.PUT("/:id", controller.UpdateTranscription)                        //done
-		transcriptionGroup.POST("/:id/delete", controller.DeleteTranscription)                // done
+		transcriptionGroup.POST("/", audited("transcription.list", transcription, controller.GetTranscriptions)...)                            //done
+		transcriptionGroup.POST("/:id/download", audited("transcription.download", transcription, controller.DownloadTranscription)...)        //done
+		transcriptionGroup.POST("/:id/getTranscriptionByID", audited("transcription.read", transcription, controller.GetTranscriptionByID)...) //done
+		transcriptionGroup.PUT("/:id", audited("transcription.update", transcription, controller.UpdateTranscription)...)                      //done
+		transcriptionGroup.POST("/:id/delete", audited("transcription.delete", transcription, controller.DeleteTranscription)...)              // done
+		transcriptionGroup.POST("/:id/status", audited("transcription.status", transcription, controller.UpdateTranscriptionStatus)...)        // Review, sign or amend the report
+		transcriptionGroup.GET("/:id/revisions", audited("transcription.revisions", transcription, controller.ListTranscriptionRevisions)...)
+		transcriptionGroup.GET("/:id/revisions/:revision_id/diff", audited("transcription.revisions", transcription, controller.DiffTranscriptionRevision)...)
+		transcriptionGroup.POST("/:id/revisions/:revision_id/restore", audited("transcription.restore", transcription, controller.RestoreTranscriptionRevision)...)
+		transcriptionGroup.GET("/search", audited("transcription.search", transcription, controller.SearchTranscriptions)...)
 	}
 
 	// Patient routes
-	patientsGroup := r.Group("/patients")
+	patientsGroup := r.Group("/patients", middleware.Deprecated(v1Deprecated, "/api/v2/patients"))
 	{
-		patientsGroup.POST("/", controller.CreatePatient)                  //done
-		patientsGroup.POST("/patientsList", controller.GetPatients)        //done
-		patientsGroup.POST("/:id/getPatient", controller.GetPatientByID)   // done
-		patientsGroup.PUT("/:id/update", controller.UpdatePatient)         // done
-		patientsGroup.POST("/:id", controller.DeletePatient)               // done
-		patientsGroup.POST("/transcript", controller.GetPatientTranscript) // Get patient transcript by name
+		patientsGroup.POST("/", audited("transcription_job.create", models.AuditResourceTranscriptionJob, controller.CreatePatient)...)            //done
+		patientsGroup.POST("/patientsList", audited("patient.list", patient, controller.GetPatients)...)                                           //done
+		patientsGroup.POST("/:id/getPatient", audited("patient.read", patient, controller.GetPatientByID)...)                                      // done
+		patientsGroup.PUT("/:id/update", audited("patient.update", patient, controller.UpdatePatient)...)                                          // done
+		patientsGroup.POST("/:id", audited("patient.delete", patient, controller.DeletePatient)...)                                                // done
+		patientsGroup.POST("/transcript", audited("transcription.read", transcription, controller.GetPatientTranscript)...)                        // Get patient transcript by name
+		patientsGroup.GET("/jobs/:id", audited("transcription_job.read", models.AuditResourceTranscriptionJob, controller.GetTranscriptionJob)...) // Poll a transcription job started by POST /patients/
+		patientsGroup.GET("/search", audited("patient.search", patient, controller.SearchPatients)...)
 	}
 
 	// Dashboard & Statistics routes
-	dashboardGroup := r.Group("/dashboard")
+	dashboardGroup := r.Group("/dashboard", middleware.Deprecated(v1Deprecated, "/api/v2/dashboard"))
 	{
-		dashboardGroup.POST("/transcripts", controller.GetDashboardTranscripts)
-		dashboardGroup.POST("/patients", controller.GetDashboardPatients)
-		dashboardGroup.POST("/statistics/daily", controller.GetDailyStatistics)
-		dashboardGroup.POST("/statistics/monthly", controller.GetMonthlyStatistics)
-		dashboardGroup.POST("/statistics/busiest-days", controller.GetBusiestDays)
+		dashboardGroup.POST("/transcripts", audited("dashboard.transcripts", models.AuditResourceDashboard, controller.GetDashboardTranscripts)...)
+		dashboardGroup.POST("/patients", audited("dashboard.patients", models.AuditResourceDashboard, controller.GetDashboardPatients)...)
+		dashboardGroup.POST("/statistics/daily", middleware.RequireAuth(), controller.GetDailyStatistics)
+		dashboardGroup.POST("/statistics/monthly", middleware.RequireAuth(), controller.GetMonthlyStatistics)
+		dashboardGroup.POST("/statistics/busiest-days", middleware.RequireAuth(), controller.GetBusiestDays)
 	}
 
-	// Optionally, for audio upload (future feature)
-	// r.POST("/upload_audio", controller.UploadAudio)
+	// Audio upload; the returned audio_file_id is passed to POST /patients/
+	r.Group("/upload_audio", middleware.Deprecated(v1Deprecated, "/api/v2/audio")).POST("", audited("audio.upload", models.AuditResourceAudioFile, controller.UploadAudio)...)
+
+	// Audit log queries are themselves audited
+	r.Group("/audit/events", middleware.Deprecated(v1Deprecated, "/api/v2/audit-events")).GET("", audited("audit.read", models.AuditResourceAuditLog, controller.ListAuditEvents)...)
+}
+
+// setupV2Routes registers the /api/v2 routes, which address resources by path,
+// use the HTTP method for the operation and take filters from the query.
+func setupV2Routes(r *gin.Engine) {
+	transcription := models.AuditResourceTranscription
+	patient := models.AuditResourcePatient
+	dashboard := models.AuditResourceDashboard
+
+	v2 := r.Group("/api/v2")
+
+	auth := v2.Group("/auth")
+	{
+		auth.POST("/signup", controller.SignUp)
+		auth.POST("/login", controller.Login)
+		auth.POST("/refresh", controller.RefreshToken)
+		auth.POST("/logout", middleware.RequireAuth(), controller.Logout)
+		auth.GET("/me", middleware.RequireAuth(), controller.GetProfile)
+		auth.PATCH("/me", middleware.RequireAuth(), controller.UpdateProfile)
+	}
+
+	// ?patient_id= and ?status= filter the list
+	transcriptions := v2.Group("/transcriptions")
+	{
+		transcriptions.GET("", audited("transcription.list", transcription, controller.GetTranscriptions)...)
+		transcriptions.GET("/latest", audited("transcription.read", transcription, controller.GetLatestTranscript)...)
+		transcriptions.GET("/search", audited("transcription.search", transcription, controller.SearchTranscriptions)...)
+		transcriptions.GET("/:id", audited("transcription.read", transcription, controller.GetTranscription)...)
+		transcriptions.PATCH("/:id", audited("transcription.update", transcription, controller.PatchTranscription)...)
+		transcriptions.DELETE("/:id", audited("transcription.delete", transcription, controller.RemoveTranscription)...)
+		transcriptions.GET("/:id/download", audited("transcription.download", transcription, controller.DownloadTranscription)...)
+		transcriptions.POST("/:id/status", audited("transcription.status", transcription, controller.UpdateTranscriptionStatus)...)
+		transcriptions.GET("/:id/revisions", audited("transcription.revisions", transcription, controller.ListTranscriptionRevisions)...)
+		transcriptions.GET("/:id/revisions/:revision_id/diff", audited("transcription.revisions", transcription, controller.DiffTranscriptionRevision)...)
+		transcriptions.POST("/:id/revisions/:revision_id/restore", audited("transcription.restore", transcription, controller.RestoreTranscriptionRevision)...)
+	}
+
+	patients := v2.Group("/patients")
+	{
+		patients.GET("", audited("patient.list", patient, controller.GetPatients)...)
+		patients.POST("", audited("transcription_job.create", models.AuditResourceTranscriptionJob, controller.CreatePatient)...)
+		patients.GET("/search", audited("patient.search", patient, controller.SearchPatients)...)
+		patients.GET("/duplicates", audited("patient.duplicates", patient, controller.GetDuplicatePatients)...)
+		patients.GET("/:id", audited("patient.read", patient, controller.GetPatient)...)
+		patients.PATCH("/:id", audited("patient.update", patient, controller.PatchPatient)...)
+		patients.DELETE("/:id", audited("patient.delete", patient, controller.RemovePatient)...)
+		patients.POST("/:id/recordings", audited("transcription_job.create", models.AuditResourceTranscriptionJob, controller.AddRecording)...)
+		patients.GET("/:id/timeline", audited("patient.timeline", patient, controller.GetPatientTimeline)...)
+		patients.POST("/:id/merge", audited("patient.merge", patient, controller.MergePatient)...)
+	}
+
+	v2.GET("/transcription-jobs/:id", audited("transcription_job.read", models.AuditResourceTranscriptionJob, controller.GetTranscriptionJob)...)
+	v2.POST("/audio", audited("audio.upload", models.AuditResourceAudioFile, controller.UploadAudio)...)
+
+	// ?days= (or ?months= for monthly statistics) sets the period
+	dashboardGroup := v2.Group("/dashboard")
+	{
+		dashboardGroup.GET("/transcripts", audited("dashboard.transcripts", dashboard, controller.GetDashboardTranscripts)...)
+		dashboardGroup.GET("/patients", audited("dashboard.patients", dashboard, controller.GetDashboardPatients)...)
+		dashboardGroup.GET("/statistics/daily", middleware.RequireAuth(), controller.GetDailyStatistics)
+		dashboardGroup.GET("/statistics/monthly", middleware.RequireAuth(), controller.GetMonthlyStatistics)
+		dashboardGroup.GET("/statistics/busiest-days", middleware.RequireAuth(), controller.GetBusiestDays)
+	}
+
+	v2.GET("/audit-events", audited("audit.read", models.AuditResourceAuditLog, controller.ListAuditEvents)...)
+
+	// Exports of all of a doctor's data. The archive is downloaded with the signed link
+	// GET /exports/:id returns rather than a bearer token, and the download audits itself
+	exports := v2.Group("/exports")
+	{
+		exports.POST("", audited("export.create", models.AuditResourceExport, controller.CreateExport)...)
+		exports.GET("/:id", audited("export.read", models.AuditResourceExport, controller.GetExport)...)
+		exports.GET("/:id/download", controller.DownloadExport)
+	}
+
+	// HL7 FHIR R4 exports, with errors as OperationOutcomes. Compositions, diagnostic
+	// reports and document references are the reports of the transcription with their ID
+	fhirGroup := v2.Group("/fhir", middleware.FHIRErrorHandler())
+	{
+		fhirGroup.GET("/Patient/:id", audited("fhir.read", patient, controller.GetFHIRPatient)...)
+		fhirGroup.GET("/Patient/:id/$everything", audited("fhir.export", patient, controller.GetFHIRPatientEverything)...)
+		fhirGroup.GET("/Practitioner/:id", middleware.RequireAuth(), controller.GetFHIRPractitioner)
+		fhirGroup.GET("/Encounter/:id", audited("fhir.read", models.AuditResourceEncounter, controller.GetFHIREncounter)...)
+		fhirGroup.GET("/Composition/:id", audited("fhir.read", transcription, controller.GetFHIRComposition)...)
+		fhirGroup.GET("/DiagnosticReport/:id", audited("fhir.read", transcription, controller.GetFHIRDiagnosticReport)...)
+		fhirGroup.GET("/DocumentReference/:id", audited("fhir.read", transcription, controller.GetFHIRDocumentReference)...)
+	}
+}
+
+// audited registers handler behind RequireAuth with middleware.Audit ahead of both, so
+// requests rejected for a missing or invalid token are logged too, without an actor.
+func audited(action, resourceType string, handler gin.HandlerFunc) []gin.HandlerFunc {
+	return []gin.HandlerFunc{middleware.Audit(action, resourceType), middleware.RequireAuth(), handler}
 }
@@ -5,11 +5,42 @@ import (
 	"net/http/httptest"
 	"testing"
 
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+
 	"github.com/gin-gonic/gin"
 	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
 )
 
+// setupAuditTestDB gives the audit middleware, which runs ahead of RequireAuth, a
+// database to record rejected requests in.
+func setupAuditTestDB(t *testing.T) {
+	db, err := gorm.Open(sqlite.Open(":memory:"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	if err := db.Exec(`CREATE TABLE audit_events (
+		id TEXT PRIMARY KEY,
+		actor_id TEXT,
+		action TEXT NOT NULL,
+		resource_type TEXT NOT NULL,
+		resource_id TEXT,
+		patient_id TEXT,
+		ip_address TEXT,
+		user_agent TEXT,
+		outcome TEXT NOT NULL,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error; err != nil {
+		t.Fatalf("Failed to create audit_events table: %v", err)
+	}
+	initializers.DB = db
+}
+
 func TestSetupRoutes(t *testing.T) {
+	setupAuditTestDB(t)
 	// Set Gin to test mode
 	gin.SetMode(gin.TestMode)
 
@@ -39,6 +70,18 @@ func TestSetupRoutes(t *testing.T) {
 			path:           "/auth/login",
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:           "Refresh Token Route",
+			method:         "POST",
+			path:           "/auth/refresh",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Logout Route",
+			method:         "POST",
+			path:           "/auth/logout",
+			expectedStatus: http.StatusOK,
+		},
 		{
 			name:           "Get Profile Route",
 			method:         "POST",
@@ -83,6 +126,30 @@ func TestSetupRoutes(t *testing.T) {
 			path:           "/transcription/123/delete",
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:           "Update Transcription Status Route",
+			method:         "POST",
+			path:           "/transcription/123/status",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "List Transcription Revisions Route",
+			method:         "GET",
+			path:           "/transcription/123/revisions",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Diff Transcription Revision Route",
+			method:         "GET",
+			path:           "/transcription/123/revisions/456/diff",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Restore Transcription Revision Route",
+			method:         "POST",
+			path:           "/transcription/123/revisions/456/restore",
+			expectedStatus: http.StatusOK,
+		},
 
 		// Patient routes
 		{
@@ -115,6 +182,28 @@ func TestSetupRoutes(t *testing.T) {
 			path:           "/patients/123",
 			expectedStatus: http.StatusOK,
 		},
+		{
+			name:           "Get Transcription Job Route",
+			method:         "GET",
+			path:           "/patients/jobs/123",
+			expectedStatus: http.StatusOK,
+		},
+
+		// Audio upload
+		{
+			name:           "Upload Audio Route",
+			method:         "POST",
+			path:           "/upload_audio",
+			expectedStatus: http.StatusOK,
+		},
+
+		// Audit log
+		{
+			name:           "List Audit Events Route",
+			method:         "GET",
+			path:           "/audit/events",
+			expectedStatus: http.StatusOK,
+		},
 
 		// Dashboard routes
 		{
@@ -147,6 +236,146 @@ func TestSetupRoutes(t *testing.T) {
 			path:           "/dashboard/statistics/busiest-days",
 			expectedStatus: http.StatusOK,
 		},
+
+		// v2 routes
+		{
+			name:           "v2 Login Route",
+			method:         "POST",
+			path:           "/api/v2/auth/login",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 Update Profile Route",
+			method:         "PATCH",
+			path:           "/api/v2/auth/me",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 List Transcriptions Route",
+			method:         "GET",
+			path:           "/api/v2/transcriptions?patient_id=123&status=draft",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 Latest Transcription Route",
+			method:         "GET",
+			path:           "/api/v2/transcriptions/latest?patient_name=John",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 Patch Transcription Route",
+			method:         "PATCH",
+			path:           "/api/v2/transcriptions/123",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 Delete Transcription Route",
+			method:         "DELETE",
+			path:           "/api/v2/transcriptions/123",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Search Transcriptions Route",
+			method:         "GET",
+			path:           "/transcription/search?q=chest+pain",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 Search Transcriptions Route",
+			method:         "GET",
+			path:           "/api/v2/transcriptions/search?q=chest+pain&from=2024-01-01",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Search Patients Route",
+			method:         "GET",
+			path:           "/patients/search?q=jo&min_age=18&sort=-age",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 Search Patients Route",
+			method:         "GET",
+			path:           "/api/v2/patients/search?q=jo",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 Get Patient Route",
+			method:         "GET",
+			path:           "/api/v2/patients/123",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 Add Recording Route",
+			method:         "POST",
+			path:           "/api/v2/patients/123/recordings",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 Patient Timeline Route",
+			method:         "GET",
+			path:           "/api/v2/patients/123/timeline",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 Download Transcription Route",
+			method:         "GET",
+			path:           "/api/v2/transcriptions/123/download",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 Duplicate Patients Route",
+			method:         "GET",
+			path:           "/api/v2/patients/duplicates",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 Merge Patient Route",
+			method:         "POST",
+			path:           "/api/v2/patients/123/merge",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 Delete Patient Route",
+			method:         "DELETE",
+			path:           "/api/v2/patients/123",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "FHIR Patient Route",
+			method:         "GET",
+			path:           "/api/v2/fhir/Patient/123",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "FHIR Patient Everything Route",
+			method:         "GET",
+			path:           "/api/v2/fhir/Patient/123/$everything",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "FHIR Document Reference Route",
+			method:         "GET",
+			path:           "/api/v2/fhir/DocumentReference/123",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Create Export Route",
+			method:         "POST",
+			path:           "/api/v2/exports",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "Download Export Route",
+			method:         "GET",
+			path:           "/api/v2/exports/123/download",
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "v2 Busiest Days Route",
+			method:         "GET",
+			path:           "/api/v2/dashboard/statistics/busiest-days?days=7",
+			expectedStatus: http.StatusOK,
+		},
 	}
 
 	// Run tests for each route
@@ -201,9 +430,12 @@ func TestRouteGroups(t *testing.T) {
 	transcriptionCount := 0
 	patientsCount := 0
 	dashboardCount := 0
+	v2Count := 0
 
 	for _, route := range routes {
 		switch {
+		case len(route.Path) >= 7 && route.Path[:7] == "/api/v2":
+			v2Count++
 		case len(route.Path) >= 5 && route.Path[:5] == "/auth":
 			authCount++
 		case len(route.Path) >= 14 && route.Path[:14] == "/transcription":
@@ -217,9 +449,82 @@ func TestRouteGroups(t *testing.T) {
 
 	// Verify route counts
 	t.Run("Route Counts", func(t *testing.T) {
-		assert.Equal(t, 4, authCount, "Auth group should have 4 routes")
-		assert.Equal(t, 5, transcriptionCount, "Transcription group should have 5 routes")
-		assert.Equal(t, 6, patientsCount, "Patients group should have 5 routes")
+		assert.Equal(t, 6, authCount, "Auth group should have 6 routes")
+		assert.Equal(t, 10, transcriptionCount, "Transcription group should have 10 routes")
+		assert.Equal(t, 8, patientsCount, "Patients group should have 8 routes")
 		assert.Equal(t, 5, dashboardCount, "Dashboard group should have 5 routes")
+		assert.Equal(t, 45, v2Count, "v2 group should have 45 routes")
+	})
+}
+
+func TestProtectedRoutesRequireAuth(t *testing.T) {
+	setupAuditTestDB(t)
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	SetupRoutes(r)
+
+	// Every route except the OpenAPI document and signup, login and refresh (in either version) must reject requests without a bearer token
+	for _, route := range r.Routes() {
+		switch route.Path {
+		case "/openapi.json", "/auth/signup", "/auth/login", "/auth/refresh",
+			"/api/v2/auth/signup", "/api/v2/auth/login", "/api/v2/auth/refresh":
+			continue
+		}
+		t.Run(route.Method+" "+route.Path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(route.Method, route.Path, nil)
+			r.ServeHTTP(w, req)
+
+			assert.Equal(t, http.StatusUnauthorized, w.Code)
+		})
+	}
+
+	// Rejected requests for patient data are audited, without an actor
+	var events []models.AuditEvent
+	assert.NoError(t, initializers.DB.Where("action = ?", "patient.read").Find(&events).Error)
+	if assert.NotEmpty(t, events) {
+		assert.Nil(t, events[0].ActorID)
+		assert.Equal(t, models.AuditOutcomeDenied, events[0].Outcome)
+	}
+}
+
+func TestV1RoutesAreDeprecated(t *testing.T) {
+	setupAuditTestDB(t)
+	gin.SetMode(gin.TestMode)
+
+	r := gin.New()
+	SetupRoutes(r)
+
+	tests := []struct {
+		method    string
+		path      string
+		successor string
+	}{
+		{method: "POST", path: "/auth/login", successor: "/api/v2/auth"},
+		{method: "POST", path: "/transcription/", successor: "/api/v2/transcriptions"},
+		{method: "POST", path: "/patients/patientsList", successor: "/api/v2/patients"},
+		{method: "POST", path: "/dashboard/transcripts", successor: "/api/v2/dashboard"},
+		{method: "POST", path: "/upload_audio", successor: "/api/v2/audio"},
+		{method: "GET", path: "/audit/events", successor: "/api/v2/audit-events"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(tt.method, tt.path, nil)
+			r.ServeHTTP(w, req)
+
+			// Set even when the request is rejected before reaching the handler
+			assert.Equal(t, "@1792195200", w.Header().Get("Deprecation"))
+			assert.Equal(t, "<"+tt.successor+">; rel=\"successor-version\"", w.Header().Get("Link"))
+		})
+	}
+
+	t.Run("v2 routes are not deprecated", func(t *testing.T) {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/api/v2/transcriptions", nil)
+		r.ServeHTTP(w, req)
+
+		assert.Empty(t, w.Header().Get("Deprecation"))
 	})
 }
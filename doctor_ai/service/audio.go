@@ -0,0 +1,213 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/storage"
+	"log"
+	"mime"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MaxAudioUploadSize is the largest recording accepted by SaveAudioUpload.
+const MaxAudioUploadSize int64 = 50 << 20 // 50 MB
+
+var (
+	ErrAudioTooLarge        error = InvalidField("audio", fmt.Sprintf("audio file exceeds the %d MB limit", MaxAudioUploadSize>>20))
+	ErrUnsupportedAudioType error = InvalidField("audio", "unsupported audio type")
+	ErrAudioFileNotFound    error = &NotFoundError{Resource: "audio file"}
+	ErrEmptyAudioFile       error = InvalidField("audio", "audio file is empty")
+)
+
+// allowedAudioTypes are the declared content types accepted for upload, mapped to the stored extension.
+var allowedAudioTypes = map[string]string{
+	"audio/mpeg":   ".mp3",
+	"audio/mp3":    ".mp3",
+	"audio/wav":    ".wav",
+	"audio/wave":   ".wav",
+	"audio/x-wav":  ".wav",
+	"audio/webm":   ".webm",
+	"audio/ogg":    ".ogg",
+	"audio/mp4":    ".m4a",
+	"audio/x-m4a":  ".m4a",
+	"audio/aac":    ".aac",
+	"audio/flac":   ".flac",
+	"audio/x-flac": ".flac",
+}
+
+// audioContentMatches reports whether the sniffed bytes are plausibly audio. The sniffer
+// can't name every audio container, so only content it positively identifies as
+// something else (text, images, documents, archives) is rejected.
+func audioContentMatches(sniffed string) bool {
+	switch {
+	case strings.HasPrefix(sniffed, "audio/"),
+		sniffed == "application/ogg",
+		sniffed == "video/webm",
+		sniffed == "video/mp4",
+		sniffed == "application/octet-stream":
+		return true
+	}
+	return false
+}
+
+var (
+	audioStorageMu sync.RWMutex
+	audioStorage   storage.ObjectStorage
+)
+
+// SetAudioStorage replaces the storage used for uploaded recordings.
+func SetAudioStorage(s storage.ObjectStorage) {
+	audioStorageMu.Lock()
+	audioStorage = s
+	audioStorageMu.Unlock()
+}
+
+// currentAudioStorage returns the configured storage, building it from the environment on first use.
+func currentAudioStorage() (storage.ObjectStorage, error) {
+	audioStorageMu.RLock()
+	s := audioStorage
+	audioStorageMu.RUnlock()
+	if s != nil {
+		return s, nil
+	}
+
+	s, err := storage.NewFromEnv()
+	if err != nil {
+		log.Println("Error configuring audio storage:", err)
+		return nil, err
+	}
+	SetAudioStorage(s)
+	return s, nil
+}
+
+// limitedReader fails once more than limit bytes have been read, so an oversized
+// upload is rejected without buffering it.
+type limitedReader struct {
+	r     io.Reader
+	limit int64
+	read  int64
+}
+
+func (l *limitedReader) Read(p []byte) (int, error) {
+	n, err := l.r.Read(p)
+	l.read += int64(n)
+	if l.read > l.limit {
+		return n, ErrAudioTooLarge
+	}
+	return n, err
+}
+
+// SaveAudioUpload checks an uploaded recording's type and size, streams it to storage
+// and records it for the doctor.
+func SaveAudioUpload(doctorID uuid.UUID, filename, contentType string, file io.Reader) (*models.AudioFile, error) {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return nil, ErrUnsupportedAudioType
+	}
+	ext, ok := allowedAudioTypes[strings.ToLower(mediaType)]
+	if !ok {
+		return nil, ErrUnsupportedAudioType
+	}
+
+	// Check the leading bytes agree with the declared type
+	head := make([]byte, 512)
+	n, err := io.ReadFull(file, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		log.Println("Error reading audio upload:", err)
+		return nil, errors.New("failed to read audio file")
+	}
+	if n == 0 {
+		return nil, ErrEmptyAudioFile
+	}
+	head = head[:n]
+	if !audioContentMatches(http.DetectContentType(head)) {
+		return nil, ErrUnsupportedAudioType
+	}
+
+	store, err := currentAudioStorage()
+	if err != nil {
+		return nil, errors.New("audio storage is not available")
+	}
+
+	audioFile := models.AudioFile{
+		ID:               uuid.New(),
+		DoctorID:         doctorID,
+		OriginalFilename: filepath.Base(filename),
+		ContentType:      strings.ToLower(mediaType),
+		CreatedAt:        time.Now(),
+	}
+	audioFile.StorageKey = fmt.Sprintf("audio/%s/%s%s", doctorID, audioFile.ID, ext)
+
+	ctx := context.Background()
+	body := &limitedReader{r: io.MultiReader(bytes.NewReader(head), file), limit: MaxAudioUploadSize}
+	size, err := store.Put(ctx, audioFile.StorageKey, body)
+	if err != nil {
+		if errors.Is(err, ErrAudioTooLarge) {
+			return nil, ErrAudioTooLarge
+		}
+		log.Println("Error storing audio upload:", err)
+		return nil, errors.New("failed to store audio file")
+	}
+	audioFile.Size = size
+
+	if err := initializers.DB.Create(&audioFile).Error; err != nil {
+		log.Println("Error creating audio file record:", err)
+		if err := store.Delete(ctx, audioFile.StorageKey); err != nil {
+			log.Println("Error removing orphaned audio object:", err)
+		}
+		return nil, errors.New("failed to save audio file")
+	}
+
+	log.Println("Audio file uploaded:", audioFile.ID)
+	return &audioFile, nil
+}
+
+// GetAudioFile returns an uploaded recording if it belongs to the doctor.
+func GetAudioFile(doctorID, audioFileID uuid.UUID) (*models.AudioFile, error) {
+	var audioFile models.AudioFile
+	if err := initializers.DB.Where("id = ? AND doctor_id = ?", audioFileID, doctorID).First(&audioFile).Error; err != nil {
+		log.Println("Audio file not found:", err)
+		return nil, ErrAudioFileNotFound
+	}
+	return &audioFile, nil
+}
+
+// transcribeAudioFile streams an uploaded recording from storage to the configured Transcriber.
+func transcribeAudioFile(ctx context.Context, audioFileID uuid.UUID) (string, error) {
+	var audioFile models.AudioFile
+	if err := initializers.DB.Where("id = ?", audioFileID).First(&audioFile).Error; err != nil {
+		return "", ErrAudioFileNotFound
+	}
+
+	t, err := currentTranscriber()
+	if err != nil {
+		return "", err
+	}
+	readerTranscriber, ok := t.(ReaderTranscriber)
+	if !ok {
+		return "", errors.New("configured transcriber cannot transcribe uploaded files")
+	}
+
+	store, err := currentAudioStorage()
+	if err != nil {
+		return "", err
+	}
+	audio, err := store.Get(ctx, audioFile.StorageKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to open audio file: %v", err)
+	}
+	defer audio.Close()
+
+	return readerTranscriber.TranscribeReader(ctx, audioFile.OriginalFilename, audio)
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/storage"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// mp3Header is the start of an MP3 file with an ID3 tag, enough for content sniffing.
+var mp3Header = append([]byte("ID3\x03\x00\x00\x00\x00\x00\x00"), bytes.Repeat([]byte{0xff, 0xfb, 0x90, 0x64}, 64)...)
+
+func setupAudioTestDB(t *testing.T) (*models.Doctor, storage.ObjectStorage) {
+	doctor := setupTranscriptionJobTestDB(t)
+	if err := initializers.DB.Exec(`CREATE TABLE IF NOT EXISTS audio_files (
+		id TEXT PRIMARY KEY,
+		doctor_id TEXT NOT NULL,
+		storage_key TEXT NOT NULL UNIQUE,
+		original_filename TEXT NOT NULL,
+		content_type TEXT NOT NULL,
+		size INTEGER NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error; err != nil {
+		t.Fatalf("Failed to create audio_files table: %v", err)
+	}
+
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	SetAudioStorage(store)
+	t.Cleanup(func() { SetAudioStorage(nil) })
+	return doctor, store
+}
+
+func TestSaveAudioUpload(t *testing.T) {
+	doctor, store := setupAudioTestDB(t)
+
+	t.Run("Stores file and record", func(t *testing.T) {
+		audioFile, err := SaveAudioUpload(doctor.ID, "visit.mp3", "audio/mpeg", bytes.NewReader(mp3Header))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(len(mp3Header)), audioFile.Size)
+		assert.Equal(t, "visit.mp3", audioFile.OriginalFilename)
+		assert.Equal(t, fmt.Sprintf("audio/%s/%s.mp3", doctor.ID, audioFile.ID), audioFile.StorageKey)
+
+		reader, err := store.Get(context.Background(), audioFile.StorageKey)
+		assert.NoError(t, err)
+		defer reader.Close()
+		stored, _ := io.ReadAll(reader)
+		assert.Equal(t, mp3Header, stored)
+
+		found, err := GetAudioFile(doctor.ID, audioFile.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, audioFile.StorageKey, found.StorageKey)
+
+		_, err = GetAudioFile(uuid.New(), audioFile.ID)
+		assert.ErrorIs(t, err, ErrAudioFileNotFound)
+	})
+
+	t.Run("Filename is reduced to its base name", func(t *testing.T) {
+		audioFile, err := SaveAudioUpload(doctor.ID, "../../etc/visit.mp3", "audio/mpeg; charset=binary", bytes.NewReader(mp3Header))
+		assert.NoError(t, err)
+		assert.Equal(t, "visit.mp3", audioFile.OriginalFilename)
+	})
+
+	t.Run("Declared type must be audio", func(t *testing.T) {
+		_, err := SaveAudioUpload(doctor.ID, "notes.txt", "text/plain", strings.NewReader("hello"))
+		assert.ErrorIs(t, err, ErrUnsupportedAudioType)
+	})
+
+	t.Run("Content must look like audio", func(t *testing.T) {
+		_, err := SaveAudioUpload(doctor.ID, "report.mp3", "audio/mpeg", strings.NewReader("%PDF-1.4 not audio"))
+		assert.ErrorIs(t, err, ErrUnsupportedAudioType)
+		_, err = SaveAudioUpload(doctor.ID, "page.mp3", "audio/mpeg", strings.NewReader("<html><body>hi</body></html>"))
+		assert.ErrorIs(t, err, ErrUnsupportedAudioType)
+	})
+
+	t.Run("Empty file", func(t *testing.T) {
+		_, err := SaveAudioUpload(doctor.ID, "empty.mp3", "audio/mpeg", strings.NewReader(""))
+		assert.ErrorIs(t, err, ErrEmptyAudioFile)
+	})
+}
+
+func TestLimitedReader(t *testing.T) {
+	_, err := io.ReadAll(&limitedReader{r: strings.NewReader("12345"), limit: 5})
+	assert.NoError(t, err)
+
+	_, err = io.ReadAll(&limitedReader{r: strings.NewReader("123456"), limit: 5})
+	assert.ErrorIs(t, err, ErrAudioTooLarge)
+}
+
+func TestTranscriptionJob_UploadedAudio(t *testing.T) {
+	doctor, _ := setupAudioTestDB(t)
+	SetTranscriber(NewFakeTranscriber(filepath.Join("..", "fixtures", "transcripts")))
+	t.Cleanup(func() { SetTranscriber(nil) })
+	useReportGenerator(t, func(ctx context.Context, rawText string) (*models.MedicalReport, error) {
+		return testReport("Viral infection"), nil
+	})
+
+	audioFile, err := SaveAudioUpload(doctor.ID, "sample_consultation.mp3", "audio/mpeg", bytes.NewReader(mp3Header))
+	assert.NoError(t, err)
+
+	t.Run("Audio file of another doctor is rejected", func(t *testing.T) {
+		_, err := EnqueueTranscriptionJob(uuid.New(), models.Patient{Name: "Jane", Age: 41, Gender: "Female"}, AudioSource{FileID: &audioFile.ID})
+		assert.ErrorIs(t, err, ErrAudioFileNotFound)
+	})
+
+	t.Run("URL and file are mutually exclusive", func(t *testing.T) {
+		_, err := EnqueueTranscriptionJob(doctor.ID, models.Patient{Name: "Jane", Age: 41, Gender: "Female"}, AudioSource{URL: "https://example.com/a.mp3", FileID: &audioFile.ID})
+		assert.Error(t, err)
+	})
+
+	t.Run("Transcribes the stored file and links it", func(t *testing.T) {
+		job, err := EnqueueTranscriptionJob(doctor.ID, models.Patient{Name: "Jane", Age: 41, Gender: "Female"}, AudioSource{FileID: &audioFile.ID})
+		assert.NoError(t, err)
+		assert.NoError(t, runTranscriptionJob(job.ID))
+
+		stored := loadJob(t, job.ID)
+		assert.Equal(t, models.JobStatusDone, stored.Status)
+
+		var transcription models.Transcription
+		assert.NoError(t, initializers.DB.Where("id = ?", *stored.TranscriptionID).First(&transcription).Error)
+		assert.True(t, strings.HasPrefix(transcription.Text, "Doctor: Good morning"))
+		if assert.NotNil(t, transcription.AudioFileID) {
+			assert.Equal(t, audioFile.ID, *transcription.AudioFileID)
+		}
+	})
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"errors"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AuditEventFilter narrows ListAuditEvents. Zero values don't filter.
+type AuditEventFilter struct {
+	PatientID *uuid.UUID
+	ActorID   *uuid.UUID // the doctor who acted
+	Action    string
+	From      *time.Time // inclusive
+	To        *time.Time // exclusive
+	Page      int
+	Limit     int
+}
+
+// RecordAuditEvent appends an event to the audit log.
+func RecordAuditEvent(event models.AuditEvent) error {
+	return appendAuditEvent(initializers.DB, event)
+}
+
+// appendAuditEvent writes an event with db, so service hooks can record it in the
+// same transaction as the change it describes.
+func appendAuditEvent(db *gorm.DB, event models.AuditEvent) error {
+	if event.ID == uuid.Nil {
+		event.ID = uuid.New()
+	}
+	if event.CreatedAt.IsZero() {
+		event.CreatedAt = time.Now()
+	}
+	if err := db.Create(&event).Error; err != nil {
+		log.Println("Error writing audit event:", err)
+		return errors.New("failed to record audit event")
+	}
+	return nil
+}
+
+// AuditPatientID returns the patient a resource belongs to, so events about a patient's
+// transcriptions and jobs can be found by patient. It returns nil when there is none.
+func AuditPatientID(resourceType, resourceID string) *uuid.UUID {
+	id, err := uuid.Parse(resourceID)
+	if err != nil {
+		return nil
+	}
+
+	var patientID *uuid.UUID
+	switch resourceType {
+	case models.AuditResourcePatient:
+		return &id
+	case models.AuditResourceTranscription:
+		var transcription models.Transcription
+		if err := initializers.DB.Select("patient_id").Where("id = ?", id).First(&transcription).Error; err == nil {
+			patientID = &transcription.PatientID
+		}
+	case models.AuditResourceEncounter:
+		var encounter models.Encounter
+		if err := initializers.DB.Select("patient_id").Where("id = ?", id).First(&encounter).Error; err == nil {
+			patientID = &encounter.PatientID
+		}
+	case models.AuditResourceTranscriptionJob:
+		var job models.TranscriptionJob
+		if err := initializers.DB.Select("patient_id").Where("id = ?", id).First(&job).Error; err == nil {
+			patientID = job.PatientID
+		}
+	}
+	return patientID
+}
+
+// ListAuditEvents returns audit events visible to the doctor, newest first, with the
+// total matching the filter. A doctor sees their own actions and every event about
+// their patients.
+func ListAuditEvents(doctorID uuid.UUID, filter AuditEventFilter) ([]models.AuditEvent, int64, error) {
+	query := initializers.DB.Model(&models.AuditEvent{}).
+		Where("actor_id = ? OR patient_id IN (?)", doctorID,
+			initializers.DB.Model(&models.Patient{}).Select("id").Where("doctor_id = ?", doctorID))
+
+	if filter.PatientID != nil {
+		query = query.Where("patient_id = ?", *filter.PatientID)
+	}
+	if filter.ActorID != nil {
+		query = query.Where("actor_id = ?", *filter.ActorID)
+	}
+	if filter.Action != "" {
+		query = query.Where("action = ?", filter.Action)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Println("Error counting audit events:", err)
+		return nil, 0, errors.New("failed to retrieve audit events")
+	}
+
+	var events []models.AuditEvent
+	if err := query.
+		Order("created_at desc").
+		Limit(filter.Limit).
+		Offset((filter.Page - 1) * filter.Limit).
+		Find(&events).Error; err != nil {
+		log.Println("Error retrieving audit events:", err)
+		return nil, 0, errors.New("failed to retrieve audit events")
+	}
+	return events, total, nil
+}
@@ -0,0 +1,120 @@
+package service
+
+import (
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// createAuditEventsTable adds the audit log to a SQLite test database
+func createAuditEventsTable(t testing.TB, db *gorm.DB) {
+	err := db.Exec(`CREATE TABLE IF NOT EXISTS audit_events (
+		id TEXT PRIMARY KEY,
+		actor_id TEXT,
+		action TEXT NOT NULL,
+		resource_type TEXT NOT NULL,
+		resource_id TEXT,
+		patient_id TEXT,
+		ip_address TEXT,
+		user_agent TEXT,
+		outcome TEXT NOT NULL,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	if err != nil {
+		t.Fatalf("Failed to create audit_events table: %v", err)
+	}
+}
+
+func TestAuditPatientID(t *testing.T) {
+	doctorID, patientID, err := setupTranscriptionTestDB(t)
+	assert.NoError(t, err)
+	transcription := createTestTranscription(t, doctorID, patientID)
+
+	assert.Equal(t, patientID, *AuditPatientID(models.AuditResourcePatient, patientID.String()))
+	assert.Equal(t, patientID, *AuditPatientID(models.AuditResourceTranscription, transcription.ID.String()))
+	assert.Nil(t, AuditPatientID(models.AuditResourceTranscription, uuid.NewString()))
+	assert.Nil(t, AuditPatientID(models.AuditResourcePatient, "not-a-uuid"))
+	assert.Nil(t, AuditPatientID(models.AuditResourceAudioFile, uuid.NewString()))
+}
+
+func TestListAuditEvents(t *testing.T) {
+	doctorID, patientID, err := setupTranscriptionTestDB(t)
+	assert.NoError(t, err)
+
+	otherDoctorID := uuid.New()
+	otherPatientID := uuid.New()
+	now := time.Now()
+	events := []models.AuditEvent{
+		{ActorID: &doctorID, Action: "patient.read", ResourceType: models.AuditResourcePatient, PatientID: &patientID, CreatedAt: now.Add(-48 * time.Hour)},
+		{ActorID: &doctorID, Action: "transcription.update", ResourceType: models.AuditResourceTranscription, PatientID: &patientID, CreatedAt: now.Add(-time.Hour)},
+		// Another doctor touching this doctor's patient is visible
+		{ActorID: &otherDoctorID, Action: "patient.read", ResourceType: models.AuditResourcePatient, PatientID: &patientID, CreatedAt: now},
+		// Another doctor's own patient is not
+		{ActorID: &otherDoctorID, Action: "patient.read", ResourceType: models.AuditResourcePatient, PatientID: &otherPatientID, CreatedAt: now},
+	}
+	for _, event := range events {
+		event.Outcome = models.AuditOutcomeSuccess
+		assert.NoError(t, RecordAuditEvent(event))
+	}
+
+	t.Run("Visible events, newest first", func(t *testing.T) {
+		got, total, err := ListAuditEvents(doctorID, AuditEventFilter{Page: 1, Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		if assert.Len(t, got, 3) {
+			assert.Equal(t, otherDoctorID, *got[0].ActorID)
+			assert.Equal(t, "patient.read", got[2].Action)
+		}
+	})
+
+	t.Run("Filter by doctor", func(t *testing.T) {
+		got, total, err := ListAuditEvents(doctorID, AuditEventFilter{ActorID: &otherDoctorID, Page: 1, Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, patientID, *got[0].PatientID)
+	})
+
+	t.Run("Filter by patient and time range", func(t *testing.T) {
+		from := now.Add(-24 * time.Hour)
+		to := now.Add(-time.Minute)
+		got, total, err := ListAuditEvents(doctorID, AuditEventFilter{PatientID: &patientID, From: &from, To: &to, Page: 1, Limit: 10})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), total)
+		assert.Equal(t, "transcription.update", got[0].Action)
+	})
+
+	t.Run("Pagination keeps the total", func(t *testing.T) {
+		got, total, err := ListAuditEvents(doctorID, AuditEventFilter{Page: 2, Limit: 2})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), total)
+		assert.Len(t, got, 1)
+	})
+}
+
+func TestSavePatientAndTranscription_Audited(t *testing.T) {
+	doctor := setupTranscriptionJobTestDB(t)
+
+	transcription, err := savePatientAndTranscription(initializers.DB, doctor.ID, models.Patient{Name: "Audit Patient", Age: 40, Gender: "Female"}, EncounterDetails{}, "raw", testReport("Flu"), nil)
+	assert.NoError(t, err)
+
+	var events []models.AuditEvent
+	assert.NoError(t, initializers.DB.Order("action").Find(&events).Error)
+	if assert.Len(t, events, 3) {
+		assert.Equal(t, "encounter.create", events[0].Action)
+		assert.Equal(t, transcription.EncounterID.String(), events[0].ResourceID)
+		assert.Equal(t, "patient.create", events[1].Action)
+		assert.Equal(t, "transcription.create", events[2].Action)
+		assert.Equal(t, transcription.ID.String(), events[2].ResourceID)
+		for _, event := range events {
+			assert.Equal(t, doctor.ID, *event.ActorID)
+			assert.Equal(t, transcription.PatientID, *event.PatientID)
+			assert.Equal(t, models.AuditOutcomeSuccess, event.Outcome)
+		}
+	}
+}
@@ -47,6 +47,12 @@ func setupTestDBSQLite(t *testing.T) (*uuid.UUID, *uuid.UUID) {
 			patient_id TEXT NOT NULL,
 			text TEXT NOT NULL,
 			report TEXT NOT NULL,
+			structured_report TEXT,
+			audio_file_id TEXT,
+			status TEXT NOT NULL DEFAULT 'draft',
+			signed_by_id TEXT,
+			signed_at DATETIME,
+			encounter_id TEXT,
 			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 			FOREIGN KEY (doctor_id) REFERENCES doctors(id),
 			FOREIGN KEY (patient_id) REFERENCES patients(id)
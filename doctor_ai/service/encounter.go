@@ -0,0 +1,158 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// EncounterDetails describes the visit a recording belongs to. With an ID the recording
+// is added to that existing encounter; otherwise a new encounter is opened.
+type EncounterDetails struct {
+	ID        *uuid.UUID
+	VisitDate *time.Time // defaults to when the recording is stored
+	Reason    string
+}
+
+// EnqueueRecording queues the transcription of a new recording of an existing patient.
+// Once transcribed it is added to the encounter named in visit, or to a new one.
+func EnqueueRecording(doctorID, patientID uuid.UUID, visit EncounterDetails, audio AudioSource) (*models.TranscriptionJob, error) {
+	patient, err := findDoctorPatient(initializers.DB, doctorID, patientID)
+	if err != nil {
+		return nil, err
+	}
+	if visit.ID != nil {
+		if visit.VisitDate != nil || visit.Reason != "" {
+			return nil, &ValidationError{
+				Message: "an existing encounter keeps its visit date and reason",
+				Fields:  map[string]string{"encounter_id": "set either encounter_id or visit_date and reason"},
+			}
+		}
+		if _, err := findPatientEncounter(initializers.DB, doctorID, patientID, *visit.ID); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateAudioSource(doctorID, audio); err != nil {
+		return nil, err
+	}
+
+	job := models.TranscriptionJob{
+		ID:              uuid.New(),
+		DoctorID:        doctorID,
+		PatientID:       &patient.ID,
+		PatientName:     patient.Name,
+		PatientAge:      patient.Age,
+		PatientGender:   patient.Gender,
+		AudioURL:        audio.URL,
+		AudioFileID:     audio.FileID,
+		EncounterID:     visit.ID,
+		VisitDate:       visit.VisitDate,
+		EncounterReason: visit.Reason,
+		Status:          models.JobStatusQueued,
+		CreatedAt:       time.Now(),
+		UpdatedAt:       time.Now(),
+	}
+	if err := queueTranscriptionJob(&job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// saveVisitTranscription stores a transcription of the patient's visit inside tx, opening
+// the encounter first unless visit names an existing one.
+func saveVisitTranscription(tx *gorm.DB, doctorID, patientID uuid.UUID, visit EncounterDetails, rawText string, report *models.MedicalReport, audioFileID *uuid.UUID) (*models.Transcription, error) {
+	var encounterID uuid.UUID
+	if visit.ID != nil {
+		encounter, err := findPatientEncounter(tx, doctorID, patientID, *visit.ID)
+		if err != nil {
+			return nil, err
+		}
+		encounterID = encounter.ID
+	} else {
+		encounter := models.Encounter{
+			ID:        uuid.New(),
+			PatientID: patientID,
+			DoctorID:  doctorID,
+			VisitDate: time.Now(),
+			Reason:    visit.Reason,
+			CreatedAt: time.Now(),
+		}
+		if visit.VisitDate != nil {
+			encounter.VisitDate = *visit.VisitDate
+		}
+		if err := tx.Create(&encounter).Error; err != nil {
+			log.Println("Error creating encounter:", err)
+			return nil, fmt.Errorf("failed to create encounter: %v", err)
+		}
+		encounterID = encounter.ID
+		if err := appendAuditEvent(tx, models.AuditEvent{
+			ActorID:      &doctorID,
+			PatientID:    &patientID,
+			Action:       "encounter.create",
+			ResourceType: models.AuditResourceEncounter,
+			ResourceID:   encounter.ID.String(),
+			Outcome:      models.AuditOutcomeSuccess,
+		}); err != nil {
+			return nil, err
+		}
+	}
+
+	newTranscription := models.Transcription{
+		ID:               uuid.New(),
+		DoctorID:         doctorID,
+		PatientID:        patientID,
+		EncounterID:      &encounterID,
+		Text:             rawText,
+		Report:           report.Text(),
+		StructuredReport: report,
+		Status:           models.ReportStatusDraft,
+		CreatedAt:        time.Now(),
+		AudioFileID:      audioFileID,
+	}
+	if err := tx.Create(&newTranscription).Error; err != nil {
+		log.Println("Error creating transcription record:", err)
+		return nil, fmt.Errorf("failed to create transcription record: %v", err)
+	}
+
+	// Transcriptions are created outside the request that asked for them, so audit them here
+	if err := appendAuditEvent(tx, models.AuditEvent{
+		ActorID:      &doctorID,
+		PatientID:    &patientID,
+		Action:       "transcription.create",
+		ResourceType: models.AuditResourceTranscription,
+		ResourceID:   newTranscription.ID.String(),
+		Outcome:      models.AuditOutcomeSuccess,
+	}); err != nil {
+		return nil, err
+	}
+	return &newTranscription, nil
+}
+
+// GetPatientTimeline returns the doctor's patient with every encounter, oldest visit
+// first, each with its transcriptions in the order they were recorded.
+func GetPatientTimeline(doctorID, patientID uuid.UUID) (*models.Patient, []models.Encounter, error) {
+	patient, err := findDoctorPatient(initializers.DB, doctorID, patientID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var encounters []models.Encounter
+	if err := initializers.DB.
+		Where("patient_id = ?", patientID).
+		Preload("Transcriptions", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at ASC").Order("id ASC")
+		}).
+		Order("visit_date ASC").
+		Order("created_at ASC").
+		Find(&encounters).Error; err != nil {
+		log.Println("Error fetching encounters:", err)
+		return nil, nil, errors.New("failed to retrieve patient timeline")
+	}
+	return patient, encounters, nil
+}
@@ -0,0 +1,173 @@
+package service
+
+import (
+	"context"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+// createEncountersTable adds encounters to a SQLite test database
+func createEncountersTable(t testing.TB, db *gorm.DB) {
+	err := db.Exec(`CREATE TABLE IF NOT EXISTS encounters (
+		id TEXT PRIMARY KEY,
+		patient_id TEXT NOT NULL,
+		doctor_id TEXT NOT NULL,
+		visit_date DATETIME NOT NULL,
+		reason TEXT NOT NULL DEFAULT '',
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	if err != nil {
+		t.Fatalf("Failed to create encounters table: %v", err)
+	}
+}
+
+// createTestPatient gives the doctor a patient to record visits of.
+func createTestPatient(t *testing.T, doctorID uuid.UUID) models.Patient {
+	patient := models.Patient{ID: uuid.New(), Name: "Returning Patient", Age: 52, Gender: "Female", DoctorID: doctorID, CreatedAt: time.Now()}
+	if err := initializers.DB.Create(&patient).Error; err != nil {
+		t.Fatalf("Failed to create test patient: %v", err)
+	}
+	return patient
+}
+
+func TestEnqueueRecording(t *testing.T) {
+	doctor := setupTranscriptionJobTestDB(t)
+	patient := createTestPatient(t, doctor.ID)
+	audio := AudioSource{URL: "https://example.com/visit.mp3"}
+
+	t.Run("Queued for the existing patient", func(t *testing.T) {
+		visitDate := time.Date(2026, time.May, 4, 10, 30, 0, 0, time.UTC)
+		job, err := EnqueueRecording(doctor.ID, patient.ID, EncounterDetails{VisitDate: &visitDate, Reason: "Follow-up"}, audio)
+		assert.NoError(t, err)
+
+		stored := loadJob(t, job.ID)
+		assert.Equal(t, patient.ID, *stored.PatientID)
+		assert.Equal(t, patient.Name, stored.PatientName)
+		assert.True(t, visitDate.Equal(*stored.VisitDate))
+		assert.Equal(t, "Follow-up", stored.EncounterReason)
+		assert.Nil(t, stored.EncounterID)
+	})
+
+	t.Run("Another doctor's patient", func(t *testing.T) {
+		_, err := EnqueueRecording(uuid.New(), patient.ID, EncounterDetails{}, audio)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("Unknown encounter", func(t *testing.T) {
+		encounterID := uuid.New()
+		_, err := EnqueueRecording(doctor.ID, patient.ID, EncounterDetails{ID: &encounterID}, audio)
+		assert.ErrorIs(t, err, ErrEncounterNotFound)
+	})
+
+	t.Run("Another patient's encounter", func(t *testing.T) {
+		other := createTestPatient(t, doctor.ID)
+		encounter := models.Encounter{ID: uuid.New(), PatientID: other.ID, DoctorID: doctor.ID, VisitDate: time.Now()}
+		assert.NoError(t, initializers.DB.Create(&encounter).Error)
+
+		_, err := EnqueueRecording(doctor.ID, patient.ID, EncounterDetails{ID: &encounter.ID}, audio)
+		assert.ErrorIs(t, err, ErrEncounterNotFound)
+	})
+
+	t.Run("Existing encounter with new visit details", func(t *testing.T) {
+		encounter := models.Encounter{ID: uuid.New(), PatientID: patient.ID, DoctorID: doctor.ID, VisitDate: time.Now()}
+		assert.NoError(t, initializers.DB.Create(&encounter).Error)
+
+		_, err := EnqueueRecording(doctor.ID, patient.ID, EncounterDetails{ID: &encounter.ID, Reason: "Changed"}, audio)
+		var validationErr *ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("Missing audio", func(t *testing.T) {
+		_, err := EnqueueRecording(doctor.ID, patient.ID, EncounterDetails{}, AudioSource{})
+		var validationErr *ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+}
+
+func TestRunTranscriptionJob_Encounters(t *testing.T) {
+	doctor := setupTranscriptionJobTestDB(t)
+	useTranscriber(t, func(ctx context.Context, audioURL string) (string, error) {
+		return "transcript of " + audioURL, nil
+	})
+	useReportGenerator(t, func(ctx context.Context, rawText string) (*models.MedicalReport, error) {
+		return testReport("Hypertension"), nil
+	})
+
+	// A new patient's first recording opens their first encounter
+	job, err := EnqueueTranscriptionJob(doctor.ID, models.Patient{Name: "Jane", Age: 52, Gender: "Female"}, AudioSource{URL: "first.mp3"})
+	assert.NoError(t, err)
+	assert.NoError(t, runTranscriptionJob(job.ID))
+	first := loadJob(t, job.ID)
+	if !assert.NotNil(t, first.EncounterID) {
+		return
+	}
+	patientID := *first.PatientID
+
+	// An earlier visit recorded afterwards is a new encounter of the same patient
+	visitDate := first.CreatedAt.AddDate(0, -1, 0).UTC().Truncate(time.Second)
+	job, err = EnqueueRecording(doctor.ID, patientID, EncounterDetails{VisitDate: &visitDate, Reason: "Blood pressure review"}, AudioSource{URL: "second.mp3"})
+	assert.NoError(t, err)
+	assert.NoError(t, runTranscriptionJob(job.ID))
+	second := loadJob(t, job.ID)
+
+	// and a further recording can be added to that visit
+	job, err = EnqueueRecording(doctor.ID, patientID, EncounterDetails{ID: second.EncounterID}, AudioSource{URL: "third.mp3"})
+	assert.NoError(t, err)
+	assert.NoError(t, runTranscriptionJob(job.ID))
+	third := loadJob(t, job.ID)
+
+	assert.Equal(t, models.JobStatusDone, third.Status)
+	assert.Equal(t, patientID, *third.PatientID)
+	assert.Equal(t, *second.EncounterID, *third.EncounterID)
+	assert.NotEqual(t, *first.EncounterID, *second.EncounterID)
+	assert.Equal(t, int64(1), countRows(t, &models.Patient{}))
+	assert.Equal(t, int64(2), countRows(t, &models.Encounter{}))
+	assert.Equal(t, int64(3), countRows(t, &models.Transcription{}))
+
+	patient, encounters, err := GetPatientTimeline(doctor.ID, patientID)
+	assert.NoError(t, err)
+	assert.Equal(t, "Jane", patient.Name)
+	if assert.Len(t, encounters, 2) {
+		// Ordered by visit date, so the back-dated visit comes before the one recorded first
+		assert.Equal(t, *second.EncounterID, encounters[0].ID)
+		assert.True(t, visitDate.Equal(encounters[0].VisitDate))
+		assert.Equal(t, "Blood pressure review", encounters[0].Reason)
+		if assert.Len(t, encounters[0].Transcriptions, 2) {
+			assert.Equal(t, "transcript of second.mp3", encounters[0].Transcriptions[0].Text)
+			assert.Equal(t, "transcript of third.mp3", encounters[0].Transcriptions[1].Text)
+		}
+
+		// Without a visit date, the visit is dated when its recording was submitted
+		assert.Equal(t, *first.EncounterID, encounters[1].ID)
+		assert.WithinDuration(t, first.CreatedAt, encounters[1].VisitDate, time.Second)
+		assert.Len(t, encounters[1].Transcriptions, 1)
+	}
+
+	_, _, err = GetPatientTimeline(uuid.New(), patientID)
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestRunTranscriptionJob_PatientDeletedMeanwhile(t *testing.T) {
+	doctor := setupTranscriptionJobTestDB(t)
+	useTranscriber(t, func(ctx context.Context, audioURL string) (string, error) {
+		return "raw transcript", nil
+	})
+	useReportGenerator(t, func(ctx context.Context, rawText string) (*models.MedicalReport, error) {
+		return testReport("Flu"), nil
+	})
+	patient := createTestPatient(t, doctor.ID)
+
+	job, err := EnqueueRecording(doctor.ID, patient.ID, EncounterDetails{}, AudioSource{URL: "visit.mp3"})
+	assert.NoError(t, err)
+	assert.NoError(t, initializers.DB.Delete(&models.Patient{}, "id = ?", patient.ID).Error)
+
+	assert.ErrorIs(t, runTranscriptionJob(job.ID), ErrPatientNotFound)
+	assert.Equal(t, int64(0), countRows(t, &models.Encounter{}))
+	assert.Equal(t, int64(0), countRows(t, &models.Transcription{}))
+}
@@ -0,0 +1,97 @@
+package service
+
+import (
+	"errors"
+)
+
+// Error kinds. Every error a service returns to a handler either matches one
+// of these with errors.Is or is an unexpected failure, which the API reports as
+// a 500 without details. middleware.ErrorHandler maps each kind to a status code.
+var (
+	ErrNotFound     = errors.New("not found")
+	ErrConflict     = errors.New("conflict")
+	ErrValidation   = errors.New("validation failed")
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrUpstream     = errors.New("upstream service failed")
+	// ErrForbidden is returned when a record exists but belongs to another doctor.
+	ErrForbidden = errors.New("resource belongs to another doctor")
+)
+
+// NotFoundError reports a missing record. It matches ErrNotFound.
+type NotFoundError struct {
+	Resource string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Resource + " not found"
+}
+
+func (e *NotFoundError) Is(target error) bool {
+	return target == ErrNotFound
+}
+
+// ConflictError reports a change the record's current state doesn't allow. It matches ErrConflict.
+type ConflictError struct {
+	Message string
+}
+
+func (e *ConflictError) Error() string {
+	return e.Message
+}
+
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// ValidationError reports invalid input. Fields maps each offending field to what
+// is wrong with it and may be empty when the input as a whole is rejected.
+// It matches ErrValidation.
+type ValidationError struct {
+	Message string
+	Fields  map[string]string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// InvalidField returns a ValidationError for a single bad field.
+func InvalidField(field, message string) *ValidationError {
+	return &ValidationError{Message: message, Fields: map[string]string{field: message}}
+}
+
+// UnauthorizedError reports missing or rejected credentials. It matches ErrUnauthorized.
+type UnauthorizedError struct {
+	Message string
+}
+
+func (e *UnauthorizedError) Error() string {
+	return e.Message
+}
+
+func (e *UnauthorizedError) Is(target error) bool {
+	return target == ErrUnauthorized
+}
+
+// UpstreamError wraps a failure of a third-party service such as the transcriber.
+// It matches ErrUpstream as well as the wrapped error.
+type UpstreamError struct {
+	Service string
+	Err     error
+}
+
+func (e *UpstreamError) Error() string {
+	return e.Service + ": " + e.Err.Error()
+}
+
+func (e *UpstreamError) Unwrap() error {
+	return e.Err
+}
+
+func (e *UpstreamError) Is(target error) bool {
+	return target == ErrUpstream
+}
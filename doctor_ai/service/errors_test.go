@@ -0,0 +1,59 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+
+	"itish41/doctor_ai_assistant/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorKinds(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		kind error
+	}{
+		{name: "Missing transcription", err: ErrTranscriptionNotFound, kind: ErrNotFound},
+		{name: "Missing patient", err: ErrPatientNotFound, kind: ErrNotFound},
+		{name: "Missing job", err: ErrTranscriptionJobNotFound, kind: ErrNotFound},
+		{name: "Signed report", err: ErrReportSigned, kind: ErrConflict},
+		{name: "Taken email", err: ErrEmailTaken, kind: ErrConflict},
+		{name: "Unknown status", err: ErrUnknownReportStatus, kind: ErrValidation},
+		{name: "Wrapped invalid report", err: fmt.Errorf("%w: missing diagnosis", ErrInvalidReport), kind: ErrValidation},
+		{name: "Bad credentials", err: ErrInvalidCredentials, kind: ErrUnauthorized},
+		{name: "Reused refresh token", err: ErrRefreshTokenReused, kind: ErrUnauthorized},
+		{name: "Transcriber failure", err: &UpstreamError{Service: "transcriber", Err: errors.New("timeout")}, kind: ErrUpstream},
+		{name: "LLM status", err: &UpstreamStatusError{StatusCode: http.StatusBadGateway}, kind: ErrUpstream},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.ErrorIs(t, tt.err, tt.kind)
+			for _, other := range []error{ErrNotFound, ErrConflict, ErrValidation, ErrUnauthorized, ErrUpstream} {
+				if other != tt.kind {
+					assert.NotErrorIs(t, tt.err, other)
+				}
+			}
+		})
+	}
+}
+
+func TestValidationErrorFields(t *testing.T) {
+	err := validatePatient(&models.Patient{Name: "Jane"})
+
+	var validationErr *ValidationError
+	if assert.ErrorAs(t, err, &validationErr) {
+		assert.Equal(t, map[string]string{"age": "patient age must be greater than zero"}, validationErr.Fields)
+	}
+}
+
+func TestUpstreamErrorUnwraps(t *testing.T) {
+	err := &UpstreamError{Service: "report generator", Err: &UpstreamStatusError{StatusCode: http.StatusTooManyRequests}}
+
+	assert.ErrorIs(t, err, ErrRateLimited)
+	assert.Equal(t, "report generator: report generator returned status 429", err.Error())
+}
@@ -0,0 +1,467 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/storage"
+	"log"
+	"net/url"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Defaults for the background export workers
+const (
+	DefaultExportWorkers   = 1
+	DefaultExportRetention = 24 * time.Hour
+	exportQueueSize        = 20
+	exportSweepInterval    = time.Hour
+)
+
+// ExportLinkTTL is how long a signed download link stays valid; polling the export
+// returns a fresh one for as long as the archive is kept.
+const ExportLinkTTL = 15 * time.Minute
+
+var (
+	ErrExportNotFound    error = &NotFoundError{Resource: "export"}
+	ErrExportInProgress  error = &ConflictError{Message: "an export is already in progress"}
+	ErrExportLinkInvalid error = &UnauthorizedError{Message: "download link is invalid or has expired"}
+)
+
+var (
+	exportStorageMu sync.RWMutex
+	exportStorage   storage.ObjectStorage
+)
+
+// SetExportStorage replaces the storage export archives are kept in.
+func SetExportStorage(s storage.ObjectStorage) {
+	exportStorageMu.Lock()
+	exportStorage = s
+	exportStorageMu.Unlock()
+}
+
+// currentExportStorage returns the configured storage, building it from the environment on first use.
+func currentExportStorage() (storage.ObjectStorage, error) {
+	exportStorageMu.RLock()
+	s := exportStorage
+	exportStorageMu.RUnlock()
+	if s != nil {
+		return s, nil
+	}
+
+	s, err := storage.NewFromEnv()
+	if err != nil {
+		log.Println("Error configuring export storage:", err)
+		return nil, err
+	}
+	SetExportStorage(s)
+	return s, nil
+}
+
+// ExportWorkerPool builds queued export archives on a fixed number of goroutines and
+// deletes archives once they have been kept for the retention period.
+type ExportWorkerPool struct {
+	queue     chan uuid.UUID
+	retention time.Duration
+	sweep     chan struct{} // closed to stop the sweeper
+
+	mu      sync.RWMutex
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+var (
+	exportPoolMu sync.RWMutex
+	exportPool   *ExportWorkerPool
+)
+
+// StartExportWorkers starts the worker pool used by EnqueueExportJob, resumes exports
+// interrupted by a restart and starts sweeping expired archives.
+func StartExportWorkers(workers int, retention time.Duration) *ExportWorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	if retention <= 0 {
+		retention = DefaultExportRetention
+	}
+
+	pool := &ExportWorkerPool{
+		queue:     make(chan uuid.UUID, exportQueueSize),
+		retention: retention,
+		sweep:     make(chan struct{}),
+	}
+	for i := 0; i < workers; i++ {
+		pool.wg.Add(1)
+		go pool.work()
+	}
+	pool.wg.Add(1)
+	go pool.sweepExpired()
+
+	exportPoolMu.Lock()
+	exportPool = pool
+	exportPoolMu.Unlock()
+
+	pool.resumePendingJobs()
+	log.Printf("Started %d export workers", workers)
+	return pool
+}
+
+// Stop stops accepting exports and waits for in-flight ones to finish.
+func (p *ExportWorkerPool) Stop() {
+	p.mu.Lock()
+	if !p.stopped {
+		p.stopped = true
+		close(p.queue)
+		close(p.sweep)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+
+	exportPoolMu.Lock()
+	if exportPool == p {
+		exportPool = nil
+	}
+	exportPoolMu.Unlock()
+}
+
+// enqueue hands a job to the workers, returning false once the pool is stopped.
+func (p *ExportWorkerPool) enqueue(jobID uuid.UUID) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.stopped {
+		return false
+	}
+	p.queue <- jobID
+	return true
+}
+
+func (p *ExportWorkerPool) work() {
+	defer p.wg.Done()
+	for jobID := range p.queue {
+		if err := runExportJob(jobID, p.retention); err != nil {
+			log.Printf("Export job %v failed: %v", jobID, err)
+			recordExportJobFailure(jobID, err)
+		}
+	}
+}
+
+// sweepExpired deletes expired archives now and then every exportSweepInterval.
+func (p *ExportWorkerPool) sweepExpired() {
+	defer p.wg.Done()
+	ticker := time.NewTicker(exportSweepInterval)
+	defer ticker.Stop()
+	for {
+		purgeExpiredExports(time.Now())
+		select {
+		case <-p.sweep:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// resumePendingJobs requeues exports interrupted by a restart. A half-written archive
+// is simply written again.
+func (p *ExportWorkerPool) resumePendingJobs() {
+	if err := initializers.DB.Model(&models.ExportJob{}).
+		Where("status = ?", models.ExportStatusRunning).
+		Updates(map[string]interface{}{"status": models.ExportStatusQueued, "updated_at": time.Now()}).Error; err != nil {
+		log.Println("Error resetting interrupted export jobs:", err)
+		return
+	}
+
+	var jobIDs []uuid.UUID
+	if err := initializers.DB.Model(&models.ExportJob{}).
+		Where("status = ?", models.ExportStatusQueued).
+		Order("created_at ASC").
+		Pluck("id", &jobIDs).Error; err != nil {
+		log.Println("Error loading pending export jobs:", err)
+		return
+	}
+	if len(jobIDs) == 0 {
+		return
+	}
+
+	log.Printf("Resuming %d pending export jobs", len(jobIDs))
+	go func() {
+		for _, jobID := range jobIDs {
+			if !p.enqueue(jobID) {
+				return
+			}
+		}
+	}()
+}
+
+// EnqueueExportJob queues an export of everything the doctor has. Only one export per
+// doctor runs at a time.
+func EnqueueExportJob(doctorID uuid.UUID) (*models.ExportJob, error) {
+	var pending int64
+	if err := initializers.DB.Model(&models.ExportJob{}).
+		Where("doctor_id = ? AND status IN ?", doctorID, []string{models.ExportStatusQueued, models.ExportStatusRunning}).
+		Count(&pending).Error; err != nil {
+		log.Println("Error checking pending exports:", err)
+		return nil, errors.New("failed to create export")
+	}
+	if pending > 0 {
+		return nil, ErrExportInProgress
+	}
+
+	job := models.ExportJob{
+		ID:        uuid.New(),
+		DoctorID:  doctorID,
+		Status:    models.ExportStatusQueued,
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if err := initializers.DB.Create(&job).Error; err != nil {
+		log.Println("Error creating export job:", err)
+		return nil, errors.New("failed to create export")
+	}
+	log.Println("Export job queued:", job.ID)
+
+	exportPoolMu.RLock()
+	pool := exportPool
+	exportPoolMu.RUnlock()
+	if pool != nil {
+		// Don't hold the request up if the queue is full; the job is already persisted
+		go pool.enqueue(job.ID)
+	} else {
+		log.Println("No export workers running, export will start when they do:", job.ID)
+	}
+	return &job, nil
+}
+
+// GetExportJob returns an export if it belongs to the doctor.
+func GetExportJob(doctorID, jobID uuid.UUID) (*models.ExportJob, error) {
+	var job models.ExportJob
+	if err := initializers.DB.Where("id = ? AND doctor_id = ?", jobID, doctorID).First(&job).Error; err != nil {
+		log.Println("Export job not found:", err)
+		return nil, ErrExportNotFound
+	}
+	return &job, nil
+}
+
+// runExportJob writes a queued export's archive to storage, checksumming it on the way.
+func runExportJob(jobID uuid.UUID, retention time.Duration) error {
+	result := initializers.DB.Model(&models.ExportJob{}).
+		Where("id = ? AND status = ?", jobID, models.ExportStatusQueued).
+		Updates(map[string]interface{}{"status": models.ExportStatusRunning, "updated_at": time.Now()})
+	if result.Error != nil {
+		return fmt.Errorf("failed to update export status: %v", result.Error)
+	}
+	if result.RowsAffected != 1 {
+		// Already running or finished elsewhere
+		return nil
+	}
+
+	var job models.ExportJob
+	if err := initializers.DB.Where("id = ?", jobID).First(&job).Error; err != nil {
+		return fmt.Errorf("failed to load export: %v", err)
+	}
+	store, err := currentExportStorage()
+	if err != nil {
+		return err
+	}
+
+	// Stream the archive into storage as it is written
+	reader, writer := io.Pipe()
+	written := make(chan exportSummary, 1)
+	go func() {
+		summary, err := writeExportArchive(writer, job.DoctorID, time.Now())
+		written <- summary
+		writer.CloseWithError(err)
+	}()
+
+	ctx := context.Background()
+	key := fmt.Sprintf("exports/%s/%s.zip", job.DoctorID, job.ID)
+	checksum := sha256.New()
+	size, err := store.Put(ctx, key, io.TeeReader(reader, checksum))
+	reader.CloseWithError(err) // unblocks the writer if storage gave up first
+	summary := <-written
+	if err != nil {
+		if err := store.Delete(ctx, key); err != nil {
+			log.Println("Error removing partial export archive:", err)
+		}
+		return fmt.Errorf("failed to write export archive: %w", err)
+	}
+
+	completedAt := time.Now()
+	expiresAt := completedAt.Add(retention)
+	if err := initializers.DB.Model(&models.ExportJob{}).Where("id = ?", jobID).Updates(map[string]interface{}{
+		"status":              models.ExportStatusDone,
+		"last_error":          "",
+		"storage_key":         key,
+		"size":                size,
+		"checksum":            hex.EncodeToString(checksum.Sum(nil)),
+		"patient_count":       summary.Patients,
+		"transcription_count": summary.Transcriptions,
+		"completed_at":        completedAt,
+		"expires_at":          expiresAt,
+		"updated_at":          completedAt,
+	}).Error; err != nil {
+		if err := store.Delete(ctx, key); err != nil {
+			log.Println("Error removing orphaned export archive:", err)
+		}
+		return fmt.Errorf("failed to complete export: %v", err)
+	}
+	log.Println("Export job completed:", jobID)
+	return nil
+}
+
+// recordExportJobFailure marks an export failed. Exports aren't retried; the doctor can
+// start another one.
+func recordExportJobFailure(jobID uuid.UUID, jobErr error) {
+	if err := initializers.DB.Model(&models.ExportJob{}).Where("id = ?", jobID).Updates(map[string]interface{}{
+		"status":     models.ExportStatusFailed,
+		"last_error": jobErr.Error(),
+		"updated_at": time.Now(),
+	}).Error; err != nil {
+		log.Println("Error recording export job failure:", err)
+	}
+}
+
+// purgeExpiredExports deletes the archives of exports that expired by now.
+func purgeExpiredExports(now time.Time) {
+	var jobs []models.ExportJob
+	if err := initializers.DB.Where("status = ? AND expires_at <= ?", models.ExportStatusDone, now).Find(&jobs).Error; err != nil {
+		log.Println("Error loading expired exports:", err)
+		return
+	}
+	if len(jobs) == 0 {
+		return
+	}
+	store, err := currentExportStorage()
+	if err != nil {
+		return
+	}
+
+	for _, job := range jobs {
+		if err := store.Delete(context.Background(), job.StorageKey); err != nil {
+			log.Println("Error deleting expired export archive:", err)
+			continue
+		}
+		if err := initializers.DB.Model(&models.ExportJob{}).Where("id = ?", job.ID).Updates(map[string]interface{}{
+			"status":      models.ExportStatusExpired,
+			"storage_key": "",
+			"updated_at":  now,
+		}).Error; err != nil {
+			log.Println("Error expiring export:", err)
+		}
+	}
+	log.Printf("Deleted %d expired export archives", len(jobs))
+}
+
+// ExportLink is a signed URL that downloads a finished export without a bearer token.
+type ExportLink struct {
+	URL       string
+	ExpiresAt time.Time
+}
+
+// NewExportLink signs a link to download a finished export from downloadURL. It is valid
+// for ExportLinkTTL, and never for longer than the archive is kept.
+func NewExportLink(job models.ExportJob, downloadURL string, now time.Time) (*ExportLink, bool) {
+	if job.Status != models.ExportStatusDone || job.ExpiresAt == nil || !now.Before(*job.ExpiresAt) {
+		return nil, false
+	}
+	expiresAt := now.Add(ExportLinkTTL)
+	if job.ExpiresAt.Before(expiresAt) {
+		expiresAt = *job.ExpiresAt
+	}
+	expires := strconv.FormatInt(expiresAt.Unix(), 10)
+	signature, ok := exportLinkSignature(job.ID, expires)
+	if !ok {
+		log.Println("No EXPORT_LINK_SECRET or JWT_SECRET set, not issuing a download link for export:", job.ID)
+		return nil, false
+	}
+	query := url.Values{"expires": {expires}, "signature": {signature}}
+	return &ExportLink{URL: downloadURL + "?" + query.Encode(), ExpiresAt: time.Unix(expiresAt.Unix(), 0)}, true
+}
+
+// ExportDownload is an export archive opened for download; callers must close Archive.
+type ExportDownload struct {
+	Job     models.ExportJob
+	Archive io.ReadCloser
+}
+
+// OpenExportDownload checks a signed link's expiry and signature and opens the archive
+// it names.
+func OpenExportDownload(jobID uuid.UUID, expires, signature string, now time.Time) (*ExportDownload, error) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || now.Unix() >= expiresAt {
+		return nil, ErrExportLinkInvalid
+	}
+	expected, ok := exportLinkSignature(jobID, expires)
+	if !ok || !hmac.Equal([]byte(signature), []byte(expected)) {
+		return nil, ErrExportLinkInvalid
+	}
+
+	var job models.ExportJob
+	if err := initializers.DB.Where("id = ?", jobID).First(&job).Error; err != nil {
+		log.Println("Export job not found:", err)
+		return nil, ErrExportNotFound
+	}
+	if job.Status != models.ExportStatusDone {
+		return nil, ErrExportNotFound
+	}
+	store, err := currentExportStorage()
+	if err != nil {
+		return nil, errors.New("export storage is not available")
+	}
+	archive, err := store.Get(context.Background(), job.StorageKey)
+	if errors.Is(err, storage.ErrObjectNotFound) {
+		return nil, ErrExportNotFound
+	}
+	if err != nil {
+		log.Println("Error opening export archive:", err)
+		return nil, errors.New("failed to open export")
+	}
+	return &ExportDownload{Job: job, Archive: archive}, nil
+}
+
+// ExportFileName names a downloaded export after the day it was made.
+func ExportFileName(job models.ExportJob) string {
+	made := job.CreatedAt
+	if job.CompletedAt != nil {
+		made = *job.CompletedAt
+	}
+	return "practice-export_" + made.Format("2006-01-02") + ".zip"
+}
+
+// ExportLinksEnabled reports whether a secret is configured to sign export download links.
+func ExportLinksEnabled() bool {
+	return exportLinkSecret() != ""
+}
+
+// exportLinkSecret returns EXPORT_LINK_SECRET, or the JWT secret when that isn't set.
+// There is deliberately no built-in fallback: anyone who knew it could forge a link to
+// a doctor's whole archive.
+func exportLinkSecret() string {
+	if secret := os.Getenv("EXPORT_LINK_SECRET"); secret != "" {
+		return secret
+	}
+	return os.Getenv("JWT_SECRET")
+}
+
+// exportLinkSignature signs an export ID and link expiry, reporting false when no
+// secret is configured.
+func exportLinkSignature(jobID uuid.UUID, expires string) (string, bool) {
+	secret := exportLinkSecret()
+	if secret == "" {
+		return "", false
+	}
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(jobID.String() + "\n" + expires))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil)), true
+}
@@ -0,0 +1,343 @@
+package service
+
+import (
+	"archive/zip"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"log"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ExportFormatVersion is the layout version recorded in an export's manifest.
+const ExportFormatVersion = 1
+
+// exportBatchSize is how many transcriptions an export loads at a time.
+const exportBatchSize = 100
+
+// ExportManifest describes an export archive. It is written last, as manifest.json, so
+// it can list the checksum of every other file.
+type ExportManifest struct {
+	FormatVersion  int                  `json:"format_version"`
+	GeneratedAt    time.Time            `json:"generated_at"`
+	DoctorID       uuid.UUID            `json:"doctor_id"`
+	Patients       int                  `json:"patients"`
+	Encounters     int                  `json:"encounters"`
+	Transcriptions int                  `json:"transcriptions"`
+	Files          []ExportManifestFile `json:"files"`
+}
+
+type ExportManifestFile struct {
+	Path   string `json:"path"`
+	Size   int64  `json:"size"`
+	SHA256 string `json:"sha256"`
+}
+
+// exportSummary counts what an archive holds.
+type exportSummary struct {
+	Patients, Encounters, Transcriptions int
+}
+
+// The records of an export's data files. Each is written as a line of the NDJSON file
+// and, without nested values, as a row of the CSV file.
+type (
+	exportProfile struct {
+		ID             uuid.UUID `json:"id"`
+		Name           string    `json:"name"`
+		Specialization string    `json:"specialization"`
+		Email          string    `json:"email"`
+		Phone          string    `json:"phone"`
+		CreatedAt      time.Time `json:"created_at"`
+	}
+	exportPatient struct {
+		ID        uuid.UUID `json:"id"`
+		Name      string    `json:"name"`
+		Age       int       `json:"age"`
+		Gender    string    `json:"gender"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	exportEncounter struct {
+		ID        uuid.UUID `json:"id"`
+		PatientID uuid.UUID `json:"patient_id"`
+		VisitDate time.Time `json:"visit_date"`
+		Reason    string    `json:"reason"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	exportTranscription struct {
+		ID               uuid.UUID             `json:"id"`
+		PatientID        uuid.UUID             `json:"patient_id"`
+		EncounterID      *uuid.UUID            `json:"encounter_id"`
+		Status           string                `json:"status"`
+		SignedByID       *uuid.UUID            `json:"signed_by_id"`
+		SignedAt         *time.Time            `json:"signed_at"`
+		CreatedAt        time.Time             `json:"created_at"`
+		Text             string                `json:"text"`
+		Report           string                `json:"report"`
+		StructuredReport *models.MedicalReport `json:"structured_report"`
+		ReportPDF        string                `json:"report_pdf"` // path of the rendered report in the archive
+	}
+)
+
+var (
+	patientColumns       = []string{"id", "name", "age", "gender", "created_at"}
+	encounterColumns     = []string{"id", "patient_id", "visit_date", "reason", "created_at"}
+	transcriptionColumns = []string{"id", "patient_id", "encounter_id", "status", "signed_by_id", "signed_at", "created_at", "text", "report", "report_pdf"}
+)
+
+func (p exportPatient) row() []string {
+	return []string{p.ID.String(), p.Name, strconv.Itoa(p.Age), p.Gender, csvTime(&p.CreatedAt)}
+}
+
+func (e exportEncounter) row() []string {
+	return []string{e.ID.String(), e.PatientID.String(), csvTime(&e.VisitDate), e.Reason, csvTime(&e.CreatedAt)}
+}
+
+func (t exportTranscription) row() []string {
+	return []string{t.ID.String(), t.PatientID.String(), csvUUID(t.EncounterID), t.Status, csvUUID(t.SignedByID),
+		csvTime(t.SignedAt), csvTime(&t.CreatedAt), t.Text, t.Report, t.ReportPDF}
+}
+
+// exportArchive writes the files of an export, keeping a manifest entry for each.
+type exportArchive struct {
+	zip      *zip.Writer
+	modified time.Time
+	files    []ExportManifestFile
+}
+
+// add writes a file to the archive, checksumming what write produces.
+func (a *exportArchive) add(name string, write func(w io.Writer) error) error {
+	entry, err := a.zip.CreateHeader(&zip.FileHeader{Name: name, Method: zip.Deflate, Modified: a.modified})
+	if err != nil {
+		return err
+	}
+	checksum := sha256.New()
+	counter := &countingWriter{w: io.MultiWriter(entry, checksum)}
+	if err := write(counter); err != nil {
+		return fmt.Errorf("failed to write %s: %w", name, err)
+	}
+	a.files = append(a.files, ExportManifestFile{Path: name, Size: counter.n, SHA256: hex.EncodeToString(checksum.Sum(nil))})
+	return nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// writeExportArchive writes everything the doctor has as a ZIP archive:
+//
+//	profile.json                           the doctor, without their password
+//	patients.ndjson, patients.csv
+//	encounters.ndjson, encounters.csv
+//	transcriptions.ndjson, transcriptions.csv
+//	reports/<transcription id>/<name>.pdf  each report, rendered as for download
+//	manifest.json                          counts and the size and SHA-256 of every file
+//
+// Transcriptions are loaded in batches, so the size of a practice doesn't decide how
+// much memory an export takes.
+func writeExportArchive(w io.Writer, doctorID uuid.UUID, now time.Time) (exportSummary, error) {
+	var summary exportSummary
+	var doctor models.Doctor
+	if err := initializers.DB.Omit("password").Where("id = ?", doctorID).First(&doctor).Error; err != nil {
+		log.Println("Error retrieving doctor to export:", err)
+		return summary, errors.New("failed to load doctor")
+	}
+	var patients []models.Patient
+	if err := initializers.DB.Where("doctor_id = ?", doctorID).Order("created_at ASC").Order("id ASC").Find(&patients).Error; err != nil {
+		log.Println("Error fetching patients to export:", err)
+		return summary, errors.New("failed to load patients")
+	}
+	var encounters []models.Encounter
+	if err := initializers.DB.Where("doctor_id = ?", doctorID).Order("visit_date ASC").Order("id ASC").Find(&encounters).Error; err != nil {
+		log.Println("Error fetching encounters to export:", err)
+		return summary, errors.New("failed to load encounters")
+	}
+	patientsByID := make(map[uuid.UUID]models.Patient, len(patients))
+	for _, patient := range patients {
+		patientsByID[patient.ID] = patient
+	}
+
+	archive := &exportArchive{zip: zip.NewWriter(w), modified: now}
+	err := archive.add("profile.json", func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(exportProfile{
+			ID: doctor.ID, Name: doctor.Name, Specialization: doctor.Specialization,
+			Email: doctor.Email, Phone: doctor.Phone, CreatedAt: doctor.CreatedAt,
+		})
+	})
+	if err != nil {
+		return summary, err
+	}
+
+	exportedPatients := make([]exportPatient, len(patients))
+	for i, patient := range patients {
+		exportedPatients[i] = exportPatient{ID: patient.ID, Name: patient.Name, Age: patient.Age, Gender: patient.Gender, CreatedAt: patient.CreatedAt}
+	}
+	if err := addRecords(archive, "patients", patientColumns, exportedPatients); err != nil {
+		return summary, err
+	}
+	exportedEncounters := make([]exportEncounter, len(encounters))
+	for i, encounter := range encounters {
+		exportedEncounters[i] = exportEncounter{ID: encounter.ID, PatientID: encounter.PatientID, VisitDate: encounter.VisitDate, Reason: encounter.Reason, CreatedAt: encounter.CreatedAt}
+	}
+	if err := addRecords(archive, "encounters", encounterColumns, exportedEncounters); err != nil {
+		return summary, err
+	}
+
+	// The transcriptions are read once for each file they appear in
+	toRecord := func(transcription models.Transcription) exportTranscription {
+		return exportTranscription{
+			ID: transcription.ID, PatientID: transcription.PatientID, EncounterID: transcription.EncounterID,
+			Status: transcription.Status, SignedByID: transcription.SignedByID, SignedAt: transcription.SignedAt,
+			CreatedAt: transcription.CreatedAt, Text: transcription.Text, Report: transcription.Report,
+			StructuredReport: transcription.StructuredReport,
+			ReportPDF:        exportReportPath(transcription, patientsByID[transcription.PatientID]),
+		}
+	}
+	err = archive.add("transcriptions.ndjson", func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		return eachExportTranscription(doctorID, func(transcription models.Transcription) error {
+			summary.Transcriptions++
+			return encoder.Encode(toRecord(transcription))
+		})
+	})
+	if err != nil {
+		return summary, err
+	}
+	err = archive.add("transcriptions.csv", func(w io.Writer) error {
+		records := csv.NewWriter(w)
+		if err := records.Write(transcriptionColumns); err != nil {
+			return err
+		}
+		err := eachExportTranscription(doctorID, func(transcription models.Transcription) error {
+			return records.Write(toRecord(transcription).row())
+		})
+		if err != nil {
+			return err
+		}
+		records.Flush()
+		return records.Error()
+	})
+	if err != nil {
+		return summary, err
+	}
+	err = eachExportTranscription(doctorID, func(transcription models.Transcription) error {
+		doc := ReportDocument{Transcription: transcription, Patient: patientsByID[transcription.PatientID], Doctor: doctor}
+		return archive.add(exportReportPath(transcription, doc.Patient), func(w io.Writer) error {
+			return GeneratePDF(w, NewReportView(doc))
+		})
+	})
+	if err != nil {
+		return summary, err
+	}
+
+	summary.Patients, summary.Encounters = len(patients), len(encounters)
+	manifest := ExportManifest{
+		FormatVersion:  ExportFormatVersion,
+		GeneratedAt:    now.UTC(),
+		DoctorID:       doctorID,
+		Patients:       summary.Patients,
+		Encounters:     summary.Encounters,
+		Transcriptions: summary.Transcriptions,
+		Files:          archive.files,
+	}
+	err = archive.add("manifest.json", func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(manifest)
+	})
+	if err != nil {
+		return summary, err
+	}
+	return summary, archive.zip.Close()
+}
+
+// addRecords writes records as <name>.ndjson and <name>.csv.
+func addRecords[T interface{ row() []string }](archive *exportArchive, name string, columns []string, records []T) error {
+	err := archive.add(name+".ndjson", func(w io.Writer) error {
+		encoder := json.NewEncoder(w)
+		for _, record := range records {
+			if err := encoder.Encode(record); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	return archive.add(name+".csv", func(w io.Writer) error {
+		rows := csv.NewWriter(w)
+		if err := rows.Write(columns); err != nil {
+			return err
+		}
+		for _, record := range records {
+			if err := rows.Write(record.row()); err != nil {
+				return err
+			}
+		}
+		rows.Flush()
+		return rows.Error()
+	})
+}
+
+// eachExportTranscription calls fn with each of the doctor's transcriptions, a batch at
+// a time, in ID order.
+func eachExportTranscription(doctorID uuid.UUID, fn func(models.Transcription) error) error {
+	var batch []models.Transcription
+	var fnErr error
+	result := initializers.DB.Where("doctor_id = ?", doctorID).FindInBatches(&batch, exportBatchSize, func(tx *gorm.DB, _ int) error {
+		for _, transcription := range batch {
+			if fnErr = fn(transcription); fnErr != nil {
+				return fnErr
+			}
+		}
+		return nil
+	})
+	if fnErr != nil {
+		return fnErr
+	}
+	if result.Error != nil {
+		log.Println("Error fetching transcriptions to export:", result.Error)
+		return errors.New("failed to load transcriptions")
+	}
+	return nil
+}
+
+// exportReportPath is where a transcription's rendered report goes in the archive. The
+// directory keeps two reports of a patient on the same day apart.
+func exportReportPath(transcription models.Transcription, patient models.Patient) string {
+	return "reports/" + transcription.ID.String() + "/" +
+		ReportFileName(ReportDocument{Transcription: transcription, Patient: patient}, "pdf")
+}
+
+func csvTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+func csvUUID(id *uuid.UUID) string {
+	if id == nil {
+		return ""
+	}
+	return id.String()
+}
@@ -0,0 +1,393 @@
+package service
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/csv"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"itish41/doctor_ai_assistant/storage"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func setupExportTestDB(t *testing.T) (*models.Doctor, storage.ObjectStorage) {
+	doctor := setupTranscriptionJobTestDB(t)
+	if err := initializers.DB.Exec(`CREATE TABLE IF NOT EXISTS export_jobs (
+		id TEXT PRIMARY KEY,
+		doctor_id TEXT NOT NULL,
+		status TEXT NOT NULL,
+		last_error TEXT,
+		storage_key TEXT,
+		size INTEGER NOT NULL DEFAULT 0,
+		checksum TEXT,
+		patient_count INTEGER NOT NULL DEFAULT 0,
+		transcription_count INTEGER NOT NULL DEFAULT 0,
+		completed_at DATETIME,
+		expires_at DATETIME,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error; err != nil {
+		t.Fatalf("Failed to create export_jobs table: %v", err)
+	}
+
+	store, err := storage.NewLocal(t.TempDir())
+	if err != nil {
+		t.Fatalf("Failed to create storage: %v", err)
+	}
+	useExportStorage(t, store)
+	return doctor, store
+}
+
+func useExportStorage(t *testing.T, store storage.ObjectStorage) {
+	SetExportStorage(store)
+	t.Cleanup(func() { SetExportStorage(nil) })
+}
+
+// failingStorage rejects every object written to it.
+type failingStorage struct{ storage.ObjectStorage }
+
+func (failingStorage) Put(ctx context.Context, key string, r io.Reader) (int64, error) {
+	return 0, errors.New("disk full")
+}
+
+func (failingStorage) Delete(ctx context.Context, key string) error {
+	return nil
+}
+
+func loadExportJob(t *testing.T, jobID uuid.UUID) models.ExportJob {
+	var job models.ExportJob
+	assert.NoError(t, initializers.DB.Where("id = ?", jobID).First(&job).Error)
+	return job
+}
+
+func waitForExportStatus(t *testing.T, jobID uuid.UUID, status string) models.ExportJob {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job := loadExportJob(t, jobID)
+		if job.Status == status {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	job := loadExportJob(t, jobID)
+	t.Fatalf("export %v did not reach status %q, last status %q", jobID, status, job.Status)
+	return job
+}
+
+// readArchive reads every file of a ZIP archive.
+func readArchive(t *testing.T, data []byte) map[string][]byte {
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("Failed to open archive: %v", err)
+	}
+	files := map[string][]byte{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		assert.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		reader.Close()
+		files[file.Name] = content
+	}
+	return files
+}
+
+// seedPractice gives the doctor two patients, one with a visit and a signed report and
+// one with a draft.
+func seedPractice(t *testing.T, doctorID uuid.UUID) (models.Transcription, models.Transcription) {
+	first := createTestPatient(t, doctorID)
+	second := createTestPatient(t, doctorID)
+	assert.NoError(t, initializers.DB.Model(&second).Update("name", "Second, \"Quoted\" Patient").Error)
+
+	encounter := models.Encounter{ID: uuid.New(), PatientID: first.ID, DoctorID: doctorID, VisitDate: time.Now(), Reason: "Follow-up"}
+	assert.NoError(t, initializers.DB.Create(&encounter).Error)
+	signedAt := time.Now()
+	signed := models.Transcription{
+		ID: uuid.New(), DoctorID: doctorID, PatientID: first.ID, EncounterID: &encounter.ID,
+		Text: "Patient reports a cough,\nworse at night", Report: testReport("Bronchitis").Text(), StructuredReport: testReport("Bronchitis"),
+		Status: models.ReportStatusSigned, SignedByID: &doctorID, SignedAt: &signedAt, CreatedAt: time.Now(),
+	}
+	draft := models.Transcription{
+		ID: uuid.New(), DoctorID: doctorID, PatientID: second.ID,
+		Text: "Headache", Report: "Headache", Status: models.ReportStatusDraft, CreatedAt: time.Now(),
+	}
+	assert.NoError(t, initializers.DB.Create(&signed).Error)
+	assert.NoError(t, initializers.DB.Create(&draft).Error)
+	return signed, draft
+}
+
+func TestRunExportJob(t *testing.T) {
+	doctor, store := setupExportTestDB(t)
+	signed, draft := seedPractice(t, doctor.ID)
+	// Another doctor's records stay out of the export
+	other := &models.Doctor{ID: uuid.New(), Name: "Other", Email: "other@example.com", Password: "x", Phone: "555", Specialization: "General"}
+	assert.NoError(t, initializers.DB.Create(other).Error)
+	createTestTranscription(t, other.ID, createTestPatient(t, other.ID).ID)
+
+	job, err := EnqueueExportJob(doctor.ID)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, models.ExportStatusQueued, job.Status)
+	assert.NoError(t, runExportJob(job.ID, time.Hour))
+
+	stored := loadExportJob(t, job.ID)
+	assert.Equal(t, models.ExportStatusDone, stored.Status)
+	assert.Equal(t, 2, stored.PatientCount)
+	assert.Equal(t, 2, stored.TranscriptionCount)
+	if assert.NotNil(t, stored.ExpiresAt) && assert.NotNil(t, stored.CompletedAt) {
+		assert.WithinDuration(t, stored.CompletedAt.Add(time.Hour), *stored.ExpiresAt, time.Second)
+	}
+
+	reader, err := store.Get(context.Background(), stored.StorageKey)
+	if !assert.NoError(t, err) {
+		return
+	}
+	data, _ := io.ReadAll(reader)
+	reader.Close()
+	checksum := sha256.Sum256(data)
+	assert.Equal(t, hex.EncodeToString(checksum[:]), stored.Checksum)
+	assert.Equal(t, int64(len(data)), stored.Size)
+
+	files := readArchive(t, data)
+	signedPDF := "reports/" + signed.ID.String() + "/returning-patient_" + signed.CreatedAt.Format("2006-01-02") + "_report.pdf"
+	draftPDF := "reports/" + draft.ID.String() + "/second-quoted-patient_" + draft.CreatedAt.Format("2006-01-02") + "_report.pdf"
+	for _, name := range []string{"profile.json", "patients.ndjson", "patients.csv", "encounters.ndjson", "encounters.csv",
+		"transcriptions.ndjson", "transcriptions.csv", signedPDF, draftPDF, "manifest.json"} {
+		assert.Contains(t, files, name)
+	}
+	assert.Len(t, files, 10)
+	assert.True(t, bytes.HasPrefix(files[signedPDF], []byte("%PDF-")))
+
+	t.Run("Manifest lists every other file with its checksum", func(t *testing.T) {
+		var manifest ExportManifest
+		assert.NoError(t, json.Unmarshal(files["manifest.json"], &manifest))
+		assert.Equal(t, ExportFormatVersion, manifest.FormatVersion)
+		assert.Equal(t, doctor.ID, manifest.DoctorID)
+		assert.Equal(t, 2, manifest.Patients)
+		assert.Equal(t, 1, manifest.Encounters)
+		assert.Equal(t, 2, manifest.Transcriptions)
+		assert.Len(t, manifest.Files, len(files)-1)
+		for _, file := range manifest.Files {
+			sum := sha256.Sum256(files[file.Path])
+			assert.Equal(t, hex.EncodeToString(sum[:]), file.SHA256, file.Path)
+			assert.Equal(t, int64(len(files[file.Path])), file.Size, file.Path)
+		}
+	})
+
+	t.Run("Profile leaves the password out", func(t *testing.T) {
+		var profile map[string]interface{}
+		assert.NoError(t, json.Unmarshal(files["profile.json"], &profile))
+		assert.Equal(t, doctor.Email, profile["email"])
+		assert.NotContains(t, profile, "password")
+		assert.NotContains(t, string(files["profile.json"]), doctor.Password)
+	})
+
+	t.Run("Data files", func(t *testing.T) {
+		patients, err := csv.NewReader(bytes.NewReader(files["patients.csv"])).ReadAll()
+		assert.NoError(t, err)
+		if assert.Len(t, patients, 3) {
+			assert.Equal(t, patientColumns, patients[0])
+			assert.Equal(t, "Second, \"Quoted\" Patient", patients[2][1])
+		}
+
+		transcriptions, err := csv.NewReader(bytes.NewReader(files["transcriptions.csv"])).ReadAll()
+		assert.NoError(t, err)
+		assert.Len(t, transcriptions, 3)
+
+		var records []exportTranscription
+		scanner := bufio.NewScanner(bytes.NewReader(files["transcriptions.ndjson"]))
+		for scanner.Scan() {
+			var record exportTranscription
+			assert.NoError(t, json.Unmarshal(scanner.Bytes(), &record))
+			records = append(records, record)
+		}
+		if assert.Len(t, records, 2) {
+			byID := map[uuid.UUID]exportTranscription{records[0].ID: records[0], records[1].ID: records[1]}
+			assert.Equal(t, "Patient reports a cough,\nworse at night", byID[signed.ID].Text)
+			assert.Equal(t, "Bronchitis", byID[signed.ID].StructuredReport.Diagnosis)
+			assert.Equal(t, signedPDF, byID[signed.ID].ReportPDF)
+			assert.Nil(t, byID[draft.ID].EncounterID)
+		}
+		assert.Equal(t, 1, strings.Count(string(files["encounters.ndjson"]), "\n"))
+	})
+
+	t.Run("Finished exports aren't run again", func(t *testing.T) {
+		assert.NoError(t, runExportJob(job.ID, time.Hour))
+		assert.Equal(t, stored.Checksum, loadExportJob(t, job.ID).Checksum)
+	})
+}
+
+func TestEnqueueExportJob(t *testing.T) {
+	doctor, _ := setupExportTestDB(t)
+
+	job, err := EnqueueExportJob(doctor.ID)
+	assert.NoError(t, err)
+	_, err = EnqueueExportJob(doctor.ID)
+	assert.ErrorIs(t, err, ErrExportInProgress)
+
+	found, err := GetExportJob(doctor.ID, job.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, job.ID, found.ID)
+	}
+	_, err = GetExportJob(uuid.New(), job.ID)
+	assert.ErrorIs(t, err, ErrExportNotFound)
+
+	// Another export can start once the last one finished
+	assert.NoError(t, initializers.DB.Model(&models.ExportJob{}).Where("id = ?", job.ID).Update("status", models.ExportStatusFailed).Error)
+	_, err = EnqueueExportJob(doctor.ID)
+	assert.NoError(t, err)
+}
+
+func TestExportWorkers(t *testing.T) {
+	doctor, _ := setupExportTestDB(t)
+	seedPractice(t, doctor.ID)
+
+	pool := StartExportWorkers(1, time.Hour)
+	defer pool.Stop()
+
+	job, err := EnqueueExportJob(doctor.ID)
+	assert.NoError(t, err)
+	done := waitForExportStatus(t, job.ID, models.ExportStatusDone)
+	assert.Equal(t, 2, done.TranscriptionCount)
+
+	t.Run("Storage failures fail the export", func(t *testing.T) {
+		useExportStorage(t, failingStorage{})
+		job, err := EnqueueExportJob(doctor.ID)
+		assert.NoError(t, err)
+		failed := waitForExportStatus(t, job.ID, models.ExportStatusFailed)
+		assert.Contains(t, failed.LastError, "disk full")
+		assert.Empty(t, failed.StorageKey)
+	})
+}
+
+func TestExportLinks(t *testing.T) {
+	t.Setenv("EXPORT_LINK_SECRET", "test-export-secret")
+	doctor, _ := setupExportTestDB(t)
+	job, err := EnqueueExportJob(doctor.ID)
+	assert.NoError(t, err)
+	_, ok := NewExportLink(*job, "https://api.example.com/download", time.Now())
+	assert.False(t, ok, "queued exports have nothing to download")
+
+	assert.NoError(t, runExportJob(job.ID, time.Hour))
+	done := loadExportJob(t, job.ID)
+	now := time.Now()
+	link, ok := NewExportLink(done, "https://api.example.com/download", now)
+	if !assert.True(t, ok) {
+		return
+	}
+	assert.Equal(t, now.Add(ExportLinkTTL).Unix(), link.ExpiresAt.Unix())
+	parsed, err := url.Parse(link.URL)
+	assert.NoError(t, err)
+	expires, signature := parsed.Query().Get("expires"), parsed.Query().Get("signature")
+
+	t.Run("Valid link", func(t *testing.T) {
+		download, err := OpenExportDownload(job.ID, expires, signature, now)
+		if !assert.NoError(t, err) {
+			return
+		}
+		defer download.Archive.Close()
+		data, _ := io.ReadAll(download.Archive)
+		sum := sha256.Sum256(data)
+		assert.Equal(t, done.Checksum, hex.EncodeToString(sum[:]))
+		assert.Equal(t, "practice-export_"+done.CompletedAt.Format("2006-01-02")+".zip", ExportFileName(download.Job))
+	})
+
+	t.Run("Rejected links", func(t *testing.T) {
+		for name, tc := range map[string]struct {
+			id                 uuid.UUID
+			expires, signature string
+			now                time.Time
+		}{
+			"Expired":          {job.ID, expires, signature, link.ExpiresAt},
+			"Tampered expiry":  {job.ID, expires + "0", signature, now},
+			"Wrong signature":  {job.ID, expires, signature[1:] + "A", now},
+			"Another export":   {uuid.New(), expires, signature, now},
+			"Missing":          {job.ID, "", "", now},
+			"Invalid duration": {job.ID, "soon", signature, now},
+		} {
+			_, err := OpenExportDownload(tc.id, tc.expires, tc.signature, tc.now)
+			assert.ErrorIs(t, err, ErrExportLinkInvalid, name)
+		}
+	})
+
+	t.Run("Links don't outlive the archive", func(t *testing.T) {
+		almostExpired := done.ExpiresAt.Add(-time.Minute)
+		link, ok := NewExportLink(done, "https://api.example.com/download", almostExpired)
+		if assert.True(t, ok) {
+			assert.Equal(t, done.ExpiresAt.Unix(), link.ExpiresAt.Unix())
+		}
+		_, ok = NewExportLink(done, "https://api.example.com/download", *done.ExpiresAt)
+		assert.False(t, ok)
+	})
+
+	t.Run("No secret configured", func(t *testing.T) {
+		t.Setenv("EXPORT_LINK_SECRET", "")
+		t.Setenv("JWT_SECRET", "")
+		assert.False(t, ExportLinksEnabled())
+
+		_, ok := NewExportLink(done, "https://api.example.com/download", now)
+		assert.False(t, ok)
+		// Links signed earlier, or with a guessed default, are refused too
+		_, err := OpenExportDownload(job.ID, expires, signature, now)
+		assert.ErrorIs(t, err, ErrExportLinkInvalid)
+	})
+
+	t.Run("Falls back to the JWT secret", func(t *testing.T) {
+		t.Setenv("EXPORT_LINK_SECRET", "")
+		t.Setenv("JWT_SECRET", "test-jwt-secret")
+
+		link, ok := NewExportLink(done, "https://api.example.com/download", now)
+		if !assert.True(t, ok) {
+			return
+		}
+		parsed, _ := url.Parse(link.URL)
+		download, err := OpenExportDownload(job.ID, parsed.Query().Get("expires"), parsed.Query().Get("signature"), now)
+		if assert.NoError(t, err) {
+			download.Archive.Close()
+		}
+		// A link signed with the export secret no longer matches
+		_, err = OpenExportDownload(job.ID, expires, signature, now)
+		assert.ErrorIs(t, err, ErrExportLinkInvalid)
+	})
+}
+
+func TestPurgeExpiredExports(t *testing.T) {
+	t.Setenv("EXPORT_LINK_SECRET", "test-export-secret")
+	doctor, store := setupExportTestDB(t)
+	expiring, err := EnqueueExportJob(doctor.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, runExportJob(expiring.ID, time.Minute))
+	kept, err := EnqueueExportJob(doctor.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, runExportJob(kept.ID, time.Hour))
+	expired := loadExportJob(t, expiring.ID)
+
+	purgeExpiredExports(time.Now().Add(30 * time.Minute))
+
+	gone := loadExportJob(t, expiring.ID)
+	assert.Equal(t, models.ExportStatusExpired, gone.Status)
+	assert.Empty(t, gone.StorageKey)
+	_, err = store.Get(context.Background(), expired.StorageKey)
+	assert.ErrorIs(t, err, storage.ErrObjectNotFound)
+	assert.Equal(t, models.ExportStatusDone, loadExportJob(t, kept.ID).Status)
+
+	// A link signed before the archive was deleted no longer downloads it
+	expires := "9999999999"
+	signature, _ := exportLinkSignature(expiring.ID, expires)
+	_, err = OpenExportDownload(expiring.ID, expires, signature, time.Now())
+	assert.ErrorIs(t, err, ErrExportNotFound)
+}
@@ -0,0 +1,160 @@
+package service
+
+import (
+	"errors"
+	"itish41/doctor_ai_assistant/fhir"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ErrPractitionerNotFound is returned for a FHIR practitioner that isn't a doctor.
+var ErrPractitionerNotFound error = &NotFoundError{Resource: "practitioner"}
+
+// FHIRServer says where the API is served from, so exports can carry absolute URLs.
+type FHIRServer struct {
+	BaseURL   string // the FHIR base, such as https://api.example.com/api/v2/fhir
+	ReportURL string // where transcriptions are downloaded, such as https://api.example.com/api/v2/transcriptions
+}
+
+// reportPDFURL is where the PDF of a transcription's report is downloaded.
+func (s FHIRServer) reportPDFURL(transcriptionID uuid.UUID) string {
+	return strings.TrimSuffix(s.ReportURL, "/") + "/" + transcriptionID.String() + "/download?format=pdf"
+}
+
+// GetFHIRPatient exports one of the doctor's patients.
+func GetFHIRPatient(doctorID, patientID uuid.UUID) (*fhir.Patient, error) {
+	patient, err := findDoctorPatient(initializers.DB, doctorID, patientID)
+	if err != nil {
+		return nil, err
+	}
+	export := fhir.NewPatient(*patient)
+	return &export, nil
+}
+
+// GetFHIRPractitioner exports the doctor. Other doctors' details aren't shared.
+func GetFHIRPractitioner(doctorID, practitionerID uuid.UUID) (*fhir.Practitioner, error) {
+	doctor, err := findFHIRDoctor(practitionerID)
+	if err != nil {
+		return nil, err
+	}
+	if doctor.ID != doctorID {
+		log.Println("Doctor", doctorID, "denied access to practitioner", practitionerID)
+		return nil, ErrForbidden
+	}
+	export := fhir.NewPractitioner(*doctor)
+	return &export, nil
+}
+
+// GetFHIREncounter exports one of the doctor's encounters.
+func GetFHIREncounter(doctorID, encounterID uuid.UUID) (*fhir.Encounter, error) {
+	encounter, err := findDoctorEncounter(initializers.DB, doctorID, encounterID)
+	if err != nil {
+		return nil, err
+	}
+	patient, err := findDoctorPatient(initializers.DB, doctorID, encounter.PatientID)
+	if err != nil {
+		return nil, err
+	}
+	doctor, err := findFHIRDoctor(doctorID)
+	if err != nil {
+		return nil, err
+	}
+	export := fhir.NewEncounter(*encounter, *patient, *doctor)
+	return &export, nil
+}
+
+// GetFHIRComposition exports the report of one of the doctor's transcriptions as a
+// Composition.
+func GetFHIRComposition(doctorID, transcriptionID uuid.UUID) (*fhir.Composition, error) {
+	doc, err := GetReportDocument(doctorID, transcriptionID)
+	if err != nil {
+		return nil, err
+	}
+	export := fhir.NewComposition(doc.Transcription, doc.Patient, doc.Doctor)
+	return &export, nil
+}
+
+// GetFHIRDiagnosticReport exports the report of one of the doctor's transcriptions as a
+// DiagnosticReport.
+func GetFHIRDiagnosticReport(doctorID, transcriptionID uuid.UUID) (*fhir.DiagnosticReport, error) {
+	doc, err := GetReportDocument(doctorID, transcriptionID)
+	if err != nil {
+		return nil, err
+	}
+	export := fhir.NewDiagnosticReport(doc.Transcription, doc.Patient, doc.Doctor)
+	return &export, nil
+}
+
+// GetFHIRDocumentReference exports the report of one of the doctor's transcriptions as a
+// DocumentReference linking to its PDF.
+func GetFHIRDocumentReference(doctorID, transcriptionID uuid.UUID, server FHIRServer) (*fhir.DocumentReference, error) {
+	doc, err := GetReportDocument(doctorID, transcriptionID)
+	if err != nil {
+		return nil, err
+	}
+	export := fhir.NewDocumentReference(doc.Transcription, doc.Patient, doc.Doctor, server.reportPDFURL(transcriptionID))
+	return &export, nil
+}
+
+// GetFHIRPatientEverything exports everything about one of the doctor's patients, like
+// FHIR's Patient/$everything: the patient, their encounters and each transcription's
+// report as a Composition, DiagnosticReport and DocumentReference, oldest first. The
+// doctor is included as the practitioner they all reference.
+func GetFHIRPatientEverything(doctorID, patientID uuid.UUID, server FHIRServer) (*fhir.Bundle, error) {
+	patient, err := findDoctorPatient(initializers.DB, doctorID, patientID)
+	if err != nil {
+		return nil, err
+	}
+	doctor, err := findFHIRDoctor(doctorID)
+	if err != nil {
+		return nil, err
+	}
+
+	var encounters []models.Encounter
+	if err := initializers.DB.Where("patient_id = ? AND doctor_id = ?", patientID, doctorID).
+		Order("visit_date ASC").Order("id ASC").
+		Find(&encounters).Error; err != nil {
+		log.Println("Error fetching encounters:", err)
+		return nil, errors.New("failed to export patient")
+	}
+	var transcriptions []models.Transcription
+	if err := initializers.DB.Where("patient_id = ? AND doctor_id = ?", patientID, doctorID).
+		Order("created_at ASC").Order("id ASC").
+		Find(&transcriptions).Error; err != nil {
+		log.Println("Error fetching transcriptions:", err)
+		return nil, errors.New("failed to export patient")
+	}
+
+	matches := []fhir.Resource{fhir.NewPatient(*patient)}
+	for _, encounter := range encounters {
+		matches = append(matches, fhir.NewEncounter(encounter, *patient, *doctor))
+	}
+	for _, transcription := range transcriptions {
+		matches = append(matches,
+			fhir.NewComposition(transcription, *patient, *doctor),
+			fhir.NewDiagnosticReport(transcription, *patient, *doctor),
+			fhir.NewDocumentReference(transcription, *patient, *doctor, server.reportPDFURL(transcription.ID)),
+		)
+	}
+	self := strings.TrimSuffix(server.BaseURL, "/") + "/Patient/" + patientID.String() + "/$everything"
+	bundle := fhir.NewSearchset(server.BaseURL, self, matches, []fhir.Resource{fhir.NewPractitioner(*doctor)})
+	return &bundle, nil
+}
+
+// findFHIRDoctor loads a doctor to export, without their password.
+func findFHIRDoctor(doctorID uuid.UUID) (*models.Doctor, error) {
+	var doctor models.Doctor
+	if err := initializers.DB.Omit("password").Where("id = ?", doctorID).First(&doctor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPractitionerNotFound
+		}
+		log.Println("Error retrieving doctor:", err)
+		return nil, errors.New("failed to retrieve doctor")
+	}
+	return &doctor, nil
+}
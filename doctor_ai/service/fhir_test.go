@@ -0,0 +1,120 @@
+package service
+
+import (
+	"itish41/doctor_ai_assistant/fhir"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+var testFHIRServer = FHIRServer{
+	BaseURL:   "https://api.example.com/api/v2/fhir",
+	ReportURL: "https://api.example.com/api/v2/transcriptions",
+}
+
+func TestGetFHIRPatientEverything(t *testing.T) {
+	doctor := setupTranscriptionJobTestDB(t)
+	patient := createTestPatient(t, doctor.ID)
+	other := createTestPatient(t, doctor.ID)
+
+	later := models.Encounter{ID: uuid.New(), PatientID: patient.ID, DoctorID: doctor.ID, VisitDate: time.Now(), Reason: "Follow-up"}
+	first := models.Encounter{ID: uuid.New(), PatientID: patient.ID, DoctorID: doctor.ID, VisitDate: time.Now().Add(-48 * time.Hour)}
+	assert.NoError(t, initializers.DB.Create(&later).Error)
+	assert.NoError(t, initializers.DB.Create(&first).Error)
+	transcription := createTestTranscription(t, doctor.ID, patient.ID)
+	createTestTranscription(t, doctor.ID, other.ID)
+
+	t.Run("Everything about the patient", func(t *testing.T) {
+		bundle, err := GetFHIRPatientEverything(doctor.ID, patient.ID, testFHIRServer)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, 6, *bundle.Total)
+		assert.Equal(t, testFHIRServer.BaseURL+"/Patient/"+patient.ID.String()+"/$everything", bundle.Link[0].URL)
+
+		var paths []string
+		for _, entry := range bundle.Entry {
+			paths = append(paths, entry.Resource.(fhir.Resource).Path())
+		}
+		assert.Equal(t, []string{
+			"Patient/" + patient.ID.String(),
+			"Encounter/" + first.ID.String(),
+			"Encounter/" + later.ID.String(),
+			"Composition/" + transcription.ID.String(),
+			"DiagnosticReport/" + transcription.ID.String(),
+			"DocumentReference/" + transcription.ID.String(),
+			"Practitioner/" + doctor.ID.String(),
+		}, paths, "the other patient's report isn't exported")
+
+		document := bundle.Entry[5].Resource.(fhir.DocumentReference)
+		assert.Equal(t, testFHIRServer.ReportURL+"/"+transcription.ID.String()+"/download?format=pdf", document.Content[0].Attachment.URL)
+		practitioner := bundle.Entry[6].Resource.(fhir.Practitioner)
+		assert.Equal(t, "include", bundle.Entry[6].Search.Mode)
+		assert.Equal(t, doctor.Email, practitioner.Telecom[0].Value)
+	})
+
+	t.Run("Another doctor's patient", func(t *testing.T) {
+		_, err := GetFHIRPatientEverything(uuid.New(), patient.ID, testFHIRServer)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("Unknown patient", func(t *testing.T) {
+		_, err := GetFHIRPatientEverything(doctor.ID, uuid.New(), testFHIRServer)
+		assert.ErrorIs(t, err, ErrPatientNotFound)
+	})
+}
+
+func TestGetFHIRResources(t *testing.T) {
+	doctor := setupTranscriptionJobTestDB(t)
+	patient := createTestPatient(t, doctor.ID)
+	encounter := models.Encounter{ID: uuid.New(), PatientID: patient.ID, DoctorID: doctor.ID, VisitDate: time.Now()}
+	assert.NoError(t, initializers.DB.Create(&encounter).Error)
+	transcription := createTestTranscription(t, doctor.ID, patient.ID)
+	stranger := uuid.New()
+
+	exported, err := GetFHIRPatient(doctor.ID, patient.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "female", exported.Gender)
+	}
+	_, err = GetFHIRPatient(stranger, patient.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	practitioner, err := GetFHIRPractitioner(doctor.ID, doctor.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, doctor.Name, practitioner.Name[0].Text)
+	}
+	_, err = GetFHIRPractitioner(doctor.ID, uuid.New())
+	assert.ErrorIs(t, err, ErrPractitionerNotFound)
+	_, err = GetFHIRPractitioner(stranger, doctor.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	visit, err := GetFHIREncounter(doctor.ID, encounter.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Patient/"+patient.ID.String(), visit.Subject.Reference)
+	}
+	_, err = GetFHIREncounter(doctor.ID, uuid.New())
+	assert.ErrorIs(t, err, ErrEncounterNotFound)
+	_, err = GetFHIREncounter(stranger, encounter.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+
+	composition, err := GetFHIRComposition(doctor.ID, transcription.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "preliminary", composition.Status)
+	}
+	report, err := GetFHIRDiagnosticReport(doctor.ID, transcription.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "Practitioner/"+doctor.ID.String(), report.Performer[0].Reference)
+	}
+	document, err := GetFHIRDocumentReference(doctor.ID, transcription.ID, testFHIRServer)
+	if assert.NoError(t, err) {
+		assert.Equal(t, transcription.ID.String(), document.ID)
+	}
+	_, err = GetFHIRDiagnosticReport(stranger, transcription.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = GetFHIRDocumentReference(doctor.ID, uuid.New(), testFHIRServer)
+	assert.ErrorIs(t, err, ErrTranscriptionNotFound)
+}
@@ -10,6 +10,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// ErrInvalidCredentials is returned for an unknown email or a wrong password alike.
+var ErrInvalidCredentials error = &UnauthorizedError{Message: "invalid email or password"}
+
 func DoctorLogin(user *models.Doctor) error {
 	var existingUser models.Doctor
 
@@ -33,14 +36,14 @@ func DoctorLogin(user *models.Doctor) error {
 	if result.Error != nil {
 		tx.Rollback()
 		log.Println("Email not found...")
-		return errors.New("invalid email or password")
+		return ErrInvalidCredentials
 	}
 
 	// Compare password
 	if err := bcrypt.CompareHashAndPassword([]byte(existingUser.Password), []byte(user.Password)); err != nil {
 		tx.Rollback()
 		log.Println("Incorrect password...")
-		return errors.New("invalid email or password") // Always return generic error for security
+		return ErrInvalidCredentials // Always return generic error for security
 	}
 
 	// Commit the transaction
@@ -49,6 +52,9 @@ func DoctorLogin(user *models.Doctor) error {
 		return errors.New("login failed")
 	}
 
+	// Expose the doctor's ID so the caller can issue tokens for it
+	user.ID = existingUser.ID
+
 	log.Println("Login successful:", existingUser.Email)
 	return nil
 }
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"errors"
+	"itish41/doctor_ai_assistant/models"
+	"log"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrTranscriptionNotFound error = &NotFoundError{Resource: "transcription"}
+	ErrPatientNotFound       error = &NotFoundError{Resource: "patient"}
+	ErrEncounterNotFound     error = &NotFoundError{Resource: "encounter"}
+)
+
+// findDoctorTranscription loads a transcription owned by the doctor.
+func findDoctorTranscription(db *gorm.DB, doctorID, transcriptionID uuid.UUID) (*models.Transcription, error) {
+	var transcription models.Transcription
+	if err := db.Where("id = ?", transcriptionID).First(&transcription).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTranscriptionNotFound
+		}
+		log.Println("Error retrieving transcription:", err)
+		return nil, errors.New("failed to retrieve transcription")
+	}
+	if transcription.DoctorID != doctorID {
+		log.Println("Doctor", doctorID, "denied access to transcription", transcriptionID)
+		return nil, ErrForbidden
+	}
+	return &transcription, nil
+}
+
+// findDoctorPatient loads a patient owned by the doctor.
+func findDoctorPatient(db *gorm.DB, doctorID, patientID uuid.UUID) (*models.Patient, error) {
+	var patient models.Patient
+	if err := db.Where("id = ?", patientID).First(&patient).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPatientNotFound
+		}
+		log.Println("Error retrieving patient:", err)
+		return nil, errors.New("failed to retrieve patient")
+	}
+	if patient.DoctorID != doctorID {
+		log.Println("Doctor", doctorID, "denied access to patient", patientID)
+		return nil, ErrForbidden
+	}
+	return &patient, nil
+}
+
+// findPatientEncounter loads an encounter of the doctor's patient.
+func findPatientEncounter(db *gorm.DB, doctorID, patientID, encounterID uuid.UUID) (*models.Encounter, error) {
+	encounter, err := findDoctorEncounter(db, doctorID, encounterID)
+	if err != nil {
+		return nil, err
+	}
+	if encounter.PatientID != patientID {
+		// Another of the doctor's patients; recordings can't be filed under it
+		return nil, ErrEncounterNotFound
+	}
+	return encounter, nil
+}
+
+// findDoctorEncounter loads an encounter owned by the doctor, whichever patient it is of.
+func findDoctorEncounter(db *gorm.DB, doctorID, encounterID uuid.UUID) (*models.Encounter, error) {
+	var encounter models.Encounter
+	if err := db.Where("id = ?", encounterID).First(&encounter).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrEncounterNotFound
+		}
+		log.Println("Error retrieving encounter:", err)
+		return nil, errors.New("failed to retrieve encounter")
+	}
+	if encounter.DoctorID != doctorID {
+		log.Println("Doctor", doctorID, "denied access to encounter", encounterID)
+		return nil, ErrForbidden
+	}
+	return &encounter, nil
+}
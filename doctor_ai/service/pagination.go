@@ -0,0 +1,132 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// MaxPageLimit caps the page size of lists read with findPage.
+const MaxPageLimit = 100
+
+// PageRequest selects one page of a list ordered newest first. A cursor from a
+// previous PageInfo takes precedence over Page; without one, Page picks the page by
+// offset as before.
+type PageRequest struct {
+	Page      int
+	Limit     int
+	Cursor    string
+	WithTotal bool // count every matching row, which costs a second query
+}
+
+// PageInfo describes where a page sits in its list.
+type PageInfo struct {
+	NextCursor string // empty on the last page
+	PrevCursor string // empty on the first page
+	Total      *int64 // nil unless the request asked for it
+}
+
+// pageCursor is the position a cursor points at: the (created_at, id) of the row
+// next to the wanted page, and whether the page comes after or before it.
+type pageCursor struct {
+	CreatedAt time.Time `json:"t"`
+	ID        uuid.UUID `json:"id"`
+	Before    bool      `json:"b,omitempty"`
+}
+
+func (c pageCursor) encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeCursor(value string) (pageCursor, error) {
+	var cursor pageCursor
+	data, err := base64.RawURLEncoding.DecodeString(value)
+	if err == nil {
+		err = json.Unmarshal(data, &cursor)
+	}
+	if err != nil || cursor.ID == uuid.Nil || cursor.CreatedAt.IsZero() {
+		return pageCursor{}, InvalidField("cursor", "Invalid cursor")
+	}
+	return cursor, nil
+}
+
+// findPage loads the page of query's rows selected by page, ordered by created_at and
+// then id, newest first. Keyset cursors keep pages stable while rows are inserted,
+// unlike offsets. key returns a row's (created_at, id).
+func findPage[T any](query *gorm.DB, page PageRequest, key func(*T) (time.Time, uuid.UUID)) ([]T, PageInfo, error) {
+	var info PageInfo
+	if page.Limit < 1 {
+		page.Limit = 10
+	}
+	if page.Limit > MaxPageLimit {
+		page.Limit = MaxPageLimit
+	}
+
+	var cursor *pageCursor
+	if page.Cursor != "" {
+		decoded, err := decodeCursor(page.Cursor)
+		if err != nil {
+			return nil, info, err
+		}
+		cursor = &decoded
+	}
+
+	if page.WithTotal {
+		var total int64
+		if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+			return nil, info, err
+		}
+		info.Total = &total
+	}
+
+	// One extra row tells whether another page follows in the direction read
+	query = query.Limit(page.Limit + 1)
+	switch {
+	case cursor == nil:
+		if page.Page > 1 {
+			query = query.Offset((page.Page - 1) * page.Limit)
+		}
+		query = query.Order("created_at DESC").Order("id DESC")
+	case cursor.Before:
+		query = query.
+			Where("created_at > ? OR (created_at = ? AND id > ?)", cursor.CreatedAt, cursor.CreatedAt, cursor.ID).
+			Order("created_at ASC").Order("id ASC")
+	default:
+		query = query.
+			Where("created_at < ? OR (created_at = ? AND id < ?)", cursor.CreatedAt, cursor.CreatedAt, cursor.ID).
+			Order("created_at DESC").Order("id DESC")
+	}
+
+	var rows []T
+	if err := query.Find(&rows).Error; err != nil {
+		return nil, info, err
+	}
+	more := len(rows) > page.Limit
+	if more {
+		rows = rows[:page.Limit]
+	}
+	backward := cursor != nil && cursor.Before
+	if backward {
+		slices.Reverse(rows)
+	}
+	if len(rows) == 0 {
+		return rows, info, nil
+	}
+
+	firstAt, firstID := key(&rows[0])
+	lastAt, lastID := key(&rows[len(rows)-1])
+	// Reading forward, rows precede this page when a cursor or offset skipped them;
+	// reading backward, the cursor row itself follows it
+	if (backward && more) || (!backward && (cursor != nil || page.Page > 1)) {
+		info.PrevCursor = pageCursor{CreatedAt: firstAt, ID: firstID, Before: true}.encode()
+	}
+	if (!backward && more) || backward {
+		info.NextCursor = pageCursor{CreatedAt: lastAt, ID: lastID}.encode()
+	}
+	return rows, info, nil
+}
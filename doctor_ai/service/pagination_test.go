@@ -0,0 +1,91 @@
+package service
+
+import (
+	"errors"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetPatients_Cursors(t *testing.T) {
+	setupPatientTestDB(t)
+	doctor := createTestDoctor(t)
+
+	// Five patients, the last two created at the same instant so id breaks the tie
+	base := time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC)
+	created := []time.Time{base, base.Add(time.Hour), base.Add(2 * time.Hour), base.Add(3 * time.Hour), base.Add(3 * time.Hour)}
+	byName := map[uuid.UUID]string{}
+	for i, at := range created {
+		patient := models.Patient{ID: uuid.New(), Name: string(rune('A' + i)), Age: 30, Gender: "Male", DoctorID: doctor.ID, CreatedAt: at}
+		assert.NoError(t, initializers.DB.Create(&patient).Error)
+		byName[patient.ID] = patient.Name
+	}
+	names := func(patients []models.Patient) string {
+		var s string
+		for _, patient := range patients {
+			s += byName[patient.ID]
+		}
+		return s
+	}
+
+	first, info, err := GetPatients(doctor.ID, PageRequest{Page: 1, Limit: 2})
+	assert.NoError(t, err)
+	assert.Len(t, first, 2)
+	assert.Nil(t, info.Total)
+	assert.Empty(t, info.PrevCursor)
+	assert.NotEmpty(t, info.NextCursor)
+
+	// A patient added meanwhile doesn't shift the pages after the cursor
+	newest := models.Patient{ID: uuid.New(), Name: "Z", Age: 30, Gender: "Male", DoctorID: doctor.ID, CreatedAt: base.Add(4 * time.Hour)}
+	assert.NoError(t, initializers.DB.Create(&newest).Error)
+	byName[newest.ID] = newest.Name
+
+	second, info, err := GetPatients(doctor.ID, PageRequest{Cursor: info.NextCursor, Limit: 2, WithTotal: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "CB", names(second))
+	assert.Equal(t, int64(6), *info.Total)
+	assert.NotEmpty(t, info.PrevCursor)
+
+	third, info, err := GetPatients(doctor.ID, PageRequest{Cursor: info.NextCursor, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "A", names(third))
+	assert.Empty(t, info.NextCursor)
+
+	// Going back returns the same pages, oldest page edge included
+	back, info, err := GetPatients(doctor.ID, PageRequest{Cursor: info.PrevCursor, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "CB", names(back))
+	back, info, err = GetPatients(doctor.ID, PageRequest{Cursor: info.PrevCursor, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, names(first), names(back))
+	assert.NotEmpty(t, info.PrevCursor, "the patient added meanwhile is before the first page")
+
+	back, info, err = GetPatients(doctor.ID, PageRequest{Cursor: info.PrevCursor, Limit: 2})
+	assert.NoError(t, err)
+	assert.Equal(t, "Z", names(back))
+	assert.Empty(t, info.PrevCursor)
+	assert.NotEmpty(t, info.NextCursor)
+
+	_, _, err = GetPatients(doctor.ID, PageRequest{Cursor: "not-a-cursor", Limit: 2})
+	var validationErr *ValidationError
+	assert.True(t, errors.As(err, &validationErr))
+	assert.Contains(t, validationErr.Fields, "cursor")
+}
+
+func TestGetPatients_LimitCapped(t *testing.T) {
+	setupPatientTestDB(t)
+	doctor := createTestDoctor(t)
+	for i := 0; i < MaxPageLimit+1; i++ {
+		patient := models.Patient{ID: uuid.New(), Name: "Patient", Age: 30, Gender: "Male", DoctorID: doctor.ID}
+		assert.NoError(t, initializers.DB.Create(&patient).Error)
+	}
+
+	patients, info, err := GetPatients(doctor.ID, PageRequest{Page: 1, Limit: 1000000})
+	assert.NoError(t, err)
+	assert.Len(t, patients, MaxPageLimit)
+	assert.NotEmpty(t, info.NextCursor)
+}
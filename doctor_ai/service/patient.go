@@ -1,171 +1,59 @@
 package service
 
 import (
-	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"itish41/doctor_ai_assistant/initializers"
 	"itish41/doctor_ai_assistant/models"
 	"log"
-	"os"
 	"time"
 
-	aai "github.com/AssemblyAI/assemblyai-go-sdk"
 	"gorm.io/gorm"
 
-	"github.com/go-resty/resty/v2"
 	"github.com/google/uuid"
 )
 
-// assemblyaiTranscribe sends the audio URL to AssemblyAI and returns the raw transcription text.
-// For simplicity, this example assumes the transcription is ready.
-func assemblyaiTranscribe(audioURL string) (string, error) {
-	client := aai.NewClient(os.Getenv("ASSEMBLYAIKEY"))
-	ctx := context.Background()
-
-	transcript, err := client.Transcripts.TranscribeFromURL(ctx, audioURL, &aai.TranscriptOptionalParams{})
-	if err != nil {
-		return "", fmt.Errorf("assemblyai transcription error: %v", err)
-	}
-
-	if transcript.Text == nil {
-		return "", fmt.Errorf("transcription text is empty")
-	}
-	return *transcript.Text, nil
-}
-
-// groqEnhance sends the raw transcription text to Groq to get a structured medical report.
-func groqEnhance(rawText string) (string, error) {
-	client := resty.New()
-
-	requestBody := map[string]interface{}{
-		"model": "llama3-8b-8192",
-		"messages": []map[string]string{
-			{
-				"role":    "system",
-				"content": "You are an AI specialized in generating well-structured medical reports. Ensure the report follows a professional format.",
-			},
-			{
-				"role": "user",
-				"content": fmt.Sprintf(
-					"Format the following transcription into a structured medical report with these sections:\n"+
-						"1. Patient Information\n"+
-						"2. Patient History\n"+
-						"3. Symptoms\n"+
-						"4. Diagnosis\n"+
-						"5. Treatment Plan\n"+
-						"6. Recommendations\n\nTranscription:\n%s", rawText),
-			},
-		},
-	}
-
-	resp, err := client.R().
-		SetHeader("Authorization", "Bearer "+os.Getenv("GROQAPIKEY")).
-		SetHeader("Content-Type", "application/json").
-		SetBody(requestBody).
-		Post("https://api.groq.com/openai/v1/chat/completions")
-	if err != nil {
-		return "", fmt.Errorf("groq request error: %v", err)
-	}
-
-	var result map[string]interface{}
-	if err := json.Unmarshal(resp.Body(), &result); err != nil {
-		return "", fmt.Errorf("failed to unmarshal groq response: %v", err)
+// savePatientAndTranscription creates the patient, the encounter of their first visit and
+// its transcription inside tx, linking the uploaded recording when there is one.
+func savePatientAndTranscription(tx *gorm.DB, doctorID uuid.UUID, patientData models.Patient, visit EncounterDetails, rawText string, report *models.MedicalReport, audioFileID *uuid.UUID) (*models.Transcription, error) {
+	if patientData.ID == uuid.Nil {
+		patientData.ID = uuid.New()
 	}
-
-	choices, ok := result["choices"].([]interface{})
-	if !ok || len(choices) == 0 {
-		return "", fmt.Errorf("unexpected response from Groq API")
-	}
-
-	content, ok := choices[0].(map[string]interface{})["message"].(map[string]interface{})["content"].(string)
-	if !ok {
-		return "", fmt.Errorf("unable to extract enhanced content from Groq response")
+	patientData.DoctorID = doctorID
+	if patientData.CreatedAt.IsZero() {
+		patientData.CreatedAt = time.Now()
 	}
-
-	return content, nil
-}
-
-func CreatePatientAndTranscription(doctorEmail string, patientData models.Patient, audioURL string) (*models.Transcription, error) {
-	if err := validatePatient(&patientData); err != nil {
-		log.Println("Validation error:", err)
-		return nil, err
-	}
-	log.Println("Patient validated successfully:", patientData.ID)
-
-	// Step 1: Retrieve doctor by email
-	var doctor models.Doctor
-	if err := initializers.DB.Where("email = ?", doctorEmail).First(&doctor).Error; err != nil {
-		log.Println("Error retrieving doctor:", err)
-		return nil, fmt.Errorf("doctor not found: %v", err)
-	}
-	log.Println("Doctor found:", doctor.ID)
-
-	// Step 2: Create the patient record and link it to the doctor
-	patientData.DoctorID = doctor.ID
-	if err := initializers.DB.Create(&patientData).Error; err != nil {
+	if err := tx.Create(&patientData).Error; err != nil {
 		log.Println("Error creating patient:", err)
 		return nil, fmt.Errorf("failed to create patient: %v", err)
 	}
-	log.Println("Patient created successfully:", patientData.ID)
-
-	// Step 3: Transcribe the audio using AssemblyAI
-	rawTranscript, err := assemblyaiTranscribe(audioURL)
-	if err != nil {
-		log.Println("Error transcribing audio:", err)
-		return nil, fmt.Errorf("failed to transcribe audio: %v", err)
-	}
-	log.Println("Transcription created successfully:", patientData.ID)
-
-	// Step 4: Enhance the transcription using Groq
-	enhancedTranscript, err := groqEnhance(rawTranscript)
-	if err != nil {
-		log.Println("Error enhancing transcription:", err)
-		return nil, fmt.Errorf("failed to enhance transcription: %v", err)
-	}
-	log.Println("Enhanced transcription created successfully:", patientData.ID)
 
-	// Step 5: Create the transcription record in the database
-	newTranscription := models.Transcription{
-		ID:        uuid.New(),
-		DoctorID:  doctor.ID,
-		PatientID: patientData.ID,
-		Text:      rawTranscript,
-		Report:    enhancedTranscript,
-		CreatedAt: time.Now(),
+	// Patients are created outside the request that asked for them, so audit them here
+	if err := appendAuditEvent(tx, models.AuditEvent{
+		ActorID:      &doctorID,
+		PatientID:    &patientData.ID,
+		Action:       "patient.create",
+		ResourceType: models.AuditResourcePatient,
+		ResourceID:   patientData.ID.String(),
+		Outcome:      models.AuditOutcomeSuccess,
+	}); err != nil {
+		return nil, err
 	}
 
-	if err := initializers.DB.Create(&newTranscription).Error; err != nil {
-		log.Println("Error creating transcription record:", err)
-		return nil, fmt.Errorf("failed to create transcription record: %v", err)
-	}
-	log.Println("Transcription record created successfully:", newTranscription.ID)
-	return &newTranscription, nil
+	return saveVisitTranscription(tx, doctorID, patientData.ID, visit, rawText, report, audioFileID)
 }
 
 // Example validation function (You can modify this)
 func validatePatient(patient *models.Patient) error {
 	if patient.Name == "" {
-		return errors.New("patient name is required")
+		return InvalidField("name", "patient name is required")
 	}
 	if patient.Age <= 0 {
-		return errors.New("patient age must be greater than zero")
+		return InvalidField("age", "patient age must be greater than zero")
 	}
 	return nil
 }
 
-// In process
-// GetDoctorIDByEmail retrieves doctor ID using their email
-func GetDoctorIDByEmail(email string) (uuid.UUID, error) {
-	var doctor models.Doctor
-	result := initializers.DB.Where("email = ?", email).First(&doctor)
-	if result.Error != nil {
-		return uuid.Nil, result.Error
-	}
-	return doctor.ID, nil
-}
-
 // type TranscriptResponse struct {
 // 	ID            uuid.UUID `json:"id"`
 // 	PatientID     uuid.UUID `json:"patientId"`
@@ -183,7 +71,7 @@ func GetPatientTranscript(doctorID uuid.UUID, patientName string) (*models.Trans
 	result := initializers.DB.Where("doctor_id = ? AND name = ?", doctorID, patientName).First(&patient)
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("patient not found")
+			return nil, ErrPatientNotFound
 		}
 		return nil, fmt.Errorf("error finding patient: %v", result.Error)
 	}
@@ -196,7 +84,7 @@ func GetPatientTranscript(doctorID uuid.UUID, patientName string) (*models.Trans
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
-			return nil, fmt.Errorf("no transcript found for patient")
+			return nil, ErrTranscriptionNotFound
 		}
 		return nil, fmt.Errorf("error finding transcript: %v", result.Error)
 	}
@@ -204,52 +92,47 @@ func GetPatientTranscript(doctorID uuid.UUID, patientName string) (*models.Trans
 	return &transcript, nil
 }
 
-func GetPatients(doctorID uuid.UUID, page, limit int) ([]models.Patient, int64, error) {
-	var patients []models.Patient
-	var total int64
-
-	// Count total patients for the doctor
-	if err := initializers.DB.Model(&models.Patient{}).Where("doctor_id = ?", doctorID).Count(&total).Error; err != nil {
-		log.Println("Error counting patients:", err)
-		return nil, 0, errors.New("failed to count patients")
-	}
-
-	// Retrieve patients with pagination
-	offset := (page - 1) * limit
-	if err := initializers.DB.Where("doctor_id = ?", doctorID).Limit(limit).Offset(offset).Find(&patients).Error; err != nil {
+// GetPatients retrieves one page of a doctor's patients, newest first.
+func GetPatients(doctorID uuid.UUID, page PageRequest) ([]models.Patient, PageInfo, error) {
+	query := initializers.DB.Model(&models.Patient{}).Where("doctor_id = ?", doctorID)
+	patients, info, err := findPage(query, page, func(p *models.Patient) (time.Time, uuid.UUID) {
+		return p.CreatedAt, p.ID
+	})
+	if err != nil {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			return nil, PageInfo{}, err
+		}
 		log.Println("Error fetching patients:", err)
-		return nil, 0, errors.New("failed to retrieve patients")
+		return nil, PageInfo{}, errors.New("failed to retrieve patients")
 	}
 
-	return patients, total, nil
+	return patients, info, nil
 }
 
 func GetPatientWithTranscription(doctorID uuid.UUID, patientID uuid.UUID) (*models.Patient, *models.Transcription, error) {
-	var patient models.Patient
 	var transcription models.Transcription
 
 	// Retrieve patient only if it belongs to the doctor
-	if err := initializers.DB.Where("id = ? AND doctor_id = ?", patientID, doctorID).First(&patient).Error; err != nil {
-		log.Println("Error fetching patient:", err)
-		return nil, nil, errors.New("patient not found or does not belong to the doctor")
+	patient, err := findDoctorPatient(initializers.DB, doctorID, patientID)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	// Retrieve transcription associated with the patient
-	if err := initializers.DB.Where("patient_id = ?", patientID).First(&transcription).Error; err != nil {
+	// Retrieve the patient's latest transcription; GetPatientTimeline lists them all
+	if err := initializers.DB.Where("patient_id = ?", patientID).Order("created_at DESC").First(&transcription).Error; err != nil {
 		log.Println("Error fetching transcription:", err)
-		return &patient, nil, nil // Patient exists, but no transcription found
+		return patient, nil, nil // Patient exists, but no transcription found
 	}
 
-	return &patient, &transcription, nil
+	return patient, &transcription, nil
 }
 
 func UpdatePatientByID(doctorID uuid.UUID, patientID uuid.UUID, updateData map[string]interface{}) error {
-	var patient models.Patient
-
 	// Check if patient exists and belongs to the doctor
-	if err := initializers.DB.Where("id = ? AND doctor_id = ?", patientID, doctorID).First(&patient).Error; err != nil {
-		log.Println("Patient not found or does not belong to the doctor:", err)
-		return errors.New("patient not found or unauthorized")
+	patient, err := findDoctorPatient(initializers.DB, doctorID, patientID)
+	if err != nil {
+		return err
 	}
 
 	// Update only allowed fields
@@ -266,7 +149,7 @@ func UpdatePatientByID(doctorID uuid.UUID, patientID uuid.UUID, updateData map[s
 	}
 
 	// Update the patient record
-	if err := initializers.DB.Model(&patient).Updates(updateData).Error; err != nil {
+	if err := initializers.DB.Model(patient).Updates(updateData).Error; err != nil {
 		log.Println("Error updating patient:", err)
 		return errors.New("failed to update patient")
 	}
@@ -275,27 +158,45 @@ func UpdatePatientByID(doctorID uuid.UUID, patientID uuid.UUID, updateData map[s
 	return nil
 }
 
-// Works
-func DeletePatient(patientID uuid.UUID) error {
-	var patient models.Patient
+// DeletePatient deletes one of the doctor's patients with their transcriptions and
+// encounters, all or nothing.
+func DeletePatient(doctorID, patientID uuid.UUID) error {
+	tx := initializers.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
 
-	// Check if patient exists
-	if err := initializers.DB.Where("id = ?", patientID).First(&patient).Error; err != nil {
-		log.Println("Patient not found:", err)
-		return errors.New("patient not found")
+	// Check the patient exists and belongs to the doctor
+	patient, err := findDoctorPatient(tx, doctorID, patientID)
+	if err != nil {
+		tx.Rollback()
+		return err
 	}
 
 	// Delete transcriptions first (to maintain referential integrity)
-	if err := initializers.DB.Where("patient_id = ?", patientID).Delete(&models.Transcription{}).Error; err != nil {
+	if err := tx.Where("patient_id = ?", patientID).Delete(&models.Transcription{}).Error; err != nil {
+		tx.Rollback()
 		log.Println("Error deleting transcriptions:", err)
 		return errors.New("failed to delete transcriptions")
 	}
+	if err := tx.Where("patient_id = ?", patientID).Delete(&models.Encounter{}).Error; err != nil {
+		tx.Rollback()
+		log.Println("Error deleting encounters:", err)
+		return errors.New("failed to delete encounters")
+	}
 
 	// Delete the patient
-	if err := initializers.DB.Delete(&patient).Error; err != nil {
+	if err := tx.Delete(patient).Error; err != nil {
+		tx.Rollback()
 		log.Println("Failed to delete patient:", err)
 		return errors.New("failed to delete patient")
 	}
 
+	if err := tx.Commit().Error; err != nil {
+		log.Println("Error committing patient deletion:", err)
+		return errors.New("failed to delete patient")
+	}
 	return nil
 }
@@ -0,0 +1,350 @@
+package service
+
+import (
+	"errors"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DefaultDuplicateScore is the lowest score FindDuplicatePatients suggests by default.
+// Identical names with ages two years apart still reach it; a misspelt name needs the
+// age and gender to agree.
+const DefaultDuplicateScore = 0.7
+
+// MaxDuplicateLimit caps the number of suggestions FindDuplicatePatients returns.
+const MaxDuplicateLimit = 100
+
+// maxDuplicateCandidates caps the pairs Postgres hands back to be scored, most similar
+// names first.
+const maxDuplicateCandidates = 1000
+
+// pgTrgmSimilarityThreshold is pg_trgm's default similarity_threshold, below which the
+// % operator rejects a pair.
+const pgTrgmSimilarityThreshold = 0.3
+
+// How much each part of a patient's details counts towards a duplicate score
+const (
+	duplicateNameWeight   = 0.5
+	duplicateAgeWeight    = 0.35
+	duplicateGenderWeight = 0.15
+)
+
+// DuplicateSearch narrows FindDuplicatePatients. Zero values don't filter.
+type DuplicateSearch struct {
+	PatientID *uuid.UUID // only pairs including this patient
+	MinScore  float64    // defaults to DefaultDuplicateScore
+	Limit     int
+}
+
+// DuplicatePatients is a pair of the doctor's patients that are likely the same person.
+// Patient is the older record, the one suggested to keep.
+type DuplicatePatients struct {
+	Patient   models.Patient
+	Duplicate models.Patient
+	Score     float64 // 0 to 1
+	NameScore float64 // trigram similarity of the names, 0 to 1
+}
+
+// FindDuplicatePatients suggests pairs of the doctor's patients that are likely
+// duplicates, best match first. Pairs are scored on the trigram similarity of their
+// names, how close their ages are and whether their genders agree. On Postgres the
+// database narrows the pairs down with pg_trgm first; elsewhere every pair is compared.
+func FindDuplicatePatients(doctorID uuid.UUID, search DuplicateSearch) ([]DuplicatePatients, error) {
+	if search.MinScore < 0 || search.MinScore > 1 {
+		return nil, InvalidField("min_score", "min_score must be between 0 and 1")
+	}
+	if search.MinScore == 0 {
+		search.MinScore = DefaultDuplicateScore
+	}
+	if search.Limit < 1 || search.Limit > MaxDuplicateLimit {
+		search.Limit = 20
+	}
+	if search.PatientID != nil {
+		if _, err := findDoctorPatient(initializers.DB, doctorID, *search.PatientID); err != nil {
+			return nil, err
+		}
+	}
+
+	var pairs []DuplicatePatients
+	var err error
+	if initializers.DB.Dialector.Name() == "postgres" {
+		pairs, err = findDuplicatePairsTrigram(doctorID, search)
+	} else {
+		pairs, err = findDuplicatePairsFallback(doctorID, search)
+	}
+	if err != nil {
+		log.Println("Error finding duplicate patients:", err)
+		return nil, errors.New("failed to find duplicate patients")
+	}
+
+	sort.SliceStable(pairs, func(a, b int) bool {
+		return pairs[a].Score > pairs[b].Score
+	})
+	if len(pairs) > search.Limit {
+		pairs = pairs[:search.Limit]
+	}
+	return pairs, nil
+}
+
+// duplicateCandidate is a pair of patients the database found close enough to score.
+type duplicateCandidate struct {
+	PatientID   uuid.UUID
+	DuplicateID uuid.UUID
+	NameScore   float64
+}
+
+// duplicateCandidatesQuery pairs up the doctor's patients in SQL, older record first,
+// keeping only pairs that could still reach the search's MinScore: a name similar
+// enough for the rest of the score to make up the difference, and ages and genders
+// that agree when the score can't do without them. Once the name bound reaches
+// pg_trgm's default similarity_threshold, % lets the trigram index find the pairs.
+func duplicateCandidatesQuery(db *gorm.DB, doctorID uuid.UUID, search DuplicateSearch) *gorm.DB {
+	query := db.Table("patients AS a").
+		Select("a.id AS patient_id, b.id AS duplicate_id, similarity(LOWER(a.name), LOWER(b.name)) AS name_score").
+		Joins("JOIN patients AS b ON b.doctor_id = a.doctor_id AND (a.created_at < b.created_at OR (a.created_at = b.created_at AND a.id < b.id))").
+		Where("a.doctor_id = ?", doctorID)
+	if search.PatientID != nil {
+		query = query.Where("(a.id = ? OR b.id = ?)", *search.PatientID, *search.PatientID)
+	}
+
+	// The epsilon keeps rounding from dropping a pair right on the bound
+	minNameScore := (search.MinScore-duplicateAgeWeight-duplicateGenderWeight)/duplicateNameWeight - 1e-9
+	if minNameScore > 0 {
+		if minNameScore >= pgTrgmSimilarityThreshold {
+			query = query.Where("LOWER(a.name) % LOWER(b.name)")
+		}
+		query = query.Where("similarity(LOWER(a.name), LOWER(b.name)) >= ?", minNameScore)
+	}
+	if search.MinScore > duplicateNameWeight+duplicateGenderWeight {
+		// ageSimilarity is 0 beyond two years
+		query = query.Where("ABS(a.age - b.age) <= 2")
+	}
+	if search.MinScore > duplicateNameWeight+duplicateAgeWeight {
+		// genderSimilarity is 0 only when both are recorded and differ
+		query = query.Where("(TRIM(a.gender) = '' OR TRIM(b.gender) = '' OR LOWER(LEFT(TRIM(a.gender), 1)) = LOWER(LEFT(TRIM(b.gender), 1)))")
+	}
+	return query.Order("name_score DESC").Limit(maxDuplicateCandidates)
+}
+
+// findDuplicatePairsTrigram scores the candidate pairs Postgres finds with pg_trgm.
+func findDuplicatePairsTrigram(doctorID uuid.UUID, search DuplicateSearch) ([]DuplicatePatients, error) {
+	var candidates []duplicateCandidate
+	if err := duplicateCandidatesQuery(initializers.DB, doctorID, search).Scan(&candidates).Error; err != nil {
+		return nil, err
+	}
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]uuid.UUID, 0, 2*len(candidates))
+	for _, candidate := range candidates {
+		ids = append(ids, candidate.PatientID, candidate.DuplicateID)
+	}
+	var patients []models.Patient
+	if err := initializers.DB.Where("id IN ?", ids).Find(&patients).Error; err != nil {
+		return nil, err
+	}
+	byID := make(map[uuid.UUID]models.Patient, len(patients))
+	for _, patient := range patients {
+		byID[patient.ID] = patient
+	}
+
+	var pairs []DuplicatePatients
+	for _, candidate := range candidates {
+		patient, duplicate := byID[candidate.PatientID], byID[candidate.DuplicateID]
+		if score := duplicateScore(patient, duplicate, candidate.NameScore); score >= search.MinScore {
+			pairs = append(pairs, DuplicatePatients{Patient: patient, Duplicate: duplicate, Score: score, NameScore: candidate.NameScore})
+		}
+	}
+	return pairs, nil
+}
+
+// findDuplicatePairsFallback compares every pair of the doctor's patients in memory,
+// for databases without pg_trgm.
+func findDuplicatePairsFallback(doctorID uuid.UUID, search DuplicateSearch) ([]DuplicatePatients, error) {
+	var patients []models.Patient
+	if err := initializers.DB.Where("doctor_id = ?", doctorID).
+		Order("created_at ASC").Order("id ASC").
+		Find(&patients).Error; err != nil {
+		return nil, err
+	}
+
+	grams := make([]map[string]bool, len(patients))
+	for i := range patients {
+		grams[i] = nameTrigrams(patients[i].Name)
+	}
+
+	var pairs []DuplicatePatients
+	for i := range patients {
+		for j := i + 1; j < len(patients); j++ {
+			if search.PatientID != nil && patients[i].ID != *search.PatientID && patients[j].ID != *search.PatientID {
+				continue
+			}
+			nameScore := trigramSimilarity(grams[i], grams[j])
+			if score := duplicateScore(patients[i], patients[j], nameScore); score >= search.MinScore {
+				pairs = append(pairs, DuplicatePatients{Patient: patients[i], Duplicate: patients[j], Score: score, NameScore: nameScore})
+			}
+		}
+	}
+	return pairs, nil
+}
+
+// duplicateScore weighs a pair's name similarity, ages and genders into one score.
+func duplicateScore(a, b models.Patient, nameScore float64) float64 {
+	return duplicateNameWeight*nameScore +
+		duplicateAgeWeight*ageSimilarity(a.Age, b.Age) +
+		duplicateGenderWeight*genderSimilarity(a.Gender, b.Gender)
+}
+
+// nameTrigrams splits a name into trigrams the way pg_trgm does: lower-cased words of
+// letters and digits, each padded with two spaces in front and one behind.
+func nameTrigrams(name string) map[string]bool {
+	grams := map[string]bool{}
+	words := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for _, word := range words {
+		padded := []rune("  " + word + " ")
+		for i := 0; i+3 <= len(padded); i++ {
+			grams[string(padded[i:i+3])] = true
+		}
+	}
+	return grams
+}
+
+// trigramSimilarity is the share of trigrams two names have in common, like pg_trgm's
+// similarity().
+func trigramSimilarity(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	shared := 0
+	for gram := range a {
+		if b[gram] {
+			shared++
+		}
+	}
+	return float64(shared) / float64(len(a)+len(b)-shared)
+}
+
+// ageSimilarity allows for a birthday between visits and small typing mistakes.
+func ageSimilarity(a, b int) float64 {
+	switch diff := a - b; {
+	case diff == 0:
+		return 1
+	case diff == 1 || diff == -1:
+		return 0.75
+	case diff == 2 || diff == -2:
+		return 0.5
+	default:
+		return 0
+	}
+}
+
+// genderSimilarity compares genders by their first letter, so "M" matches "Male". An
+// unrecorded gender neither confirms nor rules out a match.
+func genderSimilarity(a, b string) float64 {
+	a, b = strings.ToLower(strings.TrimSpace(a)), strings.ToLower(strings.TrimSpace(b))
+	switch {
+	case a == "" || b == "":
+		return 0.5
+	case a[0] == b[0]:
+		return 1
+	default:
+		return 0
+	}
+}
+
+// MergePatients merges the duplicate into the surviving patient, both the doctor's. The
+// duplicate's transcriptions, encounters and transcription jobs move to the survivor and
+// the duplicate is deleted, all in one transaction that also records the merge.
+func MergePatients(doctorID, survivorID, duplicateID uuid.UUID) (*models.PatientMerge, error) {
+	if survivorID == duplicateID {
+		return nil, InvalidField("duplicate_id", "a patient can't be merged into itself")
+	}
+
+	tx := initializers.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err := findDoctorPatient(tx, doctorID, survivorID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	duplicate, err := findDoctorPatient(tx, doctorID, duplicateID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	merge := models.PatientMerge{
+		ID:              uuid.New(),
+		DoctorID:        doctorID,
+		SurvivorID:      survivorID,
+		MergedID:        duplicate.ID,
+		MergedName:      duplicate.Name,
+		MergedAge:       duplicate.Age,
+		MergedGender:    duplicate.Gender,
+		MergedCreatedAt: duplicate.CreatedAt,
+		CreatedAt:       time.Now(),
+	}
+	for _, move := range []struct {
+		model interface{}
+		name  string
+		count *int
+	}{
+		{&models.Transcription{}, "transcriptions", &merge.TranscriptionCount},
+		{&models.Encounter{}, "encounters", &merge.EncounterCount},
+		{&models.TranscriptionJob{}, "transcription jobs", &merge.JobCount},
+	} {
+		result := tx.Model(move.model).Where("patient_id = ?", duplicate.ID).Update("patient_id", survivorID)
+		if result.Error != nil {
+			tx.Rollback()
+			log.Println("Error moving "+move.name+":", result.Error)
+			return nil, errors.New("failed to merge patients")
+		}
+		*move.count = int(result.RowsAffected)
+	}
+
+	if err := tx.Create(&merge).Error; err != nil {
+		tx.Rollback()
+		log.Println("Error recording patient merge:", err)
+		return nil, errors.New("failed to merge patients")
+	}
+	// The request is audited against the survivor; this event keeps the duplicate's own
+	// history pointing at the merge
+	if err := appendAuditEvent(tx, models.AuditEvent{
+		ActorID:      &doctorID,
+		PatientID:    &duplicate.ID,
+		Action:       "patient.merge",
+		ResourceType: models.AuditResourcePatient,
+		ResourceID:   duplicate.ID.String(),
+		Outcome:      models.AuditOutcomeSuccess,
+	}); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if err := tx.Delete(duplicate).Error; err != nil {
+		tx.Rollback()
+		log.Println("Error deleting merged patient:", err)
+		return nil, errors.New("failed to merge patients")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		log.Println("Error committing patient merge:", err)
+		return nil, errors.New("failed to merge patients")
+	}
+	log.Println("Patient", duplicate.ID, "merged into", survivorID)
+	return &merge, nil
+}
@@ -0,0 +1,211 @@
+package service
+
+import (
+	"fmt"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRunPostgres returns a Postgres connection that only builds statements, so the
+// SQL behind Postgres-only features can be checked without a server.
+func dryRunPostgres(t *testing.T) *gorm.DB {
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=localhost", PreferSimpleProtocol: true}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatalf("Failed to open dry-run Postgres connection: %v", err)
+	}
+	return db
+}
+
+// createPatientMergesTable adds patient_merges to a SQLite test database
+func createPatientMergesTable(t *testing.T, db *gorm.DB) {
+	err := db.Exec(`CREATE TABLE IF NOT EXISTS patient_merges (
+		id TEXT PRIMARY KEY,
+		doctor_id TEXT NOT NULL,
+		survivor_id TEXT NOT NULL,
+		merged_id TEXT NOT NULL,
+		merged_name TEXT NOT NULL,
+		merged_age INTEGER NOT NULL,
+		merged_gender TEXT NOT NULL,
+		merged_created_at DATETIME,
+		transcription_count INTEGER NOT NULL DEFAULT 0,
+		encounter_count INTEGER NOT NULL DEFAULT 0,
+		job_count INTEGER NOT NULL DEFAULT 0,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	if err != nil {
+		t.Fatalf("Failed to create patient_merges table: %v", err)
+	}
+}
+
+func TestFindDuplicatePatients(t *testing.T) {
+	doctor := setupTranscriptionJobTestDB(t)
+
+	base := time.Date(2026, time.January, 5, 9, 0, 0, 0, time.UTC)
+	patients := map[string]models.Patient{}
+	for i, p := range []models.Patient{
+		{Name: "John Smith", Age: 45, Gender: "Male"},
+		{Name: "john  smith", Age: 45, Gender: "M"},     // same person, typed differently
+		{Name: "Jon Smith", Age: 46, Gender: "Male"},    // misspelt, had a birthday since
+		{Name: "John Smith", Age: 12, Gender: "Male"},   // a namesake
+		{Name: "Mary Jones", Age: 45, Gender: "Female"}, // unrelated
+	} {
+		p.ID, p.DoctorID, p.CreatedAt = uuid.New(), doctor.ID, base.Add(time.Duration(i)*time.Hour)
+		assert.NoError(t, initializers.DB.Create(&p).Error)
+		patients[fmt.Sprintf("%s %d", p.Name, p.Age)] = p
+	}
+	// Another doctor's identical patient is never suggested
+	other := models.Patient{ID: uuid.New(), DoctorID: uuid.New(), Name: "John Smith", Age: 45, Gender: "Male", CreatedAt: base}
+	assert.NoError(t, initializers.DB.Create(&other).Error)
+
+	original := patients["John Smith 45"]
+	pairs, err := FindDuplicatePatients(doctor.ID, DuplicateSearch{})
+	assert.NoError(t, err)
+	if assert.Len(t, pairs, 3) {
+		assert.Equal(t, original.ID, pairs[0].Patient.ID, "the older record is suggested to keep")
+		assert.Equal(t, patients["john  smith 45"].ID, pairs[0].Duplicate.ID)
+		assert.InDelta(t, 1.0, pairs[0].Score, 0.001)
+		assert.InDelta(t, 1.0, pairs[0].NameScore, 0.001)
+		for _, pair := range pairs[1:] {
+			assert.Equal(t, patients["Jon Smith 46"].ID, pair.Duplicate.ID)
+			assert.Less(t, pair.Score, pairs[0].Score)
+		}
+	}
+
+	// A lower threshold also finds the namesake, whose age rules out a match otherwise
+	pairs, err = FindDuplicatePatients(doctor.ID, DuplicateSearch{PatientID: &original.ID, MinScore: 0.6})
+	assert.NoError(t, err)
+	assert.Len(t, pairs, 3)
+	for _, pair := range pairs {
+		assert.True(t, pair.Patient.ID == original.ID || pair.Duplicate.ID == original.ID)
+	}
+
+	_, err = FindDuplicatePatients(doctor.ID, DuplicateSearch{MinScore: 2})
+	var validationErr *ValidationError
+	assert.ErrorAs(t, err, &validationErr)
+
+	_, err = FindDuplicatePatients(doctor.ID, DuplicateSearch{PatientID: &other.ID})
+	assert.ErrorIs(t, err, ErrForbidden)
+}
+
+func TestDuplicateCandidatesQuery(t *testing.T) {
+	doctorID, patientID := uuid.New(), uuid.New()
+	build := func(search DuplicateSearch) (string, []interface{}) {
+		var candidates []duplicateCandidate
+		stmt := duplicateCandidatesQuery(dryRunPostgres(t), doctorID, search).Find(&candidates).Statement
+		return stmt.SQL.String(), stmt.Vars
+	}
+
+	t.Run("Default score", func(t *testing.T) {
+		sql, vars := build(DuplicateSearch{MinScore: DefaultDuplicateScore})
+		assert.Contains(t, sql, "similarity(LOWER(a.name), LOWER(b.name)) AS name_score")
+		assert.Contains(t, sql, "JOIN patients AS b ON b.doctor_id = a.doctor_id AND (a.created_at < b.created_at OR (a.created_at = b.created_at AND a.id < b.id))")
+		// The name bound is high enough for the trigram index
+		assert.Contains(t, sql, "LOWER(a.name) % LOWER(b.name)")
+		assert.Contains(t, sql, "similarity(LOWER(a.name), LOWER(b.name)) >= $2")
+		assert.Contains(t, sql, "ABS(a.age - b.age) <= 2")
+		assert.NotContains(t, sql, "LEFT(TRIM(a.gender), 1)")
+		assert.Contains(t, sql, "ORDER BY name_score DESC LIMIT $3")
+		if assert.Len(t, vars, 3) {
+			assert.Equal(t, doctorID, vars[0])
+			assert.InDelta(t, 0.4, vars[1], 0.001)
+			assert.Equal(t, maxDuplicateCandidates, vars[2])
+		}
+	})
+
+	t.Run("Strict score", func(t *testing.T) {
+		sql, vars := build(DuplicateSearch{MinScore: 0.9, PatientID: &patientID})
+		assert.Contains(t, sql, "(a.id = $2 OR b.id = $3)")
+		assert.Contains(t, sql, "LOWER(LEFT(TRIM(a.gender), 1)) = LOWER(LEFT(TRIM(b.gender), 1))")
+		if assert.Len(t, vars, 5) {
+			assert.Equal(t, patientID, vars[1])
+			assert.InDelta(t, 0.8, vars[3], 0.001)
+		}
+	})
+
+	t.Run("Loose score", func(t *testing.T) {
+		// A name bound below pg_trgm's threshold can't use %, and none at all filters nothing
+		sql, _ := build(DuplicateSearch{MinScore: 0.6})
+		assert.NotContains(t, sql, "%")
+		assert.Contains(t, sql, "similarity(LOWER(a.name), LOWER(b.name)) >= $2")
+		assert.NotContains(t, sql, "ABS(a.age - b.age)")
+
+		sql, vars := build(DuplicateSearch{MinScore: 0.4})
+		assert.NotContains(t, sql, "similarity(LOWER(a.name), LOWER(b.name)) >=")
+		assert.Len(t, vars, 2)
+	})
+}
+
+func TestMergePatients(t *testing.T) {
+	doctor := setupTranscriptionJobTestDB(t)
+	createPatientMergesTable(t, initializers.DB)
+
+	survivor := createTestPatient(t, doctor.ID)
+	duplicate := createTestPatient(t, doctor.ID)
+	encounter := models.Encounter{ID: uuid.New(), PatientID: duplicate.ID, DoctorID: doctor.ID, VisitDate: time.Now()}
+	assert.NoError(t, initializers.DB.Create(&encounter).Error)
+	for i := 0; i < 2; i++ {
+		assert.NoError(t, initializers.DB.Create(&models.Transcription{
+			ID: uuid.New(), DoctorID: doctor.ID, PatientID: duplicate.ID, EncounterID: &encounter.ID,
+			Text: "notes", Report: "report", Status: models.ReportStatusDraft,
+		}).Error)
+	}
+	job, err := EnqueueRecording(doctor.ID, duplicate.ID, EncounterDetails{}, AudioSource{URL: "visit.mp3"})
+	assert.NoError(t, err)
+
+	t.Run("Into itself", func(t *testing.T) {
+		_, err := MergePatients(doctor.ID, survivor.ID, survivor.ID)
+		var validationErr *ValidationError
+		assert.ErrorAs(t, err, &validationErr)
+	})
+
+	t.Run("Another doctor's patient", func(t *testing.T) {
+		_, err := MergePatients(uuid.New(), survivor.ID, duplicate.ID)
+		assert.ErrorIs(t, err, ErrForbidden)
+		assert.Equal(t, int64(2), countRows(t, &models.Patient{}))
+	})
+
+	t.Run("Moves everything onto the survivor", func(t *testing.T) {
+		merge, err := MergePatients(doctor.ID, survivor.ID, duplicate.ID)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Equal(t, 2, merge.TranscriptionCount)
+		assert.Equal(t, 1, merge.EncounterCount)
+		assert.Equal(t, 1, merge.JobCount)
+		assert.Equal(t, duplicate.Name, merge.MergedName)
+
+		var transcriptions []models.Transcription
+		assert.NoError(t, initializers.DB.Find(&transcriptions).Error)
+		for _, transcription := range transcriptions {
+			assert.Equal(t, survivor.ID, transcription.PatientID)
+			assert.Equal(t, encounter.ID, *transcription.EncounterID)
+		}
+		var moved models.Encounter
+		assert.NoError(t, initializers.DB.First(&moved, "id = ?", encounter.ID).Error)
+		assert.Equal(t, survivor.ID, moved.PatientID)
+		assert.Equal(t, survivor.ID, *loadJob(t, job.ID).PatientID)
+		assert.Equal(t, int64(1), countRows(t, &models.Patient{}))
+
+		var stored models.PatientMerge
+		assert.NoError(t, initializers.DB.First(&stored, "merged_id = ?", duplicate.ID).Error)
+		assert.Equal(t, survivor.ID, stored.SurvivorID)
+
+		var event models.AuditEvent
+		assert.NoError(t, initializers.DB.First(&event, "action = ?", "patient.merge").Error)
+		assert.Equal(t, duplicate.ID, *event.PatientID)
+		assert.Equal(t, doctor.ID, *event.ActorID)
+	})
+
+	t.Run("Already merged", func(t *testing.T) {
+		_, err := MergePatients(doctor.ID, survivor.ID, duplicate.ID)
+		assert.ErrorIs(t, err, ErrPatientNotFound)
+	})
+}
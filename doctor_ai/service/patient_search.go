@@ -0,0 +1,162 @@
+package service
+
+import (
+	"errors"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"log"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Sort orders accepted by SearchPatients. A leading "-" sorts descending.
+const (
+	PatientSortRelevance = "relevance"
+	PatientSortName      = "name"
+	PatientSortAge       = "age"
+	PatientSortCreatedAt = "created_at"
+)
+
+// MaxPatientSearchLimit caps the page size of SearchPatients.
+const MaxPatientSearchLimit = 100
+
+var patientSortColumns = map[string]string{
+	PatientSortName:      "LOWER(name)",
+	PatientSortAge:       "age",
+	PatientSortCreatedAt: "created_at",
+}
+
+// PatientSearch narrows SearchPatients. Zero values don't filter.
+type PatientSearch struct {
+	Query       string // matched against the start of the name or of any word in it, and fuzzily on Postgres
+	MinAge      *int
+	MaxAge      *int
+	Gender      string     // case-insensitive
+	CreatedFrom *time.Time // inclusive
+	CreatedTo   *time.Time // exclusive
+	Sort        string     // relevance (the default with a query), name, age or created_at (the default otherwise)
+	Page        int
+	Limit       int
+}
+
+// validate checks the search and fills in its defaults.
+func (s *PatientSearch) validate() error {
+	s.Query = strings.TrimSpace(s.Query)
+	if s.MinAge != nil && s.MaxAge != nil && *s.MinAge > *s.MaxAge {
+		return InvalidField("max_age", "max_age must not be less than min_age")
+	}
+	if s.CreatedFrom != nil && s.CreatedTo != nil && s.CreatedTo.Before(*s.CreatedFrom) {
+		return InvalidField("created_to", "created_to must not be before created_from")
+	}
+	if s.Sort == "" {
+		s.Sort = "-" + PatientSortCreatedAt
+		if s.Query != "" {
+			s.Sort = PatientSortRelevance
+		}
+	}
+	if _, ok := patientSortColumns[strings.TrimPrefix(s.Sort, "-")]; !ok && s.Sort != PatientSortRelevance {
+		return InvalidField("sort", "sort must be one of relevance, name, age or created_at, optionally prefixed with -")
+	}
+	if s.Sort == PatientSortRelevance && s.Query == "" {
+		return InvalidField("sort", "sorting by relevance needs a search query")
+	}
+	if s.Page < 1 {
+		s.Page = 1
+	}
+	if s.Limit < 1 || s.Limit > MaxPatientSearchLimit {
+		s.Limit = 10
+	}
+	return nil
+}
+
+// likePrefix escapes the LIKE wildcards in a search term.
+var likePrefix = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchPatients finds the doctor's patients matching the search and returns one page
+// of them with the total number of matches. Names match case-insensitively when they,
+// or one of their words, start with the query. On Postgres names within a trigram
+// word similarity of the query match as well, so misspellings are still found.
+func SearchPatients(doctorID uuid.UUID, search PatientSearch) ([]models.Patient, int64, error) {
+	if err := search.validate(); err != nil {
+		return nil, 0, err
+	}
+
+	fuzzy := initializers.DB.Dialector.Name() == "postgres"
+	query := filterPatients(initializers.DB.Model(&models.Patient{}), doctorID, search, fuzzy)
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Println("Error counting patients:", err)
+		return nil, 0, errors.New("failed to search patients")
+	}
+
+	var patients []models.Patient
+	if err := pagePatients(query, search, fuzzy).Find(&patients).Error; err != nil {
+		log.Println("Error searching patients:", err)
+		return nil, 0, errors.New("failed to search patients")
+	}
+	return patients, total, nil
+}
+
+// filterPatients narrows query to the doctor's patients matching a validated search.
+// fuzzy adds the pg_trgm word similarity match, which needs Postgres.
+func filterPatients(query *gorm.DB, doctorID uuid.UUID, search PatientSearch, fuzzy bool) *gorm.DB {
+	query = query.Where("doctor_id = ?", doctorID)
+
+	term := strings.ToLower(search.Query)
+	if term != "" {
+		prefix := likePrefix.Replace(term) + "%"
+		if fuzzy {
+			query = query.Where(`(LOWER(name) LIKE ? ESCAPE '\' OR LOWER(name) LIKE ? ESCAPE '\' OR ? <% LOWER(name))`, prefix, "% "+prefix, term)
+		} else {
+			query = query.Where(`(LOWER(name) LIKE ? ESCAPE '\' OR LOWER(name) LIKE ? ESCAPE '\')`, prefix, "% "+prefix)
+		}
+	}
+	if search.MinAge != nil {
+		query = query.Where("age >= ?", *search.MinAge)
+	}
+	if search.MaxAge != nil {
+		query = query.Where("age <= ?", *search.MaxAge)
+	}
+	if search.Gender != "" {
+		query = query.Where("LOWER(gender) = ?", strings.ToLower(search.Gender))
+	}
+	if search.CreatedFrom != nil {
+		query = query.Where("created_at >= ?", *search.CreatedFrom)
+	}
+	if search.CreatedTo != nil {
+		query = query.Where("created_at < ?", *search.CreatedTo)
+	}
+	return query
+}
+
+// pagePatients sorts filtered patients as a validated search asks and selects its page.
+// fuzzy ranks by pg_trgm word similarity, which needs Postgres.
+func pagePatients(query *gorm.DB, search PatientSearch, fuzzy bool) *gorm.DB {
+	// Ties go by name, then id, so pages don't overlap
+	const tiebreak = ", LOWER(name) ASC, id ASC"
+	term := strings.ToLower(search.Query)
+	switch {
+	case search.Sort == PatientSortRelevance && fuzzy:
+		// Best word match first; exact prefixes score 1. The whole ORDER BY is one
+		// expression because gorm drops an expression when columns are added after it
+		query = query.Order(orderByExpr("word_similarity(?, LOWER(name)) DESC"+tiebreak, term))
+	case search.Sort == PatientSortRelevance:
+		// Without trigrams, names starting with the query beat names with a later word starting with it
+		query = query.Order(orderByExpr(`CASE WHEN LOWER(name) LIKE ? ESCAPE '\' THEN 0 ELSE 1 END`+tiebreak, likePrefix.Replace(term)+"%"))
+	case strings.HasPrefix(search.Sort, "-"):
+		query = query.Order(patientSortColumns[search.Sort[1:]] + " DESC" + tiebreak)
+	default:
+		query = query.Order(patientSortColumns[search.Sort] + " ASC" + tiebreak)
+	}
+	return query.Limit(search.Limit).Offset((search.Page - 1) * search.Limit)
+}
+
+// orderByExpr is an ORDER BY term with bound parameters.
+func orderByExpr(sql string, vars ...interface{}) clause.OrderBy {
+	return clause.OrderBy{Expression: clause.Expr{SQL: sql, Vars: vars, WithoutParentheses: true}}
+}
@@ -0,0 +1,139 @@
+package service
+
+import (
+	"errors"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchPatients(t *testing.T) {
+	setupPatientTestDB(t)
+	doctor := createTestDoctor(t)
+	otherDoctorID := uuid.New()
+
+	base := time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC)
+	seed := []models.Patient{
+		{Name: "John Doe", Age: 45, Gender: "Male", DoctorID: doctor.ID, CreatedAt: base},
+		{Name: "Johanna Smith", Age: 30, Gender: "Female", DoctorID: doctor.ID, CreatedAt: base.AddDate(0, 0, 1)},
+		{Name: "Mary Johnson", Age: 62, Gender: "female", DoctorID: doctor.ID, CreatedAt: base.AddDate(0, 0, 2)},
+		{Name: "Peter 100%_Pan", Age: 12, Gender: "Male", DoctorID: doctor.ID, CreatedAt: base.AddDate(0, 0, 3)},
+		{Name: "John Other", Age: 45, Gender: "Male", DoctorID: otherDoctorID, CreatedAt: base},
+	}
+	for i := range seed {
+		seed[i].ID = uuid.New()
+		assert.NoError(t, initializers.DB.Create(&seed[i]).Error)
+	}
+
+	age := func(n int) *int { return &n }
+	date := func(days int) *time.Time {
+		d := base.AddDate(0, 0, days)
+		return &d
+	}
+
+	tests := []struct {
+		name      string
+		search    PatientSearch
+		wantNames []string
+		wantTotal int64
+		wantField string
+	}{
+		{
+			name:      "Newest first by default",
+			search:    PatientSearch{},
+			wantNames: []string{"Peter 100%_Pan", "Mary Johnson", "Johanna Smith", "John Doe"},
+			wantTotal: 4,
+		},
+		{
+			name:      "Case-insensitive prefix of the name or a later word, whole-name prefixes first",
+			search:    PatientSearch{Query: "JOH"},
+			wantNames: []string{"Johanna Smith", "John Doe", "Mary Johnson"},
+			wantTotal: 3,
+		},
+		{
+			name:      "Wildcards in the query are literal",
+			search:    PatientSearch{Query: "100%_"},
+			wantNames: []string{"Peter 100%_Pan"},
+			wantTotal: 1,
+		},
+		{
+			name:      "Age range and case-insensitive gender",
+			search:    PatientSearch{MinAge: age(18), MaxAge: age(62), Gender: "FEMALE", Sort: "-age"},
+			wantNames: []string{"Mary Johnson", "Johanna Smith"},
+			wantTotal: 2,
+		},
+		{
+			name:      "Created-at range",
+			search:    PatientSearch{CreatedFrom: date(1), CreatedTo: date(3), Sort: "name"},
+			wantNames: []string{"Johanna Smith", "Mary Johnson"},
+			wantTotal: 2,
+		},
+		{
+			name:      "Paged with the total of all matches",
+			search:    PatientSearch{Sort: "age", Page: 2, Limit: 2},
+			wantNames: []string{"John Doe", "Mary Johnson"},
+			wantTotal: 4,
+		},
+		{name: "Inverted age range", search: PatientSearch{MinAge: age(50), MaxAge: age(20)}, wantField: "max_age"},
+		{name: "Inverted created-at range", search: PatientSearch{CreatedFrom: date(2), CreatedTo: date(1)}, wantField: "created_to"},
+		{name: "Unknown sort", search: PatientSearch{Sort: "gender"}, wantField: "sort"},
+		{name: "Relevance without a query", search: PatientSearch{Sort: "relevance"}, wantField: "sort"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			patients, total, err := SearchPatients(doctor.ID, tt.search)
+			if tt.wantField != "" {
+				var validationErr *ValidationError
+				assert.True(t, errors.As(err, &validationErr))
+				assert.Contains(t, validationErr.Fields, tt.wantField)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantTotal, total)
+			names := make([]string, len(patients))
+			for i, patient := range patients {
+				names[i] = patient.Name
+			}
+			assert.Equal(t, tt.wantNames, names)
+		})
+	}
+}
+
+func TestSearchPatients_PostgresQuery(t *testing.T) {
+	doctorID := uuid.New()
+	minAge := 18
+	search := PatientSearch{Query: " Jon_ ", MinAge: &minAge, Page: 2, Limit: 5}
+	assert.NoError(t, search.validate())
+
+	var patients []models.Patient
+	stmt := pagePatients(filterPatients(dryRunPostgres(t).Model(&models.Patient{}), doctorID, search, true), search, true).
+		Find(&patients).Statement
+	assert.Equal(t, `SELECT * FROM "patients" WHERE doctor_id = $1 AND `+
+		`((LOWER(name) LIKE $2 ESCAPE '\' OR LOWER(name) LIKE $3 ESCAPE '\' OR $4 <% LOWER(name))) AND age >= $5 `+
+		`ORDER BY word_similarity($6, LOWER(name)) DESC, LOWER(name) ASC, id ASC LIMIT $7 OFFSET $8`, stmt.SQL.String())
+	assert.Equal(t, []interface{}{doctorID, `jon\_%`, `% jon\_%`, "jon_", 18, "jon_", 5, 5}, stmt.Vars)
+
+	t.Run("Without relevance", func(t *testing.T) {
+		search := PatientSearch{Query: "jon", Sort: "-age"}
+		assert.NoError(t, search.validate())
+
+		stmt := pagePatients(filterPatients(dryRunPostgres(t).Model(&models.Patient{}), doctorID, search, true), search, true).
+			Find(&patients).Statement
+		assert.Contains(t, stmt.SQL.String(), "$4 <% LOWER(name)")
+		assert.Contains(t, stmt.SQL.String(), "ORDER BY age DESC, LOWER(name) ASC, id ASC")
+		assert.NotContains(t, stmt.SQL.String(), "word_similarity")
+	})
+
+	t.Run("Relevance without trigrams", func(t *testing.T) {
+		stmt := pagePatients(filterPatients(dryRunPostgres(t).Model(&models.Patient{}), doctorID, search, false), search, false).
+			Find(&patients).Statement
+		assert.NotContains(t, stmt.SQL.String(), "<%")
+		assert.Contains(t, stmt.SQL.String(), `ORDER BY CASE WHEN LOWER(name) LIKE $5 ESCAPE '\' THEN 0 ELSE 1 END, LOWER(name) ASC, id ASC`)
+	})
+}
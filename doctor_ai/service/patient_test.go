@@ -57,6 +57,12 @@ func setupPatientTestDB(t *testing.T) *gorm.DB {
 		patient_id TEXT NOT NULL,
 		text TEXT NOT NULL,
 		report TEXT NOT NULL,
+		structured_report TEXT,
+		audio_file_id TEXT,
+		status TEXT NOT NULL DEFAULT 'draft',
+		signed_by_id TEXT,
+		signed_at DATETIME,
+		encounter_id TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (doctor_id) REFERENCES doctors(id),
 		FOREIGN KEY (patient_id) REFERENCES patients(id)
@@ -64,6 +70,8 @@ func setupPatientTestDB(t *testing.T) *gorm.DB {
 	if err != nil {
 		t.Fatalf("Failed to create transcriptions table: %v", err)
 	}
+	createAuditEventsTable(t, db)
+	createEncountersTable(t, db)
 
 	// Set the global DB instance
 	initializers.DB = db
@@ -205,13 +213,13 @@ func TestGetPatients(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			patients, total, err := GetPatients(tt.doctorID, tt.page, tt.limit)
+			patients, info, err := GetPatients(tt.doctorID, PageRequest{Page: tt.page, Limit: tt.limit, WithTotal: true})
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
 				assert.Equal(t, tt.wantCount, len(patients))
-				assert.Equal(t, tt.wantTotal, total)
+				assert.Equal(t, tt.wantTotal, *info.Total)
 			}
 		})
 	}
@@ -255,7 +263,7 @@ func TestGetPatientWithTranscription(t *testing.T) {
 		patientID         uuid.UUID
 		wantPatient       bool
 		wantTranscription bool
-		wantErr           bool
+		wantErr           error
 	}{
 		{
 			name:              "Valid patient and transcription",
@@ -263,7 +271,6 @@ func TestGetPatientWithTranscription(t *testing.T) {
 			patientID:         patient.ID,
 			wantPatient:       true,
 			wantTranscription: true,
-			wantErr:           false,
 		},
 		{
 			name:              "Patient not found",
@@ -271,7 +278,7 @@ func TestGetPatientWithTranscription(t *testing.T) {
 			patientID:         uuid.New(),
 			wantPatient:       false,
 			wantTranscription: false,
-			wantErr:           true,
+			wantErr:           ErrPatientNotFound,
 		},
 		{
 			name:              "Unauthorized doctor",
@@ -279,15 +286,15 @@ func TestGetPatientWithTranscription(t *testing.T) {
 			patientID:         patient.ID,
 			wantPatient:       false,
 			wantTranscription: false,
-			wantErr:           true,
+			wantErr:           ErrForbidden,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			patient, transcription, err := GetPatientWithTranscription(tt.doctorID, tt.patientID)
-			if tt.wantErr {
-				assert.Error(t, err)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
 				assert.Nil(t, patient)
 				assert.Nil(t, transcription)
 			} else {
@@ -329,7 +336,7 @@ func TestUpdatePatientByID(t *testing.T) {
 		doctorID   uuid.UUID
 		patientID  uuid.UUID
 		updateData map[string]interface{}
-		wantErr    bool
+		wantErr    error
 	}{
 		{
 			name:      "Valid update",
@@ -340,7 +347,6 @@ func TestUpdatePatientByID(t *testing.T) {
 				"age":    35,
 				"gender": "Female",
 			},
-			wantErr: false,
 		},
 		{
 			name:      "Patient not found",
@@ -349,7 +355,7 @@ func TestUpdatePatientByID(t *testing.T) {
 			updateData: map[string]interface{}{
 				"name": "Updated Name",
 			},
-			wantErr: true,
+			wantErr: ErrPatientNotFound,
 		},
 		{
 			name:      "Unauthorized doctor",
@@ -358,7 +364,7 @@ func TestUpdatePatientByID(t *testing.T) {
 			updateData: map[string]interface{}{
 				"name": "Updated Name",
 			},
-			wantErr: true,
+			wantErr: ErrForbidden,
 		},
 		{
 			name:      "Disallowed fields",
@@ -368,15 +374,14 @@ func TestUpdatePatientByID(t *testing.T) {
 				"name":      "Updated Name",
 				"doctor_id": uuid.New(), // Should be ignored
 			},
-			wantErr: false,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			err := UpdatePatientByID(tt.doctorID, tt.patientID, tt.updateData)
-			if tt.wantErr {
-				assert.Error(t, err)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
 			} else {
 				assert.NoError(t, err)
 
@@ -439,26 +444,34 @@ func TestDeletePatient(t *testing.T) {
 
 	tests := []struct {
 		name      string
+		doctorID  uuid.UUID
 		patientID uuid.UUID
-		wantErr   bool
+		wantErr   error
 	}{
+		{
+			name:      "Other doctor's patient",
+			doctorID:  uuid.New(),
+			patientID: patient.ID,
+			wantErr:   ErrForbidden,
+		},
 		{
 			name:      "Valid deletion",
+			doctorID:  doctor.ID,
 			patientID: patient.ID,
-			wantErr:   false,
 		},
 		{
 			name:      "Patient not found",
+			doctorID:  doctor.ID,
 			patientID: uuid.New(),
-			wantErr:   true,
+			wantErr:   ErrPatientNotFound,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := DeletePatient(tt.patientID)
-			if tt.wantErr {
-				assert.Error(t, err)
+			err := DeletePatient(tt.doctorID, tt.patientID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
 			} else {
 				assert.NoError(t, err)
 
@@ -475,3 +488,23 @@ func TestDeletePatient(t *testing.T) {
 		})
 	}
 }
+
+func TestDeletePatient_Rollback(t *testing.T) {
+	db := setupPatientTestDB(t)
+	doctor := createTestDoctor(t)
+	patient := models.Patient{ID: uuid.New(), DoctorID: doctor.ID, Name: "Test Patient", Age: 30, Gender: "Male", CreatedAt: time.Now()}
+	assert.NoError(t, db.Create(&patient).Error)
+	transcription := models.Transcription{ID: uuid.New(), DoctorID: doctor.ID, PatientID: patient.ID, Text: "Kept", Report: "Kept", CreatedAt: time.Now()}
+	assert.NoError(t, db.Create(&transcription).Error)
+
+	// Deleting the encounters fails after the transcriptions are gone
+	assert.NoError(t, db.Exec("DROP TABLE encounters").Error)
+	assert.Error(t, DeletePatient(doctor.ID, patient.ID))
+
+	// Nothing was deleted
+	var count int64
+	assert.NoError(t, db.Model(&models.Transcription{}).Where("patient_id = ?", patient.ID).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+	assert.NoError(t, db.Model(&models.Patient{}).Where("id = ?", patient.ID).Count(&count).Error)
+	assert.Equal(t, int64(1), count)
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/jung-kurt/gofpdf"
+)
+
+// Height of the logo in the letterhead, in mm
+const pdfLogoHeight = 18
+
+// GeneratePDF writes the report as a PDF. Every page carries a letterhead with the clinic
+// and the treating doctor and a footer with page numbers; the report ends in a signature
+// block. The fonts and page size come from the configured ReportTemplate. The document
+// is built in memory, so nothing is written to w when rendering fails.
+func GeneratePDF(w io.Writer, view ReportView) error {
+	tpl := currentReportTemplate()
+	pageSize := "A4"
+	if strings.EqualFold(tpl.PageSize, "letter") {
+		pageSize = "Letter"
+	}
+
+	pdf := gofpdf.New("P", "mm", pageSize, "")
+	pdf.SetTitle(view.Title, true)
+	pdf.SetAuthor(view.Doctor, true)
+	pdf.SetCreator("Doctor AI Assistant", true)
+	pdf.SetCreationDate(time.Now())
+	pdf.SetAutoPageBreak(true, 20)
+	pdf.AliasNbPages("")
+
+	// The core fonts only cover Windows-1252, so text is translated for them; a UTF-8
+	// font takes any script as is
+	family, text := "Helvetica", pdf.UnicodeTranslatorFromDescriptor("")
+	if tpl.FontPath != "" {
+		bold := tpl.BoldFontPath
+		if bold == "" {
+			bold = tpl.FontPath
+		}
+		for style, path := range map[string]string{"": tpl.FontPath, "B": bold} {
+			font, err := os.ReadFile(path)
+			if err != nil {
+				log.Println("Error reading PDF font:", err)
+				return errors.New("failed to generate pdf")
+			}
+			pdf.AddUTF8FontFromBytes("report", style, font)
+		}
+		family, text = "report", func(s string) string { return s }
+	}
+
+	pageWidth, _ := pdf.GetPageSize()
+	left, top, right, _ := pdf.GetMargins()
+	bodyWidth := pageWidth - left - right
+
+	pdf.SetHeaderFunc(func() {
+		x := left
+		if view.LogoPath != "" {
+			pdf.ImageOptions(view.LogoPath, left, top, 0, pdfLogoHeight, false, gofpdf.ImageOptions{ReadDpi: true}, 0, "")
+			if info := pdf.GetImageInfo(view.LogoPath); info != nil && info.Height() > 0 {
+				x += info.Width()/info.Height()*pdfLogoHeight + 4
+			}
+		}
+		line := func(style string, size float64, value string) {
+			if value == "" {
+				return
+			}
+			pdf.SetFont(family, style, size)
+			pdf.SetX(x)
+			pdf.CellFormat(pageWidth-right-x, size*0.45, text(value), "", 1, "L", false, 0, "")
+		}
+
+		pdf.SetY(top)
+		line("B", 14, view.ClinicName)
+		line("", 9, view.ClinicAddress)
+		line("B", 11, view.Doctor)
+		line("", 9, view.Specialization)
+		line("", 9, view.Contact)
+
+		y := pdf.GetY() + 2
+		if view.LogoPath != "" && y < top+pdfLogoHeight+2 {
+			y = top + pdfLogoHeight + 2
+		}
+		pdf.SetDrawColor(160, 160, 160)
+		pdf.Line(left, y, pageWidth-right, y)
+		pdf.SetY(y + 6)
+	})
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-15)
+		pdf.SetFont(family, "", 8)
+		pdf.SetTextColor(110, 110, 110)
+		pdf.CellFormat(bodyWidth*0.7, 10, text(view.FooterNote), "", 0, "L", false, 0, "")
+		pdf.CellFormat(bodyWidth*0.3, 10, fmt.Sprintf("Page %d of {nb}", pdf.PageNo()), "", 0, "R", false, 0, "")
+		pdf.SetTextColor(0, 0, 0)
+	})
+	pdf.AddPage()
+
+	pdf.SetFont(family, "B", 16)
+	pdf.CellFormat(bodyWidth, 9, text(view.Title), "", 1, "L", false, 0, "")
+	pdf.Ln(2)
+
+	// Patient details as label and value rows
+	for _, field := range view.Details {
+		pdf.SetFont(family, "B", 10)
+		pdf.CellFormat(32, 6, text(field.Label), "", 0, "L", false, 0, "")
+		pdf.SetFont(family, "", 10)
+		pdf.MultiCell(bodyWidth-32, 6, text(field.Value), "", "L", false)
+	}
+	pdf.Ln(4)
+
+	for _, section := range view.Sections {
+		pdf.SetFont(family, "B", 12)
+		pdf.CellFormat(bodyWidth, 7, text(section.Heading), "", 1, "L", false, 0, "")
+		pdf.SetFont(family, "", 11)
+		if section.Text != "" {
+			pdf.MultiCell(bodyWidth, 6, text(section.Text), "", "L", false)
+		}
+		for _, item := range section.Items {
+			pdf.MultiCell(bodyWidth, 6, text("• "+item), "", "L", false)
+		}
+		pdf.Ln(3)
+	}
+
+	// Keep the signature block together on one page
+	_, pageHeight := pdf.GetPageSize()
+	_, _, _, bottom := pdf.GetMargins()
+	if pdf.GetY()+32 > pageHeight-bottom {
+		pdf.AddPage()
+	}
+	pdf.Ln(6)
+	pdf.SetFont(family, "", 10)
+	if view.Signature != "" {
+		pdf.MultiCell(bodyWidth, 6, text(view.Signature), "", "L", false)
+	} else {
+		pdf.Ln(10)
+		y := pdf.GetY()
+		pdf.SetDrawColor(0, 0, 0)
+		pdf.Line(left, y, left+70, y)
+		pdf.Line(left+bodyWidth-50, y, left+bodyWidth, y)
+		pdf.CellFormat(bodyWidth-50, 6, text("Signature"), "", 0, "L", false, 0, "")
+		pdf.CellFormat(50, 6, text("Date"), "", 1, "L", false, 0, "")
+	}
+	pdf.SetFont(family, "B", 10)
+	pdf.CellFormat(bodyWidth, 6, text(view.Doctor), "", 1, "L", false, 0, "")
+	pdf.SetFont(family, "", 10)
+	pdf.CellFormat(bodyWidth, 6, text(view.Specialization), "", 1, "L", false, 0, "")
+
+	if err := pdf.Error(); err != nil {
+		log.Println("Error rendering PDF:", err)
+		return errors.New("failed to generate pdf")
+	}
+	if err := pdf.Output(w); err != nil {
+		log.Println("Error writing PDF:", err)
+		return errors.New("failed to generate pdf")
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"itish41/doctor_ai_assistant/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func testReportDocument() ReportDocument {
+	return ReportDocument{
+		Transcription: models.Transcription{
+			ID:               uuid.New(),
+			Report:           testReport("Hypertension").Text(),
+			StructuredReport: testReport("Hypertension"),
+			Status:           models.ReportStatusDraft,
+			CreatedAt:        time.Date(2026, time.March, 1, 10, 0, 0, 0, time.UTC),
+		},
+		Patient: models.Patient{ID: uuid.New(), Name: "José Álvarez", Age: 61, Gender: "Male"},
+		Doctor:  models.Doctor{Name: "Dr. Test", Specialization: "Cardiology", Email: "test@example.com", Phone: "1234567890"},
+	}
+}
+
+// useGofpdfFonts configures the DejaVu fonts shipped with gofpdf, skipping the test when
+// the module source isn't available.
+func useGofpdfFonts(t *testing.T, tpl *ReportTemplate) {
+	out, err := exec.Command("go", "list", "-m", "-f", "{{.Dir}}", "github.com/jung-kurt/gofpdf").Output()
+	dir := strings.TrimSpace(string(out))
+	if err != nil || dir == "" {
+		t.Skip("gofpdf fonts not available")
+	}
+	tpl.FontPath = filepath.Join(dir, "font", "DejaVuSansCondensed.ttf")
+	tpl.BoldFontPath = filepath.Join(dir, "font", "DejaVuSansCondensed-Bold.ttf")
+}
+
+func useReportTemplate(t *testing.T, tpl ReportTemplate) {
+	SetReportTemplate(tpl)
+	t.Cleanup(func() { SetReportTemplate(ReportTemplate{}) })
+}
+
+func TestGeneratePDF(t *testing.T) {
+	t.Run("Default template", func(t *testing.T) {
+		useReportTemplate(t, ReportTemplate{})
+		var buf bytes.Buffer
+		assert.NoError(t, GeneratePDF(&buf, NewReportView(testReportDocument())))
+		assert.True(t, bytes.HasPrefix(buf.Bytes(), []byte("%PDF-")))
+		assert.Contains(t, buf.String(), "/Count 1")
+	})
+
+	t.Run("Long reports run onto more pages", func(t *testing.T) {
+		useReportTemplate(t, ReportTemplate{})
+		doc := testReportDocument()
+		doc.Transcription.StructuredReport.PatientHistory = strings.Repeat("Long standing history of raised blood pressure. ", 200)
+		var buf bytes.Buffer
+		assert.NoError(t, GeneratePDF(&buf, NewReportView(doc)))
+		assert.NotContains(t, buf.String(), "/Count 1\n")
+	})
+
+	t.Run("UTF-8 font with a logo", func(t *testing.T) {
+		logo := filepath.Join(t.TempDir(), "logo.png")
+		img := image.NewRGBA(image.Rect(0, 0, 40, 20))
+		for x := 0; x < 40; x++ {
+			img.Set(x, 10, color.RGBA{R: 200, A: 255})
+		}
+		file, err := os.Create(logo)
+		assert.NoError(t, err)
+		assert.NoError(t, png.Encode(file, img))
+		file.Close()
+
+		tpl := ReportTemplate{ClinicName: "Clinique Sainte-Marie", LogoPath: logo, FooterNote: "Confidential", PageSize: "Letter"}
+		useGofpdfFonts(t, &tpl)
+		useReportTemplate(t, tpl)
+
+		doc := testReportDocument()
+		doc.Patient.Name = "Иван Петров 王伟"
+		signedAt := time.Date(2026, time.March, 2, 9, 30, 0, 0, time.UTC)
+		doc.Transcription.Status, doc.Transcription.SignedAt = models.ReportStatusSigned, &signedAt
+
+		var buf bytes.Buffer
+		assert.NoError(t, GeneratePDF(&buf, NewReportView(doc)))
+		assert.Contains(t, buf.String(), "/FontFile2", "the TrueType font is embedded")
+		assert.Contains(t, buf.String(), "/Subtype /Image")
+	})
+
+	t.Run("Missing font", func(t *testing.T) {
+		useReportTemplate(t, ReportTemplate{FontPath: filepath.Join(t.TempDir(), "missing.ttf")})
+		var buf bytes.Buffer
+		assert.Error(t, GeneratePDF(&buf, NewReportView(testReportDocument())))
+		assert.Zero(t, buf.Len())
+	})
+}
@@ -10,6 +10,9 @@ import (
 	"gorm.io/gorm"
 )
 
+// ErrDoctorNotFound is returned when no doctor has the given email.
+var ErrDoctorNotFound error = &NotFoundError{Resource: "doctor profile"}
+
 func GetDoctorProfile(email string) (*models.Doctor, error) {
 	var doctor models.Doctor
 
@@ -34,7 +37,7 @@ func GetDoctorProfile(email string) (*models.Doctor, error) {
 	if err != nil {
 		if err == sql.ErrNoRows {
 			log.Println("No doctor found with email:", email)
-			return nil, errors.New("doctor profile not found")
+			return nil, ErrDoctorNotFound
 		}
 		log.Println("Error retrieving doctor profile:", err)
 		return nil, errors.New("error retrieving doctor profile")
@@ -65,7 +68,8 @@ func UpdateDoctorProfile(email, name, specialization, phone string) error {
 	result := tx.Where("email = ?", email).First(&doctor)
 	if result.Error != nil {
 		log.Println("Doctor not found:", result.Error)
-		return errors.New("doctor profile not found")
+		tx.Rollback()
+		return ErrDoctorNotFound
 	}
 
 	// Prepare fields to update
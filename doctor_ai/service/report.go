@@ -0,0 +1,229 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"log"
+	"os"
+	"strings"
+	"sync"
+	"unicode"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const defaultReportTitle = "Medical Transcription Report"
+
+// ReportTemplate brands exported reports. Every format shows the title, clinic, logo and
+// footer note; the fonts and page size only apply to PDFs.
+type ReportTemplate struct {
+	Title         string // defaults to "Medical Transcription Report"
+	ClinicName    string
+	ClinicAddress string
+	LogoPath      string // PNG, JPEG or GIF
+	FooterNote    string
+	FontPath      string // UTF-8 TrueType font for PDFs; without one only Latin text renders
+	BoldFontPath  string // defaults to FontPath
+	PageSize      string // A4 (the default) or Letter
+}
+
+// NewReportTemplateFromEnv builds the ReportTemplate described by REPORT_TITLE,
+// REPORT_CLINIC_NAME, REPORT_CLINIC_ADDRESS, REPORT_LOGO, REPORT_FOOTER, PDF_FONT,
+// PDF_FONT_BOLD and PDF_PAGE_SIZE. All are optional; the files they name must exist.
+func NewReportTemplateFromEnv() (ReportTemplate, error) {
+	template := ReportTemplate{
+		Title:         os.Getenv("REPORT_TITLE"),
+		ClinicName:    os.Getenv("REPORT_CLINIC_NAME"),
+		ClinicAddress: os.Getenv("REPORT_CLINIC_ADDRESS"),
+		LogoPath:      os.Getenv("REPORT_LOGO"),
+		FooterNote:    os.Getenv("REPORT_FOOTER"),
+		FontPath:      os.Getenv("PDF_FONT"),
+		BoldFontPath:  os.Getenv("PDF_FONT_BOLD"),
+		PageSize:      os.Getenv("PDF_PAGE_SIZE"),
+	}
+	for key, path := range map[string]string{"REPORT_LOGO": template.LogoPath, "PDF_FONT": template.FontPath, "PDF_FONT_BOLD": template.BoldFontPath} {
+		if path == "" {
+			continue
+		}
+		if _, err := os.Stat(path); err != nil {
+			return ReportTemplate{}, fmt.Errorf("invalid %s %q: %v", key, path, err)
+		}
+	}
+	if template.BoldFontPath != "" && template.FontPath == "" {
+		return ReportTemplate{}, errors.New("PDF_FONT_BOLD needs PDF_FONT to be set as well")
+	}
+	switch strings.ToLower(template.PageSize) {
+	case "", "a4", "letter":
+	default:
+		return ReportTemplate{}, fmt.Errorf("unknown PDF_PAGE_SIZE %q", template.PageSize)
+	}
+	return template, nil
+}
+
+var (
+	reportTemplateMu sync.RWMutex
+	reportTemplate   ReportTemplate
+)
+
+// SetReportTemplate replaces the template reports are exported with.
+func SetReportTemplate(t ReportTemplate) {
+	reportTemplateMu.Lock()
+	reportTemplate = t
+	reportTemplateMu.Unlock()
+}
+
+func currentReportTemplate() ReportTemplate {
+	reportTemplateMu.RLock()
+	defer reportTemplateMu.RUnlock()
+	return reportTemplate
+}
+
+// ReportDocument is a transcription report together with the patient it is about and the
+// doctor who wrote it, as rendered into a downloadable document.
+type ReportDocument struct {
+	Transcription models.Transcription
+	Patient       models.Patient
+	Doctor        models.Doctor
+}
+
+// GetReportDocument loads one of the doctor's transcriptions with its patient and doctor,
+// so a download shows what the database holds rather than what the client sent.
+func GetReportDocument(doctorID, transcriptionID uuid.UUID) (*ReportDocument, error) {
+	transcription, err := findDoctorTranscription(initializers.DB, doctorID, transcriptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	doc := ReportDocument{Transcription: *transcription}
+	if err := initializers.DB.Where("id = ?", transcription.PatientID).First(&doc.Patient).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrPatientNotFound
+		}
+		log.Println("Error retrieving patient:", err)
+		return nil, errors.New("failed to retrieve patient")
+	}
+	if err := initializers.DB.Omit("password").Where("id = ?", transcription.DoctorID).First(&doc.Doctor).Error; err != nil {
+		log.Println("Error retrieving doctor:", err)
+		return nil, errors.New("failed to retrieve doctor")
+	}
+	return &doc, nil
+}
+
+// ReportView is what every export format shows of a report, in reading order, so the
+// formats only differ in layout.
+type ReportView struct {
+	Title          string
+	ClinicName     string
+	ClinicAddress  string
+	LogoPath       string
+	Doctor         string
+	Specialization string
+	Contact        string // the doctor's email and phone
+	Details        []ReportField
+	Sections       []ReportSection
+	Signature      string // the electronic signature; empty leaves a block to sign by hand
+	FooterNote     string
+}
+
+// ReportField is one labelled patient or report detail.
+type ReportField struct {
+	Label string
+	Value string
+}
+
+// ReportSection is a headed part of the report: a paragraph, a bulleted list, or both.
+type ReportSection struct {
+	Heading string
+	Text    string // may span several lines
+	Items   []string
+}
+
+// NewReportView lays the document out with the configured ReportTemplate.
+func NewReportView(doc ReportDocument) ReportView {
+	tpl := currentReportTemplate()
+	view := ReportView{
+		Title:          tpl.Title,
+		ClinicName:     tpl.ClinicName,
+		ClinicAddress:  tpl.ClinicAddress,
+		LogoPath:       tpl.LogoPath,
+		Doctor:         doc.Doctor.Name,
+		Specialization: doc.Doctor.Specialization,
+		Contact:        joinNonEmpty(" | ", doc.Doctor.Email, doc.Doctor.Phone),
+		FooterNote:     tpl.FooterNote,
+	}
+	if view.Title == "" {
+		view.Title = defaultReportTitle
+	}
+
+	gender := doc.Patient.Gender
+	if gender == "" {
+		gender = "Not recorded"
+	}
+	view.Details = []ReportField{
+		{"Patient", doc.Patient.Name},
+		{"Age", fmt.Sprintf("%d", doc.Patient.Age)},
+		{"Gender", gender},
+		{"Patient ID", doc.Patient.ID.String()},
+		{"Report date", doc.Transcription.CreatedAt.Format("2 January 2006")},
+		{"Status", doc.Transcription.Status},
+	}
+
+	section := func(heading, text string, items []string) {
+		if text == "" && len(items) == 0 {
+			text = "Not documented."
+		}
+		view.Sections = append(view.Sections, ReportSection{Heading: heading, Text: text, Items: items})
+	}
+	if report := doc.Transcription.StructuredReport; report != nil {
+		section("Patient Information", report.PatientInformation, nil)
+		section("Patient History", report.PatientHistory, nil)
+		section("Symptoms", "", report.Symptoms)
+		section("Diagnosis", report.Diagnosis, nil)
+		section("Treatment Plan", report.TreatmentPlan, nil)
+		section("Recommendations", "", report.Recommendations)
+	} else {
+		section("Report", doc.Transcription.Report, nil)
+	}
+
+	if doc.Transcription.Status == models.ReportStatusSigned && doc.Transcription.SignedAt != nil {
+		view.Signature = fmt.Sprintf("Electronically signed by %s on %s.",
+			doc.Doctor.Name, doc.Transcription.SignedAt.Format("2 January 2006 at 15:04 MST"))
+	}
+	return view
+}
+
+// ReportFileName names the download of a report, such as
+// "jane-doe_2026-03-01_report.pdf". Letters of any script are kept.
+func ReportFileName(doc ReportDocument, extension string) string {
+	var name strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(doc.Patient.Name) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			name.WriteRune(r)
+			dash = false
+		case !dash && name.Len() > 0:
+			name.WriteRune('-')
+			dash = true
+		}
+	}
+	patient := strings.TrimSuffix(name.String(), "-")
+	if patient == "" {
+		patient = "patient"
+	}
+	return fmt.Sprintf("%s_%s_report.%s", patient, doc.Transcription.CreatedAt.Format("2006-01-02"), extension)
+}
+
+// joinNonEmpty joins the values that aren't empty.
+func joinNonEmpty(sep string, values ...string) string {
+	kept := values[:0:0]
+	for _, value := range values {
+		if value != "" {
+			kept = append(kept, value)
+		}
+	}
+	return strings.Join(kept, sep)
+}
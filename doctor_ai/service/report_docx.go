@@ -0,0 +1,244 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"image"
+	_ "image/gif" // decoders for the logo's size
+	_ "image/jpeg"
+	_ "image/png"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// Height of the logo in the letterhead, in EMU (18 mm, as in the PDF)
+const docxLogoHeight = 18 * 36000
+
+// GenerateDOCX writes the report as a Word document, so it can be edited into a referral
+// letter. The page size follows the configured ReportTemplate.
+func GenerateDOCX(w io.Writer, view ReportView) error {
+	var body strings.Builder
+	relationships := []string{
+		`<Relationship Id="rIdStyles" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>`,
+	}
+	var logo []byte
+	var logoExt string
+
+	if view.LogoPath != "" {
+		var err error
+		if logo, err = os.ReadFile(view.LogoPath); err != nil {
+			log.Println("Error reading report logo:", err)
+			return errors.New("failed to generate docx report")
+		}
+		config, format, err := image.DecodeConfig(bytes.NewReader(logo))
+		if err != nil || config.Height == 0 {
+			log.Println("Skipping unreadable report logo:", err)
+			logo = nil
+		} else {
+			logoExt = map[string]string{"jpeg": "jpg"}[format]
+			if logoExt == "" {
+				logoExt = format
+			}
+			relationships = append(relationships, `<Relationship Id="rIdLogo" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/image" Target="media/logo.`+logoExt+`"/>`)
+			body.WriteString(`<w:p><w:r>` + docxImage(config.Width*docxLogoHeight/config.Height, docxLogoHeight) + `</w:r></w:p>`)
+		}
+	}
+
+	paragraph := func(style, value string, bold bool) {
+		body.WriteString(`<w:p>`)
+		if style != "" {
+			body.WriteString(`<w:pPr><w:pStyle w:val="` + style + `"/></w:pPr>`)
+		}
+		body.WriteString(docxRun(value, bold))
+		body.WriteString(`</w:p>`)
+	}
+	if view.ClinicName != "" {
+		paragraph("", view.ClinicName, true)
+	}
+	if view.ClinicAddress != "" {
+		paragraph("", view.ClinicAddress, false)
+	}
+	paragraph("", view.Doctor, true)
+	if view.Specialization != "" {
+		paragraph("", view.Specialization, false)
+	}
+	if view.Contact != "" {
+		paragraph("", view.Contact, false)
+	}
+	body.WriteString(`<w:p><w:pPr><w:pBdr><w:bottom w:val="single" w:sz="6" w:space="1" w:color="A0A0A0"/></w:pBdr></w:pPr></w:p>`)
+
+	paragraph("Title", view.Title, false)
+	for _, field := range view.Details {
+		body.WriteString(`<w:p>` + docxRun(field.Label+": ", true) + docxRun(field.Value, false) + `</w:p>`)
+	}
+	for _, section := range view.Sections {
+		paragraph("Heading1", section.Heading, false)
+		if section.Text != "" {
+			paragraph("", section.Text, false)
+		}
+		for _, item := range section.Items {
+			paragraph("ListBullet", "• "+item, false)
+		}
+	}
+
+	body.WriteString(`<w:p/>`)
+	if view.Signature != "" {
+		paragraph("", view.Signature, false)
+	} else {
+		body.WriteString(`<w:p/><w:p>` + docxRun("Signature: ______________________", false) + `</w:p>`)
+		body.WriteString(`<w:p>` + docxRun("Date: ______________", false) + `</w:p>`)
+	}
+	paragraph("", view.Doctor, true)
+	if view.Specialization != "" {
+		paragraph("", view.Specialization, false)
+	}
+
+	// Page size and margins in twentieths of a point
+	width, height := 11906, 16838
+	if strings.EqualFold(currentReportTemplate().PageSize, "letter") {
+		width, height = 12240, 15840
+	}
+	sectPr := fmt.Sprintf(`<w:sectPr>%s<w:pgSz w:w="%d" w:h="%d"/><w:pgMar w:top="1134" w:right="1134" w:bottom="1134" w:left="1134" w:header="567" w:footer="567" w:gutter="0"/></w:sectPr>`,
+		docxFooterReference(view.FooterNote), width, height)
+	if view.FooterNote != "" {
+		relationships = append(relationships, `<Relationship Id="rIdFooter" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/footer" Target="footer1.xml"/>`)
+	}
+
+	parts := []docxPart{
+		{"[Content_Types].xml", []byte(docxContentTypes(logoExt, view.FooterNote != ""))},
+		{"_rels/.rels", []byte(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>` +
+			`<Relationship Id="rId2" Type="http://schemas.openxmlformats.org/package/2006/relationships/metadata/core-properties" Target="docProps/core.xml"/>` +
+			`</Relationships>`)},
+		{"docProps/core.xml", []byte(xml.Header + `<cp:coreProperties xmlns:cp="http://schemas.openxmlformats.org/package/2006/metadata/core-properties" xmlns:dc="http://purl.org/dc/elements/1.1/" xmlns:dcterms="http://purl.org/dc/terms/" xmlns:xsi="http://www.w3.org/2001/XMLSchema-instance">` +
+			`<dc:title>` + docxEscape(view.Title) + `</dc:title><dc:creator>` + docxEscape(view.Doctor) + `</dc:creator>` +
+			`<dcterms:created xsi:type="dcterms:W3CDTF">` + time.Now().UTC().Format(time.RFC3339) + `</dcterms:created>` +
+			`</cp:coreProperties>`)},
+		{"word/_rels/document.xml.rels", []byte(xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+			strings.Join(relationships, "") + `</Relationships>`)},
+		{"word/document.xml", []byte(xml.Header + `<w:document ` + docxNamespaces + `><w:body>` + body.String() + sectPr + `</w:body></w:document>`)},
+		{"word/styles.xml", []byte(docxStyles)},
+	}
+	if view.FooterNote != "" {
+		parts = append(parts, docxPart{"word/footer1.xml", []byte(xml.Header + `<w:ftr ` + docxNamespaces + `><w:p><w:pPr><w:pStyle w:val="Footer"/></w:pPr>` +
+			docxRun(view.FooterNote, false) + `</w:p></w:ftr>`)})
+	}
+	if logo != nil {
+		parts = append(parts, docxPart{"word/media/logo." + logoExt, logo})
+	}
+
+	// Build the archive in memory, so nothing is written to w when it fails
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+	for _, part := range parts {
+		file, err := archive.Create(part.name)
+		if err == nil {
+			_, err = file.Write(part.content)
+		}
+		if err != nil {
+			log.Println("Error building DOCX:", err)
+			return errors.New("failed to generate docx report")
+		}
+	}
+	if err := archive.Close(); err != nil {
+		log.Println("Error building DOCX:", err)
+		return errors.New("failed to generate docx report")
+	}
+	if _, err := buf.WriteTo(w); err != nil {
+		log.Println("Error writing DOCX:", err)
+		return errors.New("failed to generate docx report")
+	}
+	return nil
+}
+
+// docxPart is a file in the DOCX archive.
+type docxPart struct {
+	name    string
+	content []byte
+}
+
+const docxNamespaces = `xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main" ` +
+	`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships" ` +
+	`xmlns:wp="http://schemas.openxmlformats.org/drawingml/2006/wordprocessingDrawing" ` +
+	`xmlns:a="http://schemas.openxmlformats.org/drawingml/2006/main" ` +
+	`xmlns:pic="http://schemas.openxmlformats.org/drawingml/2006/picture"`
+
+const docxStyles = xml.Header + `<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">` +
+	`<w:docDefaults><w:rPrDefault><w:rPr><w:rFonts w:ascii="Calibri" w:hAnsi="Calibri" w:cs="Calibri"/><w:sz w:val="22"/></w:rPr></w:rPrDefault>` +
+	`<w:pPrDefault><w:pPr><w:spacing w:after="80"/></w:pPr></w:pPrDefault></w:docDefaults>` +
+	`<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Title"><w:name w:val="Title"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/>` +
+	`<w:pPr><w:spacing w:before="240" w:after="120"/></w:pPr><w:rPr><w:b/><w:sz w:val="32"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/>` +
+	`<w:pPr><w:keepNext/><w:spacing w:before="240" w:after="60"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="24"/></w:rPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="ListBullet"><w:name w:val="List Bullet"/><w:basedOn w:val="Normal"/>` +
+	`<w:pPr><w:ind w:left="360" w:hanging="360"/></w:pPr></w:style>` +
+	`<w:style w:type="paragraph" w:styleId="Footer"><w:name w:val="footer"/><w:basedOn w:val="Normal"/><w:rPr><w:color w:val="6E6E6E"/><w:sz w:val="16"/></w:rPr></w:style>` +
+	`</w:styles>`
+
+func docxContentTypes(logoExt string, footer bool) string {
+	var b strings.Builder
+	b.WriteString(xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">`)
+	b.WriteString(`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>`)
+	b.WriteString(`<Default Extension="xml" ContentType="application/xml"/>`)
+	if logoExt != "" {
+		contentType := map[string]string{"png": "image/png", "jpg": "image/jpeg", "gif": "image/gif"}[logoExt]
+		b.WriteString(`<Default Extension="` + logoExt + `" ContentType="` + contentType + `"/>`)
+	}
+	b.WriteString(`<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>`)
+	b.WriteString(`<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>`)
+	if footer {
+		b.WriteString(`<Override PartName="/word/footer1.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.footer+xml"/>`)
+	}
+	b.WriteString(`<Override PartName="/docProps/core.xml" ContentType="application/vnd.openxmlformats-package.core-properties+xml"/>`)
+	b.WriteString(`</Types>`)
+	return b.String()
+}
+
+func docxFooterReference(note string) string {
+	if note == "" {
+		return ""
+	}
+	return `<w:footerReference w:type="default" r:id="rIdFooter"/>`
+}
+
+// docxRun is a run of text. Line breaks in the value become breaks in the run, and
+// bullets in list paragraphs are written as text so no numbering part is needed.
+func docxRun(value string, bold bool) string {
+	var b strings.Builder
+	b.WriteString(`<w:r>`)
+	if bold {
+		b.WriteString(`<w:rPr><w:b/></w:rPr>`)
+	}
+	for i, line := range strings.Split(strings.ReplaceAll(value, "\r\n", "\n"), "\n") {
+		if i > 0 {
+			b.WriteString(`<w:br/>`)
+		}
+		b.WriteString(`<w:t xml:space="preserve">` + docxEscape(line) + `</w:t>`)
+	}
+	b.WriteString(`</w:r>`)
+	return b.String()
+}
+
+// docxImage is an inline drawing of the logo, width and height in EMU.
+func docxImage(width, height int) string {
+	return fmt.Sprintf(`<w:drawing><wp:inline distT="0" distB="0" distL="0" distR="0"><wp:extent cx="%[1]d" cy="%[2]d"/>`+
+		`<wp:docPr id="1" name="Logo"/><a:graphic><a:graphicData uri="http://schemas.openxmlformats.org/drawingml/2006/picture">`+
+		`<pic:pic><pic:nvPicPr><pic:cNvPr id="1" name="Logo"/><pic:cNvPicPr/></pic:nvPicPr>`+
+		`<pic:blipFill><a:blip r:embed="rIdLogo"/><a:stretch><a:fillRect/></a:stretch></pic:blipFill>`+
+		`<pic:spPr><a:xfrm><a:off x="0" y="0"/><a:ext cx="%[1]d" cy="%[2]d"/></a:xfrm><a:prstGeom prst="rect"><a:avLst/></a:prstGeom></pic:spPr>`+
+		`</pic:pic></a:graphicData></a:graphic></wp:inline></w:drawing>`, width, height)
+}
+
+// docxEscape escapes text for the document XML, replacing characters XML can't hold.
+func docxEscape(value string) string {
+	var b strings.Builder
+	xml.EscapeText(&b, []byte(value))
+	return b.String()
+}
@@ -0,0 +1,241 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ReportExporter renders a ReportView in one download format.
+type ReportExporter struct {
+	Format      string // the name clients ask for with ?format=, also the file extension
+	ContentType string
+	Render      func(w io.Writer, view ReportView) error
+}
+
+// reportExporters are the download formats, keyed by the name clients ask for.
+var reportExporters = map[string]ReportExporter{
+	"pdf":  {Format: "pdf", ContentType: "application/pdf", Render: GeneratePDF},
+	"docx": {Format: "docx", ContentType: "application/vnd.openxmlformats-officedocument.wordprocessingml.document", Render: GenerateDOCX},
+	"html": {Format: "html", ContentType: "text/html; charset=utf-8", Render: GenerateHTML},
+	"md":   {Format: "md", ContentType: "text/markdown; charset=utf-8", Render: GenerateMarkdown},
+	"txt":  {Format: "txt", ContentType: "text/plain; charset=utf-8", Render: GenerateText},
+}
+
+// reportFormats orders reportExporters by preference: when an Accept header rates several
+// formats equally, the earlier one is sent.
+var reportFormats = []string{"pdf", "docx", "html", "md", "txt"}
+
+// ErrNotAcceptable is returned when an Accept header allows none of the report formats.
+var ErrNotAcceptable error = InvalidField("accept", "none of the accepted types is a report format: "+
+	strings.Join(ReportFormats(), ", "))
+
+// ReportFormats names the formats reports can be downloaded in.
+func ReportFormats() []string {
+	return append([]string(nil), reportFormats...)
+}
+
+// FindReportExporter looks up the exporter for a format name, ignoring case.
+func FindReportExporter(format string) (ReportExporter, bool) {
+	exporter, ok := reportExporters[strings.ToLower(format)]
+	return exporter, ok
+}
+
+// NegotiateReportExporter picks the exporter for a download. An explicit format wins;
+// otherwise the Accept header decides, with PDF the default when it is missing.
+func NegotiateReportExporter(format, accept string) (ReportExporter, error) {
+	if format != "" {
+		exporter, ok := FindReportExporter(format)
+		if !ok {
+			return ReportExporter{}, InvalidField("format", "format must be one of "+strings.Join(ReportFormats(), ", "))
+		}
+		return exporter, nil
+	}
+	if strings.TrimSpace(accept) == "" {
+		return reportExporters[reportFormats[0]], nil
+	}
+
+	ranges := parseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, format := range reportFormats {
+		mediaType, _, _ := mime.ParseMediaType(reportExporters[format].ContentType)
+		if q := acceptQuality(ranges, mediaType); q > bestQ {
+			best, bestQ = format, q
+		}
+	}
+	if best == "" {
+		return ReportExporter{}, ErrNotAcceptable
+	}
+	return reportExporters[best], nil
+}
+
+// mediaRange is one entry of an Accept header.
+type mediaRange struct {
+	mediaType string // may be type/* or */*
+	q         float64
+}
+
+// parseAccept reads an Accept header, skipping entries that don't parse. Unlike gin's
+// NegotiateFormat it honours q-values, so "application/pdf;q=0.1, text/html" prefers HTML.
+func parseAccept(accept string) []mediaRange {
+	var ranges []mediaRange
+	for _, part := range strings.Split(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || !strings.Contains(mediaType, "/") {
+			continue
+		}
+		q := 1.0
+		if value, ok := params["q"]; ok {
+			if q, err = strconv.ParseFloat(value, 64); err != nil || q < 0 || q > 1 {
+				continue
+			}
+		}
+		ranges = append(ranges, mediaRange{mediaType: mediaType, q: q})
+	}
+	// The most specific range that matches a type sets its quality
+	sort.SliceStable(ranges, func(i, j int) bool {
+		return rangeSpecificity(ranges[i].mediaType) > rangeSpecificity(ranges[j].mediaType)
+	})
+	return ranges
+}
+
+func rangeSpecificity(mediaType string) int {
+	switch {
+	case mediaType == "*/*":
+		return 0
+	case strings.HasSuffix(mediaType, "/*"):
+		return 1
+	default:
+		return 2
+	}
+}
+
+// acceptQuality is the quality the Accept ranges give a media type, 0 when it isn't accepted.
+func acceptQuality(ranges []mediaRange, mediaType string) float64 {
+	for _, r := range ranges {
+		if r.mediaType == mediaType || r.mediaType == "*/*" ||
+			(strings.HasSuffix(r.mediaType, "/*") && strings.HasPrefix(mediaType, strings.TrimSuffix(r.mediaType, "*"))) {
+			return r.q
+		}
+	}
+	return 0
+}
+
+// GenerateText writes the report as plain text.
+func GenerateText(w io.Writer, view ReportView) error {
+	var b strings.Builder
+	for _, line := range []string{view.ClinicName, view.ClinicAddress} {
+		if line != "" {
+			b.WriteString(line + "\n")
+		}
+	}
+	if view.ClinicName != "" || view.ClinicAddress != "" {
+		b.WriteString("\n")
+	}
+	fmt.Fprintf(&b, "%s\n\n", view.Title)
+	for _, field := range view.Details {
+		fmt.Fprintf(&b, "%s: %s\n", field.Label, field.Value)
+	}
+	fmt.Fprintf(&b, "Doctor: %s\n", joinNonEmpty(", ", view.Doctor, view.Specialization))
+	if view.Contact != "" {
+		fmt.Fprintf(&b, "Contact: %s\n", view.Contact)
+	}
+	for _, section := range view.Sections {
+		fmt.Fprintf(&b, "\n%s\n", section.Heading)
+		if section.Text != "" {
+			b.WriteString(section.Text + "\n")
+		}
+		for _, item := range section.Items {
+			fmt.Fprintf(&b, "- %s\n", item)
+		}
+	}
+	if view.Signature != "" {
+		fmt.Fprintf(&b, "\n%s\n", view.Signature)
+	}
+	if view.FooterNote != "" {
+		fmt.Fprintf(&b, "\n%s\n", view.FooterNote)
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		log.Println("Error writing text report:", err)
+		return errors.New("failed to generate text report")
+	}
+	return nil
+}
+
+// GenerateMarkdown writes the report as Markdown. Text from the report is escaped, so it
+// can't add formatting or links of its own.
+func GenerateMarkdown(w io.Writer, view ReportView) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %s\n\n", markdownText(view.Title))
+	if clinic := joinNonEmpty(" — ", view.ClinicName, view.ClinicAddress); clinic != "" {
+		fmt.Fprintf(&b, "%s\n\n", markdownText(clinic))
+	}
+	fmt.Fprintf(&b, "**%s**", markdownText(view.Doctor))
+	if view.Specialization != "" {
+		fmt.Fprintf(&b, ", %s", markdownText(view.Specialization))
+	}
+	if view.Contact != "" {
+		fmt.Fprintf(&b, "  \n%s", markdownText(view.Contact))
+	}
+	b.WriteString("\n\n| | |\n|---|---|\n")
+	for _, field := range view.Details {
+		fmt.Fprintf(&b, "| **%s** | %s |\n", markdownText(field.Label), markdownText(field.Value))
+	}
+	for _, section := range view.Sections {
+		fmt.Fprintf(&b, "\n## %s\n\n", markdownText(section.Heading))
+		if section.Text != "" {
+			// Keep the report's own line breaks
+			fmt.Fprintf(&b, "%s\n", strings.ReplaceAll(markdownText(section.Text), "\n", "  \n"))
+		}
+		if section.Text != "" && len(section.Items) > 0 {
+			b.WriteString("\n")
+		}
+		for _, item := range section.Items {
+			fmt.Fprintf(&b, "- %s\n", markdownText(item))
+		}
+	}
+	if view.Signature != "" {
+		fmt.Fprintf(&b, "\n---\n\n*%s*\n", markdownText(view.Signature))
+	}
+	if view.FooterNote != "" {
+		fmt.Fprintf(&b, "\n<sub>%s</sub>\n", markdownText(view.FooterNote))
+	}
+
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		log.Println("Error writing markdown report:", err)
+		return errors.New("failed to generate markdown report")
+	}
+	return nil
+}
+
+var markdownEscaper = strings.NewReplacer(
+	`\`, `\\`, "`", "\\`", "*", `\*`, "_", `\_`, "[", `\[`, "]", `\]`, "<", "&lt;", ">", "&gt;",
+	"#", `\#`, "|", `\|`, "!", `\!`,
+)
+
+// markdownLineMarker matches the start of a line Markdown would read as a list item or
+// heading underline.
+var markdownLineMarker = regexp.MustCompile(`^ *(?:[-+=]|\d+[.)]) `)
+
+// markdownText escapes the characters Markdown would read as formatting. Lines starting
+// with a list or quote marker are escaped too.
+func markdownText(s string) string {
+	lines := strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		line = markdownEscaper.Replace(line)
+		if marker := markdownLineMarker.FindStringIndex(line); marker != nil {
+			// Escape the marker's last character: "1\. " or "\- "
+			end := marker[1] - 2
+			line = line[:end] + `\` + line[end:]
+		}
+		lines[i] = line
+	}
+	return strings.Join(lines, "\n")
+}
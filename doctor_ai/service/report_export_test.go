@@ -0,0 +1,154 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"itish41/doctor_ai_assistant/models"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNegotiateReportExporter(t *testing.T) {
+	for _, tc := range []struct {
+		name, format, accept, expected string
+	}{
+		{"No preference", "", "", "pdf"},
+		{"Anything", "", "*/*", "pdf"},
+		{"Explicit format wins", "DOCX", "application/pdf", "docx"},
+		{"Exact type", "", "text/markdown", "md"},
+		{"Type wildcard prefers HTML", "", "text/*", "html"},
+		{"Q-values", "", "application/pdf;q=0.2, text/plain;q=0.8, */*;q=0.1", "txt"},
+		{"Specific range beats a wildcard", "", "text/*;q=0.9, text/html;q=0.1", "md"},
+		{"Excluded type", "", "application/pdf;q=0, */*", "docx"},
+		{"Browser default", "", "text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "html"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			exporter, err := NegotiateReportExporter(tc.format, tc.accept)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.expected, exporter.Format)
+		})
+	}
+
+	_, err := NegotiateReportExporter("", "image/png, application/json")
+	assert.ErrorIs(t, err, ErrNotAcceptable)
+
+	_, err = NegotiateReportExporter("rtf", "")
+	var validationErr *ValidationError
+	if assert.ErrorAs(t, err, &validationErr) {
+		assert.Contains(t, validationErr.Fields, "format")
+	}
+}
+
+func TestGenerateText(t *testing.T) {
+	doc := testReportDocument()
+	signedAt := time.Date(2026, time.March, 2, 9, 30, 0, 0, time.UTC)
+	doc.Transcription.Status, doc.Transcription.SignedAt = models.ReportStatusSigned, &signedAt
+
+	var buf bytes.Buffer
+	assert.NoError(t, GenerateText(&buf, NewReportView(doc)))
+	assert.Contains(t, buf.String(), "Patient: José Álvarez\n")
+	assert.Contains(t, buf.String(), "Doctor: Dr. Test, Cardiology\n")
+	assert.Contains(t, buf.String(), "\nDiagnosis\nHypertension\n")
+	assert.Contains(t, buf.String(), "\nSymptoms\n- Cough\n")
+	assert.Contains(t, buf.String(), "Electronically signed by Dr. Test on 2 March 2026 at 09:30 UTC.")
+}
+
+func TestGenerateMarkdown(t *testing.T) {
+	doc := testReportDocument()
+	doc.Transcription.StructuredReport.Diagnosis = "*Acute* bronchitis [see](http://evil.example)\n- not a list\n1. not numbered"
+
+	var buf bytes.Buffer
+	assert.NoError(t, GenerateMarkdown(&buf, NewReportView(doc)))
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "# Medical Transcription Report\n"))
+	assert.Contains(t, out, "| **Patient** | José Álvarez |\n")
+	assert.Contains(t, out, "## Symptoms\n\n- Cough\n")
+	assert.Contains(t, out, "## Diagnosis\n\n\\*Acute\\* bronchitis \\[see\\](http://evil.example)  \n\\- not a list  \n1\\. not numbered\n")
+}
+
+func TestGenerateHTML(t *testing.T) {
+	logo := writeTestLogo(t)
+	useReportTemplate(t, ReportTemplate{ClinicName: "Riverside & Co", LogoPath: logo, FooterNote: "Confidential"})
+	doc := testReportDocument()
+	doc.Transcription.StructuredReport.Diagnosis = "<script>alert(1)</script>"
+
+	var buf bytes.Buffer
+	assert.NoError(t, GenerateHTML(&buf, NewReportView(doc)))
+	out := buf.String()
+	assert.True(t, strings.HasPrefix(out, "<!DOCTYPE html>"))
+	assert.Contains(t, out, "<title>Medical Transcription Report – José Álvarez</title>")
+	assert.Contains(t, out, "Riverside &amp; Co")
+	assert.Contains(t, out, `<img src="data:image/png;base64,`)
+	assert.Contains(t, out, "&lt;script&gt;alert(1)&lt;/script&gt;")
+	assert.NotContains(t, out, "<script>")
+	assert.Contains(t, out, "<li>Cough</li>")
+	assert.Contains(t, out, "<footer>Confidential</footer>")
+	assert.Contains(t, out, `<span class="date">Date</span>`, "unsigned reports leave space to sign")
+}
+
+func TestGenerateDOCX(t *testing.T) {
+	useReportTemplate(t, ReportTemplate{LogoPath: writeTestLogo(t), FooterNote: "Confidential", PageSize: "Letter"})
+	doc := testReportDocument()
+	doc.Transcription.StructuredReport.Diagnosis = "Hypertension & <stage 2>\nreview in a week"
+
+	var buf bytes.Buffer
+	assert.NoError(t, GenerateDOCX(&buf, NewReportView(doc)))
+	archive, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	parts := map[string]string{}
+	for _, file := range archive.File {
+		reader, err := file.Open()
+		assert.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		assert.NoError(t, err)
+		reader.Close()
+		parts[file.Name] = string(content)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "docProps/core.xml", "word/_rels/document.xml.rels",
+		"word/document.xml", "word/styles.xml", "word/footer1.xml", "word/media/logo.png"} {
+		if !assert.Contains(t, parts, name) || strings.HasSuffix(name, ".png") {
+			continue
+		}
+		// Every XML part is well formed
+		decoder := xml.NewDecoder(strings.NewReader(parts[name]))
+		for {
+			if _, err := decoder.Token(); err != nil {
+				assert.ErrorIs(t, err, io.EOF, name)
+				break
+			}
+		}
+	}
+
+	document := parts["word/document.xml"]
+	assert.Contains(t, document, "José Álvarez")
+	assert.Contains(t, document, "Hypertension &amp; &lt;stage 2&gt;</w:t><w:br/><w:t xml:space=\"preserve\">review in a week")
+	assert.Contains(t, document, `<w:pgSz w:w="12240" w:h="15840"/>`)
+	assert.Contains(t, document, `r:embed="rIdLogo"`)
+	assert.Contains(t, parts["[Content_Types].xml"], `<Default Extension="png" ContentType="image/png"/>`)
+}
+
+func writeTestLogo(t *testing.T) string {
+	logo := filepath.Join(t.TempDir(), "logo.png")
+	file, err := os.Create(logo)
+	if err != nil {
+		t.Fatalf("Failed to create logo: %v", err)
+	}
+	defer file.Close()
+	if err := png.Encode(file, image.NewRGBA(image.Rect(0, 0, 40, 20))); err != nil {
+		t.Fatalf("Failed to write logo: %v", err)
+	}
+	return logo
+}
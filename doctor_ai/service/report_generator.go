@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"itish41/doctor_ai_assistant/models"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+)
+
+// Report generator backends selectable through the REPORT_GENERATOR environment variable
+const (
+	ReportGeneratorOpenAI = "openai"
+	ReportGeneratorStub   = "stub"
+
+	defaultLLMBaseURL = "https://api.groq.com/openai/v1"
+	defaultLLMModel   = "llama3-8b-8192"
+	defaultLLMTimeout = 60 * time.Second
+)
+
+const reportSystemPrompt = "You are an AI specialized in generating well-structured medical reports. Ensure the report follows a professional format."
+
+const reportUserPrompt = "Format the following transcription into a structured medical report. " +
+	"Reply with a single JSON object and nothing else, using exactly these keys:\n" +
+	"\"patient_information\" (string), \"patient_history\" (string), \"symptoms\" (array of strings), " +
+	"\"diagnosis\" (string), \"treatment_plan\" (string), \"recommendations\" (array of strings).\n" +
+	"Use an empty string or empty array when the transcription does not cover a section.\n\nTranscription:\n%s"
+
+var (
+	// ErrRateLimited matches an UpstreamStatusError for a 429 response.
+	ErrRateLimited = errors.New("report generator rate limited")
+	// ErrInvalidReport is returned when a report is not valid JSON or misses required sections.
+	ErrInvalidReport error = InvalidField("structured_report", "invalid medical report")
+)
+
+// UpstreamStatusError is returned when the LLM backend answers with a non-200 status.
+type UpstreamStatusError struct {
+	StatusCode int
+	Message    string
+	RetryAfter time.Duration // only set for rate limits that send Retry-After
+}
+
+func (e *UpstreamStatusError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("report generator returned status %d", e.StatusCode)
+	}
+	return fmt.Sprintf("report generator returned status %d: %s", e.StatusCode, e.Message)
+}
+
+// Is lets errors.Is(err, ErrRateLimited) match rate-limit responses. Every
+// UpstreamStatusError matches ErrUpstream.
+func (e *UpstreamStatusError) Is(target error) bool {
+	if target == ErrUpstream {
+		return true
+	}
+	return target == ErrRateLimited && e.StatusCode == http.StatusTooManyRequests
+}
+
+// ReportGenerator turns a raw transcript into a structured medical report.
+type ReportGenerator interface {
+	GenerateReport(ctx context.Context, rawText string) (*models.MedicalReport, error)
+}
+
+// ReportGeneratorFunc adapts an ordinary function to the ReportGenerator interface.
+type ReportGeneratorFunc func(ctx context.Context, rawText string) (*models.MedicalReport, error)
+
+func (f ReportGeneratorFunc) GenerateReport(ctx context.Context, rawText string) (*models.MedicalReport, error) {
+	return f(ctx, rawText)
+}
+
+// ParseMedicalReport decodes and validates a report from an LLM reply or API payload.
+// Markdown code fences around the JSON are tolerated.
+func ParseMedicalReport(content string) (*models.MedicalReport, error) {
+	content = strings.TrimSpace(content)
+	if strings.HasPrefix(content, "```") {
+		content = strings.TrimPrefix(content, "```json")
+		content = strings.TrimPrefix(content, "```")
+		content = strings.TrimSuffix(strings.TrimSpace(content), "```")
+	}
+
+	var report models.MedicalReport
+	if err := json.Unmarshal([]byte(content), &report); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidReport, err)
+	}
+	if err := ValidateMedicalReport(&report); err != nil {
+		return nil, err
+	}
+	return &report, nil
+}
+
+// ValidateMedicalReport trims the report's fields and checks the required sections are present.
+func ValidateMedicalReport(report *models.MedicalReport) error {
+	report.PatientInformation = strings.TrimSpace(report.PatientInformation)
+	report.PatientHistory = strings.TrimSpace(report.PatientHistory)
+	report.Diagnosis = strings.TrimSpace(report.Diagnosis)
+	report.TreatmentPlan = strings.TrimSpace(report.TreatmentPlan)
+	report.Symptoms = compactStrings(report.Symptoms)
+	report.Recommendations = compactStrings(report.Recommendations)
+
+	var missing []string
+	if report.Diagnosis == "" {
+		missing = append(missing, "diagnosis")
+	}
+	if report.TreatmentPlan == "" {
+		missing = append(missing, "treatment_plan")
+	}
+	if len(missing) > 0 {
+		return fmt.Errorf("%w: missing %s", ErrInvalidReport, strings.Join(missing, ", "))
+	}
+	return nil
+}
+
+// compactStrings trims each item and drops empty ones.
+func compactStrings(items []string) []string {
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
+
+// OpenAIChatConfig configures an OpenAI-compatible chat completions backend.
+type OpenAIChatConfig struct {
+	BaseURL     string   // e.g. https://api.groq.com/openai/v1
+	APIKey      string   // sent as a bearer token when set
+	Model       string   // model name understood by the backend
+	Temperature *float64 // nil leaves the backend default
+	Timeout     time.Duration
+}
+
+type chatMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+type chatResponseFormat struct {
+	Type string `json:"type"`
+}
+
+type chatCompletionRequest struct {
+	Model          string              `json:"model"`
+	Messages       []chatMessage       `json:"messages"`
+	Temperature    *float64            `json:"temperature,omitempty"`
+	ResponseFormat *chatResponseFormat `json:"response_format,omitempty"`
+}
+
+type chatCompletionResponse struct {
+	Choices []struct {
+		Message chatMessage `json:"message"`
+	} `json:"choices"`
+}
+
+type chatErrorResponse struct {
+	Error struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// OpenAIChatReportGenerator generates reports through any OpenAI-compatible
+// /chat/completions endpoint (Groq, OpenAI, a self-hosted model server, ...).
+type OpenAIChatReportGenerator struct {
+	config OpenAIChatConfig
+	client *resty.Client
+}
+
+func NewOpenAIChatReportGenerator(config OpenAIChatConfig) *OpenAIChatReportGenerator {
+	if config.BaseURL == "" {
+		config.BaseURL = defaultLLMBaseURL
+	}
+	if config.Model == "" {
+		config.Model = defaultLLMModel
+	}
+	if config.Timeout <= 0 {
+		config.Timeout = defaultLLMTimeout
+	}
+	config.BaseURL = strings.TrimRight(config.BaseURL, "/")
+
+	client := resty.New().SetTimeout(config.Timeout)
+	return &OpenAIChatReportGenerator{config: config, client: client}
+}
+
+// GenerateReport sends the transcript to the chat completions endpoint and parses the JSON reply.
+func (g *OpenAIChatReportGenerator) GenerateReport(ctx context.Context, rawText string) (*models.MedicalReport, error) {
+	requestBody := chatCompletionRequest{
+		Model: g.config.Model,
+		Messages: []chatMessage{
+			{Role: "system", Content: reportSystemPrompt},
+			{Role: "user", Content: fmt.Sprintf(reportUserPrompt, rawText)},
+		},
+		Temperature:    g.config.Temperature,
+		ResponseFormat: &chatResponseFormat{Type: "json_object"},
+	}
+
+	var result chatCompletionResponse
+	var apiError chatErrorResponse
+	request := g.client.R().
+		SetContext(ctx).
+		SetHeader("Content-Type", "application/json").
+		SetBody(requestBody).
+		SetResult(&result).
+		SetError(&apiError)
+	if g.config.APIKey != "" {
+		request.SetHeader("Authorization", "Bearer "+g.config.APIKey)
+	}
+
+	resp, err := request.Post(g.config.BaseURL + "/chat/completions")
+	if err != nil {
+		return nil, fmt.Errorf("report generator request error: %v", err)
+	}
+
+	if resp.StatusCode() != http.StatusOK {
+		statusErr := &UpstreamStatusError{StatusCode: resp.StatusCode(), Message: apiError.Error.Message}
+		if resp.StatusCode() == http.StatusTooManyRequests {
+			if seconds, err := strconv.Atoi(resp.Header().Get("Retry-After")); err == nil {
+				statusErr.RetryAfter = time.Duration(seconds) * time.Second
+			}
+		}
+		return nil, statusErr
+	}
+
+	if len(result.Choices) == 0 || strings.TrimSpace(result.Choices[0].Message.Content) == "" {
+		return nil, errors.New("report generator returned no content")
+	}
+	return ParseMedicalReport(result.Choices[0].Message.Content)
+}
+
+// StubReportGenerator builds a fixed report around the transcript without any
+// network calls, for tests and offline environments.
+type StubReportGenerator struct{}
+
+func (StubReportGenerator) GenerateReport(ctx context.Context, rawText string) (*models.MedicalReport, error) {
+	return &models.MedicalReport{
+		PatientInformation: "See patient record.",
+		PatientHistory:     "Not assessed.",
+		Symptoms:           compactStrings([]string{rawText}),
+		Diagnosis:          "Pending clinical review.",
+		TreatmentPlan:      "Pending clinical review.",
+		Recommendations:    []string{"Follow up as needed."},
+	}, nil
+}
+
+// NewReportGeneratorFromEnv builds the ReportGenerator named by REPORT_GENERATOR (default openai).
+// The openai backend reads LLM_BASE_URL, LLM_API_KEY (falling back to GROQAPIKEY), LLM_MODEL,
+// LLM_TEMPERATURE and LLM_TIMEOUT (a Go duration such as 45s).
+func NewReportGeneratorFromEnv() (ReportGenerator, error) {
+	backend := strings.ToLower(strings.TrimSpace(os.Getenv("REPORT_GENERATOR")))
+	switch backend {
+	case "", ReportGeneratorOpenAI:
+		config := OpenAIChatConfig{
+			BaseURL: os.Getenv("LLM_BASE_URL"),
+			APIKey:  strings.TrimSpace(os.Getenv("LLM_API_KEY")),
+			Model:   os.Getenv("LLM_MODEL"),
+		}
+		if config.APIKey == "" {
+			config.APIKey = strings.TrimSpace(os.Getenv("GROQAPIKEY"))
+		}
+		if value := os.Getenv("LLM_TEMPERATURE"); value != "" {
+			temperature, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid LLM_TEMPERATURE %q: %v", value, err)
+			}
+			config.Temperature = &temperature
+		}
+		if value := os.Getenv("LLM_TIMEOUT"); value != "" {
+			timeout, err := time.ParseDuration(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid LLM_TIMEOUT %q: %v", value, err)
+			}
+			config.Timeout = timeout
+		}
+		return NewOpenAIChatReportGenerator(config), nil
+	case ReportGeneratorStub:
+		return StubReportGenerator{}, nil
+	default:
+		return nil, fmt.Errorf("unknown report generator %q", backend)
+	}
+}
+
+var (
+	reportGeneratorMu sync.RWMutex
+	reportGenerator   ReportGenerator
+)
+
+// SetReportGenerator replaces the ReportGenerator used to enhance transcriptions.
+func SetReportGenerator(g ReportGenerator) {
+	reportGeneratorMu.Lock()
+	reportGenerator = g
+	reportGeneratorMu.Unlock()
+}
+
+// currentReportGenerator returns the configured ReportGenerator, building it from the environment on first use.
+func currentReportGenerator() (ReportGenerator, error) {
+	reportGeneratorMu.RLock()
+	g := reportGenerator
+	reportGeneratorMu.RUnlock()
+	if g != nil {
+		return g, nil
+	}
+
+	g, err := NewReportGeneratorFromEnv()
+	if err != nil {
+		log.Println("Error configuring report generator:", err)
+		return nil, err
+	}
+	SetReportGenerator(g)
+	return g, nil
+}
+
+// generateReport turns a raw transcript into a report with the configured ReportGenerator.
+func generateReport(ctx context.Context, rawText string) (*models.MedicalReport, error) {
+	g, err := currentReportGenerator()
+	if err != nil {
+		return nil, err
+	}
+	return g.GenerateReport(ctx, rawText)
+}
@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOpenAIChatReportGenerator(t *testing.T) {
+	t.Run("Success", func(t *testing.T) {
+		var received chatCompletionRequest
+		var authHeader, path string
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			path = r.URL.Path
+			authHeader = r.Header.Get("Authorization")
+			_ = json.NewDecoder(r.Body).Decode(&received)
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"{\"patient_information\":\"Adult\",\"symptoms\":[\"cough\"],\"diagnosis\":\"Bronchitis\",\"treatment_plan\":\"Rest\",\"recommendations\":[]}"}}]}`))
+		}))
+		defer server.Close()
+
+		temperature := 0.3
+		generator := NewOpenAIChatReportGenerator(OpenAIChatConfig{
+			BaseURL:     server.URL + "/v1/",
+			APIKey:      "secret",
+			Model:       "local-model",
+			Temperature: &temperature,
+		})
+
+		report, err := generator.GenerateReport(context.Background(), "patient has a cough")
+		assert.NoError(t, err)
+		assert.Equal(t, "Bronchitis", report.Diagnosis)
+		assert.Equal(t, []string{"cough"}, report.Symptoms)
+		assert.Equal(t, "/v1/chat/completions", path)
+		assert.Equal(t, "Bearer secret", authHeader)
+		assert.Equal(t, "local-model", received.Model)
+		assert.Equal(t, &temperature, received.Temperature)
+		assert.Len(t, received.Messages, 2)
+		assert.Equal(t, "system", received.Messages[0].Role)
+		assert.Contains(t, received.Messages[1].Content, "patient has a cough")
+		assert.Equal(t, "json_object", received.ResponseFormat.Type)
+	})
+
+	t.Run("Reply that is not a valid report", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"choices":[{"message":{"role":"assistant","content":"1. Patient Information ..."}}]}`))
+		}))
+		defer server.Close()
+
+		_, err := NewOpenAIChatReportGenerator(OpenAIChatConfig{BaseURL: server.URL}).GenerateReport(context.Background(), "text")
+		assert.ErrorIs(t, err, ErrInvalidReport)
+	})
+
+	t.Run("Non-200 is an UpstreamStatusError", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			w.WriteHeader(http.StatusBadGateway)
+			_, _ = w.Write([]byte(`{"error":{"message":"model overloaded"}}`))
+		}))
+		defer server.Close()
+
+		_, err := NewOpenAIChatReportGenerator(OpenAIChatConfig{BaseURL: server.URL}).GenerateReport(context.Background(), "text")
+		var statusErr *UpstreamStatusError
+		assert.True(t, errors.As(err, &statusErr))
+		assert.Equal(t, http.StatusBadGateway, statusErr.StatusCode)
+		assert.Equal(t, "model overloaded", statusErr.Message)
+		assert.False(t, errors.Is(err, ErrRateLimited))
+	})
+
+	t.Run("429 is a rate limit", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Retry-After", "7")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		_, err := NewOpenAIChatReportGenerator(OpenAIChatConfig{BaseURL: server.URL}).GenerateReport(context.Background(), "text")
+		assert.True(t, errors.Is(err, ErrRateLimited))
+		var statusErr *UpstreamStatusError
+		assert.True(t, errors.As(err, &statusErr))
+		assert.Equal(t, 7*time.Second, statusErr.RetryAfter)
+	})
+
+	t.Run("Empty choices", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"choices":[]}`))
+		}))
+		defer server.Close()
+
+		_, err := NewOpenAIChatReportGenerator(OpenAIChatConfig{BaseURL: server.URL}).GenerateReport(context.Background(), "text")
+		assert.Error(t, err)
+	})
+
+	t.Run("Timeout", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(200 * time.Millisecond)
+		}))
+		defer server.Close()
+
+		_, err := NewOpenAIChatReportGenerator(OpenAIChatConfig{BaseURL: server.URL, Timeout: 20 * time.Millisecond}).GenerateReport(context.Background(), "text")
+		assert.Error(t, err)
+	})
+}
+
+func TestStubReportGenerator(t *testing.T) {
+	first, err := StubReportGenerator{}.GenerateReport(context.Background(), "  headache  ")
+	assert.NoError(t, err)
+	second, _ := StubReportGenerator{}.GenerateReport(context.Background(), "  headache  ")
+	assert.Equal(t, first, second)
+	assert.Equal(t, []string{"headache"}, first.Symptoms)
+	assert.NoError(t, ValidateMedicalReport(first))
+}
+
+func TestParseMedicalReport(t *testing.T) {
+	t.Run("Valid JSON", func(t *testing.T) {
+		report, err := ParseMedicalReport(`{"patient_information":" Adult male ","patient_history":"","symptoms":["fever"," ",""],"diagnosis":"Influenza","treatment_plan":"Oseltamivir","recommendations":["Rest"]}`)
+		assert.NoError(t, err)
+		assert.Equal(t, "Adult male", report.PatientInformation)
+		assert.Equal(t, []string{"fever"}, report.Symptoms)
+		assert.Equal(t, "Influenza", report.Diagnosis)
+	})
+
+	t.Run("Code fences are tolerated", func(t *testing.T) {
+		report, err := ParseMedicalReport("```json\n{\"diagnosis\":\"Migraine\",\"treatment_plan\":\"Ibuprofen\"}\n```")
+		assert.NoError(t, err)
+		assert.Equal(t, "Migraine", report.Diagnosis)
+	})
+
+	t.Run("Not JSON", func(t *testing.T) {
+		_, err := ParseMedicalReport("Diagnosis: flu")
+		assert.ErrorIs(t, err, ErrInvalidReport)
+	})
+
+	t.Run("Wrong types", func(t *testing.T) {
+		_, err := ParseMedicalReport(`{"diagnosis":"Flu","treatment_plan":"Rest","symptoms":"fever"}`)
+		assert.ErrorIs(t, err, ErrInvalidReport)
+	})
+
+	t.Run("Missing required sections", func(t *testing.T) {
+		_, err := ParseMedicalReport(`{"diagnosis":"  ","symptoms":["fever"]}`)
+		assert.ErrorIs(t, err, ErrInvalidReport)
+		assert.Contains(t, err.Error(), "diagnosis")
+		assert.Contains(t, err.Error(), "treatment_plan")
+	})
+}
+
+func TestNewReportGeneratorFromEnv(t *testing.T) {
+	t.Run("Default is OpenAI-compatible with Groq settings", func(t *testing.T) {
+		t.Setenv("REPORT_GENERATOR", "")
+		t.Setenv("LLM_BASE_URL", "")
+		t.Setenv("LLM_API_KEY", "")
+		t.Setenv("LLM_MODEL", "")
+		t.Setenv("LLM_TEMPERATURE", "")
+		t.Setenv("LLM_TIMEOUT", "")
+		t.Setenv("GROQAPIKEY", "groq-key")
+
+		generator, err := NewReportGeneratorFromEnv()
+		assert.NoError(t, err)
+		openAI, ok := generator.(*OpenAIChatReportGenerator)
+		assert.True(t, ok)
+		assert.Equal(t, defaultLLMBaseURL, openAI.config.BaseURL)
+		assert.Equal(t, defaultLLMModel, openAI.config.Model)
+		assert.Equal(t, "groq-key", openAI.config.APIKey)
+		assert.Nil(t, openAI.config.Temperature)
+		assert.Equal(t, defaultLLMTimeout, openAI.config.Timeout)
+	})
+
+	t.Run("Custom backend", func(t *testing.T) {
+		t.Setenv("REPORT_GENERATOR", "openai")
+		t.Setenv("LLM_BASE_URL", "http://localhost:11434/v1")
+		t.Setenv("LLM_API_KEY", "local-key")
+		t.Setenv("LLM_MODEL", "llama3")
+		t.Setenv("LLM_TEMPERATURE", "0.1")
+		t.Setenv("LLM_TIMEOUT", "45s")
+
+		generator, err := NewReportGeneratorFromEnv()
+		assert.NoError(t, err)
+		openAI := generator.(*OpenAIChatReportGenerator)
+		assert.Equal(t, "http://localhost:11434/v1", openAI.config.BaseURL)
+		assert.Equal(t, "local-key", openAI.config.APIKey)
+		assert.Equal(t, "llama3", openAI.config.Model)
+		assert.Equal(t, 0.1, *openAI.config.Temperature)
+		assert.Equal(t, 45*time.Second, openAI.config.Timeout)
+	})
+
+	t.Run("Invalid settings", func(t *testing.T) {
+		t.Setenv("REPORT_GENERATOR", "openai")
+		t.Setenv("LLM_TEMPERATURE", "warm")
+		_, err := NewReportGeneratorFromEnv()
+		assert.Error(t, err)
+
+		t.Setenv("LLM_TEMPERATURE", "")
+		t.Setenv("LLM_TIMEOUT", "soon")
+		_, err = NewReportGeneratorFromEnv()
+		assert.Error(t, err)
+	})
+
+	t.Run("Stub", func(t *testing.T) {
+		t.Setenv("REPORT_GENERATOR", "stub")
+		generator, err := NewReportGeneratorFromEnv()
+		assert.NoError(t, err)
+		assert.IsType(t, StubReportGenerator{}, generator)
+	})
+
+	t.Run("Unknown", func(t *testing.T) {
+		t.Setenv("REPORT_GENERATOR", "bard")
+		_, err := NewReportGeneratorFromEnv()
+		assert.Error(t, err)
+	})
+}
@@ -0,0 +1,137 @@
+package service
+
+import (
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+)
+
+// reportHTML is a standalone page: the styles are inline and the logo is embedded, so the
+// file opens the same anywhere.
+var reportHTML = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<meta name="viewport" content="width=device-width, initial-scale=1">
+<title>{{.View.Title}} – {{.Patient}}</title>
+<style>
+body { font-family: "DejaVu Sans", Helvetica, Arial, sans-serif; color: #111; max-width: 48rem; margin: 2rem auto; padding: 0 1rem; line-height: 1.45; }
+header { display: flex; gap: 1rem; align-items: flex-start; border-bottom: 1px solid #a0a0a0; padding-bottom: .75rem; margin-bottom: 1.5rem; }
+header img { height: 4.5rem; }
+header p { margin: 0; font-size: .9rem; }
+header .clinic, header .doctor { font-weight: bold; font-size: 1.1rem; }
+h1 { font-size: 1.6rem; margin: 0 0 .75rem; }
+h2 { font-size: 1.15rem; margin: 1.5rem 0 .4rem; }
+table.details th { text-align: left; padding-right: 1.5rem; font-weight: bold; }
+.text { white-space: pre-wrap; margin: 0 0 .5rem; }
+.signature { margin-top: 2.5rem; }
+.sign-here { display: flex; justify-content: space-between; margin-top: 3rem; }
+.sign-here span { border-top: 1px solid #000; width: 16rem; padding-top: .25rem; }
+.sign-here span.date { width: 10rem; }
+footer { margin-top: 2.5rem; font-size: .8rem; color: #6e6e6e; }
+@media print { body { margin: 0; max-width: none; } }
+</style>
+</head>
+<body>
+<header>
+{{- if .Logo}}
+<img src="{{.Logo}}" alt="">
+{{- end}}
+<div>
+{{- with .View.ClinicName}}
+<p class="clinic">{{.}}</p>
+{{- end}}
+{{- with .View.ClinicAddress}}
+<p>{{.}}</p>
+{{- end}}
+<p class="doctor">{{.View.Doctor}}</p>
+{{- with .View.Specialization}}
+<p>{{.}}</p>
+{{- end}}
+{{- with .View.Contact}}
+<p>{{.}}</p>
+{{- end}}
+</div>
+</header>
+<main>
+<h1>{{.View.Title}}</h1>
+<table class="details">
+{{- range .View.Details}}
+<tr><th scope="row">{{.Label}}</th><td>{{.Value}}</td></tr>
+{{- end}}
+</table>
+{{- range .View.Sections}}
+<section>
+<h2>{{.Heading}}</h2>
+{{- with .Text}}
+<p class="text">{{.}}</p>
+{{- end}}
+{{- with .Items}}
+<ul>
+{{- range .}}
+<li>{{.}}</li>
+{{- end}}
+</ul>
+{{- end}}
+</section>
+{{- end}}
+<div class="signature">
+{{- if .View.Signature}}
+<p>{{.View.Signature}}</p>
+{{- else}}
+<div class="sign-here"><span>Signature</span><span class="date">Date</span></div>
+{{- end}}
+<p><strong>{{.View.Doctor}}</strong><br>{{.View.Specialization}}</p>
+</div>
+</main>
+{{- with .View.FooterNote}}
+<footer>{{.}}</footer>
+{{- end}}
+</body>
+</html>
+`))
+
+// GenerateHTML writes the report as a standalone HTML page.
+func GenerateHTML(w io.Writer, view ReportView) error {
+	data := struct {
+		View    ReportView
+		Patient string
+		Logo    template.URL
+	}{View: view}
+	for _, field := range view.Details {
+		if field.Label == "Patient" {
+			data.Patient = field.Value
+		}
+	}
+
+	if view.LogoPath != "" {
+		logo, err := os.ReadFile(view.LogoPath)
+		if err != nil {
+			log.Println("Error reading report logo:", err)
+			return errors.New("failed to generate html report")
+		}
+		// Only images a browser shows are embedded, so the data URI can't carry anything else
+		switch contentType := http.DetectContentType(logo); contentType {
+		case "image/png", "image/jpeg", "image/gif":
+			data.Logo = template.URL("data:" + contentType + ";base64," + base64.StdEncoding.EncodeToString(logo))
+		default:
+			log.Println("Skipping report logo of type", contentType)
+		}
+	}
+
+	var b strings.Builder
+	if err := reportHTML.Execute(&b, data); err != nil {
+		log.Println("Error rendering HTML report:", err)
+		return errors.New("failed to generate html report")
+	}
+	if _, err := io.WriteString(w, b.String()); err != nil {
+		log.Println("Error writing HTML report:", err)
+		return errors.New("failed to generate html report")
+	}
+	return nil
+}
@@ -0,0 +1,92 @@
+package service
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetReportDocument(t *testing.T) {
+	doctorID, patientID, _ := setupTranscriptionTestDB(t)
+	older := createTestTranscription(t, doctorID, patientID)
+	latest := createTestTranscription(t, doctorID, patientID)
+	assert.NoError(t, initializers.DB.Model(older).Update("created_at", time.Now().Add(-time.Hour)).Error)
+
+	// The transcription asked for, not the patient's latest
+	doc, err := GetReportDocument(doctorID, older.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, older.ID, doc.Transcription.ID)
+		assert.Equal(t, "Test Patient", doc.Patient.Name)
+		assert.Equal(t, 30, doc.Patient.Age)
+		assert.Equal(t, "Test Doctor", doc.Doctor.Name)
+		assert.Empty(t, doc.Doctor.Password)
+	}
+	assert.NotEqual(t, latest.ID, doc.Transcription.ID)
+
+	_, err = GetReportDocument(uuid.New(), older.ID)
+	assert.ErrorIs(t, err, ErrForbidden)
+	_, err = GetReportDocument(doctorID, uuid.New())
+	assert.ErrorIs(t, err, ErrTranscriptionNotFound)
+}
+
+func TestNewReportView(t *testing.T) {
+	useReportTemplate(t, ReportTemplate{ClinicName: "Riverside Clinic"})
+	doc := testReportDocument()
+	doc.Patient.Gender = ""
+
+	view := NewReportView(doc)
+	assert.Equal(t, defaultReportTitle, view.Title)
+	assert.Equal(t, "Riverside Clinic", view.ClinicName)
+	assert.Equal(t, "test@example.com | 1234567890", view.Contact)
+	assert.Contains(t, view.Details, ReportField{"Gender", "Not recorded"})
+	assert.Contains(t, view.Details, ReportField{"Report date", "1 March 2026"})
+	if assert.Len(t, view.Sections, 6) {
+		assert.Equal(t, ReportSection{Heading: "Diagnosis", Text: "Hypertension"}, view.Sections[3])
+		assert.Equal(t, doc.Transcription.StructuredReport.Symptoms, view.Sections[2].Items)
+	}
+	assert.Empty(t, view.Signature)
+
+	// Reports without a structure are one section, and signing adds the signature
+	doc.Transcription.StructuredReport = nil
+	signedAt := time.Date(2026, time.March, 2, 9, 30, 0, 0, time.UTC)
+	doc.Transcription.Status, doc.Transcription.SignedAt = models.ReportStatusSigned, &signedAt
+	view = NewReportView(doc)
+	assert.Equal(t, []ReportSection{{Heading: "Report", Text: doc.Transcription.Report}}, view.Sections)
+	assert.Equal(t, "Electronically signed by Dr. Test on 2 March 2026 at 09:30 UTC.", view.Signature)
+}
+
+func TestNewReportTemplateFromEnv(t *testing.T) {
+	t.Setenv("REPORT_CLINIC_NAME", "Riverside Clinic")
+	t.Setenv("PDF_PAGE_SIZE", "letter")
+	tpl, err := NewReportTemplateFromEnv()
+	assert.NoError(t, err)
+	assert.Equal(t, "Riverside Clinic", tpl.ClinicName)
+
+	t.Setenv("PDF_PAGE_SIZE", "A5")
+	_, err = NewReportTemplateFromEnv()
+	assert.Error(t, err)
+
+	t.Setenv("PDF_PAGE_SIZE", "")
+	t.Setenv("REPORT_LOGO", filepath.Join(t.TempDir(), "missing.png"))
+	_, err = NewReportTemplateFromEnv()
+	assert.Error(t, err)
+}
+
+func TestReportFileName(t *testing.T) {
+	doc := testReportDocument()
+	for name, expected := range map[string]string{
+		"Jane O'Neil  Doe": "jane-o-neil-doe_2026-03-01_report.pdf",
+		"José Álvarez":     "josé-álvarez_2026-03-01_report.pdf",
+		"王伟":               "王伟_2026-03-01_report.pdf",
+		" ../ ":            "patient_2026-03-01_report.pdf",
+	} {
+		doc.Patient.Name = name
+		assert.Equal(t, expected, ReportFileName(doc, "pdf"))
+	}
+}
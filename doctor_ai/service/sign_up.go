@@ -11,27 +11,30 @@ import (
 	"gorm.io/gorm"
 )
 
+// ErrEmailTaken is returned when signing up with an email that already has an account.
+var ErrEmailTaken error = &ConflictError{Message: "email already exists"}
+
 func validateDoctor(doctor *models.Doctor) error {
 	// Email validation
 	emailRegex := regexp.MustCompile(`^[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,4}$`)
 	if !emailRegex.MatchString(doctor.Email) {
-		return errors.New("invalid email format")
+		return InvalidField("email", "invalid email format")
 	}
 
 	// Name validation
 	if len(doctor.Name) < 2 {
-		return errors.New("name must be at least 2 characters long")
+		return InvalidField("name", "name must be at least 2 characters long")
 	}
 
 	// Phone validation (basic check)
 	phoneRegex := regexp.MustCompile(`^[0-9]{10}$`)
 	if !phoneRegex.MatchString(doctor.Phone) {
-		return errors.New("phone number must be 10 digits")
+		return InvalidField("phone", "phone number must be 10 digits")
 	}
 
 	// Password validation
 	if len(doctor.Password) < 8 {
-		return errors.New("password must be at least 8 characters long")
+		return InvalidField("password", "password must be at least 8 characters long")
 	}
 
 	return nil
@@ -63,7 +66,7 @@ func CreateDoctor(doctor *models.Doctor) error {
 	result := tx.Where("email = ?", doctor.Email).First(&existingDoctor)
 	if result.Error == nil {
 		tx.Rollback()
-		return errors.New("email already exists")
+		return ErrEmailTaken
 	}
 
 	// Hash the password
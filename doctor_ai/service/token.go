@@ -0,0 +1,192 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// RefreshTokenTTL is how long a refresh token can be exchanged for a new access token.
+const RefreshTokenTTL = 30 * 24 * time.Hour
+
+var (
+	ErrInvalidRefreshToken error = &UnauthorizedError{Message: "invalid or expired refresh token"}
+	ErrRefreshTokenReused  error = &UnauthorizedError{Message: "refresh token reuse detected"}
+)
+
+// hashRefreshToken returns the hex SHA-256 digest stored in place of the raw token.
+func hashRefreshToken(rawToken string) string {
+	sum := sha256.Sum256([]byte(rawToken))
+	return hex.EncodeToString(sum[:])
+}
+
+// newRefreshTokenValue generates a random, URL-safe refresh token.
+func newRefreshTokenValue() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// createRefreshToken persists a new refresh token in the given family and returns the raw value.
+func createRefreshToken(tx *gorm.DB, doctorID, familyID uuid.UUID) (*models.RefreshToken, string, error) {
+	rawToken, err := newRefreshTokenValue()
+	if err != nil {
+		log.Println("Error generating refresh token:", err)
+		return nil, "", errors.New("failed to generate refresh token")
+	}
+
+	refreshToken := models.RefreshToken{
+		ID:        uuid.New(),
+		DoctorID:  doctorID,
+		FamilyID:  familyID,
+		TokenHash: hashRefreshToken(rawToken),
+		ExpiresAt: time.Now().Add(RefreshTokenTTL),
+		CreatedAt: time.Now(),
+	}
+	if err := tx.Create(&refreshToken).Error; err != nil {
+		log.Println("Error storing refresh token:", err)
+		return nil, "", errors.New("failed to store refresh token")
+	}
+	return &refreshToken, rawToken, nil
+}
+
+// IssueRefreshToken starts a new token family for the doctor (on login) and returns the raw token.
+func IssueRefreshToken(doctorID uuid.UUID) (string, error) {
+	_, rawToken, err := createRefreshToken(initializers.DB, doctorID, uuid.New())
+	if err != nil {
+		return "", err
+	}
+	return rawToken, nil
+}
+
+// RotateRefreshToken exchanges a refresh token for a new one in the same family.
+// Presenting a token that was already rotated or revoked revokes the whole family.
+func RotateRefreshToken(rawToken string) (*models.Doctor, string, error) {
+	var current models.RefreshToken
+	if err := initializers.DB.Where("token_hash = ?", hashRefreshToken(rawToken)).First(&current).Error; err != nil {
+		log.Println("Refresh token not found:", err)
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	if current.RevokedAt != nil {
+		log.Println("Reuse of revoked refresh token detected, revoking family:", current.FamilyID)
+		if err := revokeRefreshTokenFamily(current.FamilyID); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrRefreshTokenReused
+	}
+
+	if time.Now().After(current.ExpiresAt) {
+		log.Println("Refresh token expired:", current.ID)
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	var doctor models.Doctor
+	if err := initializers.DB.Where("id = ?", current.DoctorID).First(&doctor).Error; err != nil {
+		log.Println("Doctor for refresh token not found:", err)
+		return nil, "", ErrInvalidRefreshToken
+	}
+
+	// Start transaction
+	tx := initializers.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	next, nextRaw, err := createRefreshToken(tx, current.DoctorID, current.FamilyID)
+	if err != nil {
+		tx.Rollback()
+		return nil, "", err
+	}
+
+	// Only the first concurrent rotation may consume the token
+	now := time.Now()
+	result := tx.Model(&models.RefreshToken{}).
+		Where("id = ? AND revoked_at IS NULL", current.ID).
+		Updates(map[string]interface{}{"revoked_at": now, "replaced_by": next.ID})
+	if result.Error != nil {
+		tx.Rollback()
+		log.Println("Error rotating refresh token:", result.Error)
+		return nil, "", errors.New("failed to rotate refresh token")
+	}
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		log.Println("Refresh token rotated concurrently, revoking family:", current.FamilyID)
+		if err := revokeRefreshTokenFamily(current.FamilyID); err != nil {
+			return nil, "", err
+		}
+		return nil, "", ErrRefreshTokenReused
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		log.Println("Error committing transaction:", err)
+		return nil, "", errors.New("failed to rotate refresh token")
+	}
+
+	doctor.Password = ""
+	return &doctor, nextRaw, nil
+}
+
+// revokeRefreshTokenFamily revokes every still-active token in a family.
+func revokeRefreshTokenFamily(familyID uuid.UUID) error {
+	if err := initializers.DB.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error; err != nil {
+		log.Println("Error revoking refresh token family:", err)
+		return errors.New("failed to revoke refresh tokens")
+	}
+	return nil
+}
+
+// RevokeRefreshToken revokes the family of a refresh token owned by the doctor (on logout).
+func RevokeRefreshToken(doctorID uuid.UUID, rawToken string) error {
+	var refreshToken models.RefreshToken
+	if err := initializers.DB.Where("token_hash = ? AND doctor_id = ?", hashRefreshToken(rawToken), doctorID).
+		First(&refreshToken).Error; err != nil {
+		log.Println("Refresh token not found for doctor:", err)
+		return ErrInvalidRefreshToken
+	}
+	return revokeRefreshTokenFamily(refreshToken.FamilyID)
+}
+
+// RevokeAccessToken adds an access token's jti to the denylist until it expires.
+func RevokeAccessToken(jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return errors.New("access token has no jti")
+	}
+
+	// Entries for tokens that have expired on their own are no longer needed
+	if err := initializers.DB.Where("expires_at < ?", time.Now()).Delete(&models.RevokedToken{}).Error; err != nil {
+		log.Println("Error purging expired denylist entries:", err)
+	}
+
+	revoked := models.RevokedToken{JTI: jti, ExpiresAt: expiresAt, CreatedAt: time.Now()}
+	if err := initializers.DB.Create(&revoked).Error; err != nil {
+		log.Println("Error revoking access token:", err)
+		return errors.New("failed to revoke access token")
+	}
+	return nil
+}
+
+// IsAccessTokenRevoked reports whether the access token with the given jti is on the denylist.
+func IsAccessTokenRevoked(jti string) (bool, error) {
+	var count int64
+	if err := initializers.DB.Model(&models.RevokedToken{}).Where("jti = ?", jti).Count(&count).Error; err != nil {
+		log.Println("Error checking access token denylist:", err)
+		return false, err
+	}
+	return count > 0, nil
+}
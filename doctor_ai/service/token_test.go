@@ -0,0 +1,177 @@
+package service
+
+import (
+	"fmt"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTokenTestDB(t *testing.T) *models.Doctor {
+	dbName := fmt.Sprintf("file:tokendb%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+
+	err = db.Exec(`CREATE TABLE IF NOT EXISTS doctors (
+		id TEXT PRIMARY KEY,
+		name TEXT NOT NULL,
+		specialization TEXT NOT NULL,
+		email TEXT NOT NULL UNIQUE,
+		password TEXT NOT NULL,
+		phone TEXT NOT NULL UNIQUE,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	if err != nil {
+		t.Fatalf("Failed to create doctors table: %v", err)
+	}
+
+	err = db.Exec(`CREATE TABLE IF NOT EXISTS refresh_tokens (
+		id TEXT PRIMARY KEY,
+		doctor_id TEXT NOT NULL,
+		family_id TEXT NOT NULL,
+		token_hash TEXT NOT NULL UNIQUE,
+		expires_at DATETIME NOT NULL,
+		revoked_at DATETIME,
+		replaced_by TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		FOREIGN KEY (doctor_id) REFERENCES doctors(id)
+	)`).Error
+	if err != nil {
+		t.Fatalf("Failed to create refresh_tokens table: %v", err)
+	}
+
+	err = db.Exec(`CREATE TABLE IF NOT EXISTS revoked_tokens (
+		jti TEXT PRIMARY KEY,
+		expires_at DATETIME NOT NULL,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+	)`).Error
+	if err != nil {
+		t.Fatalf("Failed to create revoked_tokens table: %v", err)
+	}
+
+	initializers.DB = db
+
+	doctor := &models.Doctor{
+		ID:             uuid.New(),
+		Name:           "Test Doctor",
+		Email:          "token@example.com",
+		Password:       "hashedpassword",
+		Phone:          "1234567890",
+		Specialization: "Test Specialization",
+		CreatedAt:      time.Now(),
+	}
+	if err := db.Create(doctor).Error; err != nil {
+		t.Fatalf("Failed to create test doctor: %v", err)
+	}
+	return doctor
+}
+
+func TestIssueRefreshToken(t *testing.T) {
+	doctor := setupTokenTestDB(t)
+
+	rawToken, err := IssueRefreshToken(doctor.ID)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, rawToken)
+
+	// Only the hash is stored
+	var stored models.RefreshToken
+	err = initializers.DB.Where("token_hash = ?", hashRefreshToken(rawToken)).First(&stored).Error
+	assert.NoError(t, err)
+	assert.Equal(t, doctor.ID, stored.DoctorID)
+	assert.NotEqual(t, rawToken, stored.TokenHash)
+	assert.Nil(t, stored.RevokedAt)
+}
+
+func TestRotateRefreshToken(t *testing.T) {
+	doctor := setupTokenTestDB(t)
+
+	first, err := IssueRefreshToken(doctor.ID)
+	assert.NoError(t, err)
+
+	// A valid token rotates into a new one in the same family
+	rotatedDoctor, second, err := RotateRefreshToken(first)
+	assert.NoError(t, err)
+	assert.Equal(t, doctor.Email, rotatedDoctor.Email)
+	assert.NotEqual(t, first, second)
+
+	var firstRecord, secondRecord models.RefreshToken
+	initializers.DB.Where("token_hash = ?", hashRefreshToken(first)).First(&firstRecord)
+	initializers.DB.Where("token_hash = ?", hashRefreshToken(second)).First(&secondRecord)
+	assert.NotNil(t, firstRecord.RevokedAt)
+	assert.Equal(t, secondRecord.ID, *firstRecord.ReplacedBy)
+	assert.Equal(t, firstRecord.FamilyID, secondRecord.FamilyID)
+
+	// Reusing the rotated token revokes the whole family
+	_, _, err = RotateRefreshToken(first)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	_, _, err = RotateRefreshToken(second)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+
+	var active int64
+	initializers.DB.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", firstRecord.FamilyID).
+		Count(&active)
+	assert.Equal(t, int64(0), active)
+}
+
+func TestRotateRefreshToken_Invalid(t *testing.T) {
+	doctor := setupTokenTestDB(t)
+
+	_, _, err := RotateRefreshToken("unknown-token")
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	// Expired tokens are rejected
+	_, rawToken, err := createRefreshToken(initializers.DB, doctor.ID, uuid.New())
+	assert.NoError(t, err)
+	initializers.DB.Model(&models.RefreshToken{}).
+		Where("token_hash = ?", hashRefreshToken(rawToken)).
+		Update("expires_at", time.Now().Add(-time.Hour))
+
+	_, _, err = RotateRefreshToken(rawToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+}
+
+func TestRevokeRefreshToken(t *testing.T) {
+	doctor := setupTokenTestDB(t)
+
+	rawToken, err := IssueRefreshToken(doctor.ID)
+	assert.NoError(t, err)
+
+	// Another doctor can't revoke the token
+	err = RevokeRefreshToken(uuid.New(), rawToken)
+	assert.ErrorIs(t, err, ErrInvalidRefreshToken)
+
+	err = RevokeRefreshToken(doctor.ID, rawToken)
+	assert.NoError(t, err)
+
+	_, _, err = RotateRefreshToken(rawToken)
+	assert.ErrorIs(t, err, ErrRefreshTokenReused)
+}
+
+func TestRevokeAccessToken(t *testing.T) {
+	setupTokenTestDB(t)
+
+	revoked, err := IsAccessTokenRevoked("some-jti")
+	assert.NoError(t, err)
+	assert.False(t, revoked)
+
+	err = RevokeAccessToken("some-jti", time.Now().Add(time.Minute))
+	assert.NoError(t, err)
+
+	revoked, err = IsAccessTokenRevoked("some-jti")
+	assert.NoError(t, err)
+	assert.True(t, revoked)
+
+	// A token without a jti can't be revoked
+	err = RevokeAccessToken("", time.Now().Add(time.Minute))
+	assert.Error(t, err)
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	aai "github.com/AssemblyAI/assemblyai-go-sdk"
+)
+
+// Transcriber providers selectable through the TRANSCRIBER environment variable
+const (
+	TranscriberAssemblyAI = "assemblyai"
+	TranscriberFake       = "fake"
+
+	defaultTranscriptFixturesDir = "fixtures/transcripts"
+)
+
+// Transcriber turns an audio recording into raw transcript text.
+type Transcriber interface {
+	Transcribe(ctx context.Context, audioURL string) (string, error)
+}
+
+// ReaderTranscriber is implemented by transcribers that can also take the audio itself,
+// as needed for uploaded recordings that have no public URL.
+type ReaderTranscriber interface {
+	TranscribeReader(ctx context.Context, filename string, audio io.Reader) (string, error)
+}
+
+// TranscriberFunc adapts an ordinary function to the Transcriber interface.
+type TranscriberFunc func(ctx context.Context, audioURL string) (string, error)
+
+func (f TranscriberFunc) Transcribe(ctx context.Context, audioURL string) (string, error) {
+	return f(ctx, audioURL)
+}
+
+// AssemblyAITranscriber transcribes recordings with the AssemblyAI API.
+type AssemblyAITranscriber struct {
+	client *aai.Client
+}
+
+func NewAssemblyAITranscriber(apiKey string) *AssemblyAITranscriber {
+	return &AssemblyAITranscriber{client: aai.NewClient(apiKey)}
+}
+
+// Transcribe sends the audio URL to AssemblyAI and waits for the transcript.
+func (t *AssemblyAITranscriber) Transcribe(ctx context.Context, audioURL string) (string, error) {
+	transcript, err := t.client.Transcripts.TranscribeFromURL(ctx, audioURL, &aai.TranscriptOptionalParams{})
+	if err != nil {
+		return "", fmt.Errorf("assemblyai transcription error: %v", err)
+	}
+
+	return transcriptText(transcript)
+}
+
+// TranscribeReader uploads the audio to AssemblyAI and waits for the transcript.
+func (t *AssemblyAITranscriber) TranscribeReader(ctx context.Context, filename string, audio io.Reader) (string, error) {
+	transcript, err := t.client.Transcripts.TranscribeFromReader(ctx, audio, &aai.TranscriptOptionalParams{})
+	if err != nil {
+		return "", fmt.Errorf("assemblyai transcription error: %v", err)
+	}
+	return transcriptText(transcript)
+}
+
+func transcriptText(transcript aai.Transcript) (string, error) {
+	if transcript.Text == nil {
+		return "", fmt.Errorf("transcription text is empty")
+	}
+	return *transcript.Text, nil
+}
+
+// FakeTranscriber is an offline Transcriber for tests, CI and staging. It returns the
+// contents of <fixtureDir>/<audio file name without extension>.txt, or a fixed
+// transcript naming the file when no fixture exists, so results are deterministic.
+type FakeTranscriber struct {
+	fixtureDir string
+}
+
+func NewFakeTranscriber(fixtureDir string) *FakeTranscriber {
+	return &FakeTranscriber{fixtureDir: fixtureDir}
+}
+
+func (t *FakeTranscriber) Transcribe(ctx context.Context, audioURL string) (string, error) {
+	name := audioFileName(audioURL)
+	if name == "" {
+		return "", fmt.Errorf("cannot determine audio file name from %q", audioURL)
+	}
+	return t.fixture(name)
+}
+
+// TranscribeReader looks the fixture up by the uploaded file's name; the audio is not read.
+func (t *FakeTranscriber) TranscribeReader(ctx context.Context, filename string, audio io.Reader) (string, error) {
+	name := audioFileName(filename)
+	if name == "" {
+		return "", fmt.Errorf("cannot determine audio file name from %q", filename)
+	}
+	return t.fixture(name)
+}
+
+// fixture returns the transcript for an audio file name.
+func (t *FakeTranscriber) fixture(name string) (string, error) {
+	fixture := filepath.Join(t.fixtureDir, strings.TrimSuffix(name, path.Ext(name))+".txt")
+	content, err := os.ReadFile(fixture)
+	if err == nil {
+		return strings.TrimSpace(string(content)), nil
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("failed to read transcript fixture: %v", err)
+	}
+
+	return fmt.Sprintf("Doctor: What brings you in today? Patient: I have been feeling unwell. (fake transcript of %s)", name), nil
+}
+
+// audioFileName returns the last path segment of an audio URL or file path.
+func audioFileName(audioURL string) string {
+	p := audioURL
+	if parsed, err := url.Parse(audioURL); err == nil && parsed.Path != "" {
+		p = parsed.Path
+	}
+	name := path.Base(strings.ReplaceAll(p, "\\", "/"))
+	if name == "." || name == "/" {
+		return ""
+	}
+	return name
+}
+
+// NewTranscriberFromEnv builds the Transcriber named by TRANSCRIBER (default assemblyai).
+// The fake reads fixtures from TRANSCRIBER_FIXTURES_DIR (default fixtures/transcripts).
+func NewTranscriberFromEnv() (Transcriber, error) {
+	provider := strings.ToLower(strings.TrimSpace(os.Getenv("TRANSCRIBER")))
+	switch provider {
+	case "", TranscriberAssemblyAI:
+		apiKey := strings.TrimSpace(os.Getenv("ASSEMBLYAIKEY"))
+		if apiKey == "" {
+			return nil, errors.New("ASSEMBLYAIKEY must be set to use the assemblyai transcriber")
+		}
+		return NewAssemblyAITranscriber(apiKey), nil
+	case TranscriberFake:
+		fixtureDir := os.Getenv("TRANSCRIBER_FIXTURES_DIR")
+		if fixtureDir == "" {
+			fixtureDir = defaultTranscriptFixturesDir
+		}
+		return NewFakeTranscriber(fixtureDir), nil
+	default:
+		return nil, fmt.Errorf("unknown transcriber %q", provider)
+	}
+}
+
+var (
+	transcriberMu sync.RWMutex
+	transcriber   Transcriber
+)
+
+// SetTranscriber replaces the Transcriber used to create patients' transcriptions.
+func SetTranscriber(t Transcriber) {
+	transcriberMu.Lock()
+	transcriber = t
+	transcriberMu.Unlock()
+}
+
+// currentTranscriber returns the configured Transcriber, building it from the environment on first use.
+func currentTranscriber() (Transcriber, error) {
+	transcriberMu.RLock()
+	t := transcriber
+	transcriberMu.RUnlock()
+	if t != nil {
+		return t, nil
+	}
+
+	t, err := NewTranscriberFromEnv()
+	if err != nil {
+		log.Println("Error configuring transcriber:", err)
+		return nil, err
+	}
+	SetTranscriber(t)
+	return t, nil
+}
+
+// transcribeAudio transcribes a recording with the configured Transcriber.
+func transcribeAudio(ctx context.Context, audioURL string) (string, error) {
+	t, err := currentTranscriber()
+	if err != nil {
+		return "", err
+	}
+	return t.Transcribe(ctx, audioURL)
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFakeTranscriber(t *testing.T) {
+	dir := t.TempDir()
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "visit1.txt"), []byte("  Patient reports a headache.\n"), 0o644))
+	transcriber := NewFakeTranscriber(dir)
+
+	t.Run("Returns fixture for audio file", func(t *testing.T) {
+		text, err := transcriber.Transcribe(context.Background(), "https://cdn.example.com/audio/visit1.mp3?sig=abc")
+		assert.NoError(t, err)
+		assert.Equal(t, "Patient reports a headache.", text)
+	})
+
+	t.Run("Local file paths resolve too", func(t *testing.T) {
+		text, err := transcriber.Transcribe(context.Background(), "/var/uploads/visit1.wav")
+		assert.NoError(t, err)
+		assert.Equal(t, "Patient reports a headache.", text)
+	})
+
+	t.Run("Missing fixture is deterministic", func(t *testing.T) {
+		first, err := transcriber.Transcribe(context.Background(), "https://example.com/other.mp3")
+		assert.NoError(t, err)
+		second, err := transcriber.Transcribe(context.Background(), "https://example.com/other.mp3")
+		assert.NoError(t, err)
+		assert.Equal(t, first, second)
+		assert.Contains(t, first, "other.mp3")
+	})
+
+	t.Run("No file name", func(t *testing.T) {
+		_, err := transcriber.Transcribe(context.Background(), "https://example.com/")
+		assert.Error(t, err)
+	})
+}
+
+func TestNewTranscriberFromEnv(t *testing.T) {
+	tests := []struct {
+		name        string
+		provider    string
+		apiKey      string
+		expectError bool
+		expectType  Transcriber
+	}{
+		{name: "Default is AssemblyAI", provider: "", apiKey: "key", expectType: &AssemblyAITranscriber{}},
+		{name: "AssemblyAI", provider: "assemblyai", apiKey: "key", expectType: &AssemblyAITranscriber{}},
+		{name: "AssemblyAI without key", provider: "assemblyai", apiKey: "", expectError: true},
+		{name: "Fake", provider: "FAKE", expectType: &FakeTranscriber{}},
+		{name: "Unknown", provider: "whisper", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("TRANSCRIBER", tt.provider)
+			t.Setenv("ASSEMBLYAIKEY", tt.apiKey)
+
+			transcriber, err := NewTranscriberFromEnv()
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.IsType(t, tt.expectType, transcriber)
+		})
+	}
+}
+
+func TestRunTranscriptionJob_FakeTranscriber(t *testing.T) {
+	doctor := setupTranscriptionJobTestDB(t)
+
+	// Use the checked-in fixtures, as CI and staging do
+	t.Setenv("TRANSCRIBER", TranscriberFake)
+	t.Setenv("TRANSCRIBER_FIXTURES_DIR", filepath.Join("..", "fixtures", "transcripts"))
+	t.Setenv("REPORT_GENERATOR", ReportGeneratorStub)
+	SetTranscriber(nil)
+	SetReportGenerator(nil)
+	t.Cleanup(func() {
+		SetTranscriber(nil)
+		SetReportGenerator(nil)
+	})
+
+	job, err := EnqueueTranscriptionJob(doctor.ID, models.Patient{Name: "Jane Roe", Age: 41, Gender: "Female"}, AudioSource{URL: "https://example.com/recordings/sample_consultation.mp3"})
+	assert.NoError(t, err)
+	assert.NoError(t, runTranscriptionJob(job.ID))
+
+	done := loadJob(t, job.ID)
+	assert.Equal(t, models.JobStatusDone, done.Status)
+	var transcription models.Transcription
+	assert.NoError(t, initializers.DB.Where("id = ?", done.TranscriptionID).First(&transcription).Error)
+	assert.True(t, strings.HasPrefix(transcription.Text, "Doctor: Good morning"))
+	assert.Contains(t, transcription.Report, "3. Symptoms\n- "+transcription.Text)
+	assert.Equal(t, []string{transcription.Text}, transcription.StructuredReport.Symptoms)
+	assert.Equal(t, models.ReportStatusDraft, transcription.Status)
+
+	var patient models.Patient
+	assert.NoError(t, initializers.DB.Where("id = ?", transcription.PatientID).First(&patient).Error)
+	assert.Equal(t, "Jane Roe", patient.Name)
+	assert.Equal(t, doctor.ID, patient.DoctorID)
+}
@@ -1,16 +1,16 @@
 package service
 
 import (
+	"encoding/json"
 	"errors"
 	"fmt"
 	"itish41/doctor_ai_assistant/initializers"
 	"itish41/doctor_ai_assistant/models"
 	"log"
-	"os"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jung-kurt/gofpdf"
+	"gorm.io/gorm"
 )
 
 // func CreateTranscription(doctorID uuid.UUID, file *multipart.FileHeader, patientID *uuid.UUID) (*models.Transcription, error) {
@@ -69,108 +69,192 @@ import (
 // // 	return err
 // // }
 
-// GetTranscriptions retrieves the list of transcriptions for a given doctor with pagination.
-func GetTranscriptions(doctorID uuid.UUID, page, limit int) ([]map[string]interface{}, []string, int64, error) {
-	var transcriptions []models.Transcription
-	var total int64
-
-	// Calculate the offset based on page and limit.
-	offset := (page - 1) * limit
+// TranscriptionFilter narrows GetTranscriptions. Zero values don't filter.
+type TranscriptionFilter struct {
+	PatientID *uuid.UUID
+	Status    string
+}
 
-	// Count the total number of transcriptions for the doctor.
-	if err := initializers.DB.
-		Model(&models.Transcription{}).
-		Where("doctor_id = ?", doctorID).
-		Count(&total).Error; err != nil {
-		return nil, nil, 0, err
+// GetTranscriptions retrieves one page of a doctor's transcriptions, newest first.
+// Each transcript embeds a summary of its patient, or nil when the patient is gone;
+// the patients are loaded in one extra query however long the page is.
+func GetTranscriptions(doctorID uuid.UUID, filter TranscriptionFilter, page PageRequest) ([]map[string]interface{}, PageInfo, error) {
+	query := initializers.DB.Model(&models.Transcription{}).Where("doctor_id = ?", doctorID)
+	if filter.PatientID != nil {
+		query = query.Where("patient_id = ?", *filter.PatientID)
 	}
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	query = query.Preload("Patient", func(db *gorm.DB) *gorm.DB {
+		return db.Select("id", "name", "age", "gender")
+	})
 
-	// Retrieve the transcriptions with pagination.
-	if err := initializers.DB.
-		Where("doctor_id = ?", doctorID).
-		Limit(limit).
-		Offset(offset).
-		Order("created_at desc").
-		Find(&transcriptions).Error; err != nil {
-		return nil, nil, 0, err
+	transcriptions, info, err := findPage(query, page, func(t *models.Transcription) (time.Time, uuid.UUID) {
+		return t.CreatedAt, t.ID
+	})
+	if err != nil {
+		return nil, PageInfo{}, err
 	}
 
-	// Prepare patient names list and formatted transcriptions
-	patientNames := make([]string, len(transcriptions))
 	formattedTranscriptions := make([]map[string]interface{}, len(transcriptions))
-
 	for i, t := range transcriptions {
-		// Get patient name
-		var patient models.Patient
-		if err := initializers.DB.
-			Where("id = ?", t.PatientID).
-			First(&patient).Error; err != nil {
-			patientNames[i] = "Unknown Patient"
-		} else {
-			patientNames[i] = patient.Name
+		var patient map[string]interface{}
+		if t.Patient.ID != uuid.Nil {
+			patient = map[string]interface{}{
+				"id":     t.Patient.ID,
+				"name":   t.Patient.Name,
+				"age":    t.Patient.Age,
+				"gender": t.Patient.Gender,
+			}
 		}
 
-		// Format transcription
 		formattedTranscriptions[i] = map[string]interface{}{
 			"id":            t.ID,
 			"patientId":     t.PatientID,
+			"patient":       patient,
 			"timestamp":     t.CreatedAt,
 			"text":          t.Text,
 			"report":        t.Report,
+			"status":        t.Status,
 			"transcriptUrl": fmt.Sprintf("/transcription/%s/download", t.ID),
 		}
 	}
 
-	return formattedTranscriptions, patientNames, total, nil
+	return formattedTranscriptions, info, nil
 }
 
-// Works
-func GetTranscriptionByID(transcriptionID uuid.UUID) (*models.Transcription, error) {
-	var transcription models.Transcription
-	result := initializers.DB.Where("id = ?", transcriptionID).First(&transcription)
-	if result.Error != nil {
-		log.Println("Error retrieving transcription:", result.Error)
-		return nil, errors.New("transcription not found")
+// GetTranscriptionByID returns one of the doctor's transcriptions.
+func GetTranscriptionByID(doctorID, transcriptionID uuid.UUID) (*models.Transcription, error) {
+	transcription, err := findDoctorTranscription(initializers.DB, doctorID, transcriptionID)
+	if err != nil {
+		log.Printf("Error retrieving transcription %v for doctor %v: %v", transcriptionID, doctorID, err)
+		return nil, err
 	}
-	// log.Println("Retrieved transcription:", transcription)
+	return transcription, nil
+}
 
-	return &transcription, nil
+// editableTranscriptionFields are the columns a doctor may change with UpdateTranscription.
+var editableTranscriptionFields = map[string]bool{
+	"text":              true,
+	"report":            true,
+	"structured_report": true,
 }
 
-func UpdateTranscription(transcriptionID uuid.UUID, updateData map[string]interface{}) error {
-	result := initializers.DB.Model(&models.Transcription{}).
+// UpdateTranscription applies a doctor's edit to one of their transcriptions. The
+// content it replaces is kept as a revision authored by the doctor. Only the text and
+// reports can be edited, and changing the plain-text report drops the structured one.
+// Signed reports can't be edited, and the review status only changes through
+// TransitionTranscriptionStatus.
+func UpdateTranscription(doctorID, transcriptionID uuid.UUID, updateData map[string]interface{}) error {
+	updates := make(map[string]interface{}, len(updateData))
+	invalid := map[string]string{}
+	for key, value := range updateData {
+		switch {
+		case editableTranscriptionFields[key]:
+			updates[key] = value
+		case key == "status":
+			invalid[key] = "status changes through the status endpoint"
+		default:
+			invalid[key] = "field cannot be edited"
+		}
+	}
+	if len(invalid) > 0 {
+		return &ValidationError{Message: "update contains fields that cannot be edited", Fields: invalid}
+	}
+	if len(updates) == 0 {
+		return &ValidationError{
+			Message: "nothing to update",
+			Fields:  map[string]string{"update_data": "set text, report or structured_report"},
+		}
+	}
+	for _, key := range []string{"text", "report"} {
+		if value, ok := updates[key]; ok {
+			if _, isString := value.(string); !isString {
+				return InvalidField(key, key+" must be a string")
+			}
+		}
+	}
+
+	// A structured report is validated and keeps the plain-text report in step
+	if raw, ok := updates["structured_report"]; ok {
+		encoded, err := json.Marshal(raw)
+		if err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidReport, err)
+		}
+		report, err := ParseMedicalReport(string(encoded))
+		if err != nil {
+			return err
+		}
+		updates["structured_report"] = report
+		updates["report"] = report.Text()
+	}
+
+	tx := initializers.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	transcription, err := findDoctorTranscription(tx, doctorID, transcriptionID)
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+	if transcription.Status == models.ReportStatusSigned {
+		tx.Rollback()
+		return ErrReportSigned
+	}
+
+	// The exports prefer the structured report, so a plain-text edit replaces it rather
+	// than leaving it to contradict the new text
+	if _, structured := updates["structured_report"]; !structured {
+		if report, ok := updates["report"]; ok && report != transcription.Report {
+			updates["structured_report"] = nil
+		}
+	}
+
+	// Resubmitting the current content changes nothing and leaves no revision
+	if !transcriptionChanged(transcription, updates) {
+		tx.Rollback()
+		return nil
+	}
+
+	if err := recordTranscriptionRevision(tx, transcription, doctorID); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	result := tx.Model(&models.Transcription{}).
 		Where("id = ?", transcriptionID).
-		Updates(updateData)
+		Updates(updates)
 
 	if result.Error != nil {
+		tx.Rollback()
 		log.Println("Error updating transcription:", result.Error)
 		return errors.New("failed to update transcription")
 	}
 
+	if err := tx.Commit().Error; err != nil {
+		log.Println("Error committing transcription update:", err)
+		return errors.New("failed to update transcription")
+	}
+
 	log.Println("Transcription updated successfully:", transcriptionID)
 	return nil
 }
 
-func DeleteTranscription(transcriptionID uuid.UUID) error {
-	var transcription models.Transcription
-
-	// First, find the transcription to get its filepath
-	result := initializers.DB.Where("id = ?", transcriptionID).First(&transcription)
-	if result.Error != nil {
-		log.Println("Error finding transcription:", result.Error)
-		return errors.New("transcription not found")
+// DeleteTranscription deletes one of the doctor's transcriptions.
+func DeleteTranscription(doctorID, transcriptionID uuid.UUID) error {
+	transcription, err := findDoctorTranscription(initializers.DB, doctorID, transcriptionID)
+	if err != nil {
+		return err
 	}
 
-	// // Delete the file
-	// if err := os.Remove(transcription.Filepath); err != nil && !os.IsNotExist(err) {
-	// 	log.Println("Error deleting transcription file:", err)
-	// 	return errors.New("failed to delete transcription file")
-	// }
-
 	// Delete the database record
-	result = initializers.DB.Delete(&transcription)
-	if result.Error != nil {
-		log.Println("Error deleting transcription record:", result.Error)
+	if err := initializers.DB.Delete(transcription).Error; err != nil {
+		log.Println("Error deleting transcription record:", err)
 		return errors.New("failed to delete transcription record")
 	}
 
@@ -186,59 +270,14 @@ func GetTranscriptionByPatient(doctorID, patientID uuid.UUID) (*models.Transcrip
 		Order("created_at desc").
 		First(&transcription).Error
 	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrTranscriptionNotFound
+		}
 		return nil, fmt.Errorf("failed to retrieve transcription: %v", err)
 	}
 	return &transcription, nil
 }
 
-// GeneratePDF creates a PDF document from the transcription report and patient details.
-// It returns the file path to the generated PDF.
-func GeneratePDF(report string, patient models.Patient) (string, error) {
-	pdf := gofpdf.New("P", "mm", "A4", "")
-	pdf.AddPage()
-	pdf.SetFont("Arial", "B", 16)
-
-	// Add Header
-	pdf.Cell(40, 10, "Medical Transcription Report")
-	pdf.Ln(12)
-
-	// Add Patient Information
-	pdf.SetFont("Arial", "", 12)
-	pdf.Cell(40, 10, fmt.Sprintf("Patient ID: %s", patient.ID))
-	pdf.Ln(8)
-	pdf.Cell(40, 10, fmt.Sprintf("Patient Name: %s", patient.Name))
-	pdf.Ln(8)
-	pdf.Cell(40, 10, fmt.Sprintf("Age: %d", patient.Age))
-	pdf.Ln(8)
-	pdf.Cell(40, 10, fmt.Sprintf("Gender: %s", patient.Gender))
-	pdf.Ln(12)
-
-	// Add Report Title
-	pdf.SetFont("Arial", "B", 14)
-	pdf.Cell(40, 10, "Transcription Report:")
-	pdf.Ln(10)
-
-	// Add Report Content using MultiCell for long text
-	pdf.SetFont("Arial", "", 12)
-	pdf.MultiCell(0, 7, report, "", "L", false)
-
-	// Generate file name with timestamp
-	fileName := fmt.Sprintf("transcription_%d.pdf", time.Now().Unix())
-	filePath := "./pdfs/" + fileName
-
-	// Ensure the directory exists
-	if err := os.MkdirAll("./pdfs", os.ModePerm); err != nil {
-		return "", fmt.Errorf("failed to create pdf directory: %v", err)
-	}
-
-	// Save the PDF to file
-	if err := pdf.OutputFileAndClose(filePath); err != nil {
-		return "", fmt.Errorf("failed to save pdf: %v", err)
-	}
-
-	return filePath, nil
-}
-
 type Patient struct {
 	ID        string `gorm:"primaryKey"`
 	Name      string
@@ -0,0 +1,413 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Defaults for the background transcription workers
+const (
+	DefaultTranscriptionWorkers    = 2
+	DefaultTranscriptionMaxRetries = 3
+	DefaultTranscriptionRetryDelay = 30 * time.Second
+	transcriptionQueueSize         = 100
+)
+
+var ErrTranscriptionJobNotFound error = &NotFoundError{Resource: "transcription job"}
+
+// TranscriptionWorkerPool runs queued transcription jobs on a fixed number of goroutines.
+type TranscriptionWorkerPool struct {
+	queue      chan uuid.UUID
+	maxRetries int
+	retryDelay time.Duration
+
+	mu      sync.RWMutex
+	stopped bool
+	wg      sync.WaitGroup
+}
+
+var (
+	transcriptionPoolMu sync.RWMutex
+	transcriptionPool   *TranscriptionWorkerPool
+)
+
+// StartTranscriptionWorkers starts the worker pool used by EnqueueTranscriptionJob and
+// resumes any jobs that were still pending when the process last stopped.
+func StartTranscriptionWorkers(workers, maxRetries int, retryDelay time.Duration) *TranscriptionWorkerPool {
+	if workers < 1 {
+		workers = 1
+	}
+	if maxRetries < 0 {
+		maxRetries = 0
+	}
+
+	pool := &TranscriptionWorkerPool{
+		queue:      make(chan uuid.UUID, transcriptionQueueSize),
+		maxRetries: maxRetries,
+		retryDelay: retryDelay,
+	}
+	for i := 0; i < workers; i++ {
+		pool.wg.Add(1)
+		go pool.work()
+	}
+
+	transcriptionPoolMu.Lock()
+	transcriptionPool = pool
+	transcriptionPoolMu.Unlock()
+
+	pool.resumePendingJobs()
+	log.Printf("Started %d transcription workers", workers)
+	return pool
+}
+
+// Stop stops accepting jobs and waits for in-flight jobs to finish.
+// Jobs still waiting for a retry stay queued and are resumed on the next start.
+func (p *TranscriptionWorkerPool) Stop() {
+	p.mu.Lock()
+	if !p.stopped {
+		p.stopped = true
+		close(p.queue)
+	}
+	p.mu.Unlock()
+	p.wg.Wait()
+
+	transcriptionPoolMu.Lock()
+	if transcriptionPool == p {
+		transcriptionPool = nil
+	}
+	transcriptionPoolMu.Unlock()
+}
+
+// enqueue hands a job to the workers, returning false once the pool is stopped.
+func (p *TranscriptionWorkerPool) enqueue(jobID uuid.UUID) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.stopped {
+		return false
+	}
+	p.queue <- jobID
+	return true
+}
+
+func (p *TranscriptionWorkerPool) work() {
+	defer p.wg.Done()
+	for jobID := range p.queue {
+		p.process(jobID)
+	}
+}
+
+// process runs a job once and schedules a retry if it failed and retries are left.
+func (p *TranscriptionWorkerPool) process(jobID uuid.UUID) {
+	err := runTranscriptionJob(jobID)
+	if err == nil {
+		return
+	}
+	log.Printf("Transcription job %v failed: %v", jobID, err)
+
+	retryCount, retry := recordTranscriptionJobFailure(jobID, err, p.maxRetries)
+	if !retry {
+		return
+	}
+	delay := p.retryDelay * time.Duration(retryCount)
+	// Respect the backend's Retry-After when it asks us to wait longer
+	var statusErr *UpstreamStatusError
+	if errors.As(err, &statusErr) && statusErr.RetryAfter > delay {
+		delay = statusErr.RetryAfter
+	}
+	time.AfterFunc(delay, func() {
+		p.enqueue(jobID)
+	})
+}
+
+// resumePendingJobs requeues jobs interrupted by a restart.
+func (p *TranscriptionWorkerPool) resumePendingJobs() {
+	// Jobs caught mid-step were abandoned by the previous process
+	if err := initializers.DB.Model(&models.TranscriptionJob{}).
+		Where("status IN ?", []string{models.JobStatusTranscribing, models.JobStatusEnhancing}).
+		Updates(map[string]interface{}{"status": models.JobStatusQueued, "updated_at": time.Now()}).Error; err != nil {
+		log.Println("Error resetting interrupted transcription jobs:", err)
+		return
+	}
+
+	var jobIDs []uuid.UUID
+	if err := initializers.DB.Model(&models.TranscriptionJob{}).
+		Where("status = ?", models.JobStatusQueued).
+		Order("created_at ASC").
+		Pluck("id", &jobIDs).Error; err != nil {
+		log.Println("Error loading pending transcription jobs:", err)
+		return
+	}
+	if len(jobIDs) == 0 {
+		return
+	}
+
+	log.Printf("Resuming %d pending transcription jobs", len(jobIDs))
+	go func() {
+		for _, jobID := range jobIDs {
+			if !p.enqueue(jobID) {
+				return
+			}
+		}
+	}()
+}
+
+// AudioSource names the recording for a new patient: a public URL or an uploaded audio file.
+type AudioSource struct {
+	URL    string
+	FileID *uuid.UUID
+}
+
+// EnqueueTranscriptionJob validates the new patient, persists a queued job and hands it to the
+// workers. The patient and their first encounter are only created once the recording has been
+// transcribed and enhanced.
+func EnqueueTranscriptionJob(doctorID uuid.UUID, patientData models.Patient, audio AudioSource) (*models.TranscriptionJob, error) {
+	if err := validatePatient(&patientData); err != nil {
+		log.Println("Validation error:", err)
+		return nil, err
+	}
+	if err := validateAudioSource(doctorID, audio); err != nil {
+		return nil, err
+	}
+
+	job := models.TranscriptionJob{
+		ID:            uuid.New(),
+		DoctorID:      doctorID,
+		PatientName:   patientData.Name,
+		PatientAge:    patientData.Age,
+		PatientGender: patientData.Gender,
+		AudioURL:      audio.URL,
+		AudioFileID:   audio.FileID,
+		Status:        models.JobStatusQueued,
+		CreatedAt:     time.Now(),
+		UpdatedAt:     time.Now(),
+	}
+	if err := queueTranscriptionJob(&job); err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
+
+// validateAudioSource checks that exactly one recording is named and that an uploaded
+// one belongs to the doctor.
+func validateAudioSource(doctorID uuid.UUID, audio AudioSource) error {
+	if (audio.URL == "") == (audio.FileID == nil) {
+		return &ValidationError{
+			Message: "exactly one of audio URL or audio file is required",
+			Fields:  map[string]string{"audio": "set either audio or audio_file_id", "audio_file_id": "set either audio or audio_file_id"},
+		}
+	}
+	if audio.FileID != nil {
+		// The upload must belong to the same doctor
+		if _, err := GetAudioFile(doctorID, *audio.FileID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// queueTranscriptionJob persists a queued job and hands it to the workers.
+func queueTranscriptionJob(job *models.TranscriptionJob) error {
+	if err := initializers.DB.Create(job).Error; err != nil {
+		log.Println("Error creating transcription job:", err)
+		return errors.New("failed to create transcription job")
+	}
+	log.Println("Transcription job queued:", job.ID)
+
+	transcriptionPoolMu.RLock()
+	pool := transcriptionPool
+	transcriptionPoolMu.RUnlock()
+	if pool != nil {
+		// Don't hold the request up if the queue is full; the job is already persisted
+		go pool.enqueue(job.ID)
+	} else {
+		log.Println("No transcription workers running, job will start when they do:", job.ID)
+	}
+	return nil
+}
+
+// GetTranscriptionJob returns a job if it belongs to the doctor.
+func GetTranscriptionJob(doctorID uuid.UUID, jobID uuid.UUID) (*models.TranscriptionJob, error) {
+	var job models.TranscriptionJob
+	if err := initializers.DB.Where("id = ? AND doctor_id = ?", jobID, doctorID).First(&job).Error; err != nil {
+		log.Println("Transcription job not found:", err)
+		return nil, ErrTranscriptionJobNotFound
+	}
+	return &job, nil
+}
+
+// claimTranscriptionJob moves a job from fromStatus to toStatus, reporting whether this caller won it.
+func claimTranscriptionJob(jobID uuid.UUID, fromStatus, toStatus string) (bool, error) {
+	result := initializers.DB.Model(&models.TranscriptionJob{}).
+		Where("id = ? AND status = ?", jobID, fromStatus).
+		Updates(map[string]interface{}{"status": toStatus, "updated_at": time.Now()})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to update job status: %v", result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// runTranscriptionJob transcribes, enhances and stores a queued job. A transcript obtained by an
+// earlier attempt is reused so a retry after an enhancement failure does not transcribe again.
+func runTranscriptionJob(jobID uuid.UUID) error {
+	claimed, err := claimTranscriptionJob(jobID, models.JobStatusQueued, models.JobStatusTranscribing)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		// Already running, finished or failed elsewhere
+		return nil
+	}
+
+	var job models.TranscriptionJob
+	if err := initializers.DB.Where("id = ?", jobID).First(&job).Error; err != nil {
+		return fmt.Errorf("failed to load job: %v", err)
+	}
+
+	// Step 1: Transcribe the audio with the configured transcriber
+	if job.RawText == "" {
+		var rawTranscript string
+		if job.AudioFileID != nil {
+			rawTranscript, err = transcribeAudioFile(context.Background(), *job.AudioFileID)
+		} else {
+			rawTranscript, err = transcribeAudio(context.Background(), job.AudioURL)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to transcribe audio: %w", err)
+		}
+		if err := initializers.DB.Model(&job).Update("raw_text", rawTranscript).Error; err != nil {
+			return fmt.Errorf("failed to store transcript: %v", err)
+		}
+		job.RawText = rawTranscript
+	}
+
+	// Step 2: Turn the transcript into a report with the configured generator
+	claimed, err = claimTranscriptionJob(jobID, models.JobStatusTranscribing, models.JobStatusEnhancing)
+	if err != nil {
+		return err
+	}
+	if !claimed {
+		// Requeued or finished by another worker while this one was transcribing
+		return nil
+	}
+	enhancedTranscript, err := generateReport(context.Background(), job.RawText)
+	if err != nil {
+		return fmt.Errorf("failed to enhance transcription: %w", err)
+	}
+
+	// Step 3: Create the patient and transcription and complete the job together
+	tx := initializers.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	visit := EncounterDetails{ID: job.EncounterID, VisitDate: job.VisitDate, Reason: job.EncounterReason}
+	if visit.ID == nil && visit.VisitDate == nil {
+		// The visit happened when the recording was submitted, not when it was transcribed
+		visit.VisitDate = &job.CreatedAt
+	}
+	var transcription *models.Transcription
+	if job.PatientID != nil {
+		// A recording of an existing patient, who may have been deleted since
+		if _, err = findDoctorPatient(tx, job.DoctorID, *job.PatientID); err == nil {
+			transcription, err = saveVisitTranscription(tx, job.DoctorID, *job.PatientID, visit, job.RawText, enhancedTranscript, job.AudioFileID)
+		}
+	} else {
+		patientData := models.Patient{Name: job.PatientName, Age: job.PatientAge, Gender: job.PatientGender}
+		transcription, err = savePatientAndTranscription(tx, job.DoctorID, patientData, visit, job.RawText, enhancedTranscript, job.AudioFileID)
+	}
+	if err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	// Only the worker still holding the enhancing claim may complete the job
+	result := tx.Model(&models.TranscriptionJob{}).Where("id = ? AND status = ?", jobID, models.JobStatusEnhancing).Updates(map[string]interface{}{
+		"status":           models.JobStatusDone,
+		"last_error":       "",
+		"patient_id":       transcription.PatientID,
+		"transcription_id": transcription.ID,
+		"encounter_id":     transcription.EncounterID,
+		"updated_at":       time.Now(),
+	})
+	if result.Error != nil {
+		tx.Rollback()
+		return fmt.Errorf("failed to complete job: %v", result.Error)
+	}
+	if result.RowsAffected != 1 {
+		tx.Rollback()
+		log.Println("Transcription job was taken over by another worker, discarding results:", jobID)
+		return nil
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("failed to commit job results: %v", err)
+	}
+	log.Println("Transcription job completed:", jobID)
+	return nil
+}
+
+// isTransientJobError reports whether another attempt at a failed job could succeed.
+// Rate limits, upstream 5xx responses and network or database failures are retried;
+// a rejected request, or a patient, encounter or upload that is gone or belongs to
+// another doctor, fails the job straight away.
+func isTransientJobError(err error) bool {
+	var statusErr *UpstreamStatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.StatusCode == http.StatusTooManyRequests || statusErr.StatusCode >= http.StatusInternalServerError
+	}
+	if errors.Is(err, ErrInvalidReport) {
+		// The model's output was unusable, not the job's input; a new attempt may do better
+		return true
+	}
+	return !errors.Is(err, ErrValidation) && !errors.Is(err, ErrNotFound) &&
+		!errors.Is(err, ErrForbidden) && !errors.Is(err, ErrUnauthorized) && !errors.Is(err, ErrConflict)
+}
+
+// recordTranscriptionJobFailure stores the error and either requeues the job or marks it failed.
+// It returns the job's retry count and whether another attempt should be made.
+func recordTranscriptionJobFailure(jobID uuid.UUID, jobErr error, maxRetries int) (int, bool) {
+	var job models.TranscriptionJob
+	if err := initializers.DB.Where("id = ?", jobID).First(&job).Error; err != nil {
+		log.Println("Error loading failed transcription job:", err)
+		return 0, false
+	}
+
+	updates := map[string]interface{}{"last_error": jobErr.Error(), "updated_at": time.Now()}
+	retry := job.RetryCount < maxRetries && isTransientJobError(jobErr)
+	if retry {
+		job.RetryCount++
+		updates["retry_count"] = job.RetryCount
+		updates["status"] = models.JobStatusQueued
+	} else {
+		updates["status"] = models.JobStatusFailed
+	}
+
+	if err := initializers.DB.Model(&models.TranscriptionJob{}).Where("id = ?", jobID).Updates(updates).Error; err != nil {
+		log.Println("Error recording transcription job failure:", err)
+		return job.RetryCount, false
+	}
+
+	if !retry {
+		if err := RecordAuditEvent(models.AuditEvent{
+			ActorID:      &job.DoctorID,
+			Action:       "transcription.create",
+			ResourceType: models.AuditResourceTranscriptionJob,
+			ResourceID:   job.ID.String(),
+			Outcome:      models.AuditOutcomeFailure,
+		}); err != nil {
+			log.Println("Error auditing failed transcription job:", err)
+		}
+	}
+	return job.RetryCount, retry
+}
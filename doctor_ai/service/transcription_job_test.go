@@ -0,0 +1,431 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupTranscriptionJobTestDB(t *testing.T) *models.Doctor {
+	dbName := fmt.Sprintf("file:jobdb%d?mode=memory&cache=shared", time.Now().UnixNano())
+	db, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("Failed to connect to test database: %v", err)
+	}
+	// Workers write concurrently; serialize access to the in-memory database
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatalf("Failed to get database handle: %v", err)
+	}
+	sqlDB.SetMaxOpenConns(1)
+
+	statements := []string{
+		`CREATE TABLE IF NOT EXISTS doctors (
+			id TEXT PRIMARY KEY,
+			name TEXT NOT NULL,
+			specialization TEXT NOT NULL,
+			email TEXT NOT NULL UNIQUE,
+			password TEXT NOT NULL,
+			phone TEXT NOT NULL UNIQUE,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS patients (
+			id TEXT PRIMARY KEY,
+			doctor_id TEXT NOT NULL,
+			name TEXT NOT NULL,
+			age INTEGER NOT NULL,
+			gender TEXT NOT NULL,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS transcriptions (
+			id TEXT PRIMARY KEY,
+			doctor_id TEXT NOT NULL,
+			patient_id TEXT NOT NULL,
+			text TEXT NOT NULL,
+			report TEXT NOT NULL,
+			structured_report TEXT,
+			audio_file_id TEXT,
+			status TEXT NOT NULL DEFAULT 'draft',
+			signed_by_id TEXT,
+			signed_at DATETIME,
+			encounter_id TEXT,
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+		`CREATE TABLE IF NOT EXISTS transcription_jobs (
+			id TEXT PRIMARY KEY,
+			doctor_id TEXT NOT NULL,
+			patient_name TEXT NOT NULL,
+			patient_age INTEGER NOT NULL,
+			patient_gender TEXT NOT NULL,
+			audio_url TEXT NOT NULL,
+			audio_file_id TEXT,
+			status TEXT NOT NULL,
+			retry_count INTEGER NOT NULL DEFAULT 0,
+			last_error TEXT,
+			raw_text TEXT,
+			patient_id TEXT,
+			transcription_id TEXT,
+			encounter_id TEXT,
+			visit_date DATETIME,
+			encounter_reason TEXT NOT NULL DEFAULT '',
+			created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+			updated_at DATETIME DEFAULT CURRENT_TIMESTAMP
+		)`,
+	}
+	for _, stmt := range statements {
+		if err := db.Exec(stmt).Error; err != nil {
+			t.Fatalf("Failed to create table: %v", err)
+		}
+	}
+	createAuditEventsTable(t, db)
+	createEncountersTable(t, db)
+
+	initializers.DB = db
+
+	doctor := &models.Doctor{
+		ID:             uuid.New(),
+		Name:           "Test Doctor",
+		Email:          "jobs@example.com",
+		Password:       "password123",
+		Phone:          "1234567890",
+		Specialization: "General",
+		CreatedAt:      time.Now(),
+	}
+	if err := db.Create(doctor).Error; err != nil {
+		t.Fatalf("Failed to create doctor: %v", err)
+	}
+	return doctor
+}
+
+// useTranscriber installs fn as the transcriber for the duration of the test.
+func useTranscriber(t *testing.T, fn func(ctx context.Context, audioURL string) (string, error)) {
+	SetTranscriber(TranscriberFunc(fn))
+	t.Cleanup(func() { SetTranscriber(nil) })
+}
+
+// useReportGenerator installs fn as the report generator for the duration of the test.
+func useReportGenerator(t *testing.T, fn func(ctx context.Context, rawText string) (*models.MedicalReport, error)) {
+	SetReportGenerator(ReportGeneratorFunc(fn))
+	t.Cleanup(func() { SetReportGenerator(nil) })
+}
+
+// testReport builds a valid structured report with the given diagnosis.
+func testReport(diagnosis string) *models.MedicalReport {
+	return &models.MedicalReport{
+		PatientInformation: "Adult patient",
+		Symptoms:           []string{"Cough"},
+		Diagnosis:          diagnosis,
+		TreatmentPlan:      "Rest and fluids",
+	}
+}
+
+func countRows(t *testing.T, model interface{}) int64 {
+	var count int64
+	assert.NoError(t, initializers.DB.Model(model).Count(&count).Error)
+	return count
+}
+
+func loadJob(t *testing.T, jobID uuid.UUID) models.TranscriptionJob {
+	var job models.TranscriptionJob
+	assert.NoError(t, initializers.DB.Where("id = ?", jobID).First(&job).Error)
+	return job
+}
+
+func waitForJobStatus(t *testing.T, jobID uuid.UUID, status string) models.TranscriptionJob {
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		job := loadJob(t, jobID)
+		if job.Status == status {
+			return job
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	job := loadJob(t, jobID)
+	t.Fatalf("job %v did not reach status %q, last status %q", jobID, status, job.Status)
+	return job
+}
+
+func TestEnqueueTranscriptionJob(t *testing.T) {
+	doctor := setupTranscriptionJobTestDB(t)
+
+	t.Run("Invalid patient", func(t *testing.T) {
+		job, err := EnqueueTranscriptionJob(doctor.ID, models.Patient{Age: 30, Gender: "Male"}, AudioSource{URL: "https://example.com/a.mp3"})
+		assert.Error(t, err)
+		assert.Nil(t, job)
+	})
+
+	t.Run("Missing audio", func(t *testing.T) {
+		job, err := EnqueueTranscriptionJob(doctor.ID, models.Patient{Name: "John", Age: 30, Gender: "Male"}, AudioSource{})
+		assert.Error(t, err)
+		assert.Nil(t, job)
+	})
+
+	t.Run("Queued without creating a patient", func(t *testing.T) {
+		job, err := EnqueueTranscriptionJob(doctor.ID, models.Patient{Name: "John", Age: 30, Gender: "Male"}, AudioSource{URL: "https://example.com/a.mp3"})
+		assert.NoError(t, err)
+		assert.Equal(t, models.JobStatusQueued, job.Status)
+
+		stored := loadJob(t, job.ID)
+		assert.Equal(t, "John", stored.PatientName)
+		assert.Equal(t, doctor.ID, stored.DoctorID)
+		assert.Equal(t, int64(0), countRows(t, &models.Patient{}))
+	})
+}
+
+func TestGetTranscriptionJob(t *testing.T) {
+	doctor := setupTranscriptionJobTestDB(t)
+	job, err := EnqueueTranscriptionJob(doctor.ID, models.Patient{Name: "John", Age: 30, Gender: "Male"}, AudioSource{URL: "https://example.com/a.mp3"})
+	assert.NoError(t, err)
+
+	found, err := GetTranscriptionJob(doctor.ID, job.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, job.ID, found.ID)
+
+	// Another doctor cannot see the job
+	_, err = GetTranscriptionJob(uuid.New(), job.ID)
+	assert.ErrorIs(t, err, ErrTranscriptionJobNotFound)
+}
+
+func TestRunTranscriptionJob(t *testing.T) {
+	t.Run("Success creates patient and transcription", func(t *testing.T) {
+		doctor := setupTranscriptionJobTestDB(t)
+		useTranscriber(t, func(ctx context.Context, audioURL string) (string, error) {
+			return "raw transcript", nil
+		})
+		useReportGenerator(t, func(ctx context.Context, rawText string) (*models.MedicalReport, error) {
+			return testReport("Diagnosis for " + rawText), nil
+		})
+
+		job, err := EnqueueTranscriptionJob(doctor.ID, models.Patient{Name: "John", Age: 30, Gender: "Male"}, AudioSource{URL: "https://example.com/a.mp3"})
+		assert.NoError(t, err)
+		assert.NoError(t, runTranscriptionJob(job.ID))
+
+		stored := loadJob(t, job.ID)
+		assert.Equal(t, models.JobStatusDone, stored.Status)
+		assert.NotNil(t, stored.PatientID)
+		assert.NotNil(t, stored.TranscriptionID)
+
+		var transcription models.Transcription
+		assert.NoError(t, initializers.DB.Where("id = ?", *stored.TranscriptionID).First(&transcription).Error)
+		assert.Equal(t, "raw transcript", transcription.Text)
+		assert.Contains(t, transcription.Report, "4. Diagnosis\nDiagnosis for raw transcript")
+		if assert.NotNil(t, transcription.StructuredReport) {
+			assert.Equal(t, "Diagnosis for raw transcript", transcription.StructuredReport.Diagnosis)
+			assert.Equal(t, []string{"Cough"}, transcription.StructuredReport.Symptoms)
+		}
+		assert.Equal(t, *stored.PatientID, transcription.PatientID)
+	})
+
+	t.Run("Enhancement failure leaves no patient and keeps transcript", func(t *testing.T) {
+		doctor := setupTranscriptionJobTestDB(t)
+		var transcribeCalls int32
+		useTranscriber(t, func(ctx context.Context, audioURL string) (string, error) {
+			atomic.AddInt32(&transcribeCalls, 1)
+			return "raw transcript", nil
+		})
+		useReportGenerator(t, func(ctx context.Context, rawText string) (*models.MedicalReport, error) {
+			return nil, errors.New("llm unavailable")
+		})
+
+		job, err := EnqueueTranscriptionJob(doctor.ID, models.Patient{Name: "John", Age: 30, Gender: "Male"}, AudioSource{URL: "https://example.com/a.mp3"})
+		assert.NoError(t, err)
+		assert.Error(t, runTranscriptionJob(job.ID))
+
+		assert.Equal(t, int64(0), countRows(t, &models.Patient{}))
+		assert.Equal(t, "raw transcript", loadJob(t, job.ID).RawText)
+
+		// First failure is retried
+		retryCount, retry := recordTranscriptionJobFailure(job.ID, errors.New("llm unavailable"), 1)
+		assert.True(t, retry)
+		assert.Equal(t, 1, retryCount)
+		assert.Equal(t, models.JobStatusQueued, loadJob(t, job.ID).Status)
+
+		// The retry reuses the stored transcript
+		assert.Error(t, runTranscriptionJob(job.ID))
+		assert.Equal(t, int32(1), atomic.LoadInt32(&transcribeCalls))
+
+		// Out of retries
+		_, retry = recordTranscriptionJobFailure(job.ID, errors.New("llm unavailable"), 1)
+		assert.False(t, retry)
+		stored := loadJob(t, job.ID)
+		assert.Equal(t, models.JobStatusFailed, stored.Status)
+		assert.Equal(t, "llm unavailable", stored.LastError)
+		assert.Equal(t, int64(0), countRows(t, &models.Patient{}))
+
+		// Only the final failure is audited
+		var events []models.AuditEvent
+		assert.NoError(t, initializers.DB.Find(&events).Error)
+		if assert.Len(t, events, 1) {
+			assert.Equal(t, models.AuditOutcomeFailure, events[0].Outcome)
+			assert.Equal(t, job.ID.String(), events[0].ResourceID)
+		}
+	})
+
+	t.Run("Job taken over mid-run is stored once", func(t *testing.T) {
+		doctor := setupTranscriptionJobTestDB(t)
+		useTranscriber(t, func(ctx context.Context, audioURL string) (string, error) {
+			return "raw transcript", nil
+		})
+		job, err := EnqueueTranscriptionJob(doctor.ID, models.Patient{Name: "John", Age: 30, Gender: "Male"}, AudioSource{URL: "https://example.com/a.mp3"})
+		assert.NoError(t, err)
+
+		var generateCalls int32
+		useReportGenerator(t, func(ctx context.Context, rawText string) (*models.MedicalReport, error) {
+			if atomic.AddInt32(&generateCalls, 1) == 1 {
+				// Another process resets the job at startup and runs it to completion
+				assert.NoError(t, initializers.DB.Model(&models.TranscriptionJob{}).Where("id = ?", job.ID).Update("status", models.JobStatusQueued).Error)
+				assert.NoError(t, runTranscriptionJob(job.ID))
+			}
+			return testReport("Viral infection"), nil
+		})
+
+		assert.NoError(t, runTranscriptionJob(job.ID))
+
+		assert.Equal(t, int32(2), atomic.LoadInt32(&generateCalls))
+		assert.Equal(t, models.JobStatusDone, loadJob(t, job.ID).Status)
+		assert.Equal(t, int64(1), countRows(t, &models.Patient{}))
+		assert.Equal(t, int64(1), countRows(t, &models.Transcription{}))
+	})
+
+	t.Run("Job requeued while transcribing is not enhanced", func(t *testing.T) {
+		doctor := setupTranscriptionJobTestDB(t)
+		job, err := EnqueueTranscriptionJob(doctor.ID, models.Patient{Name: "John", Age: 30, Gender: "Male"}, AudioSource{URL: "https://example.com/a.mp3"})
+		assert.NoError(t, err)
+		useTranscriber(t, func(ctx context.Context, audioURL string) (string, error) {
+			assert.NoError(t, initializers.DB.Model(&models.TranscriptionJob{}).Where("id = ?", job.ID).Update("status", models.JobStatusQueued).Error)
+			return "raw transcript", nil
+		})
+		useReportGenerator(t, func(ctx context.Context, rawText string) (*models.MedicalReport, error) {
+			t.Fatal("report generator should not be called")
+			return nil, nil
+		})
+
+		assert.NoError(t, runTranscriptionJob(job.ID))
+		assert.Equal(t, models.JobStatusQueued, loadJob(t, job.ID).Status)
+		assert.Equal(t, int64(0), countRows(t, &models.Patient{}))
+	})
+
+	t.Run("Finished job is not run again", func(t *testing.T) {
+		doctor := setupTranscriptionJobTestDB(t)
+		useTranscriber(t, func(ctx context.Context, audioURL string) (string, error) {
+			t.Fatal("transcriber should not be called")
+			return "", nil
+		})
+
+		job, err := EnqueueTranscriptionJob(doctor.ID, models.Patient{Name: "John", Age: 30, Gender: "Male"}, AudioSource{URL: "https://example.com/a.mp3"})
+		assert.NoError(t, err)
+		assert.NoError(t, initializers.DB.Model(&models.TranscriptionJob{}).Where("id = ?", job.ID).Update("status", models.JobStatusDone).Error)
+
+		assert.NoError(t, runTranscriptionJob(job.ID))
+	})
+}
+
+func TestIsTransientJobError(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		transient bool
+	}{
+		{name: "Rate limited", err: fmt.Errorf("failed to enhance transcription: %w", &UpstreamStatusError{StatusCode: 429}), transient: true},
+		{name: "Upstream 5xx", err: fmt.Errorf("failed to enhance transcription: %w", &UpstreamStatusError{StatusCode: 503}), transient: true},
+		{name: "Upstream 4xx", err: fmt.Errorf("failed to enhance transcription: %w", &UpstreamStatusError{StatusCode: 400}), transient: false},
+		{name: "Invalid report", err: fmt.Errorf("%w: missing diagnosis", ErrInvalidReport), transient: true},
+		{name: "Network", err: fmt.Errorf("failed to transcribe audio: %w", errors.New("connection reset")), transient: true},
+		{name: "Patient deleted", err: ErrPatientNotFound, transient: false},
+		{name: "Another doctor's upload", err: fmt.Errorf("failed to transcribe audio: %w", ErrForbidden), transient: false},
+		{name: "Invalid input", err: InvalidField("audio", "unsupported audio type"), transient: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.transient, isTransientJobError(tt.err))
+		})
+	}
+}
+
+func TestRecordTranscriptionJobFailure_Permanent(t *testing.T) {
+	doctor := setupTranscriptionJobTestDB(t)
+	job, err := EnqueueTranscriptionJob(doctor.ID, models.Patient{Name: "John", Age: 30, Gender: "Male"}, AudioSource{URL: "https://example.com/a.mp3"})
+	assert.NoError(t, err)
+
+	retryCount, retry := recordTranscriptionJobFailure(job.ID, ErrPatientNotFound, 3)
+	assert.False(t, retry)
+	assert.Equal(t, 0, retryCount)
+	stored := loadJob(t, job.ID)
+	assert.Equal(t, models.JobStatusFailed, stored.Status)
+	assert.Equal(t, ErrPatientNotFound.Error(), stored.LastError)
+}
+
+func TestTranscriptionWorkerPool(t *testing.T) {
+	t.Run("Retries until success", func(t *testing.T) {
+		doctor := setupTranscriptionJobTestDB(t)
+		var transcribeCalls int32
+		useTranscriber(t, func(ctx context.Context, audioURL string) (string, error) {
+			if atomic.AddInt32(&transcribeCalls, 1) == 1 {
+				return "", errors.New("assemblyai unavailable")
+			}
+			return "raw transcript", nil
+		})
+		useReportGenerator(t, func(ctx context.Context, rawText string) (*models.MedicalReport, error) {
+			return testReport("Viral infection"), nil
+		})
+
+		pool := StartTranscriptionWorkers(2, 2, 10*time.Millisecond)
+		defer pool.Stop()
+
+		job, err := EnqueueTranscriptionJob(doctor.ID, models.Patient{Name: "John", Age: 30, Gender: "Male"}, AudioSource{URL: "https://example.com/a.mp3"})
+		assert.NoError(t, err)
+
+		stored := waitForJobStatus(t, job.ID, models.JobStatusDone)
+		assert.Equal(t, 1, stored.RetryCount)
+		assert.Equal(t, int64(1), countRows(t, &models.Patient{}))
+		assert.Equal(t, int64(1), countRows(t, &models.Transcription{}))
+	})
+
+	t.Run("Fails after retries are exhausted", func(t *testing.T) {
+		doctor := setupTranscriptionJobTestDB(t)
+		useTranscriber(t, func(ctx context.Context, audioURL string) (string, error) {
+			return "", errors.New("assemblyai unavailable")
+		})
+
+		pool := StartTranscriptionWorkers(1, 1, 10*time.Millisecond)
+		defer pool.Stop()
+
+		job, err := EnqueueTranscriptionJob(doctor.ID, models.Patient{Name: "John", Age: 30, Gender: "Male"}, AudioSource{URL: "https://example.com/a.mp3"})
+		assert.NoError(t, err)
+
+		stored := waitForJobStatus(t, job.ID, models.JobStatusFailed)
+		assert.Equal(t, 1, stored.RetryCount)
+		assert.Contains(t, stored.LastError, "assemblyai unavailable")
+		assert.Equal(t, int64(0), countRows(t, &models.Patient{}))
+	})
+
+	t.Run("Resumes interrupted jobs on start", func(t *testing.T) {
+		doctor := setupTranscriptionJobTestDB(t)
+		useTranscriber(t, func(ctx context.Context, audioURL string) (string, error) {
+			return "raw transcript", nil
+		})
+		useReportGenerator(t, func(ctx context.Context, rawText string) (*models.MedicalReport, error) {
+			return testReport("Viral infection"), nil
+		})
+
+		// Queued while no workers were running, then left mid-step by a crash
+		job, err := EnqueueTranscriptionJob(doctor.ID, models.Patient{Name: "John", Age: 30, Gender: "Male"}, AudioSource{URL: "https://example.com/a.mp3"})
+		assert.NoError(t, err)
+		assert.NoError(t, initializers.DB.Model(&models.TranscriptionJob{}).Where("id = ?", job.ID).Update("status", models.JobStatusEnhancing).Error)
+
+		pool := StartTranscriptionWorkers(1, 0, time.Millisecond)
+		defer pool.Stop()
+
+		waitForJobStatus(t, job.ID, models.JobStatusDone)
+	})
+}
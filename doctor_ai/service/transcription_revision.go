@@ -0,0 +1,249 @@
+package service
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrRevisionNotFound error = &NotFoundError{Resource: "revision"}
+
+// Operations in a line diff
+const (
+	DiffEqual  = "equal"
+	DiffInsert = "insert"
+	DiffDelete = "delete"
+)
+
+// DiffLine is one line of a diff between two versions of a text.
+type DiffLine struct {
+	Op   string `json:"op"`
+	Text string `json:"text"`
+}
+
+// TranscriptionRevisionDiff compares a revision with a later revision, or with the
+// transcription's current content when To is nil.
+type TranscriptionRevisionDiff struct {
+	From   models.TranscriptionRevision
+	To     *models.TranscriptionRevision
+	Text   []DiffLine
+	Report []DiffLine
+}
+
+// findTranscriptionRevision loads one revision of a transcription.
+func findTranscriptionRevision(db *gorm.DB, transcriptionID, revisionID uuid.UUID) (*models.TranscriptionRevision, error) {
+	var revision models.TranscriptionRevision
+	err := db.Where("id = ? AND transcription_id = ?", revisionID, transcriptionID).First(&revision).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrRevisionNotFound
+		}
+		log.Println("Error retrieving revision:", err)
+		return nil, errors.New("failed to retrieve revision")
+	}
+	return &revision, nil
+}
+
+// recordTranscriptionRevision saves the transcription's current content as its next
+// revision before authorID's edit replaces it.
+func recordTranscriptionRevision(tx *gorm.DB, transcription *models.Transcription, authorID uuid.UUID) error {
+	var latest int
+	if err := tx.Model(&models.TranscriptionRevision{}).
+		Where("transcription_id = ?", transcription.ID).
+		Select("COALESCE(MAX(version), 0)").
+		Scan(&latest).Error; err != nil {
+		log.Println("Error reading latest revision:", err)
+		return errors.New("failed to record revision")
+	}
+
+	revision := models.TranscriptionRevision{
+		ID:               uuid.New(),
+		TranscriptionID:  transcription.ID,
+		Version:          latest + 1,
+		AuthorID:         authorID,
+		Text:             transcription.Text,
+		Report:           transcription.Report,
+		StructuredReport: transcription.StructuredReport,
+	}
+	if err := tx.Create(&revision).Error; err != nil {
+		log.Println("Error creating revision:", err)
+		return errors.New("failed to record revision")
+	}
+	return nil
+}
+
+// transcriptionChanged reports whether applying updates would change any of the
+// content a revision keeps.
+func transcriptionChanged(transcription *models.Transcription, updates map[string]interface{}) bool {
+	if text, ok := updates["text"]; ok && text != transcription.Text {
+		return true
+	}
+	if report, ok := updates["report"]; ok && report != transcription.Report {
+		return true
+	}
+	if structured, ok := updates["structured_report"]; ok {
+		// Compare the reports as they are stored
+		report, _ := structured.(*models.MedicalReport)
+		submitted, _ := json.Marshal(report)
+		current, _ := json.Marshal(transcription.StructuredReport)
+		if !bytes.Equal(submitted, current) {
+			return true
+		}
+	}
+	return false
+}
+
+// ListTranscriptionRevisions returns a transcription's revisions, newest first.
+func ListTranscriptionRevisions(doctorID, transcriptionID uuid.UUID) ([]models.TranscriptionRevision, error) {
+	if _, err := findDoctorTranscription(initializers.DB, doctorID, transcriptionID); err != nil {
+		return nil, err
+	}
+
+	var revisions []models.TranscriptionRevision
+	if err := initializers.DB.
+		Where("transcription_id = ?", transcriptionID).
+		Order("version desc").
+		Find(&revisions).Error; err != nil {
+		log.Println("Error listing revisions:", err)
+		return nil, errors.New("failed to list revisions")
+	}
+	return revisions, nil
+}
+
+// DiffTranscriptionRevisions diffs the text and report of revision fromID against
+// revision toID, or against the current content when toID is nil.
+func DiffTranscriptionRevisions(doctorID, transcriptionID, fromID uuid.UUID, toID *uuid.UUID) (*TranscriptionRevisionDiff, error) {
+	transcription, err := findDoctorTranscription(initializers.DB, doctorID, transcriptionID)
+	if err != nil {
+		return nil, err
+	}
+	from, err := findTranscriptionRevision(initializers.DB, transcriptionID, fromID)
+	if err != nil {
+		return nil, err
+	}
+
+	diff := &TranscriptionRevisionDiff{From: *from}
+	toText, toReport := transcription.Text, transcription.Report
+	if toID != nil {
+		to, err := findTranscriptionRevision(initializers.DB, transcriptionID, *toID)
+		if err != nil {
+			return nil, err
+		}
+		diff.To = to
+		toText, toReport = to.Text, to.Report
+	}
+
+	diff.Text = DiffLines(from.Text, toText)
+	diff.Report = DiffLines(from.Report, toReport)
+	return diff, nil
+}
+
+// RestoreTranscriptionRevision puts an earlier revision's content back. The content
+// it replaces is kept as a new revision, so a restore can itself be undone. Like any
+// edit, it is refused while the report is signed.
+func RestoreTranscriptionRevision(doctorID, transcriptionID, revisionID uuid.UUID) (*models.Transcription, error) {
+	tx := initializers.DB.Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	transcription, err := findDoctorTranscription(tx, doctorID, transcriptionID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	revision, err := findTranscriptionRevision(tx, transcriptionID, revisionID)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if transcription.Status == models.ReportStatusSigned {
+		tx.Rollback()
+		return nil, ErrReportSigned
+	}
+
+	if err := recordTranscriptionRevision(tx, transcription, doctorID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	transcription.Text = revision.Text
+	transcription.Report = revision.Report
+	transcription.StructuredReport = revision.StructuredReport
+	if err := tx.Model(transcription).
+		Select("text", "report", "structured_report").
+		Updates(transcription).Error; err != nil {
+		tx.Rollback()
+		log.Println("Error restoring revision:", err)
+		return nil, errors.New("failed to restore revision")
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		log.Println("Error committing revision restore:", err)
+		return nil, errors.New("failed to restore revision")
+	}
+
+	log.Println("Transcription", transcriptionID, "restored to revision", revision.Version)
+	return transcription, nil
+}
+
+// DiffLines returns a line-by-line diff turning a into b, built from their longest
+// common subsequence. Reports are short, so the quadratic table is fine.
+func DiffLines(a, b string) []DiffLine {
+	left, right := splitLines(a), splitLines(b)
+
+	// common[i][j] is the LCS length of left[i:] and right[j:]
+	common := make([][]int, len(left)+1)
+	for i := range common {
+		common[i] = make([]int, len(right)+1)
+	}
+	for i := len(left) - 1; i >= 0; i-- {
+		for j := len(right) - 1; j >= 0; j-- {
+			if left[i] == right[j] {
+				common[i][j] = common[i+1][j+1] + 1
+			} else {
+				common[i][j] = max(common[i+1][j], common[i][j+1])
+			}
+		}
+	}
+
+	diff := make([]DiffLine, 0, len(left)+len(right))
+	i, j := 0, 0
+	for i < len(left) && j < len(right) {
+		switch {
+		case left[i] == right[j]:
+			diff = append(diff, DiffLine{Op: DiffEqual, Text: left[i]})
+			i++
+			j++
+		case common[i+1][j] >= common[i][j+1]:
+			diff = append(diff, DiffLine{Op: DiffDelete, Text: left[i]})
+			i++
+		default:
+			diff = append(diff, DiffLine{Op: DiffInsert, Text: right[j]})
+			j++
+		}
+	}
+	for ; i < len(left); i++ {
+		diff = append(diff, DiffLine{Op: DiffDelete, Text: left[i]})
+	}
+	for ; j < len(right); j++ {
+		diff = append(diff, DiffLine{Op: DiffInsert, Text: right[j]})
+	}
+	return diff
+}
+
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n")
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiffLines(t *testing.T) {
+	diff := DiffLines("Diagnosis: flu\nRest\nFluids", "Diagnosis: flu\nFluids\nParacetamol")
+	assert.Equal(t, []DiffLine{
+		{Op: DiffEqual, Text: "Diagnosis: flu"},
+		{Op: DiffDelete, Text: "Rest"},
+		{Op: DiffEqual, Text: "Fluids"},
+		{Op: DiffInsert, Text: "Paracetamol"},
+	}, diff)
+
+	assert.Empty(t, DiffLines("", ""))
+	assert.Equal(t, []DiffLine{{Op: DiffInsert, Text: "new"}}, DiffLines("", "new"))
+}
+
+func TestTranscriptionRevisions(t *testing.T) {
+	doctorID, patientID, err := setupTranscriptionTestDB(t)
+	assert.NoError(t, err)
+	transcription := createTestTranscription(t, doctorID, patientID)
+
+	// Two edits leave two revisions holding the content each edit replaced
+	assert.NoError(t, UpdateTranscription(doctorID, transcription.ID, map[string]interface{}{"text": "First edit", "report": "Report v2"}))
+	assert.NoError(t, UpdateTranscription(doctorID, transcription.ID, map[string]interface{}{"text": "Second edit"}))
+
+	revisions, err := ListTranscriptionRevisions(doctorID, transcription.ID)
+	assert.NoError(t, err)
+	if assert.Len(t, revisions, 2) {
+		assert.Equal(t, 2, revisions[0].Version)
+		assert.Equal(t, "First edit", revisions[0].Text)
+		assert.Equal(t, 1, revisions[1].Version)
+		assert.Equal(t, "Test transcription content", revisions[1].Text)
+		assert.Equal(t, "Test transcription report", revisions[1].Report)
+		assert.Equal(t, doctorID, revisions[1].AuthorID)
+	}
+	original, latest := revisions[1], revisions[0]
+
+	t.Run("Edits that change nothing leave no revision", func(t *testing.T) {
+		assert.NoError(t, UpdateTranscription(doctorID, transcription.ID, map[string]interface{}{"text": "Second edit", "report": "Report v2"}))
+
+		unchanged, err := ListTranscriptionRevisions(doctorID, transcription.ID)
+		assert.NoError(t, err)
+		assert.Len(t, unchanged, 2)
+	})
+
+	t.Run("Diff against current content", func(t *testing.T) {
+		diff, err := DiffTranscriptionRevisions(doctorID, transcription.ID, original.ID, nil)
+		assert.NoError(t, err)
+		assert.Nil(t, diff.To)
+		assert.Equal(t, []DiffLine{
+			{Op: DiffDelete, Text: "Test transcription content"},
+			{Op: DiffInsert, Text: "Second edit"},
+		}, diff.Text)
+	})
+
+	t.Run("Diff between revisions", func(t *testing.T) {
+		diff, err := DiffTranscriptionRevisions(doctorID, transcription.ID, original.ID, &latest.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, latest.ID, diff.To.ID)
+		assert.Equal(t, []DiffLine{
+			{Op: DiffDelete, Text: "Test transcription report"},
+			{Op: DiffInsert, Text: "Report v2"},
+		}, diff.Report)
+	})
+
+	t.Run("Restore keeps the replaced content", func(t *testing.T) {
+		restored, err := RestoreTranscriptionRevision(doctorID, transcription.ID, original.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Test transcription content", restored.Text)
+
+		current, err := GetTranscriptionByID(doctorID, transcription.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Test transcription content", current.Text)
+		assert.Equal(t, "Test transcription report", current.Report)
+
+		revisions, err := ListTranscriptionRevisions(doctorID, transcription.ID)
+		assert.NoError(t, err)
+		assert.Len(t, revisions, 3)
+		assert.Equal(t, "Second edit", revisions[0].Text)
+	})
+
+	t.Run("Other doctors cannot see revisions", func(t *testing.T) {
+		_, err := ListTranscriptionRevisions(uuid.New(), transcription.ID)
+		assert.ErrorIs(t, err, ErrForbidden)
+		_, err = RestoreTranscriptionRevision(uuid.New(), transcription.ID, original.ID)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("Unknown revision", func(t *testing.T) {
+		_, err := DiffTranscriptionRevisions(doctorID, transcription.ID, uuid.New(), nil)
+		assert.ErrorIs(t, err, ErrRevisionNotFound)
+		_, err = RestoreTranscriptionRevision(doctorID, transcription.ID, uuid.New())
+		assert.ErrorIs(t, err, ErrRevisionNotFound)
+	})
+}
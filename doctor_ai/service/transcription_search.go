@@ -0,0 +1,248 @@
+package service
+
+import (
+	"errors"
+	"html"
+	"itish41/doctor_ai_assistant/initializers"
+	"log"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// Snippets wrap each match in these tags. The text around them is HTML-escaped, so a
+// snippet can be rendered as HTML.
+const (
+	HighlightStart = "<mark>"
+	HighlightStop  = "</mark>"
+)
+
+// Postgres marks matches with these control characters, which can't be confused with
+// markup once the snippet is escaped.
+const (
+	headlineStart = "\x02"
+	headlineStop  = "\x03"
+)
+
+// MaxTranscriptionSearchLimit caps the page size of SearchTranscriptions.
+const MaxTranscriptionSearchLimit = 100
+
+// snippetRadius is how much context, in characters, the fallback search keeps around a match.
+const snippetRadius = 60
+
+// TranscriptionSearch narrows SearchTranscriptions. Zero values other than Query don't filter.
+type TranscriptionSearch struct {
+	Query     string // web-search syntax on Postgres: words, "quoted phrases", OR and -excluded
+	PatientID *uuid.UUID
+	From      *time.Time // inclusive
+	To        *time.Time // exclusive
+	Page      int
+	Limit     int
+}
+
+// TranscriptionHit is one transcription matching a search, best matches first.
+type TranscriptionHit struct {
+	ID            uuid.UUID
+	PatientID     uuid.UUID
+	PatientName   string
+	CreatedAt     time.Time
+	Status        string
+	Rank          float64
+	TextSnippet   string // empty when only the report matched
+	ReportSnippet string // empty when only the text matched
+}
+
+// SearchTranscriptions runs a full-text search over the text and report of the doctor's
+// transcriptions and returns one page of ranked hits with the total number of matches.
+// On Postgres it uses the trigger-maintained search_vector column, so words match
+// regardless of their form ("prescribed" finds "prescribing"). Other databases fall
+// back to a case-insensitive match of every word, newest first.
+func SearchTranscriptions(doctorID uuid.UUID, search TranscriptionSearch) ([]TranscriptionHit, int64, error) {
+	search.Query = strings.TrimSpace(search.Query)
+	if search.Query == "" {
+		return nil, 0, InvalidField("q", "Search query is required")
+	}
+	if search.From != nil && search.To != nil && search.To.Before(*search.From) {
+		return nil, 0, InvalidField("to", "to must not be before from")
+	}
+	if search.Page < 1 {
+		search.Page = 1
+	}
+	if search.Limit < 1 || search.Limit > MaxTranscriptionSearchLimit {
+		search.Limit = 10
+	}
+
+	query := initializers.DB.Table("transcriptions t").
+		Joins("JOIN patients p ON p.id = t.patient_id").
+		Where("t.doctor_id = ?", doctorID)
+	if search.PatientID != nil {
+		query = query.Where("t.patient_id = ?", *search.PatientID)
+	}
+	if search.From != nil {
+		query = query.Where("t.created_at >= ?", *search.From)
+	}
+	if search.To != nil {
+		query = query.Where("t.created_at < ?", *search.To)
+	}
+
+	if initializers.DB.Dialector.Name() == "postgres" {
+		return searchTranscriptionsFullText(query, search)
+	}
+	return searchTranscriptionsFallback(query, search)
+}
+
+func searchTranscriptionsFullText(query *gorm.DB, search TranscriptionSearch) ([]TranscriptionHit, int64, error) {
+	query = query.
+		Joins("CROSS JOIN websearch_to_tsquery('english', ?) AS q", search.Query).
+		Where("t.search_vector @@ q")
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Println("Error counting transcription search hits:", err)
+		return nil, 0, errors.New("failed to search transcriptions")
+	}
+
+	options := "StartSel=" + headlineStart + ", StopSel=" + headlineStop + ", MaxFragments=2, MaxWords=25, MinWords=8, FragmentDelimiter=\" … \""
+	var hits []TranscriptionHit
+	if err := query.
+		Select(`t.id, t.patient_id, p.name AS patient_name, t.created_at, t.status,
+			ts_rank_cd(t.search_vector, q) AS rank,
+			CASE WHEN to_tsvector('english', t.text) @@ q THEN ts_headline('english', t.text, q, ?) ELSE '' END AS text_snippet,
+			CASE WHEN to_tsvector('english', t.report) @@ q THEN ts_headline('english', t.report, q, ?) ELSE '' END AS report_snippet`,
+			options, options).
+		Order("rank DESC").
+		Order("t.created_at DESC").
+		Limit(search.Limit).
+		Offset((search.Page - 1) * search.Limit).
+		Scan(&hits).Error; err != nil {
+		log.Println("Error searching transcriptions:", err)
+		return nil, 0, errors.New("failed to search transcriptions")
+	}
+	for i := range hits {
+		hits[i].TextSnippet = escapeHeadline(hits[i].TextSnippet)
+		hits[i].ReportSnippet = escapeHeadline(hits[i].ReportSnippet)
+	}
+	return hits, total, nil
+}
+
+// escapeHeadline HTML-escapes a ts_headline snippet and turns its match markers into tags.
+func escapeHeadline(snippet string) string {
+	return strings.NewReplacer(headlineStart, HighlightStart, headlineStop, HighlightStop).Replace(html.EscapeString(snippet))
+}
+
+func searchTranscriptionsFallback(query *gorm.DB, search TranscriptionSearch) ([]TranscriptionHit, int64, error) {
+	words := strings.Fields(strings.ToLower(search.Query))
+	for _, word := range words {
+		pattern := "%" + likePrefix.Replace(word) + "%"
+		query = query.Where(`(LOWER(t.text) LIKE ? ESCAPE '\' OR LOWER(t.report) LIKE ? ESCAPE '\')`, pattern, pattern)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		log.Println("Error counting transcription search hits:", err)
+		return nil, 0, errors.New("failed to search transcriptions")
+	}
+
+	var rows []struct {
+		TranscriptionHit
+		Text   string
+		Report string
+	}
+	if err := query.
+		Select("t.id, t.patient_id, p.name AS patient_name, t.created_at, t.status, t.text, t.report").
+		Order("t.created_at DESC").
+		Limit(search.Limit).
+		Offset((search.Page - 1) * search.Limit).
+		Scan(&rows).Error; err != nil {
+		log.Println("Error searching transcriptions:", err)
+		return nil, 0, errors.New("failed to search transcriptions")
+	}
+
+	hits := make([]TranscriptionHit, len(rows))
+	for i, row := range rows {
+		hits[i] = row.TranscriptionHit
+		hits[i].TextSnippet = highlightSnippet(row.Text, words)
+		hits[i].ReportSnippet = highlightSnippet(row.Report, words)
+	}
+	return hits, total, nil
+}
+
+// highlightSnippet returns the HTML-escaped text around the first match of any word,
+// with every match in it highlighted, or "" when no word occurs in text. Words match
+// regardless of case.
+func highlightSnippet(text string, words []string) string {
+	first := -1
+	for i := 0; i < len(text) && first < 0; {
+		if matchWord(text[i:], words) > 0 {
+			first = i
+		}
+		_, size := utf8.DecodeRuneInString(text[i:])
+		i += size
+	}
+	if first < 0 {
+		return ""
+	}
+
+	start, end := first, first
+	for n := 0; n < snippetRadius && start > 0; n++ {
+		_, size := utf8.DecodeLastRuneInString(text[:start])
+		start -= size
+	}
+	for n := 0; n < 2*snippetRadius && end < len(text); n++ {
+		_, size := utf8.DecodeRuneInString(text[end:])
+		end += size
+	}
+
+	var b strings.Builder
+	if start > 0 {
+		b.WriteString("… ")
+	}
+	window := text[start:end]
+	for i := 0; i < len(window); {
+		if matched := matchWord(window[i:], words); matched > 0 {
+			b.WriteString(HighlightStart + html.EscapeString(window[i:i+matched]) + HighlightStop)
+			i += matched
+			continue
+		}
+		_, size := utf8.DecodeRuneInString(window[i:])
+		b.WriteString(html.EscapeString(window[i : i+size]))
+		i += size
+	}
+	if end < len(text) {
+		b.WriteString(" …")
+	}
+	return b.String()
+}
+
+// matchWord returns the length in bytes of the longest word that text starts with,
+// ignoring case, or 0 when it starts with none of them.
+func matchWord(text string, words []string) int {
+	longest := 0
+	for _, word := range words {
+		if n := foldedPrefix(text, word); n > longest {
+			longest = n
+		}
+	}
+	return longest
+}
+
+// foldedPrefix returns how many bytes of text match prefix rune for rune, ignoring
+// case, or 0 when text doesn't start with prefix.
+func foldedPrefix(text, prefix string) int {
+	i := 0
+	for _, want := range prefix {
+		if i >= len(text) {
+			return 0
+		}
+		got, size := utf8.DecodeRuneInString(text[i:])
+		if got != want && unicode.ToLower(got) != unicode.ToLower(want) {
+			return 0
+		}
+		i += size
+	}
+	return i
+}
@@ -0,0 +1,170 @@
+package service
+
+import (
+	"errors"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchTranscriptions(t *testing.T) {
+	doctorID, patientID, err := setupTranscriptionTestDB(t)
+	assert.NoError(t, err)
+
+	otherPatientID := uuid.New()
+	assert.NoError(t, initializers.DB.Create(&models.Patient{ID: otherPatientID, Name: "Other Patient", Age: 40, Gender: "Female", DoctorID: doctorID}).Error)
+
+	base := time.Date(2026, time.March, 1, 9, 0, 0, 0, time.UTC)
+	seed := []models.Transcription{
+		{DoctorID: doctorID, PatientID: patientID, CreatedAt: base,
+			Text: "Patient reports chest pain after exercise.", Report: "Suspected angina."},
+		{DoctorID: doctorID, PatientID: patientID, CreatedAt: base.AddDate(0, 0, 1),
+			Text: "Follow-up visit, no complaints.", Report: "Chest PAIN resolved with rest."},
+		{DoctorID: doctorID, PatientID: otherPatientID, CreatedAt: base.AddDate(0, 0, 2),
+			Text: "Mild headache and chest pain since Monday.", Report: "Tension headache."},
+		{DoctorID: uuid.New(), PatientID: patientID, CreatedAt: base.AddDate(0, 0, 3),
+			Text: "Chest pain seen by another doctor.", Report: "Chest pain."},
+	}
+	for i := range seed {
+		seed[i].ID = uuid.New()
+		assert.NoError(t, initializers.DB.Create(&seed[i]).Error)
+	}
+
+	date := func(days int) *time.Time {
+		d := base.AddDate(0, 0, days)
+		return &d
+	}
+
+	tests := []struct {
+		name      string
+		search    TranscriptionSearch
+		wantIDs   []uuid.UUID
+		wantTotal int64
+		wantField string
+	}{
+		{
+			name:      "Every word must match the text or report, newest first",
+			search:    TranscriptionSearch{Query: "chest pain"},
+			wantIDs:   []uuid.UUID{seed[2].ID, seed[1].ID, seed[0].ID},
+			wantTotal: 3,
+		},
+		{
+			name:      "By patient",
+			search:    TranscriptionSearch{Query: "chest", PatientID: &otherPatientID},
+			wantIDs:   []uuid.UUID{seed[2].ID},
+			wantTotal: 1,
+		},
+		{
+			name:      "Created-at range",
+			search:    TranscriptionSearch{Query: "pain", From: date(1), To: date(2)},
+			wantIDs:   []uuid.UUID{seed[1].ID},
+			wantTotal: 1,
+		},
+		{
+			name:      "Paged with the total of all matches",
+			search:    TranscriptionSearch{Query: "pain", Page: 2, Limit: 2},
+			wantIDs:   []uuid.UUID{seed[0].ID},
+			wantTotal: 3,
+		},
+		{
+			name:      "No matches",
+			search:    TranscriptionSearch{Query: "fracture"},
+			wantIDs:   []uuid.UUID{},
+			wantTotal: 0,
+		},
+		{name: "Empty query", search: TranscriptionSearch{Query: "  "}, wantField: "q"},
+		{name: "Inverted range", search: TranscriptionSearch{Query: "pain", From: date(2), To: date(1)}, wantField: "to"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hits, total, err := SearchTranscriptions(doctorID, tt.search)
+			if tt.wantField != "" {
+				var validationErr *ValidationError
+				assert.True(t, errors.As(err, &validationErr))
+				assert.Contains(t, validationErr.Fields, tt.wantField)
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantTotal, total)
+			ids := make([]uuid.UUID, len(hits))
+			for i, hit := range hits {
+				ids[i] = hit.ID
+			}
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+
+	t.Run("Hits carry the patient and highlighted snippets", func(t *testing.T) {
+		hits, _, err := SearchTranscriptions(doctorID, TranscriptionSearch{Query: "chest pain", PatientID: &patientID, Limit: 1})
+		assert.NoError(t, err)
+		assert.Len(t, hits, 1)
+		assert.Equal(t, patientID, hits[0].PatientID)
+		assert.Equal(t, "Test Patient", hits[0].PatientName)
+		assert.Equal(t, "", hits[0].TextSnippet)
+		assert.Equal(t, "<mark>Chest</mark> <mark>PAIN</mark> resolved with rest.", hits[0].ReportSnippet)
+	})
+}
+
+func TestSearchTranscriptions_AfterReportEdit(t *testing.T) {
+	doctorID, patientID, err := setupTranscriptionTestDB(t)
+	assert.NoError(t, err)
+	transcription := createTestTranscription(t, doctorID, patientID)
+	assert.NoError(t, UpdateTranscription(doctorID, transcription.ID, map[string]interface{}{
+		"structured_report": map[string]interface{}{"symptoms": []interface{}{"Cough"}, "diagnosis": "Bronchitis", "treatment_plan": "Rest"},
+	}))
+
+	// The hit and the report a doctor opens from it both show the edited diagnosis
+	assert.NoError(t, UpdateTranscription(doctorID, transcription.ID, map[string]interface{}{"report": "Diagnosis: asthma"}))
+	hits, total, err := SearchTranscriptions(doctorID, TranscriptionSearch{Query: "asthma"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), total)
+	if assert.Len(t, hits, 1) {
+		assert.Equal(t, "Diagnosis: <mark>asthma</mark>", hits[0].ReportSnippet)
+		doc, err := GetReportDocument(doctorID, hits[0].ID)
+		if assert.NoError(t, err) {
+			assert.Nil(t, doc.Transcription.StructuredReport)
+		}
+	}
+
+	_, total, err = SearchTranscriptions(doctorID, TranscriptionSearch{Query: "bronchitis"})
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), total)
+}
+
+func TestHighlightSnippet(t *testing.T) {
+	long := "The patient was seen in clinic today for a routine review of blood pressure medication. " +
+		"She mentions intermittent palpitations over the last fortnight, worse at night, with no syncope. " +
+		"Plan: ambulatory ECG and repeat bloods in two weeks."
+
+	snippet := highlightSnippet(long, []string{"palpitations"})
+	assert.Contains(t, snippet, "<mark>palpitations</mark>")
+	assert.Contains(t, snippet, "… ")
+	assert.Less(t, len(snippet), len(long))
+
+	assert.Equal(t, "", highlightSnippet(long, []string{"fracture"}))
+	assert.Equal(t, "Ré<mark>sumé</mark> noted", highlightSnippet("Résumé noted", []string{"sumé"}))
+
+	t.Run("Text is HTML-escaped", func(t *testing.T) {
+		snippet := highlightSnippet(`<script>alert("x")</script> & metformin`, []string{"metformin", "script"})
+		assert.Equal(t, `&lt;<mark>script</mark>&gt;alert(&#34;x&#34;)&lt;/<mark>script</mark>&gt; &amp; <mark>metformin</mark>`, snippet)
+	})
+
+	t.Run("Lowercasing that changes byte lengths", func(t *testing.T) {
+		// "İ" lowercases to two runes, so offsets into the lowercased text don't fit the original
+		text := strings.Repeat("İ", 20) + " metformin"
+		snippet := highlightSnippet(text, []string{"metformin"})
+		assert.True(t, strings.HasSuffix(snippet, "<mark>metformin</mark>"), snippet)
+		assert.Equal(t, "<mark>ÉTÉ</mark> noted", highlightSnippet("ÉTÉ noted", []string{"été"}))
+	})
+}
+
+func TestEscapeHeadline(t *testing.T) {
+	assert.Equal(t, "&lt;b&gt; <mark>chest</mark> pain", escapeHeadline("<b> "+headlineStart+"chest"+headlineStop+" pain"))
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"itish41/doctor_ai_assistant/initializers"
+	"itish41/doctor_ai_assistant/models"
+	"log"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUnknownReportStatus     error = InvalidField("status", "unknown report status")
+	ErrInvalidStatusTransition error = &ConflictError{Message: "invalid report status transition"}
+	ErrReportSigned            error = &ConflictError{Message: "report is signed; create an amendment to edit it"}
+)
+
+// reportStatusTransitions lists the statuses each report status may move to.
+var reportStatusTransitions = map[string][]string{
+	models.ReportStatusDraft:    {models.ReportStatusReviewed},
+	models.ReportStatusReviewed: {models.ReportStatusSigned},
+	models.ReportStatusSigned:   {models.ReportStatusAmended},
+	models.ReportStatusAmended:  {models.ReportStatusSigned},
+}
+
+// canTransitionReport reports whether a report may move from one status to another.
+func canTransitionReport(from, to string) bool {
+	for _, next := range reportStatusTransitions[from] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+// TransitionTranscriptionStatus moves a doctor's report to a new review status.
+// Signing records the doctor and time; moving a signed report to amended opens it
+// for edits again until it is re-signed.
+func TransitionTranscriptionStatus(doctorID, transcriptionID uuid.UUID, status string) (*models.Transcription, error) {
+	if _, ok := reportStatusTransitions[status]; !ok {
+		return nil, ErrUnknownReportStatus
+	}
+
+	transcription, err := findDoctorTranscription(initializers.DB, doctorID, transcriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if !canTransitionReport(transcription.Status, status) {
+		return nil, fmt.Errorf("%w: %s to %s", ErrInvalidStatusTransition, transcription.Status, status)
+	}
+
+	updates := map[string]interface{}{"status": status}
+	if status == models.ReportStatusSigned {
+		now := time.Now()
+		updates["signed_by_id"] = doctorID
+		updates["signed_at"] = now
+		transcription.SignedByID = &doctorID
+		transcription.SignedAt = &now
+	}
+
+	// The status condition keeps two concurrent transitions from both applying
+	result := initializers.DB.Model(&models.Transcription{}).
+		Where("id = ? AND status = ?", transcriptionID, transcription.Status).
+		Updates(updates)
+	if result.Error != nil {
+		log.Println("Error updating report status:", result.Error)
+		return nil, errors.New("failed to update report status")
+	}
+	if result.RowsAffected == 0 {
+		return nil, fmt.Errorf("%w: status changed concurrently", ErrInvalidStatusTransition)
+	}
+
+	log.Println("Transcription", transcriptionID, "moved from", transcription.Status, "to", status)
+	transcription.Status = status
+	return transcription, nil
+}
@@ -0,0 +1,82 @@
+package service
+
+import (
+	"itish41/doctor_ai_assistant/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTransitionTranscriptionStatus(t *testing.T) {
+	doctorID, patientID, err := setupTranscriptionTestDB(t)
+	assert.NoError(t, err)
+	transcription := createTestTranscription(t, doctorID, patientID)
+
+	current, err := GetTranscriptionByID(doctorID, transcription.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, models.ReportStatusDraft, current.Status)
+
+	t.Run("Draft cannot be signed before review", func(t *testing.T) {
+		_, err := TransitionTranscriptionStatus(doctorID, transcription.ID, models.ReportStatusSigned)
+		assert.ErrorIs(t, err, ErrInvalidStatusTransition)
+	})
+
+	t.Run("Unknown status", func(t *testing.T) {
+		_, err := TransitionTranscriptionStatus(doctorID, transcription.ID, "final")
+		assert.ErrorIs(t, err, ErrUnknownReportStatus)
+	})
+
+	t.Run("Other doctors cannot sign", func(t *testing.T) {
+		_, err := TransitionTranscriptionStatus(uuid.New(), transcription.ID, models.ReportStatusReviewed)
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("Review then sign records the signer", func(t *testing.T) {
+		reviewed, err := TransitionTranscriptionStatus(doctorID, transcription.ID, models.ReportStatusReviewed)
+		assert.NoError(t, err)
+		assert.Equal(t, models.ReportStatusReviewed, reviewed.Status)
+		assert.Nil(t, reviewed.SignedAt)
+
+		signed, err := TransitionTranscriptionStatus(doctorID, transcription.ID, models.ReportStatusSigned)
+		assert.NoError(t, err)
+		assert.Equal(t, models.ReportStatusSigned, signed.Status)
+
+		stored, err := GetTranscriptionByID(doctorID, transcription.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, models.ReportStatusSigned, stored.Status)
+		assert.Equal(t, doctorID, *stored.SignedByID)
+		assert.NotNil(t, stored.SignedAt)
+	})
+
+	t.Run("Signed reports cannot be edited", func(t *testing.T) {
+		err := UpdateTranscription(doctorID, transcription.ID, map[string]interface{}{"text": "Late change"})
+		assert.ErrorIs(t, err, ErrReportSigned)
+
+		revisions, err := ListTranscriptionRevisions(doctorID, transcription.ID)
+		assert.NoError(t, err)
+		assert.Empty(t, revisions)
+	})
+
+	t.Run("Status cannot be changed through an edit", func(t *testing.T) {
+		_, err := TransitionTranscriptionStatus(doctorID, transcription.ID, models.ReportStatusAmended)
+		assert.NoError(t, err)
+
+		err = UpdateTranscription(doctorID, transcription.ID, map[string]interface{}{"text": "Amended text", "status": models.ReportStatusSigned})
+		assert.ErrorIs(t, err, ErrValidation)
+
+		err = UpdateTranscription(doctorID, transcription.ID, map[string]interface{}{"text": "Amended text"})
+		assert.NoError(t, err)
+
+		stored, err := GetTranscriptionByID(doctorID, transcription.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Amended text", stored.Text)
+		assert.Equal(t, models.ReportStatusAmended, stored.Status)
+	})
+
+	t.Run("Amended report is signed again", func(t *testing.T) {
+		signed, err := TransitionTranscriptionStatus(doctorID, transcription.ID, models.ReportStatusSigned)
+		assert.NoError(t, err)
+		assert.Equal(t, models.ReportStatusSigned, signed.Status)
+	})
+}
@@ -1,6 +1,7 @@
 package service
 
 import (
+	"bytes"
 	"fmt"
 	"itish41/doctor_ai_assistant/initializers"
 	"itish41/doctor_ai_assistant/models"
@@ -13,7 +14,7 @@ import (
 	"gorm.io/gorm"
 )
 
-func setupTranscriptionTestDB(t *testing.T) (uuid.UUID, uuid.UUID, error) {
+func setupTranscriptionTestDB(t testing.TB) (uuid.UUID, uuid.UUID, error) {
 	// Use a unique database file for each test to avoid locking
 	dbName := fmt.Sprintf("file:memdb%d?mode=memory&cache=shared", time.Now().UnixNano())
 	db, err := gorm.Open(sqlite.Open(dbName), &gorm.Config{
@@ -57,6 +58,12 @@ func setupTranscriptionTestDB(t *testing.T) (uuid.UUID, uuid.UUID, error) {
 		patient_id TEXT NOT NULL,
 		text TEXT NOT NULL,
 		report TEXT NOT NULL,
+		structured_report TEXT,
+		audio_file_id TEXT,
+		status TEXT NOT NULL DEFAULT 'draft',
+		signed_by_id TEXT,
+		signed_at DATETIME,
+		encounter_id TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (doctor_id) REFERENCES doctors(id) ON DELETE CASCADE,
 		FOREIGN KEY (patient_id) REFERENCES patients(id) ON DELETE CASCADE
@@ -101,6 +108,12 @@ func setupTranscriptionTestDB(t *testing.T) (uuid.UUID, uuid.UUID, error) {
 		patient_id TEXT,
 		text TEXT NOT NULL,
 		report TEXT NOT NULL,
+		structured_report TEXT,
+		audio_file_id TEXT,
+		status TEXT NOT NULL DEFAULT 'draft',
+		signed_by_id TEXT,
+		signed_at DATETIME,
+		encounter_id TEXT,
 		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
 		FOREIGN KEY (doctor_id) REFERENCES doctors(id),
 		FOREIGN KEY (patient_id) REFERENCES patients(id)
@@ -109,6 +122,24 @@ func setupTranscriptionTestDB(t *testing.T) (uuid.UUID, uuid.UUID, error) {
 		t.Fatalf("Failed to create transcriptions table: %v", err)
 	}
 
+	err = db.Exec(`CREATE TABLE IF NOT EXISTS transcription_revisions (
+		id TEXT PRIMARY KEY,
+		transcription_id TEXT NOT NULL,
+		version INTEGER NOT NULL,
+		author_id TEXT NOT NULL,
+		text TEXT NOT NULL,
+		report TEXT NOT NULL,
+		structured_report TEXT,
+		created_at DATETIME DEFAULT CURRENT_TIMESTAMP,
+		UNIQUE (transcription_id, version),
+		FOREIGN KEY (transcription_id) REFERENCES transcriptions(id) ON DELETE CASCADE
+	)`).Error
+	if err != nil {
+		t.Fatalf("Failed to create transcription_revisions table: %v", err)
+	}
+	createAuditEventsTable(t, db)
+	createEncountersTable(t, db)
+
 	// Create test doctor
 	doctorID := uuid.New()
 	doctor := &models.Doctor{
@@ -141,7 +172,7 @@ func setupTranscriptionTestDB(t *testing.T) (uuid.UUID, uuid.UUID, error) {
 	return doctorID, patientID, nil
 }
 
-func createTestTranscription(t *testing.T, doctorID, patientID uuid.UUID) *models.Transcription {
+func createTestTranscription(t testing.TB, doctorID, patientID uuid.UUID) *models.Transcription {
 	transcription := &models.Transcription{
 		ID:        uuid.New(),
 		DoctorID:  doctorID,
@@ -207,16 +238,16 @@ func TestGetTranscriptions(t *testing.T) {
 			}
 
 			// Get transcriptions
-			transcriptions, _, total, err := GetTranscriptions(testDoctorID, tt.page, tt.limit)
+			transcriptions, info, err := GetTranscriptions(testDoctorID, TranscriptionFilter{}, PageRequest{Page: tt.page, Limit: tt.limit, WithTotal: true})
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
 				assert.NoError(t, err)
 				if tt.expectedCount > 0 {
-					assert.Equal(t, int64(5), total) // Total should always be 5
+					assert.Equal(t, int64(5), *info.Total) // Total should always be 5
 					assert.Len(t, transcriptions, tt.expectedCount)
 				} else {
-					assert.Equal(t, int64(0), total)
+					assert.Equal(t, int64(0), *info.Total)
 					assert.Empty(t, transcriptions)
 				}
 			}
@@ -224,18 +255,115 @@ func TestGetTranscriptions(t *testing.T) {
 	}
 }
 
+func TestGetTranscriptions_Filters(t *testing.T) {
+	doctorID, patientID, err := setupTranscriptionTestDB(t)
+	assert.NoError(t, err)
+
+	otherPatientID := uuid.New()
+	assert.NoError(t, initializers.DB.Create(&models.Patient{ID: otherPatientID, Name: "Other Patient", Age: 40, Gender: "Female", DoctorID: doctorID}).Error)
+
+	for i := 0; i < 3; i++ {
+		createTestTranscription(t, doctorID, patientID)
+	}
+	signed := createTestTranscription(t, doctorID, otherPatientID)
+	assert.NoError(t, initializers.DB.Model(signed).Update("status", models.ReportStatusSigned).Error)
+
+	t.Run("By patient", func(t *testing.T) {
+		transcriptions, info, err := GetTranscriptions(doctorID, TranscriptionFilter{PatientID: &otherPatientID}, PageRequest{Page: 1, Limit: 10, WithTotal: true})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(1), *info.Total)
+		assert.Len(t, transcriptions, 1)
+		assert.Equal(t, map[string]interface{}{
+			"id":     otherPatientID,
+			"name":   "Other Patient",
+			"age":    40,
+			"gender": "Female",
+		}, transcriptions[0]["patient"])
+	})
+
+	t.Run("By status", func(t *testing.T) {
+		transcriptions, info, err := GetTranscriptions(doctorID, TranscriptionFilter{Status: models.ReportStatusDraft}, PageRequest{Page: 1, Limit: 2, WithTotal: true})
+		assert.NoError(t, err)
+		assert.Equal(t, int64(3), *info.Total)
+		assert.Len(t, transcriptions, 2)
+	})
+}
+
+// countQueries counts the SELECTs run on db from now on.
+func countQueries(t testing.TB, db *gorm.DB) *int {
+	queries := new(int)
+	name := fmt.Sprintf("test:count_queries_%d", time.Now().UnixNano())
+	if err := db.Callback().Query().After("gorm:query").Register(name, func(*gorm.DB) { *queries++ }); err != nil {
+		t.Fatalf("Failed to register query counter: %v", err)
+	}
+	return queries
+}
+
+// seedTranscriptions gives the doctor n transcriptions, each for a patient of its own.
+func seedTranscriptions(t testing.TB, doctorID uuid.UUID, n int) {
+	for i := 0; i < n; i++ {
+		patient := models.Patient{ID: uuid.New(), Name: fmt.Sprintf("Patient %d", i), Age: 30, Gender: "Female", DoctorID: doctorID}
+		if err := initializers.DB.Create(&patient).Error; err != nil {
+			t.Fatalf("Failed to create test patient: %v", err)
+		}
+		createTestTranscription(t, doctorID, patient.ID)
+	}
+}
+
+func TestGetTranscriptions_QueryCountIsFlat(t *testing.T) {
+	doctorID, _, err := setupTranscriptionTestDB(t)
+	assert.NoError(t, err)
+	seedTranscriptions(t, doctorID, 50)
+	queries := countQueries(t, initializers.DB)
+
+	for _, limit := range []int{1, 10, 50} {
+		*queries = 0
+		transcriptions, _, err := GetTranscriptions(doctorID, TranscriptionFilter{}, PageRequest{Page: 1, Limit: limit, WithTotal: true})
+		assert.NoError(t, err)
+		assert.Len(t, transcriptions, limit)
+		// The count, the page and the patients of the page
+		assert.Equal(t, 3, *queries, "limit %d", limit)
+	}
+}
+
+func BenchmarkGetTranscriptions(b *testing.B) {
+	for _, limit := range []int{10, 100} {
+		b.Run(fmt.Sprintf("limit=%d", limit), func(b *testing.B) {
+			doctorID, _, err := setupTranscriptionTestDB(b)
+			if err != nil {
+				b.Fatal(err)
+			}
+			seedTranscriptions(b, doctorID, limit)
+			queries := countQueries(b, initializers.DB)
+			page := PageRequest{Page: 1, Limit: limit}
+
+			b.ResetTimer()
+			*queries = 0
+			for i := 0; i < b.N; i++ {
+				if _, _, err := GetTranscriptions(doctorID, TranscriptionFilter{}, page); err != nil {
+					b.Fatal(err)
+				}
+			}
+			b.ReportMetric(float64(*queries)/float64(b.N), "queries/op")
+		})
+	}
+}
+
 func TestGetTranscriptionByID(t *testing.T) {
 	tests := []struct {
 		name    string
-		wantErr bool
+		wantErr error
 	}{
 		{
-			name:    "Valid transcription ID",
-			wantErr: false,
+			name: "Valid transcription ID",
 		},
 		{
 			name:    "Invalid transcription ID",
-			wantErr: true,
+			wantErr: ErrTranscriptionNotFound,
+		},
+		{
+			name:    "Other doctor's transcription",
+			wantErr: ErrForbidden,
 		},
 	}
 
@@ -250,12 +378,15 @@ func TestGetTranscriptionByID(t *testing.T) {
 
 			// Get transcription
 			transcriptionID := transcription.ID
-			if tt.wantErr {
+			if tt.wantErr == ErrTranscriptionNotFound {
 				transcriptionID = uuid.New()
 			}
-			result, err := GetTranscriptionByID(transcriptionID)
-			if tt.wantErr {
-				assert.Error(t, err)
+			if tt.wantErr == ErrForbidden {
+				doctorID = uuid.New()
+			}
+			result, err := GetTranscriptionByID(doctorID, transcriptionID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
 				assert.Nil(t, result)
 			} else {
 				assert.NoError(t, err)
@@ -282,7 +413,7 @@ func TestUpdateTranscription(t *testing.T) {
 			name:            "Valid update",
 			transcriptionID: transcription.ID,
 			updateData: map[string]interface{}{
-				"Text": "Updated test content",
+				"text": "Updated test content",
 			},
 			wantErr: false,
 		},
@@ -290,32 +421,145 @@ func TestUpdateTranscription(t *testing.T) {
 			name:            "Non-existent transcription ID",
 			transcriptionID: uuid.New(),
 			updateData: map[string]interface{}{
-				"Text": "Updated test content",
+				"text": "Updated test content",
 			},
-			wantErr: false, // Current service behavior: no error when updating non-existent record
+			wantErr: true,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := UpdateTranscription(tt.transcriptionID, tt.updateData)
+			err := UpdateTranscription(doctorID, tt.transcriptionID, tt.updateData)
 			if tt.wantErr {
-				assert.Error(t, err)
+				assert.ErrorIs(t, err, ErrTranscriptionNotFound)
 			} else {
 				assert.NoError(t, err)
 
-				// For non-existent transcription, verify it wasn't created
-				updated, err := GetTranscriptionByID(tt.transcriptionID)
-				if tt.name == "Non-existent transcription ID" {
-					assert.Error(t, err)
-					assert.Nil(t, updated)
-				} else {
-					assert.NoError(t, err)
-					assert.Equal(t, tt.updateData["Text"], updated.Text)
-				}
+				updated, err := GetTranscriptionByID(doctorID, tt.transcriptionID)
+				assert.NoError(t, err)
+				assert.Equal(t, tt.updateData["text"], updated.Text)
 			}
 		})
 	}
+
+	t.Run("Other doctor's transcription", func(t *testing.T) {
+		err := UpdateTranscription(uuid.New(), transcription.ID, map[string]interface{}{"text": "Not mine"})
+		assert.ErrorIs(t, err, ErrForbidden)
+	})
+
+	t.Run("Ownership and identity can't be edited", func(t *testing.T) {
+		otherDoctorID := uuid.New()
+		err := UpdateTranscription(doctorID, transcription.ID, map[string]interface{}{
+			"text":       "Moved",
+			"doctor_id":  otherDoctorID,
+			"patient_id": uuid.New(),
+			"created_at": "2020-01-01T00:00:00Z",
+		})
+		var validationErr *ValidationError
+		if assert.ErrorAs(t, err, &validationErr) {
+			assert.Contains(t, validationErr.Fields, "doctor_id")
+			assert.Contains(t, validationErr.Fields, "patient_id")
+			assert.Contains(t, validationErr.Fields, "created_at")
+			assert.NotContains(t, validationErr.Fields, "text")
+		}
+
+		stored, err := GetTranscriptionByID(doctorID, transcription.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, doctorID, stored.DoctorID)
+		assert.Equal(t, patientID, stored.PatientID)
+		assert.Equal(t, "Updated test content", stored.Text)
+	})
+
+	t.Run("Unknown fields are rejected", func(t *testing.T) {
+		err := UpdateTranscription(doctorID, transcription.ID, map[string]interface{}{"no_such_column": "x"})
+		assert.ErrorIs(t, err, ErrValidation)
+	})
+
+	t.Run("Text must be a string", func(t *testing.T) {
+		err := UpdateTranscription(doctorID, transcription.ID, map[string]interface{}{"text": 42})
+		assert.ErrorIs(t, err, ErrValidation)
+	})
+
+	t.Run("Nothing to update", func(t *testing.T) {
+		err := UpdateTranscription(doctorID, transcription.ID, map[string]interface{}{})
+		assert.ErrorIs(t, err, ErrValidation)
+	})
+}
+
+func TestUpdateTranscription_StructuredReport(t *testing.T) {
+	doctorID, patientID, err := setupTranscriptionTestDB(t)
+	assert.NoError(t, err)
+
+	transcription := createTestTranscription(t, doctorID, patientID)
+
+	t.Run("Valid report updates both forms", func(t *testing.T) {
+		err := UpdateTranscription(doctorID, transcription.ID, map[string]interface{}{
+			"structured_report": map[string]interface{}{
+				"symptoms":       []interface{}{"Cough", " "},
+				"diagnosis":      " Bronchitis ",
+				"treatment_plan": "Rest",
+			},
+		})
+		assert.NoError(t, err)
+
+		updated, err := GetTranscriptionByID(doctorID, transcription.ID)
+		assert.NoError(t, err)
+		assert.Equal(t, "Bronchitis", updated.StructuredReport.Diagnosis)
+		assert.Equal(t, []string{"Cough"}, updated.StructuredReport.Symptoms)
+		assert.Equal(t, updated.StructuredReport.Text(), updated.Report)
+	})
+
+	t.Run("Resubmitting the same structured report leaves no revision", func(t *testing.T) {
+		before, err := ListTranscriptionRevisions(doctorID, transcription.ID)
+		assert.NoError(t, err)
+		err = UpdateTranscription(doctorID, transcription.ID, map[string]interface{}{
+			"structured_report": map[string]interface{}{
+				"symptoms":       []interface{}{"Cough"},
+				"diagnosis":      "Bronchitis",
+				"treatment_plan": "Rest",
+			},
+		})
+		assert.NoError(t, err)
+
+		after, err := ListTranscriptionRevisions(doctorID, transcription.ID)
+		assert.NoError(t, err)
+		assert.Len(t, after, len(before))
+	})
+
+	t.Run("Resubmitting the unchanged report keeps the structured one", func(t *testing.T) {
+		current, err := GetTranscriptionByID(doctorID, transcription.ID)
+		assert.NoError(t, err)
+		err = UpdateTranscription(doctorID, transcription.ID, map[string]interface{}{"text": "New text", "report": current.Report})
+		assert.NoError(t, err)
+
+		updated, err := GetTranscriptionByID(doctorID, transcription.ID)
+		assert.NoError(t, err)
+		if assert.NotNil(t, updated.StructuredReport) {
+			assert.Equal(t, "Bronchitis", updated.StructuredReport.Diagnosis)
+		}
+	})
+
+	t.Run("Plain-text edits replace the structured report in downloads", func(t *testing.T) {
+		err := UpdateTranscription(doctorID, transcription.ID, map[string]interface{}{"report": "Diagnosis: viral pneumonia"})
+		assert.NoError(t, err)
+
+		doc, err := GetReportDocument(doctorID, transcription.ID)
+		if !assert.NoError(t, err) {
+			return
+		}
+		assert.Nil(t, doc.Transcription.StructuredReport)
+		var text bytes.Buffer
+		assert.NoError(t, GenerateText(&text, NewReportView(*doc)))
+		assert.Contains(t, text.String(), "Diagnosis: viral pneumonia")
+		assert.NotContains(t, text.String(), "Bronchitis")
+	})
+
+	t.Run("Report missing required sections", func(t *testing.T) {
+		err := UpdateTranscription(doctorID, transcription.ID, map[string]interface{}{
+			"structured_report": map[string]interface{}{"symptoms": []interface{}{"Cough"}},
+		})
+		assert.ErrorIs(t, err, ErrInvalidReport)
+	})
 }
 
 func TestDeleteTranscription(t *testing.T) {
@@ -326,31 +570,39 @@ func TestDeleteTranscription(t *testing.T) {
 
 	tests := []struct {
 		name            string
+		doctorID        uuid.UUID
 		transcriptionID uuid.UUID
-		wantErr         bool
+		wantErr         error
 	}{
+		{
+			name:            "Other doctor's transcription",
+			doctorID:        uuid.New(),
+			transcriptionID: transcription.ID,
+			wantErr:         ErrForbidden,
+		},
 		{
 			name:            "Valid delete",
+			doctorID:        doctorID,
 			transcriptionID: transcription.ID,
-			wantErr:         false,
 		},
 		{
 			name:            "Invalid transcription ID",
+			doctorID:        doctorID,
 			transcriptionID: uuid.New(),
-			wantErr:         true,
+			wantErr:         ErrTranscriptionNotFound,
 		},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := DeleteTranscription(tt.transcriptionID)
-			if tt.wantErr {
-				assert.Error(t, err)
+			err := DeleteTranscription(tt.doctorID, tt.transcriptionID)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
 			} else {
 				assert.NoError(t, err)
 				// Verify the deletion
-				_, err := GetTranscriptionByID(tt.transcriptionID)
-				assert.Error(t, err)
+				_, err := GetTranscriptionByID(tt.doctorID, tt.transcriptionID)
+				assert.ErrorIs(t, err, ErrTranscriptionNotFound)
 			}
 		})
 	}
@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLocal(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	local, err := NewLocal(root)
+	assert.NoError(t, err)
+
+	t.Run("Put and Get", func(t *testing.T) {
+		written, err := local.Put(ctx, "audio/doctor/file.mp3", strings.NewReader("audio bytes"))
+		assert.NoError(t, err)
+		assert.Equal(t, int64(11), written)
+
+		reader, err := local.Get(ctx, "audio/doctor/file.mp3")
+		assert.NoError(t, err)
+		defer reader.Close()
+		content, _ := io.ReadAll(reader)
+		assert.Equal(t, "audio bytes", string(content))
+
+		// No temporary files are left behind
+		entries, _ := os.ReadDir(filepath.Join(root, "audio", "doctor"))
+		assert.Len(t, entries, 1)
+	})
+
+	t.Run("Failed write leaves nothing", func(t *testing.T) {
+		_, err := local.Put(ctx, "audio/doctor/broken.mp3", io.MultiReader(strings.NewReader("part"), failingReader{}))
+		assert.Error(t, err)
+		_, err = local.Get(ctx, "audio/doctor/broken.mp3")
+		assert.ErrorIs(t, err, ErrObjectNotFound)
+	})
+
+	t.Run("Delete", func(t *testing.T) {
+		_, err := local.Put(ctx, "audio/doctor/delete.mp3", strings.NewReader("x"))
+		assert.NoError(t, err)
+		assert.NoError(t, local.Delete(ctx, "audio/doctor/delete.mp3"))
+		assert.NoError(t, local.Delete(ctx, "audio/doctor/delete.mp3"))
+		_, err = local.Get(ctx, "audio/doctor/delete.mp3")
+		assert.ErrorIs(t, err, ErrObjectNotFound)
+	})
+
+	t.Run("Keys cannot escape the root", func(t *testing.T) {
+		for _, key := range []string{"", "/etc/passwd", "../outside", "audio/../../outside", "audio\\file", "audio//file", "."} {
+			_, err := local.Put(ctx, key, strings.NewReader("x"))
+			assert.ErrorIs(t, err, ErrInvalidKey, key)
+		}
+	})
+}
+
+func TestNewFromEnv(t *testing.T) {
+	t.Setenv("STORAGE_BACKEND", "")
+	t.Setenv("STORAGE_LOCAL_DIR", t.TempDir())
+	store, err := NewFromEnv()
+	assert.NoError(t, err)
+	assert.IsType(t, &Local{}, store)
+
+	t.Setenv("STORAGE_BACKEND", "s3")
+	_, err = NewFromEnv()
+	assert.Error(t, err)
+}
+
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, errors.New("connection reset")
+}
@@ -0,0 +1,49 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// Storage backends selectable through the STORAGE_BACKEND environment variable
+const (
+	BackendLocal = "local"
+
+	defaultLocalDir = "uploads"
+)
+
+var (
+	ErrObjectNotFound = errors.New("object not found")
+	ErrInvalidKey     = errors.New("invalid object key")
+)
+
+// ObjectStorage stores uploaded files under slash-separated keys. It is shaped after
+// S3-style object stores so a bucket-backed implementation can replace local disk.
+type ObjectStorage interface {
+	// Put streams r into the object at key and returns the number of bytes written.
+	Put(ctx context.Context, key string, r io.Reader) (int64, error)
+	// Get opens the object at key; callers must close the reader.
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete removes the object at key; deleting a missing object is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// NewFromEnv builds the ObjectStorage named by STORAGE_BACKEND (default local).
+// The local backend writes under STORAGE_LOCAL_DIR (default uploads).
+func NewFromEnv() (ObjectStorage, error) {
+	backend := strings.ToLower(strings.TrimSpace(os.Getenv("STORAGE_BACKEND")))
+	switch backend {
+	case "", BackendLocal:
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = defaultLocalDir
+		}
+		return NewLocal(dir)
+	default:
+		return nil, fmt.Errorf("unknown storage backend %q", backend)
+	}
+}